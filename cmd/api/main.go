@@ -14,12 +14,18 @@ import (
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/db"
 	httpRouter "github.com/WailSalutem-Health-Care/organization-service/internal/http"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/logging"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/organization"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/telemetry"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
 func main() {
+	// Structured logging; the standard log package is routed through it
+	logging.Setup(logging.LoadConfig())
+
 	// Set timezone to CET (Central European Time - GMT+1, auto-adjusts to CEST in summer)
 	loc, err := time.LoadLocation("CET")
 	if err != nil {
@@ -63,25 +69,41 @@ func main() {
 	}
 	defer database.Close()
 
+	// Start collecting tenant population gauges
+	collector, err := telemetry.NewTenantCollector(database, telemetry.LoadCollectorConfig(organization.RetentionPeriod))
+	if err != nil {
+		log.Printf("Warning: failed to initialize tenant metrics collector: %v", err)
+	} else {
+		collector.Start()
+		defer collector.Close()
+	}
+
 	// Load auth config
 	cfg := auth.LoadConfig()
 
-	// Load permissions.yml
-	perms, err := auth.LoadPermissions("permissions.yml")
+	// Load permissions.yml and reload it on change or SIGHUP
+	perms, err := auth.NewPermissionStore("permissions.yml")
 	if err != nil {
 		log.Fatalf("failed to load permissions.yml: %v", err)
 	}
-	log.Printf("loaded permissions for %d roles", len(perms))
-
-	// Initialize JWKS (cached, auto-refreshed every 15 min)
-	jwks, err := auth.NewJWKS(cfg.JWKSURL, 15*time.Minute)
-	if err != nil {
-		log.Fatalf("failed to initialize JWKS: %v", err)
+	perms.Watch(auth.LoadPermissionsReloadInterval())
+	defer perms.Close()
+	log.Printf("loaded permissions for %d roles", len(perms.Permissions()))
+
+	// Initialize JWKS of every trusted issuer (cached, auto-refreshed every 15 min
+	// and on unknown key IDs)
+	keys := make(map[string]*auth.JWKS)
+	for _, issuer := range cfg.Issuers() {
+		jwks, err := auth.NewJWKSWithMetrics(issuer.JWKSURL, 15*time.Minute, metrics)
+		if err != nil {
+			log.Fatalf("failed to initialize JWKS of %s: %v", issuer.Issuer, err)
+		}
+		defer jwks.Close()
+		keys[issuer.Issuer] = jwks
 	}
-	defer jwks.Close()
 
 	// Create token verifier
-	ver := auth.NewVerifier(cfg, jwks)
+	ver := auth.NewMultiIssuerVerifier(cfg, keys)
 
 	// Initialize RabbitMQ publisher (optional dependency)
 	publisher, err := messaging.NewPublisher()
@@ -94,8 +116,13 @@ func main() {
 		log.Println("✓ RabbitMQ publisher initialized")
 	}
 
+	// Run import jobs in the background; jobs left behind by stopped replicas
+	// are marked as failed
+	importJobs := jobs.NewRunner()
+	importJobs.WatchStale(jobs.NewRepository(database))
+
 	// Setup router with all routes
-	router := httpRouter.SetupRouter(database, ver, perms, publisher, metrics)
+	router := httpRouter.SetupRouter(database, ver, perms, publisher, importJobs, metrics)
 
 	// Wrap router with OpenTelemetry instrumentation
 	router.Use(otelmux.Middleware("organization-service"))
@@ -135,5 +162,10 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Let running import jobs finish, or record that they were interrupted
+	if err := importJobs.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Import jobs interrupted by shutdown: %v", err)
+	}
+
 	log.Println("Server stopped")
 }
@@ -9,10 +9,14 @@ import (
 	"time"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/db"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/idempotency"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/logging"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/organization"
 )
 
 func main() {
+	logging.Setup(logging.LoadConfig())
+
 	log.Println("Organization Cleanup Job - Starting")
 	log.Println("Retention Policy: 3 years")
 
@@ -31,6 +35,14 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
+	// Remove idempotency keys whose replay window has passed
+	purgedKeys, err := idempotency.NewRepository(database).DeleteExpired(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to delete expired idempotency keys: %v", err)
+	} else {
+		log.Printf("Deleted %d expired idempotency keys", purgedKeys)
+	}
+
 	// Check how many organizations are eligible for cleanup
 	count, err := cleanupService.GetExpiredOrganizationsCount(ctx)
 	if err != nil {
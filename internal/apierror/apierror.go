@@ -0,0 +1,134 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ContentType is the media type of problem details responses
+const ContentType = "application/problem+json"
+
+// TypePrefix turns an error code into the problem type URI
+const TypePrefix = "urn:wailsalutem:problem:"
+
+// Error codes shared by all packages. Packages may define more specific ones.
+const (
+	CodeInvalidRequest  = "invalid_request"
+	CodeValidation      = "validation_error"
+	CodeUnauthenticated = "unauthenticated"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeInternal        = "internal_error"
+)
+
+// FieldError is one entry of the validation errors list. Import endpoints set
+// Row to the line of the uploaded file the message refers to.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Row     int    `json:"row,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problem is the application/problem+json response body. Code repeats the
+// last segment of Type for clients that switch on a plain string.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// Error is a domain error that knows its HTTP status and error code.
+// Handlers pass it to Write; wrapping it with fmt.Errorf and %w keeps the
+// mapping and adds the wrapping text to the detail.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Errors []FieldError
+}
+
+// New creates a typed error
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// BadRequest creates a 400 error
+func BadRequest(code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+// Forbidden creates a 403 error
+func Forbidden(code, detail string) *Error {
+	return New(http.StatusForbidden, code, detail)
+}
+
+// NotFound creates a 404 error
+func NotFound(code, detail string) *Error {
+	return New(http.StatusNotFound, code, detail)
+}
+
+// Conflict creates a 409 error
+func Conflict(code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+// Validation creates a 400 validation error listing the offending fields
+func Validation(detail string, errs ...FieldError) *Error {
+	e := New(http.StatusBadRequest, CodeValidation, detail)
+	e.Errors = errs
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// Write writes err as a problem response. Typed errors keep their status and
+// code and use the full error text as detail. Any other error is answered
+// with 500 and fallback as detail, so internal error text never reaches the
+// client.
+func Write(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	var typed *Error
+	if errors.As(err, &typed) {
+		problem := NewProblem(r, typed.Status, typed.Code, err.Error())
+		problem.Errors = typed.Errors
+		WriteProblem(w, problem)
+		return
+	}
+	Respond(w, r, http.StatusInternalServerError, CodeInternal, fallback)
+}
+
+// Respond writes a problem response with the given status, code and detail
+func Respond(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	WriteProblem(w, NewProblem(r, status, code, detail))
+}
+
+// NewProblem builds a problem for the request. The instance is the request
+// path.
+func NewProblem(r *http.Request, status int, code, detail string) Problem {
+	problem := Problem{
+		Type:   TypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+	if r != nil && r.URL != nil {
+		problem.Instance = r.URL.Path
+	}
+	return problem
+}
+
+// WriteProblem writes problem as application/problem+json
+func WriteProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,104 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if ct := rr.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Expected Content-Type %s, got %s", ContentType, ct)
+	}
+	var problem Problem
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	return problem
+}
+
+func TestRespond(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/organization/patients/p-1?x=1", nil)
+	rr := httptest.NewRecorder()
+
+	Respond(rr, req, http.StatusNotFound, CodeNotFound, "patient not found")
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rr.Code)
+	}
+	problem := decodeProblem(t, rr)
+	want := Problem{
+		Type:     TypePrefix + CodeNotFound,
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "patient not found",
+		Instance: "/organization/patients/p-1",
+		Code:     CodeNotFound,
+	}
+	if fmt.Sprint(problem) != fmt.Sprint(want) {
+		t.Errorf("Expected %+v, got %+v", want, problem)
+	}
+}
+
+func TestWriteTypedError(t *testing.T) {
+	errTaken := Conflict("username_taken", "username is already taken")
+	wrapped := fmt.Errorf("failed to create user: %w", errTaken)
+
+	rr := httptest.NewRecorder()
+	Write(rr, httptest.NewRequest(http.MethodPost, "/organization/users", nil), wrapped, "failed to create user")
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", rr.Code)
+	}
+	problem := decodeProblem(t, rr)
+	if problem.Code != "username_taken" {
+		t.Errorf("Expected code username_taken, got %s", problem.Code)
+	}
+	if problem.Detail != wrapped.Error() {
+		t.Errorf("Expected detail %q, got %q", wrapped.Error(), problem.Detail)
+	}
+	if !errors.Is(wrapped, errTaken) {
+		t.Error("Expected wrapped error to match its sentinel")
+	}
+}
+
+func TestWriteUntypedErrorHidesDetail(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Write(rr, httptest.NewRequest(http.MethodGet, "/organizations", nil), errors.New("pq: connection refused"), "Failed to list organizations")
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rr.Code)
+	}
+	problem := decodeProblem(t, rr)
+	if problem.Code != CodeInternal {
+		t.Errorf("Expected code %s, got %s", CodeInternal, problem.Code)
+	}
+	if problem.Detail != "Failed to list organizations" {
+		t.Errorf("Expected fallback detail, got %q", problem.Detail)
+	}
+}
+
+func TestWriteValidationErrors(t *testing.T) {
+	err := Validation("request is invalid",
+		FieldError{Field: "email", Message: "is required"},
+		FieldError{Row: 3, Message: "unknown role"},
+	)
+
+	rr := httptest.NewRecorder()
+	Write(rr, httptest.NewRequest(http.MethodPost, "/organization/patients", nil), err, "")
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+	problem := decodeProblem(t, rr)
+	if problem.Code != CodeValidation {
+		t.Errorf("Expected code %s, got %s", CodeValidation, problem.Code)
+	}
+	if len(problem.Errors) != 2 || problem.Errors[0].Field != "email" || problem.Errors[1].Row != 3 {
+		t.Errorf("Expected both field errors, got %+v", problem.Errors)
+	}
+}
@@ -5,13 +5,35 @@ package auth
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 )
 
 // Config holds auth configuration
 type Config struct {
 	Issuer  string
 	JWKSURL string
+
+	// AdditionalIssuers are trusted besides Issuer, e.g. a second realm
+	// during a migration
+	AdditionalIssuers []IssuerConfig
+
+	Audiences         []string      // Accepted aud values; empty accepts any audience
+	AuthorizedParties []string      // Accepted azp values; empty accepts any client
+	Leeway            time.Duration // Allowed clock skew when checking exp, nbf and iat
+}
+
+// IssuerConfig is a trusted token issuer and the URL of its signing keys
+type IssuerConfig struct {
+	Issuer  string
+	JWKSURL string
+}
+
+// Issuers returns Issuer followed by the additional issuers
+func (c Config) Issuers() []IssuerConfig {
+	return append([]IssuerConfig{{Issuer: c.Issuer, JWKSURL: c.JWKSURL}}, c.AdditionalIssuers...)
 }
 
 func LoadConfig() Config {
@@ -30,10 +52,48 @@ func LoadConfig() Config {
 	issuer := fmt.Sprintf("%s/realms/%s", keycloakBaseURL, realm)
 	jwks := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/certs", keycloakBaseURL, realm)
 
-	log.Printf("Keycloak configured - Issuer: %s", issuer)
+	// Additional issuers are full issuer URLs; Keycloak serves the keys of a
+	// realm below its issuer URL
+	var additional []IssuerConfig
+	for _, iss := range splitList(os.Getenv("KEYCLOAK_ADDITIONAL_ISSUERS")) {
+		iss = strings.TrimSuffix(iss, "/")
+		additional = append(additional, IssuerConfig{Issuer: iss, JWKSURL: iss + "/protocol/openid-connect/certs"})
+	}
+
+	// Get clock skew leeway with default
+	leeway := 30 * time.Second
+	if leewayStr := os.Getenv("TOKEN_LEEWAY"); leewayStr != "" {
+		if duration, err := time.ParseDuration(leewayStr); err == nil && duration >= 0 {
+			leeway = duration
+		}
+	}
+
+	cfg := Config{
+		Issuer:            issuer,
+		JWKSURL:           jwks,
+		AdditionalIssuers: additional,
+		Audiences:         splitList(os.Getenv("TOKEN_AUDIENCES")),
+		AuthorizedParties: splitList(os.Getenv("TOKEN_AUTHORIZED_PARTIES")),
+		Leeway:            leeway,
+	}
+
+	slog.Info("Keycloak configured", "issuer", issuer, "additional_issuers", len(additional),
+		"audiences", cfg.Audiences, "authorized_parties", cfg.AuthorizedParties, "leeway", leeway.String())
+	if len(cfg.Audiences) == 0 && len(cfg.AuthorizedParties) == 0 {
+		slog.Warn("TOKEN_AUDIENCES and TOKEN_AUTHORIZED_PARTIES are empty, accepting tokens issued to any client of the realm")
+	}
+
+	return cfg
+}
 
-	return Config{
-		Issuer:  issuer,
-		JWKSURL: jwks,
+// splitList splits a comma-separated environment variable, dropping empty
+// entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
 	}
+	return list
 }
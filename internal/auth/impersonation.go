@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ImpersonateHeader names the Keycloak user ID of the tenant user a
+// SUPER_ADMIN acts as; X-Organization-ID names the user's organization
+const ImpersonateHeader = "X-Impersonate-User"
+
+// ImpersonationConfig holds impersonation configuration
+type ImpersonationConfig struct {
+	Enabled     bool // Let SUPER_ADMINs act as tenant users
+	AllowWrites bool // Allow requests other than GET, HEAD and OPTIONS while impersonating
+}
+
+// LoadImpersonationConfig loads impersonation configuration from environment
+// variables
+func LoadImpersonationConfig() ImpersonationConfig {
+	// Get impersonation switches with defaults
+	enabled, _ := strconv.ParseBool(os.Getenv("IMPERSONATION_ENABLED"))
+	allowWrites, _ := strconv.ParseBool(os.Getenv("IMPERSONATION_ALLOW_WRITES"))
+
+	return ImpersonationConfig{
+		Enabled:     enabled,
+		AllowWrites: allowWrites,
+	}
+}
+
+// ImpersonationLookup returns the principal of a live tenant user or patient,
+// or nil when there is none
+type ImpersonationLookup interface {
+	ImpersonationTarget(ctx context.Context, orgID, userID string) (*Principal, error)
+}
+
+// IsImpersonated reports whether a SUPER_ADMIN acts as this principal
+func (p *Principal) IsImpersonated() bool {
+	return p.Impersonator != nil
+}
+
+// Real returns the identity that authenticated the request: the
+// impersonator of an impersonated principal, otherwise the principal itself
+func (p *Principal) Real() *Principal {
+	if p.Impersonator != nil {
+		return p.Impersonator
+	}
+	return p
+}
+
+// impersonate returns the principal named by the X-Impersonate-User header,
+// or pr when the header is absent. Only SUPER_ADMIN users may impersonate,
+// and only with read requests unless allowWrites is set.
+func impersonate(r *http.Request, pr *Principal, lookup ImpersonationLookup, allowWrites bool) (*Principal, error) {
+	userID := r.Header.Get(ImpersonateHeader)
+	if userID == "" {
+		return pr, nil
+	}
+	if lookup == nil {
+		return nil, apierror.Forbidden(apierror.CodeForbidden, "impersonation is disabled")
+	}
+	if pr.IsService() || !slices.ContainsFunc(pr.Roles, func(role string) bool { return strings.EqualFold(role, "SUPER_ADMIN") }) {
+		return nil, apierror.Forbidden(apierror.CodeForbidden, "only SUPER_ADMIN can impersonate")
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !allowWrites {
+			return nil, apierror.Forbidden(apierror.CodeForbidden, "impersonated requests are read-only")
+		}
+	}
+
+	orgID := r.Header.Get(OrganizationHeader)
+	if orgID == "" {
+		return nil, apierror.BadRequest("missing_org", OrganizationHeader+" header is required to impersonate")
+	}
+	if _, err := uuid.Parse(orgID); err != nil {
+		return nil, apierror.BadRequest(apierror.CodeInvalidRequest, OrganizationHeader+" must be an organization ID")
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, apierror.BadRequest(apierror.CodeInvalidRequest, ImpersonateHeader+" must be a Keycloak user ID")
+	}
+
+	target, err := lookup.ImpersonationTarget(r.Context(), orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, apierror.NotFound(apierror.CodeNotFound, "user to impersonate not found")
+	}
+	target.Type = PrincipalTypeUser
+	target.Impersonator = pr
+	return target, nil
+}
+
+// DBImpersonationLookup finds impersonation targets in the users and
+// patients tables of an organization
+type DBImpersonationLookup struct {
+	db *sql.DB
+}
+
+// Ensure DBImpersonationLookup implements ImpersonationLookup
+var _ ImpersonationLookup = (*DBImpersonationLookup)(nil)
+
+// NewDBImpersonationLookup creates a new DBImpersonationLookup
+func NewDBImpersonationLookup(db *sql.DB) *DBImpersonationLookup {
+	return &DBImpersonationLookup{db: db}
+}
+
+// ImpersonationTarget returns the principal of a user, with the role of its
+// users record, or of a patient, with the PATIENT role. Deleted or inactive
+// records and deleted organizations are not found.
+func (l *DBImpersonationLookup) ImpersonationTarget(ctx context.Context, orgID, userID string) (*Principal, error) {
+	var schemaName string
+	err := l.db.QueryRowContext(ctx, `
+		SELECT schema_name FROM wailsalutem.organizations WHERE id = $1 AND deleted_at IS NULL
+	`, orgID).Scan(&schemaName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	principal := func(role string) *Principal {
+		return &Principal{
+			UserID:        userID,
+			Roles:         []string{role},
+			OrgID:         orgID,
+			OrgSchemaName: schemaName,
+			Claims:        jwt.MapClaims{"sub": userID},
+		}
+	}
+
+	var role sql.NullString
+	err = l.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT role FROM %s.users WHERE keycloak_user_id = $1 AND deleted_at IS NULL AND COALESCE(is_active, true)
+	`, pq.QuoteIdentifier(schemaName)), userID).Scan(&role)
+	if err == nil {
+		if !role.Valid || role.String == "" {
+			return nil, nil
+		}
+		return principal(role.String), nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var exists bool
+	err = l.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s.patients WHERE keycloak_user_id = $1 AND deleted_at IS NULL AND COALESCE(is_active, true))
+	`, pq.QuoteIdentifier(schemaName)), userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patient: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return principal("PATIENT"), nil
+}
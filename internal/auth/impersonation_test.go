@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockImpersonationLookup returns the principals of tenant users by org and
+// user ID
+type mockImpersonationLookup map[string]*Principal
+
+func (m mockImpersonationLookup) ImpersonationTarget(ctx context.Context, orgID, userID string) (*Principal, error) {
+	target, ok := m[orgID+"/"+userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *target
+	return &copied, nil
+}
+
+// TestMiddlewareWithOptions_Impersonation tests that SUPER_ADMINs act as
+// tenant users, read-only unless writes are allowed
+func TestMiddlewareWithOptions_Impersonation(t *testing.T) {
+	privateKey, publicKey := generateTestKeyPair(t)
+	cfg := Config{Issuer: "https://test-keycloak.com/realms/test"}
+	verifier := NewVerifier(cfg, newMockJWKS(publicKey))
+	tokenOf := func(sub, role string) string {
+		return signTestToken(t, privateKey, jwt.MapClaims{
+			"sub": sub,
+			"iss": cfg.Issuer,
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{
+				"roles": []interface{}{role},
+			},
+		})
+	}
+	superAdmin := tokenOf("kc-super-admin", "SUPER_ADMIN")
+
+	orgID := "0b7f8c36-6d1e-4a51-9a47-6f0f3c1f2d10"
+	caregiverID := "5c1e2f3a-8b9d-4e6f-a1b2-c3d4e5f60718"
+	lookup := mockImpersonationLookup{
+		orgID + "/" + caregiverID: {UserID: caregiverID, Roles: []string{"CAREGIVER"}, OrgID: orgID, OrgSchemaName: "org_test_schema"},
+	}
+
+	var got *Principal
+	var gotHeaders http.Header
+	serve := func(lookup ImpersonationLookup, allowWrites bool, token, method, org, user string) int {
+		got, gotHeaders = nil, nil
+		handler := MiddlewareWithOptions(verifier, MiddlewareOptions{
+			Impersonation:            lookup,
+			ImpersonationAllowWrites: allowWrites,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = FromContext(r.Context())
+			gotHeaders = r.Header
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest(method, "/organization/patients", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if org != "" {
+			req.Header.Set(OrganizationHeader, org)
+		}
+		if user != "" {
+			req.Header.Set(ImpersonateHeader, user)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(lookup, false, superAdmin, http.MethodGet, orgID, caregiverID); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if got.UserID != caregiverID || got.OrgSchemaName != "org_test_schema" || len(got.Roles) != 1 || got.Roles[0] != "CAREGIVER" {
+		t.Errorf("Expected the caregiver as effective principal, got %+v", got)
+	}
+	if !got.IsImpersonated() || got.Real().UserID != "kc-super-admin" {
+		t.Errorf("Expected the SUPER_ADMIN as real principal, got %+v", got.Impersonator)
+	}
+	if gotHeaders.Get(OrganizationHeader) != "" || gotHeaders.Get(ImpersonateHeader) != "" {
+		t.Errorf("Expected impersonation headers to be consumed, got %v", gotHeaders)
+	}
+
+	// Without the header SUPER_ADMINs act as themselves
+	if code := serve(lookup, false, superAdmin, http.MethodGet, orgID, ""); code != http.StatusOK || got.IsImpersonated() || got.Real() != got {
+		t.Errorf("Expected unimpersonated request, got %d and %+v", code, got)
+	}
+
+	testCases := []struct {
+		name           string
+		lookup         ImpersonationLookup
+		allowWrites    bool
+		token          string
+		method         string
+		org            string
+		user           string
+		expectedStatus int
+	}{
+		{"Writes allowed", lookup, true, superAdmin, http.MethodPost, orgID, caregiverID, http.StatusOK},
+		{"Read-only", lookup, false, superAdmin, http.MethodPost, orgID, caregiverID, http.StatusForbidden},
+		{"Disabled", nil, false, superAdmin, http.MethodGet, orgID, caregiverID, http.StatusForbidden},
+		{"Not a SUPER_ADMIN", lookup, false, tokenOf("kc-org-admin", "ORG_ADMIN"), http.MethodGet, orgID, caregiverID, http.StatusForbidden},
+		{"Missing organization", lookup, false, superAdmin, http.MethodGet, "", caregiverID, http.StatusBadRequest},
+		{"Malformed user", lookup, false, superAdmin, http.MethodGet, orgID, "caregiver", http.StatusBadRequest},
+		{"Unknown user", lookup, false, superAdmin, http.MethodGet, orgID, "4f2d0c2e-0c55-4c4b-8f8e-1d9a3c0b5e77", http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := serve(tc.lookup, tc.allowWrites, tc.token, tc.method, tc.org, tc.user); code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, code)
+			}
+		})
+	}
+}
+
+// TestMyPermissionsHandler_Impersonation tests that impersonated callers see
+// the permissions of the user and who impersonates them
+func TestMyPermissionsHandler_Impersonation(t *testing.T) {
+	perms := Permissions{"CAREGIVER": {"patient:view"}, "SUPER_ADMIN": {"*"}}
+	pr := &Principal{
+		UserID:       "kc-caregiver",
+		Roles:        []string{"CAREGIVER"},
+		Impersonator: &Principal{UserID: "kc-super-admin", Roles: []string{"SUPER_ADMIN"}},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/permissions/me", nil)
+	req = req.WithContext(ContextWithPrincipal(req.Context(), pr))
+	rec := httptest.NewRecorder()
+	MyPermissionsHandler(perms).ServeHTTP(rec, req)
+
+	var resp MyPermissionsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.UserID != "kc-caregiver" || resp.Impersonator != "kc-super-admin" {
+		t.Errorf("Expected caregiver impersonated by SUPER_ADMIN, got %+v", resp)
+	}
+	if len(resp.Permissions) != 1 || resp.Permissions[0] != "patient:view" {
+		t.Errorf("Expected the caregiver's permissions, got %v", resp.Permissions)
+	}
+}
+
+// TestLoadImpersonationConfig tests the impersonation settings
+func TestLoadImpersonationConfig(t *testing.T) {
+	t.Setenv("IMPERSONATION_ENABLED", "")
+	t.Setenv("IMPERSONATION_ALLOW_WRITES", "")
+	if cfg := LoadImpersonationConfig(); cfg.Enabled || cfg.AllowWrites {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+
+	t.Setenv("IMPERSONATION_ENABLED", "true")
+	t.Setenv("IMPERSONATION_ALLOW_WRITES", "true")
+	if cfg := LoadImpersonationConfig(); !cfg.Enabled || !cfg.AllowWrites {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+}
@@ -1,50 +1,101 @@
 package auth
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"sync"
 	"time"
 )
 
+// Triggers and outcomes of JWKS refreshes, as recorded in metrics
+const (
+	RefreshTriggerStartup    = "startup"
+	RefreshTriggerScheduled  = "scheduled"
+	RefreshTriggerUnknownKid = "unknown_kid"
+
+	RefreshOutcomeSuccess   = "success"
+	RefreshOutcomeFailure   = "failure"
+	RefreshOutcomeThrottled = "throttled" // Unknown kid within the cooldown, no fetch
+)
+
+// DefaultUnknownKidCooldown is the minimum time between refreshes triggered
+// by tokens with an unknown kid, so forged kids cannot flood Keycloak
+const DefaultUnknownKidCooldown = 10 * time.Second
+
+// JWKSMetricsRecorder records JWKS refreshes
+type JWKSMetricsRecorder interface {
+	RecordJWKSRefresh(ctx context.Context, trigger, outcome string)
+}
+
 type jwkKey struct {
 	Kty string `json:"kty"`
 	Kid string `json:"kid"`
 	Alg string `json:"alg"`
-	N   string `json:"n"`
-	E   string `json:"e"`
+	Use string `json:"use"`
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA exponent
+	Crv string `json:"crv"` // EC and OKP curve
+	X   string `json:"x"`
+	Y   string `json:"y"`
 }
 
 type jwksJSON struct {
 	Keys []jwkKey `json:"keys"`
 }
 
-// JWKS caches RSA public keys by kid.
+// JWKS caches signing public keys by kid: *rsa.PublicKey, *ecdsa.PublicKey
+// or ed25519.PublicKey.
 type JWKS struct {
-	url    string
-	mu     sync.RWMutex
-	keys   map[string]*rsa.PublicKey
-	ticker *time.Ticker
-	quit   chan struct{}
+	url      string
+	interval time.Duration
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	ticker   *time.Ticker
+	quit     chan struct{}
+	metrics  JWKSMetricsRecorder
+
+	refreshed  time.Time // Last successful refresh
+	refreshErr error     // Error of the last refresh, nil after a success
+
+	refreshMu    sync.Mutex    // Serializes refreshes for unknown kids
+	cooldown     time.Duration // Minimum time between refreshes for unknown kids
+	lastOnDemand time.Time     // Last refresh for an unknown kid
 }
 
 // NewJWKS creates a JWKS instance and loads keys immediately. It also starts
 // a background refresh every refreshInterval. Pass 0 to use default 15m.
 func NewJWKS(url string, refreshInterval time.Duration) (*JWKS, error) {
+	return NewJWKSWithMetrics(url, refreshInterval, nil)
+}
+
+// NewJWKSWithMetrics creates a JWKS instance that records its refreshes
+func NewJWKSWithMetrics(url string, refreshInterval time.Duration, metrics JWKSMetricsRecorder) (*JWKS, error) {
 	if refreshInterval <= 0 {
 		refreshInterval = 15 * time.Minute
 	}
 	j := &JWKS{
-		url:    url,
-		keys:   map[string]*rsa.PublicKey{},
-		ticker: time.NewTicker(refreshInterval),
-		quit:   make(chan struct{}),
+		url:      url,
+		interval: refreshInterval,
+		keys:     map[string]crypto.PublicKey{},
+		ticker:   time.NewTicker(refreshInterval),
+		quit:     make(chan struct{}),
+		metrics:  metrics,
+		cooldown: DefaultUnknownKidCooldown,
 	}
-	if err := j.refresh(); err != nil {
+	if err := j.refreshFor(RefreshTriggerStartup); err != nil {
+		j.ticker.Stop()
 		return nil, err
 	}
 	go j.loop()
@@ -55,7 +106,7 @@ func (j *JWKS) loop() {
 	for {
 		select {
 		case <-j.ticker.C:
-			_ = j.refresh()
+			_ = j.refreshFor(RefreshTriggerScheduled)
 		case <-j.quit:
 			return
 		}
@@ -68,64 +119,211 @@ func (j *JWKS) Close() {
 	j.ticker.Stop()
 }
 
+// Healthy reports an error when the keys have not been refreshed for two
+// refresh intervals, i.e. at least one background refresh in a row failed.
+// Keys without background refresh, as in tests, are always fresh.
+func (j *JWKS) Healthy(ctx context.Context) error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.interval <= 0 {
+		return nil
+	}
+	if age := time.Since(j.refreshed); age > 2*j.interval {
+		if j.refreshErr != nil {
+			return fmt.Errorf("keys last refreshed %s ago: %w", age.Round(time.Second), j.refreshErr)
+		}
+		return fmt.Errorf("keys last refreshed %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
 func (j *JWKS) refresh() error {
-	resp, err := http.Get(j.url)
+	return j.refreshFor(RefreshTriggerScheduled)
+}
+
+// refreshFor fetches the keys and records the outcome. A failed fetch keeps
+// the last good keys.
+func (j *JWKS) refreshFor(trigger string) error {
+	err := j.fetch()
+
+	j.mu.Lock()
+	j.refreshErr = err
+	if err == nil {
+		j.refreshed = time.Now()
+	}
+	j.mu.Unlock()
+
+	outcome := RefreshOutcomeSuccess
+	if err != nil {
+		outcome = RefreshOutcomeFailure
+		slog.Warn("failed to refresh JWKS, keeping last good keys", "url", j.url, "trigger", trigger, "error", err)
+	}
+	j.record(trigger, outcome)
+	return err
+}
+
+func (j *JWKS) record(trigger, outcome string) {
+	if j.metrics != nil {
+		j.metrics.RecordJWKSRefresh(context.Background(), trigger, outcome)
+	}
+}
+
+func (j *JWKS) fetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: unexpected status %d", resp.StatusCode)
+	}
+
 	var raw jwksJSON
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
 		return err
 	}
 
-	newKeys := make(map[string]*rsa.PublicKey)
+	// Keys of other types or uses, such as Keycloak's encryption keys, are
+	// skipped; one malformed key does not discard the others
+	newKeys := make(map[string]crypto.PublicKey)
 	for _, k := range raw.Keys {
-		if k.Kty != "RSA" {
+		if k.Use != "" && k.Use != "sig" {
 			continue
 		}
+		pub, err := parseJWK(k)
+		if err != nil {
+			slog.Warn("skipping JWKS key", "kid", k.Kid, "kty", k.Kty, "error", err)
+			continue
+		}
+		newKeys[k.Kid] = pub
+	}
+	// An empty key set would reject every token, so it counts as a failed fetch
+	if len(newKeys) == 0 {
+		return errors.New("jwks: no usable signing keys")
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.keys = newKeys
+	return nil
+}
+
+// parseJWK decodes an RSA, EC (P-256, P-384, P-521) or OKP (Ed25519) key
+func parseJWK(k jwkKey) (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
 		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		n := new(big.Int).SetBytes(nBytes)
 		e := bytesToInt(eBytes)
-		pub := &rsa.PublicKey{
+		return &rsa.PublicKey{
 			N: n,
 			E: e,
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var validator ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, validator = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, validator = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, validator = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
 		}
-		newKeys[k.Kid] = pub
-	}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid coordinate length")
+		}
+		// Reject points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := validator.NewPublicKey(point); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
 
-	j.mu.Lock()
-	defer j.mu.Unlock()
-	j.keys = newKeys
-	return nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid key length")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
 }
 
-func (j *JWKS) Get(kid string) (*rsa.PublicKey, error) {
-	j.mu.RLock()
-	p := j.keys[kid]
-	j.mu.RUnlock()
-	if p != nil {
+// Get returns the key with kid. An unknown kid, as after a key rotation,
+// refreshes the keys at most once per cooldown.
+func (j *JWKS) Get(kid string) (crypto.PublicKey, error) {
+	if p := j.key(kid); p != nil {
 		return p, nil
 	}
-	// try refresh once
-	if err := j.refresh(); err != nil {
+	if j.url == "" {
+		// Static keys, as in tests
+		return nil, errors.New("jwks: key not found")
+	}
+
+	// Concurrent requests with the new kid wait for a single refresh
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+	if p := j.key(kid); p != nil {
+		return p, nil
+	}
+	if time.Since(j.lastOnDemand) < j.cooldown {
+		j.record(RefreshTriggerUnknownKid, RefreshOutcomeThrottled)
+		return nil, errors.New("jwks: key not found")
+	}
+	j.lastOnDemand = time.Now()
+	if err := j.refreshFor(RefreshTriggerUnknownKid); err != nil {
 		return nil, err
 	}
+
+	if p := j.key(kid); p != nil {
+		return p, nil
+	}
+	return nil, errors.New("jwks: key not found")
+}
+
+func (j *JWKS) key(kid string) crypto.PublicKey {
 	j.mu.RLock()
 	defer j.mu.RUnlock()
-	p = j.keys[kid]
-	if p == nil {
-		return nil, errors.New("jwks: key not found")
-	}
-	return p, nil
+	return j.keys[kid]
 }
 
 func bytesToInt(b []byte) int {
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestJWKSHealthy(t *testing.T) {
+	key := generateECKey(t)
+	server := newJWKSServer(t, ecJWK("current", &key.PublicKey))
+
+	jwks, err := NewJWKS(server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKS failed: %v", err)
+	}
+	defer jwks.Close()
+
+	if err := jwks.Healthy(context.Background()); err != nil {
+		t.Errorf("Expected fresh keys to be healthy, got %v", err)
+	}
+
+	// Two missed refreshes make the keys stale
+	jwks.mu.Lock()
+	jwks.refreshed = time.Now().Add(-3 * time.Hour)
+	jwks.mu.Unlock()
+	if err := jwks.Healthy(context.Background()); err == nil {
+		t.Error("Expected stale keys to be unhealthy")
+	}
+
+	// A failed refresh is reported with the error
+	server.Close()
+	jwks.refresh()
+	if err := jwks.Healthy(context.Background()); err == nil {
+		t.Error("Expected failed refresh to be unhealthy")
+	}
+}
+
+func TestTestJWKSIsAlwaysHealthy(t *testing.T) {
+	if err := NewTestJWKS(nil).Healthy(context.Background()); err != nil {
+		t.Errorf("Expected static keys to be healthy, got %v", err)
+	}
+}
+
+// recordedRefreshes collects JWKS refresh metrics
+type recordedRefreshes struct {
+	mu       sync.Mutex
+	outcomes []string
+}
+
+func (r *recordedRefreshes) RecordJWKSRefresh(ctx context.Context, trigger, outcome string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, trigger+":"+outcome)
+}
+
+// jwksServer serves the keys set with setKeys, or the status set with
+// setStatus, and counts requests
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	mu       sync.Mutex
+	status   int
+	keys     []jwkKey
+}
+
+func newJWKSServer(t *testing.T, keys ...jwkKey) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{status: http.StatusOK, keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		json.NewEncoder(w).Encode(jwksJSON{Keys: s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...jwkKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func ecJWK(kid string, pub *ecdsa.PublicKey) jwkKey {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	return jwkKey{
+		Kty: "EC", Kid: kid, Use: "sig", Crv: pub.Curve.Params().Name,
+		X: base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+		Y: base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func generateECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	return key
+}
+
+// TestJWKSRefreshOnUnknownKid tests that a rotated key is fetched when a
+// token with its kid arrives, and that further unknown kids are throttled
+func TestJWKSRefreshOnUnknownKid(t *testing.T) {
+	oldKey, newKey := generateECKey(t), generateECKey(t)
+	server := newJWKSServer(t, ecJWK("old", &oldKey.PublicKey))
+	metrics := &recordedRefreshes{}
+
+	jwks, err := NewJWKSWithMetrics(server.URL, time.Hour, metrics)
+	if err != nil {
+		t.Fatalf("NewJWKSWithMetrics failed: %v", err)
+	}
+	defer jwks.Close()
+
+	server.setKeys(ecJWK("old", &oldKey.PublicKey), ecJWK("new", &newKey.PublicKey))
+	if _, err := jwks.Get("new"); err != nil {
+		t.Fatalf("Expected rotated key to be fetched, got %v", err)
+	}
+	if _, err := jwks.Get("forged"); err == nil {
+		t.Error("Expected unknown kid within the cooldown to fail")
+	}
+	if _, err := jwks.Get("forged"); err == nil {
+		t.Error("Expected unknown kid within the cooldown to fail")
+	}
+	if got := server.requests.Load(); got != 2 {
+		t.Errorf("Expected 2 fetches, got %d", got)
+	}
+
+	want := []string{"startup:success", "unknown_kid:success", "unknown_kid:throttled", "unknown_kid:throttled"}
+	if len(metrics.outcomes) != len(want) {
+		t.Fatalf("Expected outcomes %v, got %v", want, metrics.outcomes)
+	}
+	for i := range want {
+		if metrics.outcomes[i] != want[i] {
+			t.Errorf("Expected outcomes %v, got %v", want, metrics.outcomes)
+			break
+		}
+	}
+
+	// After the cooldown an unknown kid refreshes again
+	jwks.cooldown = 0
+	if _, err := jwks.Get("forged"); err == nil {
+		t.Error("Expected unknown kid to fail after refresh")
+	}
+	if got := server.requests.Load(); got != 3 {
+		t.Errorf("Expected 3 fetches, got %d", got)
+	}
+}
+
+// TestJWKSKeepsLastGoodKeys tests that failed or unusable responses keep the
+// keys of the last successful refresh
+func TestJWKSKeepsLastGoodKeys(t *testing.T) {
+	key := generateECKey(t)
+	server := newJWKSServer(t, ecJWK("current", &key.PublicKey))
+
+	jwks, err := NewJWKS(server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKS failed: %v", err)
+	}
+	defer jwks.Close()
+
+	server.setStatus(http.StatusInternalServerError)
+	if err := jwks.refresh(); err == nil {
+		t.Error("Expected refresh to fail on status 500")
+	}
+	if _, err := jwks.Get("current"); err != nil {
+		t.Errorf("Expected key to be kept after failed refresh, got %v", err)
+	}
+
+	server.setStatus(http.StatusOK)
+	server.setKeys(jwkKey{Kty: "EC", Kid: "broken", Crv: "P-256", X: "AA", Y: "AA"})
+	if err := jwks.refresh(); err == nil {
+		t.Error("Expected refresh without usable keys to fail")
+	}
+	if _, err := jwks.Get("current"); err != nil {
+		t.Errorf("Expected key to be kept after unusable response, got %v", err)
+	}
+
+	server.setKeys()
+	if err := jwks.refresh(); err == nil {
+		t.Error("Expected refresh with an empty key set to fail")
+	}
+	if _, err := jwks.Get("current"); err != nil {
+		t.Errorf("Expected key to be kept after empty response, got %v", err)
+	}
+}
+
+// TestParseJWK tests decoding of the supported key types
+func TestParseJWK(t *testing.T) {
+	ecKey := generateECKey(t)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	_, rsaPublic := generateTestKeyPair(t)
+
+	offCurve := ecJWK("off-curve", &ecKey.PublicKey)
+	offCurve.Y = offCurve.X
+
+	testCases := []struct {
+		name  string
+		key   jwkKey
+		valid bool
+	}{
+		{"RSA", jwkKey{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(rsaPublic.N.Bytes()),
+			E:   "AQAB",
+		}, true},
+		{"EC P-256", ecJWK("ec", &ecKey.PublicKey), true},
+		{"Ed25519", jwkKey{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edPublic)}, true},
+		{"EC point off the curve", offCurve, false},
+		{"Unsupported curve", jwkKey{Kty: "OKP", Crv: "X25519", X: "AA"}, false},
+		{"Unsupported key type", jwkKey{Kty: "oct"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseJWK(tc.key)
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+// TestVerifier_ParseAndVerifyToken_ES256 tests tokens of realms that sign
+// with EC keys
+func TestVerifier_ParseAndVerifyToken_ES256(t *testing.T) {
+	key := generateECKey(t)
+	server := newJWKSServer(t, ecJWK("ec-key", &key.PublicKey))
+	jwks, err := NewJWKS(server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewJWKS failed: %v", err)
+	}
+	defer jwks.Close()
+
+	cfg := Config{Issuer: "https://test-keycloak.com/realms/test"}
+	verifier := NewVerifier(cfg, jwks)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"sub": "user-123",
+		"iss": cfg.Issuer,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "ec-key"
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	if _, err := verifier.ParseAndVerifyToken(tokenString); err != nil {
+		t.Errorf("Expected ES256 token to verify, got %v", err)
+	}
+
+	// An RS256 header does not make the EC key usable as an RSA key
+	rsaKey, _ := generateTestKeyPair(t)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, token.Claims)
+	forged.Header["kid"] = "ec-key"
+	forgedString, _ := forged.SignedString(rsaKey)
+	if _, err := verifier.ParseAndVerifyToken(forgedString); err == nil {
+		t.Error("Expected RS256 token with an EC key to be rejected")
+	}
+}
@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -14,42 +17,111 @@ type Principal struct {
 	OrgID         string
 	OrgSchemaName string
 	Claims        jwt.MapClaims
+
+	Type     string // PrincipalTypeUser or PrincipalTypeService
+	ClientID string // Keycloak client of a service account
+
+	// Impersonator is the SUPER_ADMIN who authenticated the request when the
+	// principal is a tenant user they impersonate; nil otherwise
+	Impersonator *Principal
+}
+
+// Types of principals
+const (
+	PrincipalTypeUser    = "user"    // A person signed in to Keycloak
+	PrincipalTypeService = "service" // A client-credentials token of another service
+)
+
+// IsService reports whether the principal is a service account
+func (p *Principal) IsService() bool {
+	return p.Type == PrincipalTypeService
 }
 
 var (
-	ErrNoToken       = errors.New("no token provided")
-	ErrInvalidToken  = errors.New("invalid token")
-	ErrInvalidIssuer = errors.New("invalid issuer")
-	ErrMissingSub    = errors.New("missing sub claim")
+	ErrNoToken                = errors.New("no token provided")
+	ErrInvalidToken           = errors.New("invalid token")
+	ErrInvalidIssuer          = errors.New("invalid issuer")
+	ErrInvalidAudience        = errors.New("invalid audience")
+	ErrInvalidAuthorizedParty = errors.New("invalid authorized party")
+	ErrMissingSub             = errors.New("missing sub claim")
 )
 
 type Verifier struct {
-	cfg  Config
-	jwks *JWKS
+	cfg     Config
+	issuers map[string]*JWKS // Signing keys by trusted issuer
 }
 
 // NewVerifier constructs a verifier with config and JWKS.
+// jwks holds the keys of cfg.Issuer.
 func NewVerifier(cfg Config, jwks *JWKS) *Verifier {
-	return &Verifier{cfg: cfg, jwks: jwks}
+	return NewMultiIssuerVerifier(cfg, map[string]*JWKS{cfg.Issuer: jwks})
+}
+
+// NewMultiIssuerVerifier constructs a verifier that trusts every issuer in
+// keys, each with its own signing keys
+func NewMultiIssuerVerifier(cfg Config, keys map[string]*JWKS) *Verifier {
+	return &Verifier{cfg: cfg, issuers: keys}
 }
 
-// ParseAndVerifyToken verifies a bearer token, validates issuer/exp and returns Principal.
+// Healthy reports whether the signing keys of every issuer are fresh
+func (v *Verifier) Healthy(ctx context.Context) error {
+	if len(v.issuers) == 0 {
+		return errors.New("no JWKS configured")
+	}
+	for issuer, jwks := range v.issuers {
+		if jwks == nil {
+			return errors.New("no JWKS configured")
+		}
+		if err := jwks.Healthy(ctx); err != nil {
+			if len(v.issuers) > 1 {
+				return fmt.Errorf("%s: %w", issuer, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAndVerifyToken verifies a bearer token, validates issuer, audience,
+// authorized party and exp/nbf/iat (with the configured leeway) and returns
+// Principal.
 func (v *Verifier) ParseAndVerifyToken(tokenString string) (*Principal, error) {
 	if tokenString == "" {
 		return nil, ErrNoToken
 	}
 	tokenString = strings.TrimSpace(tokenString)
-	parsed, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		// enforce RS256
-		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+
+	// Time claims are checked below with leeway
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	untrustedIssuer := false
+	parsed, err := parser.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		// asymmetric algorithms only; the key type must match the algorithm
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+		default:
 			return nil, ErrInvalidToken
 		}
 		kid, _ := t.Header["kid"].(string)
 		if kid == "" {
 			return nil, ErrInvalidToken
 		}
-		return v.jwks.Get(kid)
+		// The issuer selects the keys, so it is read before the signature
+		// is verified
+		claims, _ := t.Claims.(jwt.MapClaims)
+		iss, _ := claims["iss"].(string)
+		jwks, ok := v.issuers[iss]
+		if !ok {
+			untrustedIssuer = true
+			return nil, ErrInvalidIssuer
+		}
+		if jwks == nil {
+			return nil, ErrInvalidToken
+		}
+		return jwks.Get(kid)
 	})
+	if untrustedIssuer {
+		return nil, ErrInvalidIssuer
+	}
 	if err != nil || !parsed.Valid {
 		return nil, ErrInvalidToken
 	}
@@ -57,14 +129,25 @@ func (v *Verifier) ParseAndVerifyToken(tokenString string) (*Principal, error) {
 	if !ok {
 		return nil, ErrInvalidToken
 	}
-	// issuer
-	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
-		return nil, ErrInvalidIssuer
-	}
-	// exp
-	if !claims.VerifyExpiresAt(jwt.TimeFunc().Unix(), true) {
+	// exp is required; nbf and iat are checked when present
+	now := jwt.TimeFunc()
+	if !claims.VerifyExpiresAt(now.Add(-v.cfg.Leeway).Unix(), true) ||
+		!claims.VerifyNotBefore(now.Add(v.cfg.Leeway).Unix(), false) ||
+		!claims.VerifyIssuedAt(now.Add(v.cfg.Leeway).Unix(), false) {
 		return nil, ErrInvalidToken
 	}
+	// audience: at least one aud value must be accepted
+	if len(v.cfg.Audiences) > 0 && !slices.ContainsFunc(audiences(claims), func(aud string) bool {
+		return slices.Contains(v.cfg.Audiences, aud)
+	}) {
+		return nil, ErrInvalidAudience
+	}
+	// authorized party: the client the token was issued to
+	if len(v.cfg.AuthorizedParties) > 0 {
+		if azp, _ := claims["azp"].(string); !slices.Contains(v.cfg.AuthorizedParties, azp) {
+			return nil, ErrInvalidAuthorizedParty
+		}
+	}
 
 	sub, _ := claims["sub"].(string)
 	if sub == "" {
@@ -83,6 +166,19 @@ func (v *Verifier) ParseAndVerifyToken(tokenString string) (*Principal, error) {
 		}
 	}
 
+	// Service accounts get the permissions of their client, and select the
+	// tenant per request rather than through token claims
+	if clientID := serviceAccountClient(claims); clientID != "" {
+		return &Principal{
+			UserID:   sub,
+			Roles:    []string{ServiceAccountRole, ClientRole(clientID)},
+			Claims:   claims,
+			Type:     PrincipalTypeService,
+			ClientID: clientID,
+		}, nil
+	}
+	roles = slices.DeleteFunc(roles, isServiceAccountRole)
+
 	// organizationID may be string or number
 	var orgID string
 	if v, ok := claims["organizationID"].(string); ok {
@@ -101,5 +197,23 @@ func (v *Verifier) ParseAndVerifyToken(tokenString string) (*Principal, error) {
 		OrgID:         orgID,
 		OrgSchemaName: orgSchemaName,
 		Claims:        claims,
+		Type:          PrincipalTypeUser,
 	}, nil
 }
+
+// audiences returns the aud claim, which is a string or a list of strings
+func audiences(claims jwt.MapClaims) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		var list []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
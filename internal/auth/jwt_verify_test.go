@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"testing"
@@ -312,6 +313,143 @@ func TestVerifier_ParseAndVerifyToken_NoOrgClaims(t *testing.T) {
 	}
 }
 
+// TestVerifier_ParseAndVerifyToken_ClockSkew tests that exp, nbf and iat
+// are checked with the configured leeway
+func TestVerifier_ParseAndVerifyToken_ClockSkew(t *testing.T) {
+	privateKey, publicKey := generateTestKeyPair(t)
+	cfg := Config{Issuer: "https://test-keycloak.com/realms/test", Leeway: time.Minute}
+	verifier := NewVerifier(cfg, newMockJWKS(publicKey))
+
+	now := time.Now()
+	testCases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected error
+	}{
+		{"Expired within leeway", jwt.MapClaims{"exp": now.Add(-30 * time.Second).Unix()}, nil},
+		{"Expired beyond leeway", jwt.MapClaims{"exp": now.Add(-2 * time.Minute).Unix()}, ErrInvalidToken},
+		{"Not yet valid within leeway", jwt.MapClaims{"nbf": now.Add(30 * time.Second).Unix()}, nil},
+		{"Not yet valid beyond leeway", jwt.MapClaims{"nbf": now.Add(2 * time.Minute).Unix()}, ErrInvalidToken},
+		{"Issued in the future beyond leeway", jwt.MapClaims{"iat": now.Add(2 * time.Minute).Unix()}, ErrInvalidToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"sub": "user-123", "iss": cfg.Issuer, "exp": now.Add(time.Hour).Unix()}
+			for k, v := range tc.claims {
+				claims[k] = v
+			}
+
+			_, err := verifier.ParseAndVerifyToken(signTestToken(t, privateKey, claims))
+			if err != tc.expected {
+				t.Errorf("Expected %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// TestVerifier_ParseAndVerifyToken_AudienceAndAuthorizedParty tests the aud
+// and azp allow-lists
+func TestVerifier_ParseAndVerifyToken_AudienceAndAuthorizedParty(t *testing.T) {
+	privateKey, publicKey := generateTestKeyPair(t)
+	cfg := Config{
+		Issuer:            "https://test-keycloak.com/realms/test",
+		Audiences:         []string{"organization-service"},
+		AuthorizedParties: []string{"wailsalutem-web", "wailsalutem-mobile"},
+	}
+	verifier := NewVerifier(cfg, newMockJWKS(publicKey))
+
+	testCases := []struct {
+		name     string
+		aud      interface{}
+		azp      interface{}
+		expected error
+	}{
+		{"Accepted audience string", "organization-service", "wailsalutem-web", nil},
+		{"Accepted audience in list", []interface{}{"account", "organization-service"}, "wailsalutem-mobile", nil},
+		{"Other audience", "account", "wailsalutem-web", ErrInvalidAudience},
+		{"Missing audience", nil, "wailsalutem-web", ErrInvalidAudience},
+		{"Other client", "organization-service", "admin-cli", ErrInvalidAuthorizedParty},
+		{"Missing azp", "organization-service", nil, ErrInvalidAuthorizedParty},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"sub": "user-123", "iss": cfg.Issuer, "exp": time.Now().Add(time.Hour).Unix()}
+			if tc.aud != nil {
+				claims["aud"] = tc.aud
+			}
+			if tc.azp != nil {
+				claims["azp"] = tc.azp
+			}
+
+			_, err := verifier.ParseAndVerifyToken(signTestToken(t, privateKey, claims))
+			if err != tc.expected {
+				t.Errorf("Expected %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// TestVerifier_ParseAndVerifyToken_MultipleIssuers tests that each trusted
+// issuer is verified with its own keys
+func TestVerifier_ParseAndVerifyToken_MultipleIssuers(t *testing.T) {
+	oldKey, oldPublic := generateTestKeyPair(t)
+	newKey, newPublic := generateTestKeyPair(t)
+	oldIssuer := "https://keycloak.example.com/realms/old"
+	newIssuer := "https://keycloak.example.com/realms/new"
+
+	verifier := NewMultiIssuerVerifier(Config{Issuer: newIssuer}, map[string]*JWKS{
+		oldIssuer: newMockJWKS(oldPublic),
+		newIssuer: newMockJWKS(newPublic),
+	})
+
+	claims := func(iss string) jwt.MapClaims {
+		return jwt.MapClaims{"sub": "user-123", "iss": iss, "exp": time.Now().Add(time.Hour).Unix()}
+	}
+
+	if _, err := verifier.ParseAndVerifyToken(signTestToken(t, oldKey, claims(oldIssuer))); err != nil {
+		t.Errorf("Expected old realm token to verify, got: %v", err)
+	}
+	if _, err := verifier.ParseAndVerifyToken(signTestToken(t, newKey, claims(newIssuer))); err != nil {
+		t.Errorf("Expected new realm token to verify, got: %v", err)
+	}
+	// A key of one realm does not vouch for the other
+	if _, err := verifier.ParseAndVerifyToken(signTestToken(t, oldKey, claims(newIssuer))); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for cross-realm signature, got: %v", err)
+	}
+	if _, err := verifier.ParseAndVerifyToken(signTestToken(t, newKey, claims("https://evil.example.com/realms/new"))); err != ErrInvalidIssuer {
+		t.Errorf("Expected ErrInvalidIssuer, got: %v", err)
+	}
+}
+
+// TestLoadConfig_TokenValidation tests the token validation settings
+func TestLoadConfig_TokenValidation(t *testing.T) {
+	t.Setenv("KEYCLOAK_BASE_URL", "http://keycloak:8080")
+	t.Setenv("KEYCLOAK_REALM", "wailsalutem")
+	t.Setenv("KEYCLOAK_ADDITIONAL_ISSUERS", "http://keycloak:8080/realms/legacy/, ")
+	t.Setenv("TOKEN_AUDIENCES", "organization-service, account")
+	t.Setenv("TOKEN_AUTHORIZED_PARTIES", "")
+	t.Setenv("TOKEN_LEEWAY", "10s")
+
+	cfg := LoadConfig()
+
+	issuers := cfg.Issuers()
+	if len(issuers) != 2 || issuers[1].Issuer != "http://keycloak:8080/realms/legacy" ||
+		issuers[1].JWKSURL != "http://keycloak:8080/realms/legacy/protocol/openid-connect/certs" {
+		t.Errorf("Unexpected issuers %+v", issuers)
+	}
+	if len(cfg.Audiences) != 2 || cfg.Audiences[1] != "account" {
+		t.Errorf("Unexpected audiences %v", cfg.Audiences)
+	}
+	if cfg.AuthorizedParties != nil {
+		t.Errorf("Expected no authorized parties, got %v", cfg.AuthorizedParties)
+	}
+	if cfg.Leeway != 10*time.Second {
+		t.Errorf("Expected leeway 10s, got %s", cfg.Leeway)
+	}
+}
+
 // Helper functions
 
 // generateTestKeyPair generates an RSA key pair for testing
@@ -328,8 +466,21 @@ func generateTestKeyPair(t *testing.T) (*rsa.PrivateKey, *rsa.PublicKey) {
 // newMockJWKS creates a mock JWKS for testing
 func newMockJWKS(publicKey *rsa.PublicKey) *JWKS {
 	return &JWKS{
-		keys: map[string]*rsa.PublicKey{
+		keys: map[string]crypto.PublicKey{
 			"test-key-id": publicKey,
 		},
 	}
 }
+
+// signTestToken signs claims with the key of newMockJWKS
+func signTestToken(t *testing.T, privateKey *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key-id"
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return tokenString
+}
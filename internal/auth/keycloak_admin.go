@@ -2,11 +2,12 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -142,8 +143,16 @@ func (t *otelTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
-// getAdminToken obtains an admin access token using client credentials
-func (k *KeycloakAdminClient) getAdminToken() (string, error) {
+// Healthy reports whether an admin token can be obtained. A cached token
+// that has not expired counts as healthy, so probes do not hit Keycloak.
+func (k *KeycloakAdminClient) Healthy(ctx context.Context) error {
+	_, err := k.getAdminTokenContext(ctx)
+	return err
+}
+
+// getAdminTokenContext obtains an admin access token, giving up when ctx is
+// done
+func (k *KeycloakAdminClient) getAdminTokenContext(ctx context.Context) (string, error) {
 	k.tokenMux.RLock()
 	if k.accessToken != "" && time.Now().Before(k.tokenExpiry) {
 		token := k.accessToken
@@ -167,7 +176,7 @@ func (k *KeycloakAdminClient) getAdminToken() (string, error) {
 	data.Set("client_id", k.clientID)
 	data.Set("client_secret", k.clientSecret)
 
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("failed to create token request: %w", err)
 	}
@@ -182,7 +191,7 @@ func (k *KeycloakAdminClient) getAdminToken() (string, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to get admin token: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to get Keycloak admin token", "status", resp.StatusCode, "response", string(body))
 		return "", ErrUnauthorized
 	}
 
@@ -199,14 +208,14 @@ func (k *KeycloakAdminClient) getAdminToken() (string, error) {
 	k.accessToken = result.AccessToken
 	k.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn-60) * time.Second)
 
-	log.Printf("Obtained new Keycloak admin token (expires in %d seconds)", result.ExpiresIn)
+	slog.DebugContext(ctx, "obtained new Keycloak admin token", "expires_in_seconds", result.ExpiresIn)
 
 	return k.accessToken, nil
 }
 
 // CreateUser creates a new user in Keycloak
-func (k *KeycloakAdminClient) CreateUser(user KeycloakUser) (string, error) {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) CreateUser(ctx context.Context, user KeycloakUser) (string, error) {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -218,9 +227,7 @@ func (k *KeycloakAdminClient) CreateUser(user KeycloakUser) (string, error) {
 		return "", fmt.Errorf("failed to marshal user: %w", err)
 	}
 
-	log.Printf("Creating Keycloak user with payload: %s", string(body))
-
-	req, err := http.NewRequest("POST", createURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", createURL, bytes.NewBuffer(body))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -236,7 +243,7 @@ func (k *KeycloakAdminClient) CreateUser(user KeycloakUser) (string, error) {
 
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to create user: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to create Keycloak user", "status", resp.StatusCode, "response", string(body))
 		return "", fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
@@ -253,14 +260,14 @@ func (k *KeycloakAdminClient) CreateUser(user KeycloakUser) (string, error) {
 	}
 	userID := parts[len(parts)-1]
 
-	log.Printf("Created user in Keycloak: %s (ID: %s)", user.Username, userID)
+	slog.InfoContext(ctx, "created user in Keycloak", "username", user.Username, "keycloak_id", userID)
 
 	return userID, nil
 }
 
 // SetPassword sets or resets a user's password
-func (k *KeycloakAdminClient) SetPassword(userID string, password string, temporary bool) error {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) SetPassword(ctx context.Context, userID string, password string, temporary bool) error {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -278,7 +285,7 @@ func (k *KeycloakAdminClient) SetPassword(userID string, password string, tempor
 		return fmt.Errorf("failed to marshal password reset: %w", err)
 	}
 
-	req, err := http.NewRequest("PUT", resetURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "PUT", resetURL, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -294,18 +301,18 @@ func (k *KeycloakAdminClient) SetPassword(userID string, password string, tempor
 
 	if resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to set password: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to set Keycloak password", "status", resp.StatusCode, "response", string(body))
 		return fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
-	log.Printf("Set password for user %s (temporary: %v)", userID, temporary)
+	slog.InfoContext(ctx, "set Keycloak password", "keycloak_id", userID, "temporary", temporary)
 
 	return nil
 }
 
 // UpdateUser updates a user's profile information in Keycloak
-func (k *KeycloakAdminClient) UpdateUser(userID string, user KeycloakUser) error {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) UpdateUser(ctx context.Context, userID string, user KeycloakUser) error {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -317,9 +324,7 @@ func (k *KeycloakAdminClient) UpdateUser(userID string, user KeycloakUser) error
 		return fmt.Errorf("failed to marshal user: %w", err)
 	}
 
-	log.Printf("Updating Keycloak user %s with payload: %s", userID, string(body))
-
-	req, err := http.NewRequest("PUT", updateURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "PUT", updateURL, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -335,25 +340,25 @@ func (k *KeycloakAdminClient) UpdateUser(userID string, user KeycloakUser) error
 
 	if resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to update user: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to update Keycloak user", "status", resp.StatusCode, "response", string(body))
 		return fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
-	log.Printf("Updated user in Keycloak: %s", userID)
+	slog.InfoContext(ctx, "updated user in Keycloak", "keycloak_id", userID)
 
 	return nil
 }
 
 // GetUser retrieves a user from Keycloak by user ID
-func (k *KeycloakAdminClient) GetUser(userID string) (*KeycloakUser, error) {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) GetUser(ctx context.Context, userID string) (*KeycloakUser, error) {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	getUserURL := fmt.Sprintf("%s/admin/realms/%s/users/%s", k.baseURL, k.realm, userID)
 
-	req, err := http.NewRequest("GET", getUserURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", getUserURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -368,7 +373,7 @@ func (k *KeycloakAdminClient) GetUser(userID string) (*KeycloakUser, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to get user from Keycloak: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to get Keycloak user", "status", resp.StatusCode, "response", string(body))
 		if resp.StatusCode == http.StatusNotFound {
 			return nil, ErrUserNotFound
 		}
@@ -383,16 +388,106 @@ func (k *KeycloakAdminClient) GetUser(userID string) (*KeycloakUser, error) {
 	return &user, nil
 }
 
+// usersPageSize is the number of users requested per page of a user search
+const usersPageSize = 100
+
+// ListUsersByAttribute returns every user whose attribute name has value,
+// a page of users per request
+func (k *KeycloakAdminClient) ListUsersByAttribute(ctx context.Context, name, value string) ([]KeycloakUser, error) {
+	token, err := k.getAdminTokenContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []KeycloakUser
+	for first := 0; ; first += usersPageSize {
+		searchURL := fmt.Sprintf("%s/admin/realms/%s/users?briefRepresentation=true&q=%s&first=%d&max=%d",
+			k.baseURL, k.realm, url.QueryEscape(name+":"+value), first, usersPageSize)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := k.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search users: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			slog.ErrorContext(ctx, "failed to search Keycloak users", "status", resp.StatusCode, "response", string(body))
+			return nil, fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
+		}
+
+		var page []KeycloakUser
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode users: %w", err)
+		}
+
+		users = append(users, page...)
+		if len(page) < usersPageSize {
+			return users, nil
+		}
+	}
+}
+
+// FindUserByUsername looks up a user by exact username
+func (k *KeycloakAdminClient) FindUserByUsername(ctx context.Context, username string) (*KeycloakUser, error) {
+	token, err := k.getAdminTokenContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	searchURL := fmt.Sprintf("%s/admin/realms/%s/users?exact=true&username=%s",
+		k.baseURL, k.realm, url.QueryEscape(username))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		slog.ErrorContext(ctx, "failed to search Keycloak users", "status", resp.StatusCode, "response", string(body))
+		return nil, fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
+	}
+
+	var users []KeycloakUser
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+
+	if len(users) == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	return &users[0], nil
+}
+
 // GetRole fetches a realm role by name
-func (k *KeycloakAdminClient) GetRole(roleName string) (*KeycloakRole, error) {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) GetRole(ctx context.Context, roleName string) (*KeycloakRole, error) {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	roleURL := fmt.Sprintf("%s/admin/realms/%s/roles/%s", k.baseURL, k.realm, roleName)
 
-	req, err := http.NewRequest("GET", roleURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", roleURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -411,7 +506,7 @@ func (k *KeycloakAdminClient) GetRole(roleName string) (*KeycloakRole, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to get role: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to get Keycloak role", "status", resp.StatusCode, "response", string(body))
 		return nil, fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
@@ -424,8 +519,8 @@ func (k *KeycloakAdminClient) GetRole(roleName string) (*KeycloakRole, error) {
 }
 
 // AssignRole assigns a realm role to a user
-func (k *KeycloakAdminClient) AssignRole(userID string, role KeycloakRole) error {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) AssignRole(ctx context.Context, userID string, role KeycloakRole) error {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -439,7 +534,7 @@ func (k *KeycloakAdminClient) AssignRole(userID string, role KeycloakRole) error
 		return fmt.Errorf("failed to marshal role: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", assignURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", assignURL, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -455,25 +550,25 @@ func (k *KeycloakAdminClient) AssignRole(userID string, role KeycloakRole) error
 
 	if resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to assign role: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to assign Keycloak role", "status", resp.StatusCode, "response", string(body))
 		return fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
-	log.Printf("Assigned role %s to user %s", role.Name, userID)
+	slog.InfoContext(ctx, "assigned Keycloak role", "role", role.Name, "keycloak_id", userID)
 
 	return nil
 }
 
 // DeleteUser deletes a user from Keycloak (for rollback)
-func (k *KeycloakAdminClient) DeleteUser(userID string) error {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) DeleteUser(ctx context.Context, userID string) error {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return err
 	}
 
 	deleteURL := fmt.Sprintf("%s/admin/realms/%s/users/%s", k.baseURL, k.realm, userID)
 
-	req, err := http.NewRequest("DELETE", deleteURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", deleteURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -488,18 +583,18 @@ func (k *KeycloakAdminClient) DeleteUser(userID string) error {
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to delete user: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to delete Keycloak user", "status", resp.StatusCode, "response", string(body))
 		return fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
-	log.Printf("Deleted user from Keycloak: %s", userID)
+	slog.InfoContext(ctx, "deleted user from Keycloak", "keycloak_id", userID)
 
 	return nil
 }
 
 // SendEmailAction sends an email action to a user (e.g., UPDATE_PASSWORD)
-func (k *KeycloakAdminClient) SendEmailAction(userID string, actions []string) error {
-	token, err := k.getAdminToken()
+func (k *KeycloakAdminClient) SendEmailAction(ctx context.Context, userID string, actions []string) error {
+	token, err := k.getAdminTokenContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -511,7 +606,7 @@ func (k *KeycloakAdminClient) SendEmailAction(userID string, actions []string) e
 		return fmt.Errorf("failed to marshal actions: %w", err)
 	}
 
-	req, err := http.NewRequest("PUT", emailURL, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "PUT", emailURL, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -527,11 +622,11 @@ func (k *KeycloakAdminClient) SendEmailAction(userID string, actions []string) e
 
 	if resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Failed to send email action: %d - %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "failed to send Keycloak email action", "status", resp.StatusCode, "response", string(body))
 		return fmt.Errorf("%w: status %d", ErrKeycloakRequest, resp.StatusCode)
 	}
 
-	log.Printf("Sent email action to user %s: %v", userID, actions)
+	slog.InfoContext(ctx, "sent Keycloak email action", "keycloak_id", userID, "actions", actions)
 
 	return nil
 }
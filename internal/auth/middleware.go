@@ -4,11 +4,14 @@ package auth
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/logging"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -35,6 +38,33 @@ func Middleware(ver *Verifier) func(http.Handler) http.Handler {
 
 // MiddlewareWithMetrics validates token with metrics recording
 func MiddlewareWithMetrics(ver *Verifier, metrics MetricsRecorder) func(http.Handler) http.Handler {
+	return MiddlewareWithStatus(ver, metrics, nil)
+}
+
+// MiddlewareWithStatus validates token with metrics recording and, when
+// statuses is not nil, rejects tokens of deleted or inactive principals and
+// of principals of inactive organizations
+func MiddlewareWithStatus(ver *Verifier, metrics MetricsRecorder, statuses *StatusCache) func(http.Handler) http.Handler {
+	return MiddlewareWithOptions(ver, MiddlewareOptions{Metrics: metrics, Statuses: statuses})
+}
+
+// MiddlewareOptions configures MiddlewareWithOptions; every field is optional
+type MiddlewareOptions struct {
+	Metrics        MetricsRecorder
+	ServiceMetrics ServiceMetricsRecorder // Records requests of service accounts
+	Statuses       *StatusCache           // Rejects tokens of revoked principals
+	Tenants        TenantLookup           // Resolves the organization a service account selects
+
+	Impersonation            ImpersonationLookup // Lets SUPER_ADMINs act as tenant users
+	ImpersonationAllowWrites bool                // Allow impersonated requests that are not reads
+}
+
+// MiddlewareWithOptions validates token and injects the Principal. Service
+// accounts act on the organization named by the X-Organization-ID header,
+// and SUPER_ADMINs may act as the user of that organization named by the
+// X-Impersonate-User header.
+func MiddlewareWithOptions(ver *Verifier, opts MiddlewareOptions) func(http.Handler) http.Handler {
+	metrics, statuses := opts.Metrics, opts.Statuses
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -50,7 +80,7 @@ func MiddlewareWithMetrics(ver *Verifier, metrics MetricsRecorder) func(http.Han
 				if metrics != nil {
 					metrics.RecordAuthFailure(ctx, "missing_authorization")
 				}
-				http.Error(w, "missing authorization", http.StatusUnauthorized)
+				apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "missing authorization")
 				return
 			}
 
@@ -61,14 +91,14 @@ func MiddlewareWithMetrics(ver *Verifier, metrics MetricsRecorder) func(http.Han
 				if metrics != nil {
 					metrics.RecordAuthFailure(ctx, "invalid_header_format")
 				}
-				http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+				apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "invalid authorization header")
 				return
 			}
 
 			tok := parts[1]
 			pr, err := ver.ParseAndVerifyToken(tok)
 			if err != nil {
-				log.Printf("[ERROR] Token validation failed: %v", err)
+				slog.WarnContext(ctx, "token validation failed", "error", err)
 				span.SetStatus(codes.Error, "token validation failed")
 				span.SetAttributes(
 					attribute.String("error.type", "invalid_token"),
@@ -77,10 +107,64 @@ func MiddlewareWithMetrics(ver *Verifier, metrics MetricsRecorder) func(http.Han
 				if metrics != nil {
 					metrics.RecordAuthFailure(ctx, "invalid_token")
 				}
-				http.Error(w, "invalid token", http.StatusUnauthorized)
+				apierror.Respond(w, r, http.StatusUnauthorized, "invalid_token", "invalid token")
 				return
 			}
 
+			if pr.IsService() {
+				if err := selectTenant(r.WithContext(ctx), pr, opts.Tenants); err != nil {
+					slog.WarnContext(ctx, "service account tenant selection failed", "client_id", pr.ClientID, "error", err)
+					span.SetStatus(codes.Error, "tenant selection failed")
+					span.SetAttributes(attribute.String("error.type", "invalid_tenant"))
+					if metrics != nil {
+						metrics.RecordAuthFailure(ctx, "invalid_tenant")
+					}
+					apierror.Write(w, r, err, "Failed to select organization")
+					return
+				}
+			}
+
+			if statuses != nil {
+				status, err := statuses.Status(ctx, pr)
+				if err != nil {
+					slog.ErrorContext(ctx, "failed to check principal status", "error", err)
+					span.SetStatus(codes.Error, "principal status check failed")
+					apierror.Respond(w, r, http.StatusInternalServerError, apierror.CodeInternal, "failed to check account status")
+					return
+				}
+				if status != PrincipalActive {
+					slog.WarnContext(ctx, "rejected token of revoked principal", "user_id", pr.UserID, "status", status)
+					span.SetStatus(codes.Error, "principal "+status)
+					span.SetAttributes(attribute.String("error.type", "principal_"+status))
+					if metrics != nil {
+						metrics.RecordAuthFailure(ctx, "principal_"+status)
+					}
+					apierror.Respond(w, r, http.StatusUnauthorized, "invalid_token", revokedDetails[status])
+					return
+				}
+			}
+
+			// The status check applies to the real principal; impersonated
+			// users are looked up among live records only
+			if r.Header.Get(ImpersonateHeader) != "" {
+				effective, err := impersonate(r.WithContext(ctx), pr, opts.Impersonation, opts.ImpersonationAllowWrites)
+				if err != nil {
+					slog.WarnContext(ctx, "impersonation denied", "impersonator_id", pr.UserID, "target_user_id", r.Header.Get(ImpersonateHeader), "error", err)
+					span.SetStatus(codes.Error, "impersonation denied")
+					span.SetAttributes(attribute.String("error.type", "impersonation_denied"))
+					if metrics != nil {
+						metrics.RecordAuthFailure(ctx, "impersonation_denied")
+					}
+					apierror.Write(w, r, err, "Failed to impersonate user")
+					return
+				}
+				// Handlers see the request the impersonated user would send
+				pr = effective
+				r = r.Clone(ctx)
+				r.Header.Del(ImpersonateHeader)
+				r.Header.Del(OrganizationHeader)
+			}
+
 			// Add principal information to span
 			email := ""
 			if emailClaim, ok := pr.Claims["email"].(string); ok {
@@ -95,6 +179,27 @@ func MiddlewareWithMetrics(ver *Verifier, metrics MetricsRecorder) func(http.Han
 			span.SetStatus(codes.Ok, "authentication successful")
 
 			ctx = context.WithValue(ctx, principalKey, pr)
+			ctx = logging.WithUserID(ctx, pr.UserID)
+			ctx = logging.WithTenant(ctx, pr.OrgSchemaName)
+			if pr.IsService() {
+				// Requests of other services are attributed to their client
+				span.SetAttributes(
+					attribute.String("principal.type", pr.Type),
+					attribute.String("client.id", pr.ClientID),
+				)
+				ctx = logging.WithClientID(ctx, pr.ClientID)
+				slog.InfoContext(ctx, "service account request", "method", r.Method, "path", r.URL.Path, "organization_id", pr.OrgID)
+				if opts.ServiceMetrics != nil {
+					opts.ServiceMetrics.RecordServiceAccountRequest(ctx, pr.ClientID, pr.OrgSchemaName)
+				}
+			}
+			if pr.IsImpersonated() {
+				// Every impersonated request is audited with both identities
+				impersonator := pr.Real()
+				span.SetAttributes(attribute.String("impersonator.id", impersonator.UserID))
+				ctx = logging.WithImpersonatorID(ctx, impersonator.UserID)
+				slog.InfoContext(ctx, "impersonated request", "method", r.Method, "path", r.URL.Path, "organization_id", pr.OrgID, "roles", pr.Roles, "impersonator_roles", impersonator.Roles)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -106,12 +211,14 @@ type PermissionMetricsRecorder interface {
 }
 
 // RequirePermission returns middleware that ensures the principal has permission.
-func RequirePermission(per string, perms Permissions) func(http.Handler) http.Handler {
+// perms is consulted on every request, so a reloaded PermissionStore takes
+// effect immediately.
+func RequirePermission(per string, perms PermissionSource) func(http.Handler) http.Handler {
 	return RequirePermissionWithMetrics(per, perms, nil)
 }
 
 // RequirePermissionWithMetrics returns middleware with metrics recording
-func RequirePermissionWithMetrics(per string, perms Permissions, metrics PermissionMetricsRecorder) func(http.Handler) http.Handler {
+func RequirePermissionWithMetrics(per string, perms PermissionSource, metrics PermissionMetricsRecorder) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -128,11 +235,11 @@ func RequirePermissionWithMetrics(per string, perms Permissions, metrics Permiss
 				if metrics != nil {
 					metrics.RecordPermissionCheck(ctx, per, float64(time.Since(start).Milliseconds()), false)
 				}
-				http.Error(w, "unauthenticated", http.StatusUnauthorized)
+				apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthenticated")
 				return
 			}
 
-			allowed := HasPermission(pr, per, perms)
+			allowed := HasPermission(pr, per, perms.Permissions())
 			duration := float64(time.Since(start).Milliseconds())
 
 			span.SetAttributes(
@@ -146,10 +253,9 @@ func RequirePermissionWithMetrics(per string, perms Permissions, metrics Permiss
 			}
 
 			if !allowed {
-				log.Printf("[PERMISSION DENIED] User: %s, Roles: %v, Required Permission: %s, Available Permissions: %v",
-					pr.UserID, pr.Roles, per, perms)
+				slog.WarnContext(ctx, "permission denied", "roles", pr.Roles, "permission", per)
 				span.SetStatus(codes.Error, "forbidden")
-				http.Error(w, "forbidden", http.StatusForbidden)
+				apierror.Respond(w, r, http.StatusForbidden, apierror.CodeForbidden, "missing permission "+per)
 				return
 			}
 
@@ -167,24 +273,60 @@ func FromContext(ctx context.Context) (*Principal, bool) {
 
 // HasPermission checks roles -> permissions mapping.
 // Role lookup is case-insensitive so Keycloak realm roles (e.g. "patient") match permissions.yml (e.g. "PATIENT").
+// Wildcards grant every matching permission, and a deny rule in any role of
+// the principal wins over grants in all of its roles.
 func HasPermission(pr *Principal, permission string, perms Permissions) bool {
 	roleSet := map[string]struct{}{}
 	for _, r := range pr.Roles {
 		roleSet[r] = struct{}{}
 	}
+	allowed := false
 	for role := range roleSet {
-		// Try exact match first, then uppercase (permissions.yml uses PATIENT, ORG_ADMIN, etc.)
-		pList, ok := perms[role]
-		if !ok {
-			pList, ok = perms[strings.ToUpper(role)]
-		}
-		if ok {
-			for _, p := range pList {
-				if p == permission {
-					return true
+		for _, p := range rolePermissions(perms, role) {
+			if denied, ok := strings.CutPrefix(p, DenyPrefix); ok {
+				if matchPermission(denied, permission) {
+					return false
 				}
+			} else if matchPermission(p, permission) {
+				allowed = true
 			}
 		}
 	}
-	return false
+	return allowed
+}
+
+// rolePermissions looks up a role, trying an exact match first, then
+// uppercase (permissions.yml uses PATIENT, ORG_ADMIN, etc.)
+func rolePermissions(perms Permissions, role string) []string {
+	pList, ok := perms[role]
+	if !ok {
+		pList = perms[strings.ToUpper(role)]
+	}
+	return pList
+}
+
+// EffectivePermissions returns the sorted permissions the principal holds
+// according to HasPermission. Wildcards are expanded to the matching
+// KnownPermissions.
+func EffectivePermissions(pr *Principal, perms Permissions) []string {
+	candidates := map[string]struct{}{}
+	for _, p := range KnownPermissions {
+		candidates[p] = struct{}{}
+	}
+	for _, role := range pr.Roles {
+		for _, p := range rolePermissions(perms, role) {
+			if !strings.HasPrefix(p, DenyPrefix) && !strings.HasSuffix(p, "*") {
+				candidates[p] = struct{}{}
+			}
+		}
+	}
+
+	effective := []string{}
+	for p := range candidates {
+		if HasPermission(pr, p, perms) {
+			effective = append(effective, p)
+		}
+	}
+	sort.Strings(effective)
+	return effective
 }
@@ -2,11 +2,13 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -105,9 +107,15 @@ func TestMiddleware_MissingAuthorizationHeader(t *testing.T) {
 	if rec.Code != http.StatusUnauthorized {
 		t.Errorf("Expected status 401, got %d", rec.Code)
 	}
-	expectedBody := "missing authorization\n"
-	if rec.Body.String() != expectedBody {
-		t.Errorf("Expected body '%s', got '%s'", expectedBody, rec.Body.String())
+	if ct := rec.Header().Get("Content-Type"); ct != apierror.ContentType {
+		t.Errorf("Expected Content-Type %s, got %s", apierror.ContentType, ct)
+	}
+	var problem apierror.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if problem.Status != http.StatusUnauthorized || problem.Detail != "missing authorization" {
+		t.Errorf("Expected 401 problem with detail 'missing authorization', got %+v", problem)
 	}
 }
 
@@ -300,6 +308,39 @@ func TestHasPermission(t *testing.T) {
 	}
 }
 
+// TestHasPermission_WildcardsAndDeny tests wildcard grants and deny rules
+func TestHasPermission_WildcardsAndDeny(t *testing.T) {
+	perms := Permissions{
+		"SUPER_ADMIN": {"*"},
+		"ORG_ADMIN":   {"patient:*", "!patient:delete"},
+		"AUDITOR":     {"!user:*"},
+	}
+
+	testCases := []struct {
+		name       string
+		roles      []string
+		permission string
+		expected   bool
+	}{
+		{"Global wildcard", []string{"SUPER_ADMIN"}, "care-session:report", true},
+		{"Resource wildcard", []string{"ORG_ADMIN"}, "patient:update", true},
+		{"Resource wildcard does not match other resources", []string{"ORG_ADMIN"}, "user:view", false},
+		{"Resource wildcard does not match prefixes", []string{"ORG_ADMIN"}, "patients:view", false},
+		{"Deny wins over wildcard", []string{"ORG_ADMIN"}, "patient:delete", false},
+		{"Deny wins over grants of other roles", []string{"SUPER_ADMIN", "AUDITOR"}, "user:view", false},
+		{"Deny only covers its own permissions", []string{"SUPER_ADMIN", "AUDITOR"}, "patient:view", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := HasPermission(&Principal{Roles: tc.roles}, tc.permission, perms)
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
 // TestRequirePermission tests the permission enforcement middleware
 func TestRequirePermission(t *testing.T) {
 	perms := Permissions{
@@ -394,3 +435,74 @@ func TestRequirePermission(t *testing.T) {
 }
 
 // Helper functions are defined in jwt_verify_test.go to avoid duplication
+
+// TestEffectivePermissions tests the union of the permissions of all roles
+func TestEffectivePermissions(t *testing.T) {
+	perms := Permissions{
+		"ORG_ADMIN": {"user:view", "patient:view"},
+		"CAREGIVER": {"patient:view", "patient:update"},
+	}
+
+	principal := &Principal{UserID: "user-123", Roles: []string{"org_admin", "CAREGIVER", "offline_access"}}
+	got := EffectivePermissions(principal, perms)
+
+	want := []string{"patient:update", "patient:view", "user:view"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+// TestEffectivePermissions_Wildcards tests that wildcards are expanded and
+// denied permissions are left out
+func TestEffectivePermissions_Wildcards(t *testing.T) {
+	perms := Permissions{"ORG_ADMIN": {"patient:*", "!patient:delete"}}
+
+	got := EffectivePermissions(&Principal{Roles: []string{"ORG_ADMIN"}}, perms)
+
+	want := []string{"patient:create", "patient:update", "patient:view"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+// TestMyPermissionsHandler tests the effective permissions endpoint
+func TestMyPermissionsHandler(t *testing.T) {
+	perms := Permissions{"PATIENT": {"patient:view"}}
+	handler := MyPermissionsHandler(perms)
+
+	t.Run("Authenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/auth/permissions/me", nil)
+		req = req.WithContext(ContextWithPrincipal(req.Context(), &Principal{UserID: "user-123", Roles: []string{"PATIENT"}}))
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		var response MyPermissionsResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if response.UserID != "user-123" || len(response.Permissions) != 1 || response.Permissions[0] != "patient:view" {
+			t.Errorf("Unexpected response %+v", response)
+		}
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/permissions/me", nil))
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", rec.Code)
+		}
+	})
+}
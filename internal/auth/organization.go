@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+// RoleSuperAdmin may act on every organization
+const RoleSuperAdmin = "SUPER_ADMIN"
+
+var (
+	// ErrNoOrganization means the request does not name an organization and
+	// the token has none
+	ErrNoOrganization = errors.New("no organization selected")
+	// ErrOtherOrganization means a principal limited to its own organization
+	// named a different one
+	ErrOtherOrganization = errors.New("organization differs from the token organization")
+)
+
+// HasRole reports whether the principal has role, ignoring case
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetOrganization returns the organization a request acts on given the
+// organization it names, usually the X-Organization-ID header. SUPER_ADMIN
+// may name any organization and falls back to the one in its token. Other
+// principals act on the organization of their token, or the one a service
+// account selected, and may only name that one.
+func (p *Principal) TargetOrganization(orgID string) (string, error) {
+	if p.HasRole(RoleSuperAdmin) {
+		if orgID == "" {
+			orgID = p.OrgID
+		}
+	} else {
+		if orgID != "" && orgID != p.OrgID {
+			return "", ErrOtherOrganization
+		}
+		orgID = p.OrgID
+	}
+
+	if orgID == "" {
+		return "", ErrNoOrganization
+	}
+	return orgID, nil
+}
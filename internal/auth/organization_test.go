@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestTargetOrganization tests which organization a principal may act on
+func TestTargetOrganization(t *testing.T) {
+	superAdmin := &Principal{Roles: []string{"super_admin"}}
+	orgAdmin := &Principal{Roles: []string{"ORG_ADMIN"}, OrgID: "org-1"}
+
+	testCases := []struct {
+		name      string
+		principal *Principal
+		orgID     string
+		expected  string
+		err       error
+	}{
+		{"SUPER_ADMIN names any organization", superAdmin, "org-2", "org-2", nil},
+		{"SUPER_ADMIN without organization", superAdmin, "", "", ErrNoOrganization},
+		{"Own organization from token", orgAdmin, "", "org-1", nil},
+		{"Own organization named", orgAdmin, "org-1", "org-1", nil},
+		{"Other organization named", orgAdmin, "org-2", "", ErrOtherOrganization},
+		{"Token without organization", &Principal{Roles: []string{"CAREGIVER"}}, "", "", ErrNoOrganization},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			orgID, err := tc.principal.TargetOrganization(tc.orgID)
+			if !errors.Is(err, tc.err) || orgID != tc.expected {
+				t.Errorf("Expected %q, %v, got %q, %v", tc.expected, tc.err, orgID, err)
+			}
+		})
+	}
+}
@@ -1,27 +1,358 @@
 package auth
 
 import (
-	"io/ioutil"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Permissions maps role -> []permission
+// KnownPermissions lists every permission name permissions.yml may grant.
+// The care-session and nfc permissions are enforced by other WailSalutem
+// services that share the file.
+var KnownPermissions = []string{
+	"organization:create", "organization:view", "organization:update", "organization:delete", "organization:manage",
+	"patient:create", "patient:view", "patient:update", "patient:delete",
+	"user:create", "user:view", "user:update", "user:delete", "user:export",
+	"care-session:create", "care-session:read", "care-session:update", "care-session:report",
+	"nfc:assign", "nfc:check-in", "nfc:check-out",
+}
+
+// Permissions maps role -> []permission. An entry may be a wildcard
+// ("patient:*" or "*"), and an entry prefixed with DenyPrefix is a deny rule
+// that wins over any grant. Roles loaded from permissions.yml already include
+// the entries of the roles they inherit.
 type Permissions map[string][]string
 
+// DenyPrefix marks a deny rule in a role's permission list
+const DenyPrefix = "!"
+
+// Permissions returns p itself, so a fixed map can be used wherever a
+// PermissionSource is expected
+func (p Permissions) Permissions() Permissions {
+	return p
+}
+
+// PermissionSource provides the current role -> permissions mapping
+type PermissionSource interface {
+	Permissions() Permissions
+}
+
 type permissionsFile struct {
-	Roles map[string][]string `yaml:"roles"`
+	Roles   map[string]roleDefinition `yaml:"roles"`
+	Clients map[string]roleDefinition `yaml:"clients"` // Service accounts by Keycloak client ID
+}
+
+// roleDefinition is one role of permissions.yml: either a plain list of
+// permissions, or a mapping with the roles it inherits and its grants and
+// deny rules
+type roleDefinition struct {
+	Inherits    []string `yaml:"inherits"`
+	Permissions []string `yaml:"permissions"`
+	Deny        []string `yaml:"deny"`
+}
+
+// UnmarshalYAML accepts both forms of a role
+func (d *roleDefinition) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&d.Permissions)
+	}
+	type plain roleDefinition
+	return node.Decode((*plain)(d))
+}
+
+// entries returns the role's own grants and deny rules
+func (d roleDefinition) entries() []string {
+	entries := append([]string{}, d.Permissions...)
+	for _, p := range d.Deny {
+		entries = append(entries, DenyPrefix+p)
+	}
+	return entries
 }
 
 // LoadPermissions loads a permissions.yml file and returns a role->permissions map.
 func LoadPermissions(path string) (Permissions, error) {
-	b, err := ioutil.ReadFile(path)
+	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return parsePermissions(b)
+}
+
+// parsePermissions decodes and validates the contents of a permissions.yml
+// file and resolves role inheritance. Clients are returned as roles named by
+// ClientRole, which may inherit the roles of people.
+func parsePermissions(b []byte) (Permissions, error) {
 	var pf permissionsFile
 	if err := yaml.Unmarshal(b, &pf); err != nil {
 		return nil, err
 	}
-	return Permissions(pf.Roles), nil
+	if pf.Roles == nil {
+		return nil, nil
+	}
+	for clientID, def := range pf.Clients {
+		pf.Roles[ClientRole(clientID)] = def
+	}
+
+	own := make(Permissions, len(pf.Roles))
+	for role, def := range pf.Roles {
+		own[role] = def.entries()
+	}
+	if err := errors.Join(ValidatePermissions(own), validateInheritance(pf.Roles)); err != nil {
+		return nil, err
+	}
+	return resolveInheritance(pf.Roles), nil
+}
+
+// ValidatePermissions reports permission names that are not in
+// KnownPermissions, which are usually typos that would silently deny access.
+// Wildcards must name a known resource ("patient:*") or be "*".
+func ValidatePermissions(perms Permissions) error {
+	known := make(map[string]struct{}, len(KnownPermissions))
+	for _, p := range KnownPermissions {
+		known[p] = struct{}{}
+		resource, _, _ := strings.Cut(p, ":")
+		known[resource+":*"] = struct{}{}
+	}
+	known["*"] = struct{}{}
+
+	var unknown []string
+	for role, list := range perms {
+		for _, p := range list {
+			if _, ok := known[strings.TrimPrefix(p, DenyPrefix)]; !ok {
+				unknown = append(unknown, role+": "+p)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown permissions: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// validateInheritance reports inherited roles that are not defined and
+// inheritance cycles
+func validateInheritance(roles map[string]roleDefinition) error {
+	var unknown []string
+	for role, def := range roles {
+		for _, parent := range def.Inherits {
+			if _, ok := roles[parent]; !ok {
+				unknown = append(unknown, role+": "+parent)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(roles))
+	var cycles []string
+	var visit func(role string, path []string)
+	visit = func(role string, path []string) {
+		switch state[role] {
+		case done:
+			return
+		case visiting:
+			start := slices.Index(path, role)
+			cycles = append(cycles, strings.Join(path[start:], " -> ")+" -> "+role)
+			return
+		}
+		state[role] = visiting
+		for _, parent := range roles[role].Inherits {
+			if _, ok := roles[parent]; ok {
+				visit(parent, append(path, role))
+			}
+		}
+		state[role] = done
+	}
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	for _, role := range names {
+		visit(role, nil)
+	}
+
+	var errs []error
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		errs = append(errs, fmt.Errorf("unknown inherited roles: %s", strings.Join(unknown, ", ")))
+	}
+	if len(cycles) > 0 {
+		errs = append(errs, fmt.Errorf("role inheritance cycles: %s", strings.Join(cycles, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// resolveInheritance returns every role with its own entries followed by the
+// entries of the roles it inherits, directly or indirectly. The roles must
+// have passed validateInheritance.
+func resolveInheritance(roles map[string]roleDefinition) Permissions {
+	resolved := make(Permissions, len(roles))
+	var resolve func(role string) []string
+	resolve = func(role string) []string {
+		if list, ok := resolved[role]; ok {
+			return list
+		}
+		def := roles[role]
+		list := def.entries()
+		for _, parent := range def.Inherits {
+			for _, p := range resolve(parent) {
+				if !slices.Contains(list, p) {
+					list = append(list, p)
+				}
+			}
+		}
+		resolved[role] = list
+		return list
+	}
+	for role := range roles {
+		resolve(role)
+	}
+	return resolved
+}
+
+// matchPermission reports whether a granted or denied entry covers
+// permission
+func matchPermission(entry, permission string) bool {
+	if entry == "*" || entry == permission {
+		return true
+	}
+	resource, ok := strings.CutSuffix(entry, ":*")
+	return ok && strings.HasPrefix(permission, resource+":")
+}
+
+// PermissionStore holds the permissions of a permissions.yml file. Reload
+// swaps in a new mapping atomically, so requests in flight see either the old
+// or the new mapping, never a mix. An invalid file keeps the current mapping.
+type PermissionStore struct {
+	path    string
+	current atomic.Pointer[Permissions]
+
+	mu   sync.Mutex // Serializes reloads
+	hash [sha256.Size]byte
+	quit chan struct{}
+	once sync.Once
+}
+
+// Ensure PermissionStore implements PermissionSource
+var _ PermissionSource = (*PermissionStore)(nil)
+
+// NewPermissionStore loads path and fails when it is missing or invalid
+func NewPermissionStore(path string) (*PermissionStore, error) {
+	s := &PermissionStore{path: path, quit: make(chan struct{})}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Permissions returns the current mapping
+func (s *PermissionStore) Permissions() Permissions {
+	return *s.current.Load()
+}
+
+// Reload reads the file again and swaps in its mapping when the contents
+// changed. It reports whether the mapping changed.
+func (s *PermissionStore) Reload() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	hash := sha256.Sum256(b)
+	if s.current.Load() != nil && bytes.Equal(hash[:], s.hash[:]) {
+		return false, nil
+	}
+
+	perms, err := parsePermissions(b)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", s.path, err)
+	}
+	if len(perms) == 0 {
+		// Most likely a file caught halfway through being written
+		return false, fmt.Errorf("%s: no roles defined", s.path)
+	}
+
+	s.current.Store(&perms)
+	s.hash = hash
+	return true, nil
+}
+
+// Watch reloads the file every interval when its contents changed and on
+// SIGHUP, until Close is called. Polling the contents also picks up
+// Kubernetes ConfigMap updates, which replace the file through a symlink.
+func (s *PermissionStore) Watch(interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-tick:
+				s.reloadAndLog("file changed")
+			case <-hup:
+				s.reloadAndLog("SIGHUP")
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// reloadAndLog reloads the file and logs the outcome
+func (s *PermissionStore) reloadAndLog(trigger string) {
+	changed, err := s.Reload()
+	if err != nil {
+		slog.Error("failed to reload permissions, keeping current permissions", "path", s.path, "trigger", trigger, "error", err)
+		return
+	}
+	if changed {
+		slog.Info("reloaded permissions", "path", s.path, "trigger", trigger, "roles", len(s.Permissions()))
+	}
+}
+
+// Close stops watching the file
+func (s *PermissionStore) Close() {
+	s.once.Do(func() { close(s.quit) })
+}
+
+// LoadPermissionsReloadInterval returns how often permissions.yml is checked
+// for changes, from PERMISSIONS_RELOAD_INTERVAL. Zero disables polling;
+// SIGHUP still reloads.
+func LoadPermissionsReloadInterval() time.Duration {
+	// Get reload interval with default
+	interval := 30 * time.Second
+	if intervalStr := os.Getenv("PERMISSIONS_RELOAD_INTERVAL"); intervalStr != "" {
+		if duration, err := time.ParseDuration(intervalStr); err == nil && duration >= 0 {
+			interval = duration
+		}
+	}
+	return interval
 }
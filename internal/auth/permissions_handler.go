@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// MyPermissionsResponse lists the effective permissions of the caller
+type MyPermissionsResponse struct {
+	UserID       string   `json:"user_id"`
+	ClientID     string   `json:"client_id,omitempty"`       // Client of a service account
+	Impersonator string   `json:"impersonated_by,omitempty"` // SUPER_ADMIN impersonating the user
+	Roles        []string `json:"roles"`
+	Permissions  []string `json:"permissions"`
+}
+
+// MyPermissionsHandler returns the effective permissions of the
+// authenticated principal under the current permissions, so clients can hide
+// actions the caller is not allowed to take
+func MyPermissionsHandler(perms PermissionSource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pr, ok := FromContext(r.Context())
+		if !ok {
+			apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthenticated")
+			return
+		}
+
+		roles := pr.Roles
+		if roles == nil {
+			roles = []string{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(MyPermissionsResponse{
+			UserID:       pr.UserID,
+			ClientID:     pr.ClientID,
+			Impersonator: impersonatorID(pr),
+			Roles:        roles,
+			Permissions:  EffectivePermissions(pr, perms.Permissions()),
+		})
+	})
+}
+
+// impersonatorID returns the user ID of the SUPER_ADMIN impersonating pr, if
+// any
+func impersonatorID(pr *Principal) string {
+	if !pr.IsImpersonated() {
+		return ""
+	}
+	return pr.Impersonator.UserID
+}
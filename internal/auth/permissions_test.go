@@ -3,7 +3,9 @@ package auth
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestLoadPermissions_Success tests successfully loading permissions from YAML
@@ -193,7 +195,7 @@ func TestLoadPermissions_RealFile(t *testing.T) {
 	}
 
 	// Verify SUPER_ADMIN has comprehensive permissions
-	superAdminPerms := perms["SUPER_ADMIN"]
+	superAdmin := &Principal{Roles: []string{"SUPER_ADMIN"}}
 	expectedPerms := []string{
 		"organization:create",
 		"organization:view",
@@ -203,21 +205,236 @@ func TestLoadPermissions_RealFile(t *testing.T) {
 		"user:view",
 		"user:update",
 		"user:delete",
+		"user:export",
 	}
 	for _, perm := range expectedPerms {
-		if !contains(superAdminPerms, perm) {
+		if !HasPermission(superAdmin, perm, perms) {
 			t.Errorf("Expected SUPER_ADMIN to have permission '%s'", perm)
 		}
 	}
 
 	// Verify ORG_ADMIN has limited permissions
-	orgAdminPerms := perms["ORG_ADMIN"]
-	if contains(orgAdminPerms, "organization:create") {
+	orgAdmin := &Principal{Roles: []string{"ORG_ADMIN"}}
+	if HasPermission(orgAdmin, "organization:create", perms) {
 		t.Error("ORG_ADMIN should not have 'organization:create' permission")
 	}
-	if contains(orgAdminPerms, "organization:delete") {
+	if HasPermission(orgAdmin, "organization:delete", perms) {
 		t.Error("ORG_ADMIN should not have 'organization:delete' permission")
 	}
+	if !HasPermission(orgAdmin, "user:export", perms) {
+		t.Error("ORG_ADMIN should have 'user:export' permission")
+	}
+
+	// Staff contact details are only exported for admins
+	for _, role := range []string{"CAREGIVER", "PATIENT", "MUNICIPALITY", "INSURER"} {
+		if HasPermission(&Principal{Roles: []string{role}}, "user:export", perms) {
+			t.Errorf("%s should not have 'user:export' permission", role)
+		}
+	}
+}
+
+// TestLoadPermissions_UnknownPermission tests that typos in permission names
+// are rejected
+func TestLoadPermissions_UnknownPermission(t *testing.T) {
+	tmpDir := t.TempDir()
+	permFile := filepath.Join(tmpDir, "permissions.yml")
+
+	content := `roles:
+  ORG_ADMIN:
+    - patient:veiw
+    - user:view
+`
+	if err := os.WriteFile(permFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	_, err := LoadPermissions(permFile)
+	if err == nil || !strings.Contains(err.Error(), "ORG_ADMIN: patient:veiw") {
+		t.Errorf("Expected unknown permission error, got: %v", err)
+	}
+}
+
+// TestLoadPermissions_Inheritance tests that roles include the grants and
+// deny rules of the roles they inherit
+func TestLoadPermissions_Inheritance(t *testing.T) {
+	tmpDir := t.TempDir()
+	permFile := filepath.Join(tmpDir, "permissions.yml")
+
+	content := `roles:
+  PATIENT:
+    - patient:view
+  CAREGIVER:
+    inherits: [PATIENT]
+    permissions:
+      - nfc:*
+  ORG_ADMIN:
+    inherits: [CAREGIVER]
+    permissions:
+      - patient:*
+    deny:
+      - nfc:check-in
+`
+	if err := os.WriteFile(permFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	perms, err := LoadPermissions(permFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []string{"patient:*", "!nfc:check-in", "nfc:*", "patient:view"}
+	got := perms["ORG_ADMIN"]
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+	if !contains(perms["CAREGIVER"], "patient:view") {
+		t.Errorf("Expected CAREGIVER to inherit patient:view, got %v", perms["CAREGIVER"])
+	}
+}
+
+// TestLoadPermissions_InvalidInheritance tests that unknown inherited roles
+// and cycles are reported at load time
+func TestLoadPermissions_InvalidInheritance(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name: "Unknown role",
+			content: `roles:
+  ORG_ADMIN:
+    inherits: [CARE_GIVER]
+`,
+			want: "unknown inherited roles: ORG_ADMIN: CARE_GIVER",
+		},
+		{
+			name: "Cycle",
+			content: `roles:
+  CAREGIVER:
+    inherits: [ORG_ADMIN]
+  ORG_ADMIN:
+    inherits: [CAREGIVER]
+`,
+			want: "role inheritance cycles: CAREGIVER -> ORG_ADMIN -> CAREGIVER",
+		},
+		{
+			name: "Self",
+			content: `roles:
+  ORG_ADMIN:
+    inherits: [ORG_ADMIN]
+`,
+			want: "role inheritance cycles: ORG_ADMIN -> ORG_ADMIN",
+		},
+		{
+			name: "Unknown wildcard",
+			content: `roles:
+  ORG_ADMIN:
+    - patients:*
+`,
+			want: "unknown permissions: ORG_ADMIN: patients:*",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			permFile := filepath.Join(t.TempDir(), "permissions.yml")
+			if err := os.WriteFile(permFile, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			_, err := LoadPermissions(permFile)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// TestPermissionStore_Reload tests that a changed file is swapped in and an
+// invalid one keeps the current permissions
+func TestPermissionStore_Reload(t *testing.T) {
+	tmpDir := t.TempDir()
+	permFile := filepath.Join(tmpDir, "permissions.yml")
+
+	write := func(content string) {
+		if err := os.WriteFile(permFile, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	write("roles:\n  ORG_ADMIN:\n    - patient:view\n")
+	store, err := NewPermissionStore(permFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer store.Close()
+
+	changed, err := store.Reload()
+	if err != nil || changed {
+		t.Errorf("Expected unchanged file not to reload, got changed=%v err=%v", changed, err)
+	}
+
+	write("roles:\n  ORG_ADMIN:\n    - patient:view\n    - patient:update\n")
+	changed, err = store.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Expected changed file to reload, got changed=%v err=%v", changed, err)
+	}
+	if got := store.Permissions()["ORG_ADMIN"]; len(got) != 2 {
+		t.Errorf("Expected 2 permissions after reload, got %v", got)
+	}
+
+	for _, invalid := range []string{"roles:\n  ORG_ADMIN:\n    - patient:everything\n", "", "roles: ["} {
+		write(invalid)
+		if _, err := store.Reload(); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+		if got := store.Permissions()["ORG_ADMIN"]; len(got) != 2 {
+			t.Errorf("Expected current permissions to be kept, got %v", got)
+		}
+	}
+}
+
+// TestPermissionStore_Watch tests that the file is picked up by polling
+func TestPermissionStore_Watch(t *testing.T) {
+	tmpDir := t.TempDir()
+	permFile := filepath.Join(tmpDir, "permissions.yml")
+
+	if err := os.WriteFile(permFile, []byte("roles:\n  ORG_ADMIN:\n    - patient:view\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	store, err := NewPermissionStore(permFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	store.Watch(10 * time.Millisecond)
+	defer store.Close()
+
+	if err := os.WriteFile(permFile, []byte("roles:\n  CAREGIVER:\n    - patient:view\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := store.Permissions()["CAREGIVER"]; ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected watched file to be reloaded")
+}
+
+// TestNewPermissionStore_MissingFile tests that startup fails without a file
+func TestNewPermissionStore_MissingFile(t *testing.T) {
+	if _, err := NewPermissionStore(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
 }
 
 // Helper function to check if slice contains a string
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// Conditions a policy rule can require
+const (
+	ConditionSameOrganization = "same_organization" // The resource belongs to the principal's organization
+	ConditionOwner            = "owner"             // The resource describes the principal
+	ConditionAssigned         = "assigned"          // The principal is a caregiver assigned to the resource
+)
+
+var knownConditions = []string{ConditionSameOrganization, ConditionOwner, ConditionAssigned}
+
+// Resource holds the attributes of the target of a request that policies are
+// evaluated against
+type Resource struct {
+	Type    string // organization, patient or user
+	ID      string
+	OrgID   string // Organization the stored resource belongs to
+	OwnerID string // Keycloak user ID of the person the resource describes
+
+	// Assigned returns the Keycloak user IDs of the caregivers assigned to the
+	// resource. It is only called when a rule checks assignment; nil means
+	// nobody is assigned.
+	Assigned func(ctx context.Context) ([]string, error)
+}
+
+// PolicyRule allows an action when the principal has one of Roles (any role
+// when empty) and every condition holds
+type PolicyRule struct {
+	Roles      []string `yaml:"roles"`
+	Conditions []string `yaml:"when"`
+}
+
+// Policies holds the rules of policies.yml by action. Actions are permission
+// names; an action without rules is decided by the role permissions alone.
+type Policies struct {
+	Actions map[string][]PolicyRule `yaml:"policies"`
+}
+
+// LoadPolicies loads a policies.yml file. A missing file returns nil, which
+// leaves every action to the role permissions.
+func LoadPolicies(path string) (*Policies, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var policies Policies
+	if err := yaml.Unmarshal(b, &policies); err != nil {
+		return nil, err
+	}
+	if err := policies.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &policies, nil
+}
+
+// LoadPoliciesFromEnv loads the file named by POLICIES_FILE, by default
+// policies.yml next to permissions.yml
+func LoadPoliciesFromEnv() (*Policies, error) {
+	// Get file path with default
+	path := os.Getenv("POLICIES_FILE")
+	if path == "" {
+		path = "policies.yml"
+	}
+	return LoadPolicies(path)
+}
+
+// validate reports actions that are not known permissions and unknown
+// conditions
+func (p *Policies) validate() error {
+	var problems []string
+	for action, rules := range p.Actions {
+		if !slices.Contains(KnownPermissions, action) {
+			problems = append(problems, "unknown action "+action)
+		}
+		for _, rule := range rules {
+			for _, condition := range rule.Conditions {
+				if !slices.Contains(knownConditions, condition) {
+					problems = append(problems, action+": unknown condition "+condition)
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Applies reports whether action has rules
+func (p *Policies) Applies(action string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.Actions[action]
+	return ok
+}
+
+// Authorize evaluates the rules of action. It returns nil when a rule allows
+// the principal to perform action on res or when action has no rules, and a
+// 403 error otherwise.
+func (p *Policies) Authorize(ctx context.Context, pr *Principal, action string, res Resource) error {
+	if !p.Applies(action) {
+		return nil
+	}
+	for _, rule := range p.Actions[action] {
+		ok, err := rule.allows(ctx, pr, res)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return apierror.Forbidden(apierror.CodeForbidden, fmt.Sprintf("not allowed to %s this %s", action, res.Type))
+}
+
+// allows reports whether the rule matches the principal and the resource
+func (rule PolicyRule) allows(ctx context.Context, pr *Principal, res Resource) (bool, error) {
+	if len(rule.Roles) > 0 && !slices.ContainsFunc(pr.Roles, func(role string) bool {
+		return slices.ContainsFunc(rule.Roles, func(r string) bool { return strings.EqualFold(r, role) })
+	}) {
+		return false, nil
+	}
+
+	for _, condition := range rule.Conditions {
+		switch condition {
+		case ConditionSameOrganization:
+			if res.OrgID == "" || res.OrgID != pr.OrgID {
+				return false, nil
+			}
+		case ConditionOwner:
+			if res.OwnerID == "" || res.OwnerID != pr.UserID {
+				return false, nil
+			}
+		case ConditionAssigned:
+			if res.Assigned == nil {
+				return false, nil
+			}
+			assigned, err := res.Assigned(ctx)
+			if err != nil {
+				return false, fmt.Errorf("failed to load assignment: %w", err)
+			}
+			if !slices.Contains(assigned, pr.UserID) {
+				return false, nil
+			}
+		default:
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ResourceResolver loads the attributes of the resource a request targets.
+// Typed apierror errors, such as not found, are returned to the client.
+type ResourceResolver func(r *http.Request) (Resource, error)
+
+// RequirePolicy returns middleware that evaluates the rules of action against
+// the principal and the resource returned by resolve. The resource is only
+// resolved when action has rules.
+func RequirePolicy(policies *Policies, action string, resolve ResourceResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !policies.Applies(action) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, span := tracer.Start(r.Context(), "auth.RequirePolicy",
+				trace.WithSpanKind(trace.SpanKindInternal),
+				trace.WithAttributes(attribute.String("policy.action", action)),
+			)
+			defer span.End()
+
+			pr, ok := FromContext(ctx)
+			if !ok {
+				span.SetStatus(codes.Error, "unauthenticated")
+				apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthenticated")
+				return
+			}
+
+			res, err := resolve(r.WithContext(ctx))
+			if err == nil {
+				span.SetAttributes(attribute.String("resource.type", res.Type), attribute.String("resource.id", res.ID))
+				err = policies.Authorize(ctx, pr, action, res)
+			}
+			if err != nil {
+				var typed *apierror.Error
+				if errors.As(err, &typed) && typed.Status == http.StatusForbidden {
+					slog.WarnContext(ctx, "policy denied", "roles", pr.Roles, "action", action, "resource_type", res.Type, "resource_id", res.ID)
+				} else if typed == nil {
+					slog.ErrorContext(ctx, "failed to evaluate policy", "action", action, "error", err)
+				}
+				span.SetStatus(codes.Error, err.Error())
+				apierror.Write(w, r, err, "Failed to authorize request")
+				return
+			}
+
+			span.SetStatus(codes.Ok, "policy allowed")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
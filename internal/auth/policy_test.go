@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+func testPolicies() *Policies {
+	return &Policies{Actions: map[string][]PolicyRule{
+		"patient:view": {
+			{Roles: []string{"SUPER_ADMIN"}},
+			{Roles: []string{"ORG_ADMIN"}, Conditions: []string{ConditionSameOrganization}},
+			{Roles: []string{"CAREGIVER"}, Conditions: []string{ConditionSameOrganization, ConditionAssigned}},
+			{Roles: []string{"PATIENT"}, Conditions: []string{ConditionSameOrganization, ConditionOwner}},
+		},
+	}}
+}
+
+// TestAuthorize tests rule evaluation over the principal and the resource
+func TestAuthorize(t *testing.T) {
+	patient := Resource{
+		Type:    "patient",
+		ID:      "patient-1",
+		OrgID:   "org-1",
+		OwnerID: "kc-patient-1",
+		Assigned: func(ctx context.Context) ([]string, error) {
+			return []string{"kc-caregiver-1"}, nil
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		principal *Principal
+		action    string
+		expected  bool
+	}{
+		{"Unconditional role", &Principal{UserID: "admin", Roles: []string{"SUPER_ADMIN"}, OrgID: "org-2"}, "patient:view", true},
+		{"Same organization", &Principal{UserID: "kc-admin", Roles: []string{"org_admin"}, OrgID: "org-1"}, "patient:view", true},
+		{"Other organization", &Principal{UserID: "kc-admin", Roles: []string{"ORG_ADMIN"}, OrgID: "org-2"}, "patient:view", false},
+		{"Owner", &Principal{UserID: "kc-patient-1", Roles: []string{"PATIENT"}, OrgID: "org-1"}, "patient:view", true},
+		{"Other patient", &Principal{UserID: "kc-patient-2", Roles: []string{"PATIENT"}, OrgID: "org-1"}, "patient:view", false},
+		{"Assigned caregiver", &Principal{UserID: "kc-caregiver-1", Roles: []string{"CAREGIVER"}, OrgID: "org-1"}, "patient:view", true},
+		{"Unassigned caregiver", &Principal{UserID: "kc-caregiver-2", Roles: []string{"CAREGIVER"}, OrgID: "org-1"}, "patient:view", false},
+		{"Role without rule", &Principal{UserID: "kc-insurer", Roles: []string{"INSURER"}, OrgID: "org-1"}, "patient:view", false},
+		{"Action without rules", &Principal{UserID: "kc-patient-2", Roles: []string{"PATIENT"}, OrgID: "org-1"}, "patient:update", true},
+	}
+
+	policies := testPolicies()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policies.Authorize(context.Background(), tc.principal, tc.action, patient)
+			if (err == nil) != tc.expected {
+				t.Errorf("Expected allowed=%v, got %v", tc.expected, err)
+			}
+			var typed *apierror.Error
+			if err != nil && (!errors.As(err, &typed) || typed.Status != http.StatusForbidden) {
+				t.Errorf("Expected 403 error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestAuthorize_AssignmentLoadedLazily tests that assignment is only loaded
+// by rules that check it and that load errors are returned
+func TestAuthorize_AssignmentLoadedLazily(t *testing.T) {
+	calls := 0
+	res := Resource{Type: "patient", OrgID: "org-1", Assigned: func(ctx context.Context) ([]string, error) {
+		calls++
+		return nil, errors.New("database down")
+	}}
+
+	admin := &Principal{UserID: "kc-admin", Roles: []string{"ORG_ADMIN"}, OrgID: "org-1"}
+	if err := testPolicies().Authorize(context.Background(), admin, "patient:view", res); err != nil || calls != 0 {
+		t.Errorf("Expected admin to be allowed without loading assignment, got err=%v calls=%d", err, calls)
+	}
+
+	caregiver := &Principal{UserID: "kc-caregiver-1", Roles: []string{"CAREGIVER"}, OrgID: "org-1"}
+	err := testPolicies().Authorize(context.Background(), caregiver, "patient:view", res)
+	if err == nil || !strings.Contains(err.Error(), "database down") {
+		t.Errorf("Expected load error, got %v", err)
+	}
+}
+
+// TestNilPoliciesAllowEverything tests that a missing policies file leaves
+// every action to the role permissions
+func TestNilPoliciesAllowEverything(t *testing.T) {
+	var policies *Policies
+	if err := policies.Authorize(context.Background(), &Principal{}, "patient:delete", Resource{}); err != nil {
+		t.Errorf("Expected nil policies to allow, got %v", err)
+	}
+}
+
+// TestLoadPolicies tests loading and validating policies files
+func TestLoadPolicies(t *testing.T) {
+	dir := t.TempDir()
+
+	policies, err := LoadPolicies(filepath.Join(dir, "missing.yml"))
+	if err != nil || policies != nil {
+		t.Fatalf("Expected missing file to disable policies, got %v, %v", policies, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yml")
+	os.WriteFile(invalid, []byte(`
+policies:
+  patient:veiw:
+    - roles: [PATIENT]
+  patient:update:
+    - when: [same_org]
+`), 0o644)
+	_, err = LoadPolicies(invalid)
+	if err == nil || !strings.Contains(err.Error(), "unknown action patient:veiw") || !strings.Contains(err.Error(), "unknown condition same_org") {
+		t.Errorf("Expected unknown action and condition errors, got %v", err)
+	}
+}
+
+// TestRepositoryPoliciesFile tests that the policies.yml in the project root
+// loads and keeps patients to their own record
+func TestRepositoryPoliciesFile(t *testing.T) {
+	policies, err := LoadPolicies("../../policies.yml")
+	if err != nil || policies == nil {
+		t.Fatalf("Expected policies.yml to load, got %v", err)
+	}
+
+	res := Resource{Type: "patient", ID: "patient-1", OrgID: "org-1", OwnerID: "kc-patient-1"}
+	other := &Principal{UserID: "kc-patient-2", Roles: []string{"PATIENT"}, OrgID: "org-1"}
+	if err := policies.Authorize(context.Background(), other, "patient:update", res); err == nil {
+		t.Error("Expected PATIENT not to update another patient")
+	}
+	owner := &Principal{UserID: "kc-patient-1", Roles: []string{"PATIENT"}, OrgID: "org-1"}
+	if err := policies.Authorize(context.Background(), owner, "patient:update", res); err != nil {
+		t.Errorf("Expected PATIENT to update own record, got %v", err)
+	}
+}
+
+// TestRequirePolicy tests the policy enforcement middleware
+func TestRequirePolicy(t *testing.T) {
+	resolved := 0
+	resolve := func(r *http.Request) (Resource, error) {
+		resolved++
+		if r.URL.Path == "/missing" {
+			return Resource{}, apierror.NotFound(apierror.CodeNotFound, "patient not found")
+		}
+		return Resource{Type: "patient", OrgID: "org-1", OwnerID: "kc-patient-1"}, nil
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(policies *Policies, action, path string, principal *Principal) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if principal != nil {
+			req = req.WithContext(ContextWithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		RequirePolicy(policies, action, resolve)(next).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	owner := &Principal{UserID: "kc-patient-1", Roles: []string{"PATIENT"}, OrgID: "org-1"}
+	other := &Principal{UserID: "kc-patient-2", Roles: []string{"PATIENT"}, OrgID: "org-1"}
+
+	if code := serve(testPolicies(), "patient:view", "/p", owner); code != http.StatusOK {
+		t.Errorf("Expected owner to pass, got %d", code)
+	}
+	if code := serve(testPolicies(), "patient:view", "/p", other); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for other patient, got %d", code)
+	}
+	if code := serve(testPolicies(), "patient:view", "/missing", owner); code != http.StatusNotFound {
+		t.Errorf("Expected resolver error status 404, got %d", code)
+	}
+	if code := serve(testPolicies(), "patient:view", "/p", nil); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without principal, got %d", code)
+	}
+
+	resolved = 0
+	if code := serve(nil, "patient:view", "/p", other); code != http.StatusOK || resolved != 0 {
+		t.Errorf("Expected pass without resolving when no policies, got %d after %d resolves", code, resolved)
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// ServiceAccountRole is the role of every service account. Policies use it
+// to give service accounts access; realm roles of the same name in user
+// tokens are ignored.
+const ServiceAccountRole = "SERVICE_ACCOUNT"
+
+// ClientRolePrefix prefixes the role holding the permissions of a client in
+// the clients section of permissions.yml
+const ClientRolePrefix = "client:"
+
+// OrganizationHeader selects the organization a service account acts on
+const OrganizationHeader = "X-Organization-ID"
+
+// ClientRole returns the role holding the permissions of a client
+func ClientRole(clientID string) string {
+	return ClientRolePrefix + clientID
+}
+
+// isServiceAccountRole reports roles only service accounts may hold
+func isServiceAccountRole(role string) bool {
+	return strings.EqualFold(role, ServiceAccountRole) || strings.HasPrefix(strings.ToLower(role), ClientRolePrefix)
+}
+
+// serviceAccountClient returns the client ID of a client-credentials token,
+// or "" for tokens of people. Keycloak adds client_id (clientId before
+// version 20) to service account tokens. The service-account- username
+// prefix is not trusted, because people can be given such usernames.
+func serviceAccountClient(claims jwt.MapClaims) string {
+	for _, claim := range []string{"client_id", "clientId"} {
+		if clientID, _ := claims[claim].(string); clientID != "" {
+			return clientID
+		}
+	}
+	return ""
+}
+
+// TenantLookup resolves the schema of an organization; "" means the
+// organization does not exist
+type TenantLookup interface {
+	GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error)
+}
+
+// ServiceMetricsRecorder records requests of service accounts
+type ServiceMetricsRecorder interface {
+	RecordServiceAccountRequest(ctx context.Context, clientID, tenant string)
+}
+
+// selectTenant sets the organization a service account selected with the
+// X-Organization-ID header. Without the header the principal has no
+// organization, so only routes that need none are usable.
+func selectTenant(r *http.Request, pr *Principal, tenants TenantLookup) error {
+	orgID := r.Header.Get(OrganizationHeader)
+	if orgID == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(orgID); err != nil {
+		return apierror.BadRequest(apierror.CodeInvalidRequest, OrganizationHeader+" must be an organization ID")
+	}
+	if tenants == nil {
+		return apierror.New(http.StatusInternalServerError, apierror.CodeInternal, "tenant selection is not available")
+	}
+
+	schemaName, err := tenants.GetSchemaNameByOrgID(r.Context(), orgID)
+	if err != nil {
+		return err
+	}
+	if schemaName == "" {
+		return apierror.NotFound("org_not_found", "organization not found")
+	}
+	pr.OrgID = orgID
+	pr.OrgSchemaName = schemaName
+	return nil
+}
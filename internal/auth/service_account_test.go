@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockTenantLookup resolves the organizations in schemas
+type mockTenantLookup map[string]string
+
+func (m mockTenantLookup) GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error) {
+	return m[orgID], nil
+}
+
+// recordedServiceRequests collects service account request metrics
+type recordedServiceRequests []string
+
+func (r *recordedServiceRequests) RecordServiceAccountRequest(ctx context.Context, clientID, tenant string) {
+	*r = append(*r, clientID+"@"+tenant)
+}
+
+// TestVerifier_ParseAndVerifyToken_ServiceAccount tests that client-credentials
+// tokens become service principals that hold only their client's role
+func TestVerifier_ParseAndVerifyToken_ServiceAccount(t *testing.T) {
+	privateKey, publicKey := generateTestKeyPair(t)
+	cfg := Config{Issuer: "https://test-keycloak.com/realms/test"}
+	verifier := NewVerifier(cfg, newMockJWKS(publicKey))
+
+	claims := func(extra jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "service-user-1",
+			"iss": cfg.Issuer,
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{
+				"roles": []interface{}{"SUPER_ADMIN", "SERVICE_ACCOUNT", "client:reporting"},
+			},
+			"organizationID": "org-1",
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	testCases := []struct {
+		name     string
+		extra    jwt.MapClaims
+		clientID string
+	}{
+		{"client_id claim", jwt.MapClaims{"client_id": "care-session-service"}, "care-session-service"},
+		{"clientId claim", jwt.MapClaims{"clientId": "care-session-service"}, "care-session-service"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr, err := verifier.ParseAndVerifyToken(signTestToken(t, privateKey, claims(tc.extra)))
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !pr.IsService() || pr.ClientID != tc.clientID {
+				t.Errorf("Expected service principal of %s, got %+v", tc.clientID, pr)
+			}
+			if len(pr.Roles) != 2 || pr.Roles[0] != ServiceAccountRole || pr.Roles[1] != ClientRole(tc.clientID) {
+				t.Errorf("Expected only the service account roles, got %v", pr.Roles)
+			}
+			if pr.OrgID != "" {
+				t.Errorf("Expected organization claims to be ignored, got %s", pr.OrgID)
+			}
+		})
+	}
+
+	// People cannot claim the roles of service accounts
+	pr, err := verifier.ParseAndVerifyToken(signTestToken(t, privateKey, claims(nil)))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if pr.IsService() || len(pr.Roles) != 1 || pr.Roles[0] != "SUPER_ADMIN" {
+		t.Errorf("Expected user principal with SUPER_ADMIN only, got %+v", pr)
+	}
+
+	// A person named like a service account user is still a person
+	pr, err = verifier.ParseAndVerifyToken(signTestToken(t, privateKey, claims(jwt.MapClaims{"preferred_username": "service-account-nfc-service", "azp": "nfc-service"})))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if pr.IsService() || pr.ClientID != "" {
+		t.Errorf("Expected the service-account- username prefix to be ignored, got %+v", pr)
+	}
+}
+
+// TestLoadPermissions_Clients tests permissions of service accounts
+func TestLoadPermissions_Clients(t *testing.T) {
+	perms, err := parsePermissions([]byte(`
+roles:
+  INSURER:
+    - organization:view
+    - patient:view
+clients:
+  care-session-service:
+    - patient:view
+  reporting:
+    inherits: [INSURER]
+    deny: [patient:view]
+`))
+	if err != nil {
+		t.Fatalf("Expected clients to load, got %v", err)
+	}
+
+	careSessions := &Principal{Type: PrincipalTypeService, ClientID: "care-session-service", Roles: []string{ServiceAccountRole, ClientRole("care-session-service")}}
+	if !HasPermission(careSessions, "patient:view", perms) || HasPermission(careSessions, "organization:view", perms) {
+		t.Errorf("Expected care-session-service to view patients only, got %v", EffectivePermissions(careSessions, perms))
+	}
+	reporting := &Principal{Type: PrincipalTypeService, ClientID: "reporting", Roles: []string{ServiceAccountRole, ClientRole("reporting")}}
+	if got := EffectivePermissions(reporting, perms); len(got) != 1 || got[0] != "organization:view" {
+		t.Errorf("Expected reporting to view organizations only, got %v", got)
+	}
+
+	_, err = parsePermissions([]byte(`
+roles:
+  INSURER: [organization:view]
+clients:
+  reporting: [patient:veiw]
+`))
+	if err == nil {
+		t.Error("Expected unknown client permission to be rejected")
+	}
+}
+
+// TestMiddlewareWithOptions_ServiceAccount tests tenant selection and
+// attribution of service account requests
+func TestMiddlewareWithOptions_ServiceAccount(t *testing.T) {
+	privateKey, publicKey := generateTestKeyPair(t)
+	cfg := Config{Issuer: "https://test-keycloak.com/realms/test"}
+	verifier := NewVerifier(cfg, newMockJWKS(publicKey))
+	token := signTestToken(t, privateKey, jwt.MapClaims{
+		"sub":       "service-user-1",
+		"iss":       cfg.Issuer,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"client_id": "care-session-service",
+	})
+
+	orgID := "0b7f8c36-6d1e-4a51-9a47-6f0f3c1f2d10"
+	recorded := &recordedServiceRequests{}
+	var got *Principal
+	handler := MiddlewareWithOptions(verifier, MiddlewareOptions{
+		ServiceMetrics: recorded,
+		Tenants:        mockTenantLookup{orgID: "org_test_schema"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(org string) int {
+		req := httptest.NewRequest(http.MethodGet, "/organization/patients", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if org != "" {
+			req.Header.Set(OrganizationHeader, org)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(orgID); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if got.OrgID != orgID || got.OrgSchemaName != "org_test_schema" {
+		t.Errorf("Expected selected organization, got %+v", got)
+	}
+	if len(*recorded) != 1 || (*recorded)[0] != "care-session-service@org_test_schema" {
+		t.Errorf("Expected request attributed to client, got %v", *recorded)
+	}
+
+	if code := serve(""); code != http.StatusOK || got.OrgID != "" {
+		t.Errorf("Expected request without organization, got %d and %q", code, got.OrgID)
+	}
+	if code := serve("4f2d0c2e-0c55-4c4b-8f8e-1d9a3c0b5e77"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown organization, got %d", code)
+	}
+	if code := serve("not-an-id"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed organization, got %d", code)
+	}
+}
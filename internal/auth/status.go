@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Account statuses of a principal. Tokens of principals that are not active
+// are rejected even though they have not expired yet.
+const (
+	PrincipalActive               = "active"
+	PrincipalDeleted              = "deleted"
+	PrincipalInactive             = "inactive"
+	PrincipalOrganizationInactive = "organization_inactive" // Organization missing, deleted, inactive or suspended
+)
+
+// revokedDetails are the problem details of rejected principals
+var revokedDetails = map[string]string{
+	PrincipalDeleted:              "account has been deleted",
+	PrincipalInactive:             "account is inactive",
+	PrincipalOrganizationInactive: "organization is not active",
+}
+
+// maxStatusEntries bounds the status cache; expired entries are pruned when
+// it is full
+const maxStatusEntries = 10000
+
+// PrincipalStatusLookup returns the account status of a principal
+type PrincipalStatusLookup interface {
+	PrincipalStatus(ctx context.Context, pr *Principal) (string, error)
+}
+
+// StatusCheckConfig holds principal status check configuration
+type StatusCheckConfig struct {
+	Enabled  bool          // Check the account status of every authenticated request
+	CacheTTL time.Duration // How long a status is cached
+}
+
+// LoadStatusCheckConfig loads principal status check configuration from
+// environment variables
+func LoadStatusCheckConfig() StatusCheckConfig {
+	// Get status check switch with default
+	enabled, _ := strconv.ParseBool(os.Getenv("PRINCIPAL_STATUS_CHECK"))
+
+	// Get cache TTL with default
+	ttl := 30 * time.Second
+	if ttlStr := os.Getenv("PRINCIPAL_STATUS_CACHE_TTL"); ttlStr != "" {
+		if duration, err := time.ParseDuration(ttlStr); err == nil && duration >= 0 {
+			ttl = duration
+		}
+	}
+
+	return StatusCheckConfig{
+		Enabled:  enabled,
+		CacheTTL: ttl,
+	}
+}
+
+type statusEntry struct {
+	status  string
+	orgID   string
+	expires time.Time
+}
+
+// StatusCache caches principal statuses for a short TTL. Entries of an
+// organization are dropped as soon as this service deletes or changes the
+// status of one of its users, patients or the organization itself. Replicas
+// subscribed to the status events drop them when the event arrives; without
+// RabbitMQ only the replica that made the change does, and the others pick it
+// up within the TTL, as they do for changes made outside this service.
+type StatusCache struct {
+	lookup PrincipalStatusLookup
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]statusEntry
+}
+
+// NewStatusCache creates a StatusCache over lookup
+func NewStatusCache(lookup PrincipalStatusLookup, ttl time.Duration) *StatusCache {
+	return &StatusCache{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[string]statusEntry),
+	}
+}
+
+// Status returns the cached status of pr, looking it up when missing or
+// expired
+func (c *StatusCache) Status(ctx context.Context, pr *Principal) (string, error) {
+	key := pr.OrgID + "/" + pr.UserID
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.status, nil
+	}
+
+	status, err := c.lookup.PrincipalStatus(ctx, pr)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxStatusEntries {
+		c.pruneLocked()
+	}
+	c.entries[key] = statusEntry{status: status, orgID: pr.OrgID, expires: time.Now().Add(c.ttl)}
+	return status, nil
+}
+
+// pruneLocked drops expired entries, or every entry when none has expired
+func (c *StatusCache) pruneLocked() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) >= maxStatusEntries {
+		c.entries = make(map[string]statusEntry)
+	}
+}
+
+// Invalidate drops the cached statuses of an organization's principals, or
+// of every principal when orgID is empty
+func (c *StatusCache) Invalidate(orgID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if orgID == "" {
+		c.entries = make(map[string]statusEntry)
+		return
+	}
+	for key, entry := range c.entries {
+		if entry.orgID == orgID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateEvent invalidates the statuses an event of this service may have
+// changed
+func (c *StatusCache) InvalidateEvent(routingKey string, eventData interface{}) {
+	switch event := eventData.(type) {
+	case messaging.UserDeletedEvent:
+		c.Invalidate(event.Data.OrganizationID)
+	case messaging.UserStatusChangedEvent:
+		c.Invalidate(event.Data.OrganizationID)
+	case messaging.PatientDeletedEvent:
+		c.Invalidate(event.Data.OrganizationID)
+	case messaging.PatientStatusChangedEvent:
+		c.Invalidate(event.Data.OrganizationID)
+	case messaging.OrganizationDeletedEvent:
+		c.Invalidate(event.Data.OrganizationID)
+	case messaging.OrganizationStatusChangedEvent:
+		c.Invalidate(event.Data.OrganizationID)
+	default:
+		// Unknown payload of a status event: drop everything to be safe
+		if strings.HasSuffix(routingKey, ".deleted") || strings.HasSuffix(routingKey, ".status_changed") {
+			c.Invalidate("")
+		}
+	}
+}
+
+// statusEvents are the routing keys of the events that may change statuses
+var statusEvents = []string{
+	messaging.EventUserDeleted,
+	messaging.EventUserStatusChanged,
+	messaging.EventPatientDeleted,
+	messaging.EventPatientStatusChanged,
+	messaging.EventOrganizationDeleted,
+	messaging.EventOrganizationStatusChanged,
+}
+
+// Subscribe invalidates statuses on the status events published by every
+// replica
+func (c *StatusCache) Subscribe(sub messaging.SubscriberInterface) error {
+	return sub.Subscribe(statusEvents, c.InvalidateMessage)
+}
+
+// InvalidateMessage invalidates the statuses changed by a received event.
+// Every status event names the organization in data.organization_id.
+func (c *StatusCache) InvalidateMessage(ctx context.Context, routingKey string, body []byte) {
+	var event struct {
+		Data struct {
+			OrganizationID string `json:"organization_id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		slog.WarnContext(ctx, "failed to decode status event", "routing_key", routingKey, "error", err)
+	}
+	c.Invalidate(event.Data.OrganizationID)
+}
+
+// Publisher returns a publisher that invalidates statuses on the events it
+// publishes and forwards them to next, which may be nil when RabbitMQ is not
+// available
+func (c *StatusCache) Publisher(next messaging.PublisherInterface) messaging.PublisherInterface {
+	return &invalidatingPublisher{cache: c, next: next}
+}
+
+// invalidatingPublisher invalidates statuses before publishing events
+type invalidatingPublisher struct {
+	cache *StatusCache
+	next  messaging.PublisherInterface
+}
+
+// Publish invalidates the statuses changed by the event and publishes it
+func (p *invalidatingPublisher) Publish(ctx context.Context, routingKey string, eventData interface{}) error {
+	p.cache.InvalidateEvent(routingKey, eventData)
+	if p.next == nil {
+		return nil
+	}
+	return p.next.Publish(ctx, routingKey, eventData)
+}
+
+// Close closes the wrapped publisher
+func (p *invalidatingPublisher) Close() error {
+	if p.next == nil {
+		return nil
+	}
+	return p.next.Close()
+}
+
+// DBStatusLookup looks up principal statuses in the organizations table and
+// the users and patients tables of the principal's tenant
+type DBStatusLookup struct {
+	db *sql.DB
+}
+
+// Ensure DBStatusLookup implements PrincipalStatusLookup
+var _ PrincipalStatusLookup = (*DBStatusLookup)(nil)
+
+// NewDBStatusLookup creates a new DBStatusLookup
+func NewDBStatusLookup(db *sql.DB) *DBStatusLookup {
+	return &DBStatusLookup{db: db}
+}
+
+// PrincipalStatus reports principals of a missing, deleted, inactive or
+// suspended organization, and principals whose user or patient record is
+// deleted or inactive. Principals without an organization or a record in it,
+// such as platform administrators, are active; so are SUPER_ADMINs regardless
+// of their organization's status.
+func (l *DBStatusLookup) PrincipalStatus(ctx context.Context, pr *Principal) (string, error) {
+	if pr.OrgID == "" {
+		return PrincipalActive, nil
+	}
+
+	superAdmin := pr.HasRole(RoleSuperAdmin)
+
+	var schemaName, orgStatus string
+	var orgDeleted bool
+	err := l.db.QueryRowContext(ctx, `
+		SELECT schema_name, COALESCE(status, 'active'), deleted_at IS NOT NULL
+		FROM wailsalutem.organizations
+		WHERE id::text = $1
+	`, pr.OrgID).Scan(&schemaName, &orgStatus, &orgDeleted)
+	if err == sql.ErrNoRows {
+		if superAdmin {
+			return PrincipalActive, nil
+		}
+		return PrincipalOrganizationInactive, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get organization status: %w", err)
+	}
+	if (orgDeleted || orgStatus != "active") && !superAdmin {
+		return PrincipalOrganizationInactive, nil
+	}
+
+	// Records are keyed by Keycloak user ID, which is a UUID
+	if _, err := uuid.Parse(pr.UserID); err != nil {
+		return PrincipalActive, nil
+	}
+	for _, table := range []string{"users", "patients"} {
+		// A live record wins over deleted ones of the same account
+		query := fmt.Sprintf(`
+			SELECT deleted_at IS NOT NULL, COALESCE(is_active, true)
+			FROM %s.%s
+			WHERE keycloak_user_id = $1
+			ORDER BY deleted_at IS NOT NULL
+			LIMIT 1
+		`, pq.QuoteIdentifier(schemaName), table)
+
+		var deleted, active bool
+		err := l.db.QueryRowContext(ctx, query, pr.UserID).Scan(&deleted, &active)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get %s status: %w", strings.TrimSuffix(table, "s"), err)
+		}
+		switch {
+		case deleted:
+			return PrincipalDeleted, nil
+		case !active:
+			return PrincipalInactive, nil
+		}
+		return PrincipalActive, nil
+	}
+	return PrincipalActive, nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockStatusLookup returns the status of each user ID and counts lookups
+type mockStatusLookup struct {
+	statuses map[string]string
+	err      error
+	lookups  int
+}
+
+func (m *mockStatusLookup) PrincipalStatus(ctx context.Context, pr *Principal) (string, error) {
+	m.lookups++
+	if m.err != nil {
+		return "", m.err
+	}
+	if status, ok := m.statuses[pr.UserID]; ok {
+		return status, nil
+	}
+	return PrincipalActive, nil
+}
+
+// TestStatusCache tests caching and invalidation of principal statuses
+func TestStatusCache(t *testing.T) {
+	lookup := &mockStatusLookup{statuses: map[string]string{}}
+	cache := NewStatusCache(lookup, time.Hour)
+	caregiver := &Principal{UserID: "kc-caregiver", OrgID: "org-1"}
+	other := &Principal{UserID: "kc-other", OrgID: "org-2"}
+
+	cache.Status(context.Background(), caregiver)
+	cache.Status(context.Background(), other)
+	lookup.statuses["kc-caregiver"] = PrincipalDeleted
+	if status, _ := cache.Status(context.Background(), caregiver); status != PrincipalActive || lookup.lookups != 2 {
+		t.Errorf("Expected cached active status, got %s after %d lookups", status, lookup.lookups)
+	}
+
+	// Deleting a user of org-1 through the service drops that organization
+	publisher := cache.Publisher(nil)
+	err := publisher.Publish(context.Background(), messaging.EventUserDeleted, messaging.UserDeletedEvent{
+		BaseEvent: messaging.NewBaseEvent(messaging.EventUserDeleted),
+		Data:      messaging.UserDeletedData{UserID: "user-1", OrganizationID: "org-1"},
+	})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if status, _ := cache.Status(context.Background(), caregiver); status != PrincipalDeleted {
+		t.Errorf("Expected deleted status after invalidation, got %s", status)
+	}
+	cache.Status(context.Background(), other)
+	if lookup.lookups != 3 {
+		t.Errorf("Expected other organization to stay cached, got %d lookups", lookup.lookups)
+	}
+
+	// Expired entries are looked up again
+	expiring := NewStatusCache(lookup, 0)
+	expiring.Status(context.Background(), other)
+	expiring.Status(context.Background(), other)
+	if lookup.lookups != 5 {
+		t.Errorf("Expected expired entry to be looked up again, got %d lookups", lookup.lookups)
+	}
+}
+
+// mockSubscriber records subscriptions and delivers events to them
+type mockSubscriber struct {
+	routingKeys []string
+	handle      messaging.EventHandler
+}
+
+func (m *mockSubscriber) Subscribe(routingKeys []string, handle messaging.EventHandler) error {
+	m.routingKeys, m.handle = routingKeys, handle
+	return nil
+}
+
+// TestStatusCacheSubscribe tests that status events of other replicas
+// invalidate the cache
+func TestStatusCacheSubscribe(t *testing.T) {
+	lookup := &mockStatusLookup{statuses: map[string]string{}}
+	cache := NewStatusCache(lookup, time.Hour)
+	caregiver := &Principal{UserID: "kc-caregiver", OrgID: "org-1"}
+	other := &Principal{UserID: "kc-other", OrgID: "org-2"}
+
+	sub := &mockSubscriber{}
+	if err := cache.Subscribe(sub); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if len(sub.routingKeys) != 6 {
+		t.Errorf("Expected the 6 status events, got %v", sub.routingKeys)
+	}
+
+	cache.Status(context.Background(), caregiver)
+	cache.Status(context.Background(), other)
+	lookup.statuses["kc-caregiver"] = PrincipalInactive
+
+	body, _ := json.Marshal(messaging.UserStatusChangedEvent{
+		BaseEvent: messaging.NewBaseEvent(messaging.EventUserStatusChanged),
+		Data:      messaging.UserStatusChangedData{UserID: "user-1", OrganizationID: "org-1", NewStatus: "inactive"},
+	})
+	sub.handle(context.Background(), messaging.EventUserStatusChanged, body)
+
+	if status, _ := cache.Status(context.Background(), caregiver); status != PrincipalInactive {
+		t.Errorf("Expected inactive status after the event, got %s", status)
+	}
+	cache.Status(context.Background(), other)
+	if lookup.lookups != 3 {
+		t.Errorf("Expected other organization to stay cached, got %d lookups", lookup.lookups)
+	}
+
+	// Undecodable events drop everything
+	sub.handle(context.Background(), messaging.EventUserDeleted, []byte("not json"))
+	cache.Status(context.Background(), other)
+	if lookup.lookups != 4 {
+		t.Errorf("Expected cache to be cleared, got %d lookups", lookup.lookups)
+	}
+}
+
+// TestMiddlewareWithStatus tests that tokens of revoked principals are
+// rejected before their expiry
+func TestMiddlewareWithStatus(t *testing.T) {
+	privateKey, publicKey := generateTestKeyPair(t)
+	cfg := Config{Issuer: "https://test-keycloak.com/realms/test"}
+	verifier := NewVerifier(cfg, newMockJWKS(publicKey))
+
+	lookup := &mockStatusLookup{statuses: map[string]string{
+		"kc-deleted":   PrincipalDeleted,
+		"kc-inactive":  PrincipalInactive,
+		"kc-suspended": PrincipalOrganizationInactive,
+	}}
+
+	serve := func(lookup PrincipalStatusLookup, sub string) *httptest.ResponseRecorder {
+		token := signTestToken(t, privateKey, jwt.MapClaims{
+			"sub":            sub,
+			"iss":            cfg.Issuer,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"organizationID": "org-1",
+		})
+		handler := MiddlewareWithStatus(verifier, nil, NewStatusCache(lookup, time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	testCases := []struct {
+		sub            string
+		expectedStatus int
+		expectedDetail string
+	}{
+		{"kc-active", http.StatusOK, ""},
+		{"kc-deleted", http.StatusUnauthorized, "account has been deleted"},
+		{"kc-inactive", http.StatusUnauthorized, "account is inactive"},
+		{"kc-suspended", http.StatusUnauthorized, "organization is not active"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.sub, func(t *testing.T) {
+			rec := serve(lookup, tc.sub)
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, rec.Code)
+			}
+			if tc.expectedDetail == "" {
+				return
+			}
+			var problem apierror.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if problem.Detail != tc.expectedDetail {
+				t.Errorf("Expected detail %q, got %q", tc.expectedDetail, problem.Detail)
+			}
+		})
+	}
+
+	if rec := serve(&mockStatusLookup{err: errors.New("database down")}, "kc-active"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the status lookup fails, got %d", rec.Code)
+	}
+}
+
+// TestLoadStatusCheckConfig tests the status check settings
+func TestLoadStatusCheckConfig(t *testing.T) {
+	t.Setenv("PRINCIPAL_STATUS_CHECK", "")
+	t.Setenv("PRINCIPAL_STATUS_CACHE_TTL", "")
+	if cfg := LoadStatusCheckConfig(); cfg.Enabled || cfg.CacheTTL != 30*time.Second {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+
+	t.Setenv("PRINCIPAL_STATUS_CHECK", "true")
+	t.Setenv("PRINCIPAL_STATUS_CACHE_TTL", "5s")
+	if cfg := LoadStatusCheckConfig(); !cfg.Enabled || cfg.CacheTTL != 5*time.Second {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+}
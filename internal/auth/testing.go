@@ -4,6 +4,7 @@ package auth
 
 import (
 	"context"
+	"crypto"
 	"crypto/rsa"
 )
 
@@ -17,7 +18,7 @@ func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Con
 // This is exported to allow E2E tests to create test verifiers
 func NewTestJWKS(publicKey *rsa.PublicKey) *JWKS {
 	return &JWKS{
-		keys: map[string]*rsa.PublicKey{
+		keys: map[string]crypto.PublicKey{
 			"test-key-id": publicKey,
 		},
 	}
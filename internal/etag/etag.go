@@ -0,0 +1,92 @@
+package etag
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// AnyVersion lets a write apply whatever the current row version is. It is
+// used when the request has no If-Match header or If-Match is "*".
+const AnyVersion = 0
+
+var (
+	// ErrPreconditionFailed is returned when If-Match does not name the
+	// current version of the resource
+	ErrPreconditionFailed = apierror.New(http.StatusPreconditionFailed, "precondition_failed", "resource was modified by someone else, fetch it again and retry")
+	// ErrPreconditionRequired is returned when a write has no If-Match header
+	// and the header is required
+	ErrPreconditionRequired = apierror.New(http.StatusPreconditionRequired, "precondition_required", "If-Match header is required")
+)
+
+// Config holds optimistic concurrency configuration
+type Config struct {
+	RequireIfMatch bool // Reject PUT, PATCH and DELETE without If-Match
+}
+
+// LoadConfig loads optimistic concurrency configuration from environment variables
+func LoadConfig() Config {
+	// Get If-Match requirement with default
+	requireIfMatch, _ := strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
+
+	return Config{
+		RequireIfMatch: requireIfMatch,
+	}
+}
+
+// Format returns the entity tag of a row version
+func Format(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Set writes the ETag header for a row version
+func Set(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", Format(version))
+}
+
+// IfMatch returns the row version named by the If-Match header of r, or
+// AnyVersion without a header. Row versions are strong validators, so weak
+// or malformed tags never match and fail the precondition.
+func IfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return AnyVersion, nil
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, ErrPreconditionFailed
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0, ErrPreconditionFailed
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= AnyVersion {
+		return 0, ErrPreconditionFailed
+	}
+	return version, nil
+}
+
+// RequireIfMatch rejects PUT, PATCH and DELETE requests without an If-Match
+// header when cfg requires one
+func RequireIfMatch(cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.RequireIfMatch {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				if r.Header.Get("If-Match") == "" {
+					apierror.Write(w, r, ErrPreconditionRequired, "")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{"missing", "", AnyVersion, false},
+		{"wildcard", "*", AnyVersion, false},
+		{"version", `"3"`, 3, false},
+		{"surrounding space", ` "7" `, 7, false},
+		{"unquoted", "3", 0, true},
+		{"weak", `W/"3"`, 0, true},
+		{"not a number", `"abc"`, 0, true},
+		{"zero", `"0"`, 0, true},
+		{"list", `"1", "2"`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/organizations/org-1", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := IfMatch(req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrPreconditionFailed) {
+					t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected version %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Set(rec, 4)
+
+	if got := rec.Header().Get("ETag"); got != `"4"` {
+		t.Errorf(`Expected ETag "4", got %s`, got)
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name    string
+		require bool
+		method  string
+		header  string
+		want    int
+	}{
+		{"not required", false, http.MethodDelete, "", http.StatusNoContent},
+		{"missing on delete", true, http.MethodDelete, "", http.StatusPreconditionRequired},
+		{"missing on patch", true, http.MethodPatch, "", http.StatusPreconditionRequired},
+		{"present on put", true, http.MethodPut, `"2"`, http.StatusNoContent},
+		{"wildcard on put", true, http.MethodPut, "*", http.StatusNoContent},
+		{"reads are not checked", true, http.MethodGet, "", http.StatusNoContent},
+		{"creates are not checked", true, http.MethodPost, "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireIfMatch(Config{RequireIfMatch: tt.require})(next)
+
+			req := httptest.NewRequest(tt.method, "/organization/patients/p-1", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("REQUIRE_IF_MATCH", "true")
+	if !LoadConfig().RequireIfMatch {
+		t.Error("Expected If-Match to be required")
+	}
+
+	t.Setenv("REQUIRE_IF_MATCH", "")
+	if LoadConfig().RequireIfMatch {
+		t.Error("Expected If-Match to be optional by default")
+	}
+}
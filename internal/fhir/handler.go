@@ -0,0 +1,179 @@
+package fhir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+)
+
+// SchemaLookup resolves the tenant schema of an organization
+type SchemaLookup interface {
+	GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error)
+}
+
+// Search parameters shared by every resource type
+const (
+	paramCount = "_count"
+	paramPage  = "page"
+)
+
+// newMeta returns resource metadata for the given modification time, or nil
+// when it is unknown
+func newMeta(lastUpdated time.Time) *Meta {
+	if lastUpdated.IsZero() {
+		return nil
+	}
+	return &Meta{LastUpdated: lastUpdated.UTC().Format(time.RFC3339)}
+}
+
+// writeResource writes a FHIR resource as application/fhir+json
+func writeResource(w http.ResponseWriter, status int, resource interface{}) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resource)
+}
+
+// writeOutcome writes an OperationOutcome with a single error issue
+func writeOutcome(w http.ResponseWriter, status int, code, diagnostics string) {
+	writeResource(w, status, OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue: []OperationOutcomeIssue{{
+			Severity:    "error",
+			Code:        code,
+			Diagnostics: diagnostics,
+		}},
+	})
+}
+
+// baseURL returns the absolute FHIR base of the request, honouring the
+// X-Forwarded-Proto header set by the ingress
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + "/fhir"
+}
+
+// requirePrincipal returns the authenticated principal of the request
+func requirePrincipal(w http.ResponseWriter, r *http.Request) (*auth.Principal, bool) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		writeOutcome(w, http.StatusUnauthorized, "login", "User not authenticated")
+		return nil, false
+	}
+	return principal, true
+}
+
+// resolveTenant returns the tenant schema for the request. SUPER_ADMIN selects
+// the organization with the X-Organization-ID header, other roles use the
+// organization in their token.
+func resolveTenant(w http.ResponseWriter, r *http.Request, schemaLookup SchemaLookup) (string, bool) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return "", false
+	}
+
+	orgID, err := principal.TargetOrganization(r.Header.Get(auth.OrganizationHeader))
+	if errors.Is(err, auth.ErrOtherOrganization) {
+		writeOutcome(w, http.StatusForbidden, "forbidden", "Cannot access a different organization")
+		return "", false
+	}
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "required", "X-Organization-ID header or an organization in the token is required")
+		return "", false
+	}
+	if orgID == principal.OrgID && principal.OrgSchemaName != "" {
+		return principal.OrgSchemaName, true
+	}
+
+	schemaName, err := schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+		writeOutcome(w, http.StatusInternalServerError, "exception", "Failed to lookup organization schema")
+		return "", false
+	}
+	if schemaName == "" {
+		writeOutcome(w, http.StatusNotFound, "not-found", "Organization schema not found")
+		return "", false
+	}
+
+	return schemaName, true
+}
+
+// parseSearchPaging maps _count and page onto pagination parameters
+func parseSearchPaging(r *http.Request) (pagination.Params, error) {
+	params := pagination.Params{Page: pagination.DefaultPage, Limit: pagination.DefaultLimit}
+	query := r.URL.Query()
+
+	if value := query.Get(paramCount); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return params, fmt.Errorf("%s must be a positive integer", paramCount)
+		}
+		params.Limit = count
+	}
+	if value := query.Get(paramPage); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return params, fmt.Errorf("%s must be a positive integer", paramPage)
+		}
+		params.Page = page
+	}
+
+	params.Validate()
+	return params, nil
+}
+
+// newSearchBundle wraps search results in a searchset Bundle with self,
+// next and previous links
+func newSearchBundle(r *http.Request, resourceType string, meta pagination.Meta, entries []BundleEntry) Bundle {
+	if entries == nil {
+		entries = []BundleEntry{}
+	}
+
+	bundle := Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Total:        meta.TotalRecords,
+		Entry:        entries,
+	}
+
+	pageURL := func(page int) string {
+		query := r.URL.Query()
+		query.Set(paramCount, strconv.Itoa(meta.PerPage))
+		query.Set(paramPage, strconv.Itoa(page))
+		return baseURL(r) + "/" + resourceType + "?" + query.Encode()
+	}
+
+	bundle.Link = append(bundle.Link, BundleLink{Relation: "self", URL: pageURL(meta.CurrentPage)})
+	if meta.HasNext {
+		bundle.Link = append(bundle.Link, BundleLink{Relation: "next", URL: pageURL(meta.CurrentPage + 1)})
+	}
+	if meta.HasPrevious {
+		bundle.Link = append(bundle.Link, BundleLink{Relation: "previous", URL: pageURL(meta.CurrentPage - 1)})
+	}
+
+	return bundle
+}
+
+// newEntry builds a search match entry for a resource
+func newEntry(r *http.Request, resourceType, id string, resource interface{}) BundleEntry {
+	return BundleEntry{
+		FullURL:  baseURL(r) + "/" + resourceType + "/" + url.PathEscape(id),
+		Resource: resource,
+		Search:   &BundleSearch{Mode: "match"},
+	}
+}
@@ -0,0 +1,98 @@
+package fhir
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/organization"
+	"github.com/gorilla/mux"
+)
+
+// NewOrganization renders a tenant as a FHIR R4 Organization resource
+func NewOrganization(o *organization.OrganizationResponse) Organization {
+	resource := Organization{
+		ResourceType: "Organization",
+		ID:           o.ID,
+		Meta:         newMeta(o.CreatedAt),
+		Active:       o.Status == "active",
+		Name:         o.Name,
+	}
+
+	if o.ContactPhone != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "phone", Value: o.ContactPhone, Use: "work"})
+	}
+	if o.ContactEmail != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "email", Value: o.ContactEmail, Use: "work"})
+	}
+
+	if o.Address != "" {
+		resource.Address = []Address{{Use: "work", Text: o.Address}}
+	}
+
+	return resource
+}
+
+// OrganizationHandler serves tenants as FHIR Organization resources
+type OrganizationHandler struct {
+	service organization.ServiceInterface
+}
+
+// NewOrganizationHandler creates a new FHIR Organization handler
+func NewOrganizationHandler(service organization.ServiceInterface) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+// ReadOrganization handles GET /fhir/Organization/{id}
+func (h *OrganizationHandler) ReadOrganization(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.service.GetOrganization(r.Context(), mux.Vars(r)["id"], principal)
+	switch {
+	case errors.Is(err, organization.ErrForbidden):
+		writeOutcome(w, http.StatusForbidden, "forbidden", "You don't have permission to view this organization")
+		return
+	case errors.Is(err, organization.ErrOrganizationNotFound):
+		writeOutcome(w, http.StatusNotFound, "not-found", "Organization not found")
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "failed to read organization", "error", err)
+		writeOutcome(w, http.StatusInternalServerError, "exception", "Failed to retrieve organization")
+		return
+	}
+
+	writeResource(w, http.StatusOK, NewOrganization(org))
+}
+
+// SearchOrganizations handles GET /fhir/Organization with the name and
+// _count search parameters. Non-SUPER_ADMIN callers only see their own
+// organization.
+func (h *OrganizationHandler) SearchOrganizations(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	params, err := parseSearchPaging(r)
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	params.Search = r.URL.Query().Get("name")
+
+	response, err := h.service.ListOrganizationsWithPagination(r.Context(), principal, params)
+	if err != nil {
+		writeOutcome(w, http.StatusInternalServerError, "exception", err.Error())
+		return
+	}
+
+	entries := make([]BundleEntry, 0, len(response.Organizations))
+	for i := range response.Organizations {
+		entries = append(entries, newEntry(r, "Organization", response.Organizations[i].ID, NewOrganization(&response.Organizations[i])))
+	}
+
+	writeResource(w, http.StatusOK, newSearchBundle(r, "Organization", response.Pagination, entries))
+}
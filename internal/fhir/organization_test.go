@@ -0,0 +1,140 @@
+package fhir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/organization"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/gorilla/mux"
+)
+
+// mockOrganizationService implements organization.ServiceInterface for testing
+type mockOrganizationService struct {
+	getOrganizationFunc func(ctx context.Context, id string, principal *auth.Principal) (*organization.OrganizationResponse, error)
+}
+
+func (m *mockOrganizationService) CreateOrganization(ctx context.Context, req organization.CreateOrganizationRequest) (*organization.OrganizationResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockOrganizationService) ListOrganizations(ctx context.Context, principal *auth.Principal) ([]organization.OrganizationResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockOrganizationService) ListOrganizationsWithPagination(ctx context.Context, principal *auth.Principal, params pagination.Params) (*organization.PaginatedListResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockOrganizationService) FindOrganization(ctx context.Context, id string) (*organization.OrganizationResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockOrganizationService) GetOrganization(ctx context.Context, id string, principal *auth.Principal) (*organization.OrganizationResponse, error) {
+	if m.getOrganizationFunc != nil {
+		return m.getOrganizationFunc(ctx, id, principal)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockOrganizationService) UpdateOrganization(ctx context.Context, id string, req organization.UpdateOrganizationRequest, version int, principal *auth.Principal) (*organization.OrganizationResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockOrganizationService) DeleteOrganization(ctx context.Context, id string, version int) error {
+	return errors.New("not implemented")
+}
+
+func TestReadOrganization(t *testing.T) {
+	handler := NewOrganizationHandler(&mockOrganizationService{
+		getOrganizationFunc: func(ctx context.Context, id string, principal *auth.Principal) (*organization.OrganizationResponse, error) {
+			if id != principal.OrgID {
+				return nil, organization.ErrForbidden
+			}
+			return &organization.OrganizationResponse{
+				ID:           id,
+				Name:         "Care Home",
+				ContactEmail: "info@carehome.example",
+				Address:      "2 Side St",
+				Status:       "active",
+			}, nil
+		},
+	})
+
+	req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Organization/org-123", nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "org-123"})
+
+	rr := httptest.NewRecorder()
+	handler.ReadOrganization(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	var resource Organization
+	json.NewDecoder(rr.Body).Decode(&resource)
+	if resource.ResourceType != "Organization" || resource.Name != "Care Home" || !resource.Active {
+		t.Errorf("Unexpected resource: %+v", resource)
+	}
+	if len(resource.Telecom) != 1 || resource.Telecom[0].System != "email" {
+		t.Errorf("Expected an email contact point, got %+v", resource.Telecom)
+	}
+
+	req = withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Organization/other-org", nil))
+	req = mux.SetURLVars(req, map[string]string{"id": "other-org"})
+
+	rr = httptest.NewRecorder()
+	handler.ReadOrganization(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another organization, got %d", rr.Code)
+	}
+}
+
+// TestReadOrganization_Errors tests that not found and unexpected errors are
+// told apart and internal error text stays out of the outcome
+func TestReadOrganization_Errors(t *testing.T) {
+	handler := NewOrganizationHandler(&mockOrganizationService{
+		getOrganizationFunc: func(ctx context.Context, id string, principal *auth.Principal) (*organization.OrganizationResponse, error) {
+			if id == "missing-org" {
+				return nil, fmt.Errorf("failed to get organization: %w", organization.ErrOrganizationNotFound)
+			}
+			return nil, errors.New("failed to query organization: connection refused")
+		},
+	})
+
+	testCases := []struct {
+		id             string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"missing-org", http.StatusNotFound, "not-found"},
+		{"broken-org", http.StatusInternalServerError, "exception"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Organization/"+tc.id, nil))
+			req = mux.SetURLVars(req, map[string]string{"id": tc.id})
+
+			rr := httptest.NewRecorder()
+			handler.ReadOrganization(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			var outcome OperationOutcome
+			json.NewDecoder(rr.Body).Decode(&outcome)
+			if outcome.Issue[0].Code != tc.expectedCode || strings.Contains(outcome.Issue[0].Diagnostics, "connection refused") {
+				t.Errorf("Unexpected outcome: %+v", outcome)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package fhir
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/patient"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Relationship code for emergency contacts from the HL7 v2 contact role table
+var emergencyContactRelationship = CodeableConcept{
+	Coding: []Coding{{
+		System:  "http://terminology.hl7.org/CodeSystem/v2-0131",
+		Code:    "C",
+		Display: "Emergency Contact",
+	}},
+}
+
+// NewPatient renders a tenant patient as a FHIR R4 Patient resource
+func NewPatient(p *patient.PatientResponse) Patient {
+	resource := Patient{
+		ResourceType: "Patient",
+		ID:           p.ID,
+		Active:       p.IsActive,
+	}
+
+	if p.UpdatedAt != nil {
+		resource.Meta = newMeta(*p.UpdatedAt)
+	} else {
+		resource.Meta = newMeta(p.CreatedAt)
+	}
+
+	if p.PatientID != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{Use: "usual", System: SystemPatientID, Value: p.PatientID})
+	}
+	if p.KeycloakUserID != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{Use: "secondary", System: SystemKeycloakUserID, Value: p.KeycloakUserID})
+	}
+
+	if p.FirstName != "" || p.LastName != "" {
+		resource.Name = []HumanName{newHumanName("official", p.FirstName, p.LastName)}
+	}
+
+	if p.PhoneNumber != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "phone", Value: p.PhoneNumber})
+	}
+	if p.Email != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "email", Value: p.Email})
+	}
+
+	// date_of_birth is scanned from a DATE column and may carry a time part
+	if p.DateOfBirth != nil && len(*p.DateOfBirth) >= len("2006-01-02") {
+		resource.BirthDate = (*p.DateOfBirth)[:len("2006-01-02")]
+	}
+
+	if p.Address != "" {
+		resource.Address = []Address{{Use: "home", Text: p.Address}}
+	}
+
+	if p.EmergencyContactName != "" || p.EmergencyContactPhone != "" {
+		contact := PatientContact{Relationship: []CodeableConcept{emergencyContactRelationship}}
+		if p.EmergencyContactName != "" {
+			contact.Name = &HumanName{Text: p.EmergencyContactName}
+		}
+		if p.EmergencyContactPhone != "" {
+			contact.Telecom = []ContactPoint{{System: "phone", Value: p.EmergencyContactPhone}}
+		}
+		resource.Contact = []PatientContact{contact}
+	}
+
+	return resource
+}
+
+func newHumanName(use, given, family string) HumanName {
+	name := HumanName{
+		Use:    use,
+		Text:   strings.TrimSpace(given + " " + family),
+		Family: family,
+	}
+	if given != "" {
+		name.Given = []string{given}
+	}
+	return name
+}
+
+// PatientHandler serves tenant patients as FHIR resources
+type PatientHandler struct {
+	service      patient.ServiceInterface
+	schemaLookup SchemaLookup
+}
+
+// NewPatientHandler creates a new FHIR Patient handler
+func NewPatientHandler(service patient.ServiceInterface, schemaLookup SchemaLookup) *PatientHandler {
+	return &PatientHandler{
+		service:      service,
+		schemaLookup: schemaLookup,
+	}
+}
+
+// ReadPatient handles GET /fhir/Patient/{id}
+func (h *PatientHandler) ReadPatient(w http.ResponseWriter, r *http.Request) {
+	schemaName, ok := resolveTenant(w, r, h.schemaLookup)
+	if !ok {
+		return
+	}
+
+	// Patient IDs are UUIDs, so other IDs cannot exist
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		writeOutcome(w, http.StatusNotFound, "not-found", "Patient not found")
+		return
+	}
+
+	p, err := h.service.GetPatient(r.Context(), schemaName, id)
+	if errors.Is(err, patient.ErrPatientNotFound) {
+		writeOutcome(w, http.StatusNotFound, "not-found", "Patient not found")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to read patient", "error", err)
+		writeOutcome(w, http.StatusInternalServerError, "exception", "Failed to retrieve patient")
+		return
+	}
+
+	writeResource(w, http.StatusOK, NewPatient(p))
+}
+
+// SearchPatients handles GET /fhir/Patient with the name, birthdate and
+// _count search parameters
+func (h *PatientHandler) SearchPatients(w http.ResponseWriter, r *http.Request) {
+	schemaName, ok := resolveTenant(w, r, h.schemaLookup)
+	if !ok {
+		return
+	}
+
+	params, err := parseSearchPaging(r)
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+
+	// FHIR name matches parts of the name only, unlike the REST search
+	// parameter, which also matches email
+	query := r.URL.Query()
+	params.Filters = map[string]string{}
+	if name := query.Get("name"); name != "" {
+		params.Filters[patient.FilterName] = name
+	}
+
+	if birthDate := query.Get("birthdate"); birthDate != "" {
+		// Only exact dates are supported, with or without the eq prefix
+		birthDate = strings.TrimPrefix(birthDate, "eq")
+		if _, err := time.Parse("2006-01-02", birthDate); err != nil {
+			writeOutcome(w, http.StatusBadRequest, "not-supported", "birthdate must be an exact date in YYYY-MM-DD format")
+			return
+		}
+		params.Filters[patient.FilterBirthDate] = birthDate
+	}
+
+	response, err := h.service.ListPatientsWithPagination(r.Context(), schemaName, params)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to search patients", "error", err)
+		writeOutcome(w, http.StatusInternalServerError, "exception", "Failed to search patients")
+		return
+	}
+
+	entries := make([]BundleEntry, 0, len(response.Patients))
+	for i := range response.Patients {
+		entries = append(entries, newEntry(r, "Patient", response.Patients[i].ID, NewPatient(&response.Patients[i])))
+	}
+
+	writeResource(w, http.StatusOK, newSearchBundle(r, "Patient", response.Pagination, entries))
+}
@@ -0,0 +1,280 @@
+package fhir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/patient"
+	"github.com/gorilla/mux"
+)
+
+// mockPatientService implements patient.ServiceInterface for testing
+type mockPatientService struct {
+	getPatientFunc                 func(ctx context.Context, schemaName, id string) (*patient.PatientResponse, error)
+	listPatientsWithPaginationFunc func(ctx context.Context, schemaName string, params pagination.Params) (*patient.PaginatedPatientListResponse, error)
+}
+
+func (m *mockPatientService) CreatePatient(ctx context.Context, schemaName, orgID string, req patient.CreatePatientRequest) (*patient.PatientResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) GetPatient(ctx context.Context, schemaName, id string) (*patient.PatientResponse, error) {
+	if m.getPatientFunc != nil {
+		return m.getPatientFunc(ctx, schemaName, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) GetMyPatient(ctx context.Context, schemaName string, keycloakUserID string) (*patient.PatientResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) ListAssignedCaregivers(ctx context.Context, schemaName, patientID string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) ListPatients(ctx context.Context, schemaName string) ([]patient.PatientResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) ListPatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (*patient.PaginatedPatientListResponse, error) {
+	if m.listPatientsWithPaginationFunc != nil {
+		return m.listPatientsWithPaginationFunc(ctx, schemaName, params)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) ListActivePatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (*patient.PaginatedPatientListResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) SearchPatients(ctx context.Context, schemaName string, params pagination.Params) (*patient.PatientSearchResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) UpdatePatient(ctx context.Context, schemaName, id string, req patient.UpdatePatientRequest, version int) (*patient.PatientResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientService) DeletePatient(ctx context.Context, schemaName, orgID, id string, version int) error {
+	return errors.New("not implemented")
+}
+
+// mockSchemaLookup implements SchemaLookup for testing
+type mockSchemaLookup struct {
+	schemaName string
+}
+
+func (m *mockSchemaLookup) GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error) {
+	return m.schemaName, nil
+}
+
+func withOrgAdmin(req *http.Request) *http.Request {
+	principal := &auth.Principal{
+		UserID:        "admin-123",
+		Roles:         []string{"ORG_ADMIN"},
+		OrgID:         "org-123",
+		OrgSchemaName: "org_123",
+	}
+	return req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+}
+
+func testPatient() patient.PatientResponse {
+	dob := "1950-02-01T00:00:00Z"
+	return patient.PatientResponse{
+		ID:                    "p-1",
+		PatientID:             "PT-0001",
+		KeycloakUserID:        "kc-1",
+		FirstName:             "Jane",
+		LastName:              "Smith",
+		Email:                 "jane@example.com",
+		PhoneNumber:           "+31612345678",
+		DateOfBirth:           &dob,
+		Address:               "1 Main St, Amsterdam",
+		EmergencyContactName:  "John Smith",
+		EmergencyContactPhone: "+31687654321",
+		IsActive:              true,
+		CreatedAt:             time.Date(2026, 1, 11, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestNewPatient(t *testing.T) {
+	p := testPatient()
+	resource := NewPatient(&p)
+
+	if resource.ResourceType != "Patient" || resource.ID != "p-1" {
+		t.Errorf("Unexpected resource header: %s/%s", resource.ResourceType, resource.ID)
+	}
+	if len(resource.Identifier) != 2 || resource.Identifier[0].System != SystemPatientID || resource.Identifier[1].Value != "kc-1" {
+		t.Errorf("Unexpected identifiers: %+v", resource.Identifier)
+	}
+	if resource.BirthDate != "1950-02-01" {
+		t.Errorf("Expected birthDate 1950-02-01, got %s", resource.BirthDate)
+	}
+	if resource.Name[0].Family != "Smith" || resource.Name[0].Given[0] != "Jane" {
+		t.Errorf("Unexpected name: %+v", resource.Name)
+	}
+	if len(resource.Telecom) != 2 {
+		t.Errorf("Expected phone and email telecom, got %+v", resource.Telecom)
+	}
+	if len(resource.Contact) != 1 || resource.Contact[0].Relationship[0].Coding[0].Code != "C" {
+		t.Errorf("Expected an emergency contact, got %+v", resource.Contact)
+	}
+	if resource.Meta.LastUpdated != "2026-01-11T10:00:00Z" {
+		t.Errorf("Unexpected lastUpdated %s", resource.Meta.LastUpdated)
+	}
+}
+
+// TestReadPatient_Errors tests that only missing patients return 404
+func TestReadPatient_Errors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		id             string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"Not found", "7d3c9f1e-2b4a-4c8d-9e6f-0a1b2c3d4e5f", patient.ErrPatientNotFound, http.StatusNotFound, "not-found"},
+		{"Malformed ID", "missing", errors.New("unexpected lookup"), http.StatusNotFound, "not-found"},
+		{"Database error", "7d3c9f1e-2b4a-4c8d-9e6f-0a1b2c3d4e5f", errors.New("connection refused"), http.StatusInternalServerError, "exception"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewPatientHandler(&mockPatientService{
+				getPatientFunc: func(ctx context.Context, schemaName, id string) (*patient.PatientResponse, error) {
+					return nil, tc.err
+				},
+			}, &mockSchemaLookup{})
+
+			req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Patient/"+tc.id, nil))
+			req = mux.SetURLVars(req, map[string]string{"id": tc.id})
+
+			rr := httptest.NewRecorder()
+			handler.ReadPatient(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			var outcome OperationOutcome
+			json.NewDecoder(rr.Body).Decode(&outcome)
+			if outcome.ResourceType != "OperationOutcome" || outcome.Issue[0].Code != tc.expectedCode {
+				t.Errorf("Unexpected outcome: %+v", outcome)
+			}
+			if strings.Contains(outcome.Issue[0].Diagnostics, "connection refused") {
+				t.Errorf("Expected internal error to be hidden, got %q", outcome.Issue[0].Diagnostics)
+			}
+		})
+	}
+}
+
+func TestReadPatient_SuperAdminRequiresOrganization(t *testing.T) {
+	handler := NewPatientHandler(&mockPatientService{}, &mockSchemaLookup{})
+
+	req := httptest.NewRequest(http.MethodGet, "/fhir/Patient/p-1", nil)
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), &auth.Principal{UserID: "root", Roles: []string{"SUPER_ADMIN"}}))
+
+	rr := httptest.NewRecorder()
+	handler.ReadPatient(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+}
+
+// TestReadPatient_OtherOrganizationForbidden tests that only SUPER_ADMIN may
+// select another organization with X-Organization-ID
+func TestReadPatient_OtherOrganizationForbidden(t *testing.T) {
+	handler := NewPatientHandler(&mockPatientService{}, &mockSchemaLookup{schemaName: "org_other"})
+
+	req := httptest.NewRequest(http.MethodGet, "/fhir/Patient/p-1", nil)
+	req.Header.Set("X-Organization-ID", "org-other")
+	req = withOrgAdmin(req)
+
+	rr := httptest.NewRecorder()
+	handler.ReadPatient(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rr.Code)
+	}
+}
+
+func TestSearchPatients_MapsParameters(t *testing.T) {
+	var gotParams pagination.Params
+	handler := NewPatientHandler(&mockPatientService{
+		listPatientsWithPaginationFunc: func(ctx context.Context, schemaName string, params pagination.Params) (*patient.PaginatedPatientListResponse, error) {
+			gotParams = params
+			params.Validate()
+			return &patient.PaginatedPatientListResponse{
+				Patients:   []patient.PatientResponse{testPatient()},
+				Pagination: params.CalculateMeta(12),
+			}, nil
+		},
+	}, &mockSchemaLookup{})
+
+	req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Patient?name=smi&birthdate=eq1950-02-01&_count=5", nil))
+
+	rr := httptest.NewRecorder()
+	handler.SearchPatients(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != ContentType {
+		t.Errorf("Expected %s, got %s", ContentType, rr.Header().Get("Content-Type"))
+	}
+	if gotParams.Search != "" || gotParams.Filters[patient.FilterName] != "smi" || gotParams.Limit != 5 || gotParams.Filters[patient.FilterBirthDate] != "1950-02-01" {
+		t.Errorf("Unexpected params: %+v", gotParams)
+	}
+
+	var bundle struct {
+		Type  string `json:"type"`
+		Total int    `json:"total"`
+		Link  []BundleLink
+		Entry []struct {
+			FullURL  string  `json:"fullUrl"`
+			Resource Patient `json:"resource"`
+		}
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&bundle); err != nil {
+		t.Fatalf("Failed to decode bundle: %v", err)
+	}
+	if bundle.Type != "searchset" || bundle.Total != 12 || len(bundle.Entry) != 1 {
+		t.Errorf("Unexpected bundle: %+v", bundle)
+	}
+	if bundle.Entry[0].FullURL != "http://example.com/fhir/Patient/p-1" {
+		t.Errorf("Unexpected fullUrl %s", bundle.Entry[0].FullURL)
+	}
+
+	var next string
+	for _, link := range bundle.Link {
+		if link.Relation == "next" {
+			next = link.URL
+		}
+	}
+	if !strings.Contains(next, "page=2") || !strings.Contains(next, "_count=5") {
+		t.Errorf("Expected a next link to page 2, got %q", next)
+	}
+}
+
+func TestSearchPatients_InvalidBirthdate(t *testing.T) {
+	handler := NewPatientHandler(&mockPatientService{}, &mockSchemaLookup{})
+
+	req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Patient?birthdate=gt1950", nil))
+
+	rr := httptest.NewRecorder()
+	handler.SearchPatients(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,241 @@
+package fhir
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/users"
+	"github.com/gorilla/mux"
+)
+
+// practitionerRole is the staff role exposed as FHIR practitioners
+const practitionerRole = "CAREGIVER"
+
+var caregiverRoleCode = CodeableConcept{
+	Coding: []Coding{{
+		System:  SystemStaffRole,
+		Code:    practitionerRole,
+		Display: "Caregiver",
+	}},
+}
+
+// NewPractitioner renders a caregiver as a FHIR R4 Practitioner resource
+func NewPractitioner(u *users.User) Practitioner {
+	resource := Practitioner{
+		ResourceType: "Practitioner",
+		ID:           u.ID,
+		Meta:         newMeta(u.UpdatedAt),
+		Active:       u.IsActive,
+	}
+	if resource.Meta == nil {
+		resource.Meta = newMeta(u.CreatedAt)
+	}
+
+	if u.EmployeeID != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{Use: "official", System: SystemEmployeeID, Value: u.EmployeeID})
+	}
+	if u.KeycloakUserID != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{Use: "secondary", System: SystemKeycloakUserID, Value: u.KeycloakUserID})
+	}
+
+	if u.FirstName != "" || u.LastName != "" {
+		resource.Name = []HumanName{newHumanName("official", u.FirstName, u.LastName)}
+	}
+
+	resource.Telecom = staffTelecom(u)
+
+	return resource
+}
+
+// NewPractitionerRole renders the employment of a caregiver by an
+// organization. Every caregiver works for exactly one organization, so the
+// role shares the practitioner's id.
+func NewPractitionerRole(u *users.User, orgID string) PractitionerRole {
+	resource := PractitionerRole{
+		ResourceType: "PractitionerRole",
+		ID:           u.ID,
+		Meta:         newMeta(u.UpdatedAt),
+		Active:       u.IsActive,
+		Practitioner: Reference{
+			Reference: "Practitioner/" + u.ID,
+			Display:   strings.TrimSpace(u.FirstName + " " + u.LastName),
+		},
+		Organization: Reference{Reference: "Organization/" + orgID},
+		Code:         []CodeableConcept{caregiverRoleCode},
+		Telecom:      staffTelecom(u),
+	}
+	if resource.Meta == nil {
+		resource.Meta = newMeta(u.CreatedAt)
+	}
+	return resource
+}
+
+// staffTelecom returns the work contact points of a staff member
+func staffTelecom(u *users.User) []ContactPoint {
+	var telecom []ContactPoint
+	if u.PhoneNumber != "" {
+		telecom = append(telecom, ContactPoint{System: "phone", Value: u.PhoneNumber, Use: "work"})
+	}
+	if u.Email != "" {
+		telecom = append(telecom, ContactPoint{System: "email", Value: u.Email, Use: "work"})
+	}
+	return telecom
+}
+
+// writeUserError maps users service errors onto OperationOutcome responses
+func writeUserError(w http.ResponseWriter, err error) {
+	switch err {
+	case users.ErrUserNotFound:
+		writeOutcome(w, http.StatusNotFound, "not-found", "Practitioner not found")
+	case users.ErrForbidden:
+		writeOutcome(w, http.StatusForbidden, "forbidden", err.Error())
+	case users.ErrInvalidOrgSchema:
+		writeOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+	default:
+		writeOutcome(w, http.StatusInternalServerError, "exception", "Failed to retrieve practitioners")
+	}
+}
+
+// PractitionerHandler serves caregivers as FHIR Practitioner and
+// PractitionerRole resources
+type PractitionerHandler struct {
+	service users.ServiceInterface
+}
+
+// NewPractitionerHandler creates a new FHIR Practitioner handler
+func NewPractitionerHandler(service users.ServiceInterface) *PractitionerHandler {
+	return &PractitionerHandler{service: service}
+}
+
+// getCaregiver loads a user and hides anyone who is not a caregiver
+func (h *PractitionerHandler) getCaregiver(ctx context.Context, id string, principal *auth.Principal, targetOrgID string) (*users.User, error) {
+	user, err := h.service.GetUser(ctx, id, principal, targetOrgID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != practitionerRole {
+		return nil, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// ReadPractitioner handles GET /fhir/Practitioner/{id}
+func (h *PractitionerHandler) ReadPractitioner(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.getCaregiver(r.Context(), mux.Vars(r)["id"], principal, r.Header.Get("X-Organization-ID"))
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	writeResource(w, http.StatusOK, NewPractitioner(user))
+}
+
+// SearchPractitioners handles GET /fhir/Practitioner with the name and
+// _count search parameters
+func (h *PractitionerHandler) SearchPractitioners(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	params, err := parseSearchPaging(r)
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	params.Search = r.URL.Query().Get("name")
+
+	response, err := h.service.ListActiveUsersByRoleWithPagination(r.Context(), principal, r.Header.Get("X-Organization-ID"), practitionerRole, params)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	entries := make([]BundleEntry, 0, len(response.Users))
+	for i := range response.Users {
+		entries = append(entries, newEntry(r, "Practitioner", response.Users[i].ID, NewPractitioner(&response.Users[i])))
+	}
+
+	writeResource(w, http.StatusOK, newSearchBundle(r, "Practitioner", response.Pagination, entries))
+}
+
+// ReadPractitionerRole handles GET /fhir/PractitionerRole/{id}
+func (h *PractitionerHandler) ReadPractitionerRole(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	targetOrgID := r.Header.Get("X-Organization-ID")
+	orgID, _, err := h.service.ResolveOrganization(r.Context(), principal, targetOrgID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	user, err := h.getCaregiver(r.Context(), mux.Vars(r)["id"], principal, targetOrgID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	writeResource(w, http.StatusOK, NewPractitionerRole(user, orgID))
+}
+
+// SearchPractitionerRoles handles GET /fhir/PractitionerRole with the
+// practitioner and _count search parameters
+func (h *PractitionerHandler) SearchPractitionerRoles(w http.ResponseWriter, r *http.Request) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	params, err := parseSearchPaging(r)
+	if err != nil {
+		writeOutcome(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+
+	targetOrgID := r.Header.Get("X-Organization-ID")
+	orgID, _, err := h.service.ResolveOrganization(r.Context(), principal, targetOrgID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	// A practitioner reference narrows the search to that caregiver's role
+	if practitioner := r.URL.Query().Get("practitioner"); practitioner != "" {
+		entries := []BundleEntry{}
+		user, err := h.getCaregiver(r.Context(), strings.TrimPrefix(practitioner, "Practitioner/"), principal, targetOrgID)
+		switch {
+		case err == nil:
+			entries = append(entries, newEntry(r, "PractitionerRole", user.ID, NewPractitionerRole(user, orgID)))
+		case err != users.ErrUserNotFound:
+			writeUserError(w, err)
+			return
+		}
+
+		writeResource(w, http.StatusOK, newSearchBundle(r, "PractitionerRole", params.CalculateMeta(len(entries)), entries))
+		return
+	}
+
+	response, err := h.service.ListActiveUsersByRoleWithPagination(r.Context(), principal, targetOrgID, practitionerRole, params)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	entries := make([]BundleEntry, 0, len(response.Users))
+	for i := range response.Users {
+		entries = append(entries, newEntry(r, "PractitionerRole", response.Users[i].ID, NewPractitionerRole(&response.Users[i], orgID)))
+	}
+
+	writeResource(w, http.StatusOK, newSearchBundle(r, "PractitionerRole", response.Pagination, entries))
+}
@@ -0,0 +1,224 @@
+package fhir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/users"
+	"github.com/gorilla/mux"
+)
+
+// mockUserService implements users.ServiceInterface for testing
+type mockUserService struct {
+	getUserFunc                             func(userID string, principal *auth.Principal, targetOrgID string) (*users.User, error)
+	listActiveUsersByRoleWithPaginationFunc func(principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*users.PaginatedUserListResponse, error)
+	resolveOrganizationFunc                 func(principal *auth.Principal, targetOrgID string) (string, string, error)
+}
+
+func (m *mockUserService) CreateUser(ctx context.Context, req users.CreateUserRequest, principal *auth.Principal, targetOrgID string) (*users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) GetUser(ctx context.Context, userID string, principal *auth.Principal, targetOrgID string) (*users.User, error) {
+	if m.getUserFunc != nil {
+		return m.getUserFunc(userID, principal, targetOrgID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) ListUsers(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) ListUsersWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, params pagination.Params) (*users.PaginatedUserListResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) ListActiveUsersByRoleWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*users.PaginatedUserListResponse, error) {
+	if m.listActiveUsersByRoleWithPaginationFunc != nil {
+		return m.listActiveUsersByRoleWithPaginationFunc(principal, targetOrgID, role, params)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) UpdateUser(ctx context.Context, userID string, req users.UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (*users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) GetMyProfile(ctx context.Context, principal *auth.Principal) (*users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) UpdateMyProfile(ctx context.Context, req users.UpdateUserRequest, version int, principal *auth.Principal) (*users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) ResetPassword(ctx context.Context, userID string, req users.ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockUserService) DeleteUser(ctx context.Context, userID string, version int, principal *auth.Principal) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockUserService) ResolveOrganization(ctx context.Context, principal *auth.Principal, targetOrgID string) (string, string, error) {
+	if m.resolveOrganizationFunc != nil {
+		return m.resolveOrganizationFunc(principal, targetOrgID)
+	}
+	return "org-123", "org_123", nil
+}
+
+func (m *mockUserService) OrganizationOfSchema(ctx context.Context, orgSchemaName string) (string, error) {
+	return "org-123", nil
+}
+
+func (m *mockUserService) FindExistingUser(ctx context.Context, orgSchemaName, username, email string) (*users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserService) ListStaff(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]users.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func testCaregiver() users.User {
+	return users.User{
+		ID:             "u-1",
+		KeycloakUserID: "kc-2",
+		EmployeeID:     "EMP-001",
+		Email:          "carla@example.com",
+		FirstName:      "Carla",
+		LastName:       "Giver",
+		PhoneNumber:    "+31611112222",
+		Role:           "CAREGIVER",
+		IsActive:       true,
+		CreatedAt:      time.Date(2026, 1, 11, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestNewPractitionerRole(t *testing.T) {
+	u := testCaregiver()
+	role := NewPractitionerRole(&u, "org-123")
+
+	if role.Practitioner.Reference != "Practitioner/u-1" || role.Practitioner.Display != "Carla Giver" {
+		t.Errorf("Unexpected practitioner reference: %+v", role.Practitioner)
+	}
+	if role.Organization.Reference != "Organization/org-123" {
+		t.Errorf("Unexpected organization reference: %+v", role.Organization)
+	}
+	if role.Code[0].Coding[0].Code != "CAREGIVER" {
+		t.Errorf("Unexpected role code: %+v", role.Code)
+	}
+}
+
+func TestReadPractitioner(t *testing.T) {
+	tests := []struct {
+		name           string
+		user           users.User
+		err            error
+		expectedStatus int
+	}{
+		{name: "caregiver", user: testCaregiver(), expectedStatus: http.StatusOK},
+		{name: "not a caregiver", user: users.User{ID: "u-2", Role: "INSURER"}, expectedStatus: http.StatusNotFound},
+		{name: "forbidden", err: users.ErrForbidden, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPractitionerHandler(&mockUserService{
+				getUserFunc: func(userID string, principal *auth.Principal, targetOrgID string) (*users.User, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &tt.user, nil
+				},
+			})
+
+			req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Practitioner/u-1", nil))
+			req = mux.SetURLVars(req, map[string]string{"id": "u-1"})
+
+			rr := httptest.NewRecorder()
+			handler.ReadPractitioner(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if rr.Code == http.StatusOK {
+				var resource Practitioner
+				json.NewDecoder(rr.Body).Decode(&resource)
+				if resource.ResourceType != "Practitioner" || resource.Identifier[0].Value != "EMP-001" {
+					t.Errorf("Unexpected resource: %+v", resource)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchPractitioners_ListsActiveCaregivers(t *testing.T) {
+	var gotRole, gotSearch string
+	handler := NewPractitionerHandler(&mockUserService{
+		listActiveUsersByRoleWithPaginationFunc: func(principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*users.PaginatedUserListResponse, error) {
+			gotRole, gotSearch = role, params.Search
+			return &users.PaginatedUserListResponse{
+				Users:      []users.User{testCaregiver()},
+				Pagination: params.CalculateMeta(1),
+			}, nil
+		},
+	})
+
+	req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/Practitioner?name=carla", nil))
+
+	rr := httptest.NewRecorder()
+	handler.SearchPractitioners(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if gotRole != "CAREGIVER" || gotSearch != "carla" {
+		t.Errorf("Expected CAREGIVER search for carla, got %s/%s", gotRole, gotSearch)
+	}
+}
+
+func TestSearchPractitionerRoles_ByPractitioner(t *testing.T) {
+	handler := NewPractitionerHandler(&mockUserService{
+		getUserFunc: func(userID string, principal *auth.Principal, targetOrgID string) (*users.User, error) {
+			if userID != "u-1" {
+				return nil, users.ErrUserNotFound
+			}
+			u := testCaregiver()
+			return &u, nil
+		},
+	})
+
+	tests := []struct {
+		query         string
+		expectedTotal int
+	}{
+		{query: "practitioner=Practitioner/u-1", expectedTotal: 1},
+		{query: "practitioner=u-1", expectedTotal: 1},
+		{query: "practitioner=missing", expectedTotal: 0},
+	}
+
+	for _, tt := range tests {
+		req := withOrgAdmin(httptest.NewRequest(http.MethodGet, "/fhir/PractitionerRole?"+tt.query, nil))
+
+		rr := httptest.NewRecorder()
+		handler.SearchPractitionerRoles(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", tt.query, rr.Code)
+		}
+
+		var bundle Bundle
+		json.NewDecoder(rr.Body).Decode(&bundle)
+		if bundle.Total != tt.expectedTotal || len(bundle.Entry) != tt.expectedTotal {
+			t.Errorf("%s: expected %d entries, got total %d with %d entries", tt.query, tt.expectedTotal, bundle.Total, len(bundle.Entry))
+		}
+	}
+}
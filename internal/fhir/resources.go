@@ -0,0 +1,152 @@
+package fhir
+
+// Minimal FHIR R4 data types and resources. Only the elements this service
+// populates are modelled; see https://hl7.org/fhir/R4/ for the full definitions.
+
+// ContentType is the FHIR JSON media type
+const ContentType = "application/fhir+json"
+
+// Identifier systems for the business identifiers this service assigns
+const (
+	SystemPatientID      = "urn:wailsalutem:patient-id"
+	SystemKeycloakUserID = "urn:wailsalutem:keycloak-user-id"
+	SystemEmployeeID     = "urn:wailsalutem:employee-id"
+	SystemStaffRole      = "urn:wailsalutem:staff-role"
+)
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Address struct {
+	Use  string `json:"use,omitempty"`
+	Text string `json:"text"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+// PatientContact is a contact party of a patient, such as an emergency contact
+type PatientContact struct {
+	Relationship []CodeableConcept `json:"relationship,omitempty"`
+	Name         *HumanName        `json:"name,omitempty"`
+	Telecom      []ContactPoint    `json:"telecom,omitempty"`
+}
+
+// Patient is the FHIR R4 Patient resource
+type Patient struct {
+	ResourceType string           `json:"resourceType"`
+	ID           string           `json:"id"`
+	Meta         *Meta            `json:"meta,omitempty"`
+	Identifier   []Identifier     `json:"identifier,omitempty"`
+	Active       bool             `json:"active"`
+	Name         []HumanName      `json:"name,omitempty"`
+	Telecom      []ContactPoint   `json:"telecom,omitempty"`
+	BirthDate    string           `json:"birthDate,omitempty"`
+	Address      []Address        `json:"address,omitempty"`
+	Contact      []PatientContact `json:"contact,omitempty"`
+}
+
+// Practitioner is the FHIR R4 Practitioner resource
+type Practitioner struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+}
+
+// PractitionerRole links a practitioner to the organization it works for
+type PractitionerRole struct {
+	ResourceType string            `json:"resourceType"`
+	ID           string            `json:"id"`
+	Meta         *Meta             `json:"meta,omitempty"`
+	Active       bool              `json:"active"`
+	Practitioner Reference         `json:"practitioner"`
+	Organization Reference         `json:"organization"`
+	Code         []CodeableConcept `json:"code,omitempty"`
+	Telecom      []ContactPoint    `json:"telecom,omitempty"`
+}
+
+// Organization is the FHIR R4 Organization resource
+type Organization struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id"`
+	Meta         *Meta          `json:"meta,omitempty"`
+	Active       bool           `json:"active"`
+	Name         string         `json:"name"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Address      []Address      `json:"address,omitempty"`
+}
+
+// BundleLink is a navigation link of a search set
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+// BundleSearch records why an entry is part of a search set
+type BundleSearch struct {
+	Mode string `json:"mode"`
+}
+
+type BundleEntry struct {
+	FullURL  string        `json:"fullUrl"`
+	Resource interface{}   `json:"resource"`
+	Search   *BundleSearch `json:"search,omitempty"`
+}
+
+// Bundle is the FHIR R4 Bundle resource, used here for search results
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Total        int           `json:"total"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+// OperationOutcome is the FHIR error response
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
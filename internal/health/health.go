@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status values of a check and of the whole report
+const (
+	StatusOK       = "ok"
+	StatusFailed   = "failed"
+	StatusDegraded = "degraded" // Only optional checks failed
+)
+
+// DefaultTimeout bounds a check that sets no timeout of its own
+const DefaultTimeout = 2 * time.Second
+
+// Errors reported for failed checks. The readiness endpoint is public, so
+// the underlying errors are only logged.
+const (
+	ErrorFailed   = "check failed"
+	ErrorTimedOut = "check timed out"
+)
+
+// Check is one dependency checked by the readiness endpoint
+type Check struct {
+	Name     string
+	Timeout  time.Duration
+	Optional bool // A failure is reported but does not make the service unready
+	Func     func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status     string  `json:"status"`
+	Optional   bool    `json:"optional,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"` // ErrorFailed or ErrorTimedOut
+}
+
+// Report is the readiness response body
+type Report struct {
+	Status  string                 `json:"status"`
+	Service string                 `json:"service"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker runs the readiness checks of the service
+type Checker struct {
+	service string
+	checks  []Check
+}
+
+// NewChecker creates a checker for service
+func NewChecker(service string, checks ...Check) *Checker {
+	return &Checker{service: service, checks: checks}
+}
+
+// Run runs all checks concurrently, each with its own timeout
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:  StatusOK,
+		Service: c.service,
+		Checks:  make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status == StatusOK {
+				return
+			}
+			if !check.Optional {
+				report.Status = StatusFailed
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs one check. A check that does not return within its timeout fails
+// even if it ignores the context.
+func run(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Func(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:     StatusOK,
+		Optional:   check.Optional,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = ErrorFailed
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = ErrorTimedOut
+		}
+		slog.WarnContext(ctx, "readiness check failed", "check", check.Name, "optional", check.Optional, "error", err)
+	}
+	return result
+}
+
+// Livez answers the liveness probe. It only reports that the process serves
+// requests; dependencies are left to Readyz so an outage does not make
+// Kubernetes restart every replica.
+func (c *Checker) Livez(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK, Service: c.service})
+}
+
+// Readyz answers the readiness probe with the result of every check: 200
+// when all required checks pass, 503 otherwise
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	status := http.StatusOK
+	if report.Status == StatusFailed {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func TestReadyzAllChecksPass(t *testing.T) {
+	checker := NewChecker("organization-service",
+		Check{Name: "database", Func: ok},
+		Check{Name: "jwks", Func: ok},
+	)
+
+	rec := httptest.NewRecorder()
+	checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var report Report
+	json.NewDecoder(rec.Body).Decode(&report)
+	if report.Status != StatusOK || len(report.Checks) != 2 {
+		t.Errorf("Expected ok report with 2 checks, got %+v", report)
+	}
+	if report.Checks["database"].Status != StatusOK {
+		t.Errorf("Expected database ok, got %+v", report.Checks["database"])
+	}
+}
+
+func TestReadyzRequiredCheckFails(t *testing.T) {
+	checker := NewChecker("organization-service",
+		Check{Name: "database", Func: func(ctx context.Context) error { return errors.New("connection refused") }},
+		Check{Name: "jwks", Func: ok},
+	)
+
+	rec := httptest.NewRecorder()
+	checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", rec.Code)
+	}
+	var report Report
+	json.NewDecoder(rec.Body).Decode(&report)
+	if report.Status != StatusFailed {
+		t.Errorf("Expected failed report, got %s", report.Status)
+	}
+	if got := report.Checks["database"]; got.Status != StatusFailed || got.Error != ErrorFailed {
+		t.Errorf("Expected database failure with error, got %+v", got)
+	}
+}
+
+func TestReadyzOptionalCheckFails(t *testing.T) {
+	checker := NewChecker("organization-service",
+		Check{Name: "database", Func: ok},
+		Check{Name: "rabbitmq", Optional: true, Func: func(ctx context.Context) error { return errors.New("not connected") }},
+	)
+
+	rec := httptest.NewRecorder()
+	checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	var report Report
+	json.NewDecoder(rec.Body).Decode(&report)
+	if report.Status != StatusDegraded {
+		t.Errorf("Expected degraded report, got %s", report.Status)
+	}
+	if !report.Checks["rabbitmq"].Optional {
+		t.Error("Expected rabbitmq to be reported as optional")
+	}
+}
+
+func TestRunTimesOutHangingCheck(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	checker := NewChecker("organization-service", Check{
+		Name:    "keycloak",
+		Timeout: 20 * time.Millisecond,
+		Func: func(ctx context.Context) error {
+			<-release // Ignores the context
+			return nil
+		},
+	})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected check to be cut off at its timeout, took %s", elapsed)
+	}
+	if got := report.Checks["keycloak"]; got.Status != StatusFailed || got.Error != ErrorTimedOut {
+		t.Errorf("Expected timeout failure, got %+v", got)
+	}
+}
+
+func TestLivezIgnoresDependencies(t *testing.T) {
+	checker := NewChecker("organization-service",
+		Check{Name: "database", Func: func(ctx context.Context) error { return errors.New("down") }},
+	)
+
+	rec := httptest.NewRecorder()
+	checker.Livez(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HTTPMetricsRecorder records completed HTTP requests
+type HTTPMetricsRecorder interface {
+	RecordHTTPRequest(ctx context.Context, method, route string, statusCode int, durationMs float64)
+}
+
+// MetricsMiddleware records method, route template, status and duration of
+// every request matched by the router. The route template (e.g.
+// /organization/patients/{id}) keeps the number of series independent of ids.
+// Router middleware does not see unmatched requests; RecordUnmatched routes
+// them through it under the route "unmatched".
+func MetricsMiddleware(metrics HTTPMetricsRecorder) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(recorder, r)
+
+			route := "unmatched"
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+
+			durationMs := float64(time.Since(start).Microseconds()) / 1000
+			metrics.RecordHTTPRequest(r.Context(), r.Method, route, recorder.status, durationMs)
+		})
+	}
+}
+
+// RecordUnmatched records requests that match no route, or no method of a
+// route, with MetricsMiddleware. The responses are mux's defaults.
+func RecordUnmatched(r *mux.Router, metrics HTTPMetricsRecorder) {
+	record := MetricsMiddleware(metrics)
+	r.NotFoundHandler = record(http.NotFoundHandler())
+	r.MethodNotAllowedHandler = record(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type recordedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+type mockHTTPMetrics struct {
+	requests []recordedRequest
+}
+
+func (m *mockHTTPMetrics) RecordHTTPRequest(ctx context.Context, method, route string, statusCode int, durationMs float64) {
+	m.requests = append(m.requests, recordedRequest{method: method, route: route, status: statusCode})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	metrics := &mockHTTPMetrics{}
+
+	r := mux.NewRouter()
+	r.Use(MetricsMiddleware(metrics))
+	r.HandleFunc("/organization/patients/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}).Methods("GET")
+
+	RecordUnmatched(r, metrics)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/organization/patients/123", nil),
+		httptest.NewRequest("GET", "/health", nil),
+		httptest.NewRequest("GET", "/unknown", nil),
+		httptest.NewRequest("DELETE", "/health", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []recordedRequest{
+		{method: "GET", route: "/organization/patients/{id}", status: http.StatusNotFound},
+		{method: "GET", route: "/health", status: http.StatusOK},
+		{method: "GET", route: "unmatched", status: http.StatusNotFound},
+		{method: "DELETE", route: "unmatched", status: http.StatusMethodNotAllowed},
+	}
+	if len(metrics.requests) != len(want) {
+		t.Fatalf("Expected %d recorded requests, got %d", len(want), len(metrics.requests))
+	}
+	for i, got := range metrics.requests {
+		if got != want[i] {
+			t.Errorf("Request %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+)
+
+// Limit names used in metrics and error details
+const (
+	LimitUser         = "user"
+	LimitOrganization = "organization"
+)
+
+// rateWindow is the period a budget applies to
+const rateWindow = time.Minute
+
+// Budget is the number of requests per minute for one user and one
+// organization. Zero means unlimited.
+type Budget struct {
+	PerUser         int `yaml:"per_user"`
+	PerOrganization int `yaml:"per_organization"`
+}
+
+// RateLimits holds the budgets of ratelimits.yml
+type RateLimits struct {
+	Defaults Budget            `yaml:"defaults"`
+	Routes   map[string]Budget `yaml:"routes"` // By "METHOD /path/template"
+}
+
+// LoadRateLimits loads a ratelimits.yml file. A missing file returns nil,
+// which disables rate limiting.
+func LoadRateLimits(path string) (*RateLimits, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var limits RateLimits
+	if err := yaml.Unmarshal(b, &limits); err != nil {
+		return nil, err
+	}
+	if err := limits.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &limits, nil
+}
+
+// LoadRateLimitsFromEnv loads the file named by RATE_LIMITS_FILE, by default
+// ratelimits.yml next to permissions.yml
+func LoadRateLimitsFromEnv() (*RateLimits, error) {
+	// Get file path with default
+	path := os.Getenv("RATE_LIMITS_FILE")
+	if path == "" {
+		path = "ratelimits.yml"
+	}
+	return LoadRateLimits(path)
+}
+
+// validate rejects negative budgets and malformed route keys
+func (l *RateLimits) validate() error {
+	if l.Defaults.PerUser < 0 || l.Defaults.PerOrganization < 0 {
+		return errors.New("defaults: budgets must not be negative")
+	}
+	for route, budget := range l.Routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("route %q: expected \"METHOD /path\"", route)
+		}
+		if budget.PerUser < 0 || budget.PerOrganization < 0 {
+			return fmt.Errorf("route %q: budgets must not be negative", route)
+		}
+	}
+	return nil
+}
+
+// budget returns the budget of a route
+func (l *RateLimits) budget(route string) Budget {
+	if budget, ok := l.Routes[route]; ok {
+		return budget
+	}
+	return l.Defaults
+}
+
+// RateLimitMetricsRecorder records throttled requests
+type RateLimitMetricsRecorder interface {
+	RecordThrottledRequest(ctx context.Context, method, route, limit, tenant string)
+}
+
+// RateLimiter keeps a token bucket per route and user and per route and
+// organization. Buckets live in memory, so every replica enforces the
+// budgets on its own share of the traffic.
+type RateLimiter struct {
+	limits *RateLimits
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket holds the tokens left at a point in time. It refills at budget
+// tokens per rateWindow up to budget.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a rate limiter for the budgets in limits
+func NewRateLimiter(limits *RateLimits) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow takes a token for the user and the organization of a request to
+// route. When either budget is used up nothing is taken, and the limit that
+// was hit and the time until a token is available are returned.
+func (l *RateLimiter) Allow(route, userID, orgID string) (ok bool, limit string, retryAfter time.Duration) {
+	budget := l.limits.budget(route)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	type take struct {
+		limit  string
+		bucket *bucket
+		budget int
+	}
+	var takes []take
+	if userID != "" && budget.PerUser > 0 {
+		takes = append(takes, take{LimitUser, l.bucket(route+"|user|"+userID, budget.PerUser, now), budget.PerUser})
+	}
+	if orgID != "" && budget.PerOrganization > 0 {
+		takes = append(takes, take{LimitOrganization, l.bucket(route+"|org|"+orgID, budget.PerOrganization, now), budget.PerOrganization})
+	}
+
+	for _, t := range takes {
+		if t.bucket.tokens < 1 {
+			perToken := rateWindow / time.Duration(t.budget)
+			return false, t.limit, time.Duration((1 - t.bucket.tokens) * float64(perToken))
+		}
+	}
+	for _, t := range takes {
+		t.bucket.tokens--
+	}
+	return true, "", 0
+}
+
+// bucket returns the bucket of key refilled up to now
+func (l *RateLimiter) bucket(key string, budget int, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(budget), updated: now}
+		l.buckets[key] = b
+		return b
+	}
+	elapsed := now.Sub(b.updated)
+	b.tokens = math.Min(float64(budget), b.tokens+float64(budget)*elapsed.Seconds()/rateWindow.Seconds())
+	b.updated = now
+	return b
+}
+
+// sweep drops buckets that have been idle for a full window and are
+// therefore full again, at most once per window
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateWindow {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= rateWindow {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimitMiddleware rejects requests over the budget of their route with
+// 429 and a Retry-After header. It keys on the authenticated principal, so it
+// must run after the auth middleware; requests without a principal pass.
+func RateLimitMiddleware(limiter *RateLimiter, metrics RateLimitMetricsRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.FromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+
+			allowed, limit, retryAfter := limiter.Allow(r.Method+" "+route, principal.UserID, principal.OrgID)
+			if allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if metrics != nil {
+				metrics.RecordThrottledRequest(r.Context(), r.Method, route, limit, principal.OrgSchemaName)
+			}
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			apierror.Respond(w, r, http.StatusTooManyRequests, "rate_limited",
+				fmt.Sprintf("Too many requests for this %s, retry in %d seconds", limit, seconds))
+		})
+	}
+}
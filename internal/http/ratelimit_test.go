@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/gorilla/mux"
+)
+
+type mockThrottleMetrics struct {
+	throttled []string
+}
+
+func (m *mockThrottleMetrics) RecordThrottledRequest(ctx context.Context, method, route, limit, tenant string) {
+	m.throttled = append(m.throttled, method+" "+route+" "+limit+" "+tenant)
+}
+
+// newTestLimiter returns a limiter on a clock that only moves when advanced
+func newTestLimiter(limits *RateLimits) (*RateLimiter, *time.Time) {
+	clock := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(limits)
+	limiter.now = func() time.Time { return clock }
+	return limiter, &clock
+}
+
+func TestRateLimiterUserBudget(t *testing.T) {
+	limiter, clock := newTestLimiter(&RateLimits{
+		Defaults: Budget{PerUser: 2, PerOrganization: 100},
+	})
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := limiter.Allow("GET /organization/patients", "user-1", "org-1"); !ok {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+
+	ok, limit, retryAfter := limiter.Allow("GET /organization/patients", "user-1", "org-1")
+	if ok || limit != LimitUser {
+		t.Fatalf("Expected user limit, got ok=%v limit=%q", ok, limit)
+	}
+	if retryAfter != 30*time.Second {
+		t.Errorf("Expected retry after 30s, got %s", retryAfter)
+	}
+
+	// Another user of the same organization has its own budget
+	if ok, _, _ := limiter.Allow("GET /organization/patients", "user-2", "org-1"); !ok {
+		t.Error("Expected other user to be allowed")
+	}
+
+	// Half a minute refills one token
+	*clock = clock.Add(30 * time.Second)
+	if ok, _, _ := limiter.Allow("GET /organization/patients", "user-1", "org-1"); !ok {
+		t.Error("Expected request to be allowed after refill")
+	}
+}
+
+func TestRateLimiterOrganizationBudget(t *testing.T) {
+	limiter, _ := newTestLimiter(&RateLimits{
+		Defaults: Budget{PerUser: 100, PerOrganization: 3},
+	})
+
+	for _, user := range []string{"user-1", "user-2", "user-3"} {
+		if ok, _, _ := limiter.Allow("GET /organization/patients", user, "org-1"); !ok {
+			t.Fatalf("Expected %s to be allowed", user)
+		}
+	}
+
+	if ok, limit, _ := limiter.Allow("GET /organization/patients", "user-4", "org-1"); ok || limit != LimitOrganization {
+		t.Errorf("Expected organization limit, got ok=%v limit=%q", ok, limit)
+	}
+	if ok, _, _ := limiter.Allow("GET /organization/patients", "user-5", "org-2"); !ok {
+		t.Error("Expected other organization to be allowed")
+	}
+}
+
+func TestRateLimiterRouteBudgets(t *testing.T) {
+	limiter, _ := newTestLimiter(&RateLimits{
+		Defaults: Budget{PerUser: 100},
+		Routes: map[string]Budget{
+			"POST /organization/patients": {PerUser: 1},
+			"GET /health":                 {},
+		},
+	})
+
+	limiter.Allow("POST /organization/patients", "user-1", "org-1")
+	if ok, _, _ := limiter.Allow("POST /organization/patients", "user-1", "org-1"); ok {
+		t.Error("Expected route budget to apply")
+	}
+	if ok, _, _ := limiter.Allow("GET /organization/patients", "user-1", "org-1"); !ok {
+		t.Error("Expected other routes to use the defaults")
+	}
+	for i := 0; i < 200; i++ {
+		if ok, _, _ := limiter.Allow("GET /health", "user-1", "org-1"); !ok {
+			t.Fatal("Expected zero budget to be unlimited")
+		}
+	}
+}
+
+func TestRateLimiterSweepsIdleBuckets(t *testing.T) {
+	limiter, clock := newTestLimiter(&RateLimits{Defaults: Budget{PerUser: 5}})
+
+	limiter.Allow("GET /organization/patients", "user-1", "")
+	*clock = clock.Add(2 * rateWindow)
+	limiter.Allow("GET /organization/patients", "user-2", "")
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected idle bucket to be dropped, got %d buckets", len(limiter.buckets))
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter, _ := newTestLimiter(&RateLimits{Defaults: Budget{PerUser: 1}})
+	metrics := &mockThrottleMetrics{}
+
+	router := mux.NewRouter()
+	router.Handle("/organization/patients/{id}", RateLimitMiddleware(limiter, metrics)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)).Methods("GET")
+
+	principal := &auth.Principal{UserID: "user-1", OrgID: "org-1", OrgSchemaName: "org_test_12345678"}
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("/organization/patients/p-1"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	// The budget is per route template, not per patient
+	rec := serve("/organization/patients/p-2")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", got)
+	}
+
+	want := "GET /organization/patients/{id} user org_test_12345678"
+	if len(metrics.throttled) != 1 || metrics.throttled[0] != want {
+		t.Errorf("Expected throttled metric %q, got %v", want, metrics.throttled)
+	}
+}
+
+func TestRateLimitMiddlewareWithoutPrincipal(t *testing.T) {
+	limiter, _ := newTestLimiter(&RateLimits{Defaults: Budget{PerUser: 1, PerOrganization: 1}})
+	handler := RateLimitMiddleware(limiter, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+	}
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	dir := t.TempDir()
+
+	limits, err := LoadRateLimits(filepath.Join(dir, "missing.yml"))
+	if err != nil || limits != nil {
+		t.Fatalf("Expected missing file to disable rate limiting, got %v, %v", limits, err)
+	}
+
+	valid := filepath.Join(dir, "ratelimits.yml")
+	os.WriteFile(valid, []byte(`
+defaults:
+  per_user: 10
+routes:
+  POST /organization/patients:
+    per_user: 2
+    per_organization: 5
+`), 0o644)
+	limits, err = LoadRateLimits(valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := limits.budget("POST /organization/patients"); got != (Budget{PerUser: 2, PerOrganization: 5}) {
+		t.Errorf("Unexpected route budget %+v", got)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yml")
+	os.WriteFile(invalid, []byte(`
+routes:
+  /organization/patients:
+    per_user: 2
+`), 0o644)
+	if _, err := LoadRateLimits(invalid); err == nil {
+		t.Error("Expected error for route without method")
+	}
+}
+
+func TestRepositoryRateLimitsFile(t *testing.T) {
+	limits, err := LoadRateLimits("../../ratelimits.yml")
+	if err != nil || limits == nil {
+		t.Fatalf("Expected ratelimits.yml to load, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/logging"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		reuse    bool
+	}{
+		{"generated when missing", "", false},
+		{"reused when valid", "abc-123", true},
+		{"replaced when too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"replaced when not printable", "abc\ndef", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = logging.RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/organization/patients", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if seen == "" {
+				t.Fatal("Expected a request ID in the context")
+			}
+			if got := rr.Header().Get(RequestIDHeader); got != seen {
+				t.Errorf("Expected response header %q, got %q", seen, got)
+			}
+			if tt.reuse && seen != tt.incoming {
+				t.Errorf("Expected incoming request ID %q to be reused, got %q", tt.incoming, seen)
+			}
+			if !tt.reuse && seen == tt.incoming {
+				t.Errorf("Expected request ID %q to be replaced", tt.incoming)
+			}
+		})
+	}
+}
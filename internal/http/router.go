@@ -3,11 +3,20 @@ package http
 // Development Team: Muhammad Faizan, Roozbeh Kouchaki, Fatemehalsadat Sabaghjafari, Dipika Bhandari
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/fhir"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/health"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/idempotency"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/organization"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/patient"
@@ -18,22 +27,39 @@ import (
 
 // SetupRouter initializes all routes for the application
 // Development Team: Muhammad Faizan, Roozbeh Kouchaki, Fatemehalsadat Sabaghjafari, Dipika Bhandari
-func SetupRouter(db *sql.DB, verifier *auth.Verifier, perms map[string][]string, publisher messaging.PublisherInterface, metrics *telemetry.Metrics) *mux.Router {
+func SetupRouter(db *sql.DB, verifier *auth.Verifier, perms auth.PermissionSource, publisher messaging.PublisherInterface, importJobs *jobs.Runner, metrics *telemetry.Metrics) *mux.Router {
 	// Initialize Keycloak admin client
 	keycloakAdmin, err := auth.NewKeycloakAdminClient()
 	if err != nil {
 		log.Fatalf("failed to initialize Keycloak admin client: %v", err)
 	}
 
-	return SetupRouterWithKeycloak(db, verifier, perms, publisher, keycloakAdmin, metrics)
+	return SetupRouterWithKeycloak(db, verifier, perms, publisher, keycloakAdmin, importJobs, metrics)
 }
 
 // SetupRouterWithKeycloak initializes all routes with a provided Keycloak client
 // This is useful for testing where you can pass a mock Keycloak client
-func SetupRouterWithKeycloak(db *sql.DB, verifier *auth.Verifier, perms map[string][]string, publisher messaging.PublisherInterface, keycloakAdmin interface{}, metrics *telemetry.Metrics) *mux.Router {
+// Import jobs run on importJobs, which may be nil when nothing drains them.
+func SetupRouterWithKeycloak(db *sql.DB, verifier *auth.Verifier, perms auth.PermissionSource, publisher messaging.PublisherInterface, keycloakAdmin interface{}, importJobs *jobs.Runner, metrics *telemetry.Metrics) *mux.Router {
+	// Optionally reject tokens of deleted and deactivated accounts. Statuses
+	// are cached briefly and dropped on this service's own delete and status
+	// events, which repositories publish through the cache and every replica
+	// receives from RabbitMQ.
+	events := publisher
+	var statuses *auth.StatusCache
+	if statusCheck := auth.LoadStatusCheckConfig(); statusCheck.Enabled && db != nil {
+		statuses = auth.NewStatusCache(auth.NewDBStatusLookup(db), statusCheck.CacheTTL)
+		events = statuses.Publisher(publisher)
+		if sub, ok := publisher.(messaging.SubscriberInterface); ok {
+			if err := statuses.Subscribe(sub); err != nil {
+				slog.Warn("status changes of other replicas apply after the cache TTL", "error", err)
+			}
+		}
+	}
+
 	// Initialize organization components
-	orgRepo := organization.NewRepository(db, publisher)
-	orgService := organization.NewService(orgRepo)
+	orgRepo := organization.NewRepository(db, events)
+	orgService := organization.NewServiceWithMetrics(orgRepo, metrics)
 	orgHandler := organization.NewHandler(orgService)
 
 	// Cast keycloakAdmin to the appropriate interface types
@@ -63,212 +89,459 @@ func SetupRouterWithKeycloak(db *sql.DB, verifier *auth.Verifier, perms map[stri
 		log.Printf("Warning: keycloakAdmin is nil - user and patient creation will fail")
 	}
 
+	// Bulk import jobs are shared by patient and staff imports
+	importJobRepo := jobs.NewRepository(db)
+
 	// Initialize patient components
-	patientRepo := patient.NewRepository(db, publisher)
-	patientService := patient.NewService(patientRepo, patientKeycloak)
+	patientRepo := patient.NewRepository(db, events)
+	patientService := patient.NewServiceWithMetrics(patientRepo, patientKeycloak, metrics)
 	patientSchemaLookup := patient.NewDBSchemaLookup(db)
+
+	// Optionally let SUPER_ADMINs act as tenant users, read-only by default
+	var impersonation auth.ImpersonationLookup
+	impersonationCfg := auth.LoadImpersonationConfig()
+	if impersonationCfg.Enabled && db != nil {
+		impersonation = auth.NewDBImpersonationLookup(db)
+	}
+
+	// Service accounts select their organization per request
+	authenticate := auth.MiddlewareWithOptions(verifier, auth.MiddlewareOptions{
+		Metrics:                  metrics,
+		ServiceMetrics:           metrics,
+		Statuses:                 statuses,
+		Tenants:                  patientSchemaLookup,
+		Impersonation:            impersonation,
+		ImpersonationAllowWrites: impersonationCfg.AllowWrites,
+	})
 	patientHandler := patient.NewHandler(patientService, patientSchemaLookup)
+	patientImporter := patient.NewImporterWithRunner(patientService, importJobRepo, importJobs)
+	patientImportHandler := patient.NewImportHandler(patientImporter, patientSchemaLookup)
 
 	// Initialize user components
-	userRepo := users.NewRepository(db, publisher)
-	userService := users.NewService(userRepo, userKeycloak)
+	userRepo := users.NewRepository(db, events)
+	userService := users.NewServiceWithMetrics(userRepo, userKeycloak, metrics)
 	userHandler := users.NewHandler(userService)
+	userImporter := users.NewImporterWithRunner(userService, importJobRepo, importJobs)
+	userImportHandler := users.NewImportHandler(userImporter, userService)
+
+	// Initialize FHIR views of patients, caregivers and organizations
+	fhirPatientHandler := fhir.NewPatientHandler(patientService, patientSchemaLookup)
+	fhirPractitionerHandler := fhir.NewPractitionerHandler(userService)
+	fhirOrganizationHandler := fhir.NewOrganizationHandler(orgService)
+
+	// Create endpoints replay the stored response of a retried Idempotency-Key
+	idempotent := idempotency.Middleware(idempotency.NewRepository(db), idempotency.LoadConfig())
+
+	// Per-route request budgets of users and organizations
+	rateLimits, err := LoadRateLimitsFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load rate limits: %v", err)
+	}
+	var limiter *RateLimiter
+	if rateLimits != nil {
+		limiter = NewRateLimiter(rateLimits)
+	} else {
+		slog.Warn("rate limits file not found, rate limiting is disabled", "env", "RATE_LIMITS_FILE")
+	}
+	limit := RateLimitMiddleware(limiter, metrics)
+
+	// Attribute-based rules over the caller and the resource a request targets
+	policies, err := auth.LoadPoliciesFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load policies: %v", err)
+	}
+	if policies == nil {
+		slog.Warn("policies file not found, only role permissions are checked", "env", "POLICIES_FILE")
+	}
+	policy := func(action string, resolve auth.ResourceResolver) func(http.Handler) http.Handler {
+		return auth.RequirePolicy(policies, action, resolve)
+	}
 
 	r := mux.NewRouter()
 
+	// Correlate log records of a request
+	r.Use(RequestIDMiddleware)
+
+	// Record HTTP metrics for every request, matched or not
+	if metrics != nil {
+		r.Use(MetricsMiddleware(metrics))
+		RecordUnmatched(r, metrics)
+	}
+
+	// Optionally require If-Match on every write to an existing resource
+	r.Use(etag.RequireIfMatch(etag.LoadConfig()))
+
 	// Public health endpoint
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"ok","service":"organization-service"}`))
 	}).Methods("GET")
 
+	// Kubernetes probes
+	checker := health.NewChecker("organization-service", healthChecks(db, verifier, publisher, keycloakAdmin)...)
+	r.HandleFunc("/livez", checker.Livez).Methods("GET")
+	r.HandleFunc("/readyz", checker.Readyz).Methods("GET")
+
+	// Effective permissions of the caller, for hiding unavailable actions
+	r.Handle("/auth/permissions/me",
+		authenticate(
+			limit(auth.MyPermissionsHandler(perms)),
+		),
+	).Methods("GET")
+
 	r.Handle("/organizations",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("organization:create", perms, metrics)(
-				http.HandlerFunc(orgHandler.CreateOrganization),
+				limit(idempotent(http.HandlerFunc(orgHandler.CreateOrganization))),
 			),
 		),
 	).Methods("POST")
 
 	r.Handle("/organizations",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("organization:view", perms, metrics)(
-				http.HandlerFunc(orgHandler.ListOrganizations),
+				limit(policy("organization:view", orgHandler.PolicyCollection)(http.HandlerFunc(orgHandler.ListOrganizations))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organizations/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("organization:view", perms, metrics)(
-				http.HandlerFunc(orgHandler.GetOrganization),
+				limit(policy("organization:view", orgHandler.PolicyResource)(http.HandlerFunc(orgHandler.GetOrganization))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organizations/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("organization:update", perms, metrics)(
-				http.HandlerFunc(orgHandler.UpdateOrganization),
+				limit(policy("organization:update", orgHandler.PolicyResource)(http.HandlerFunc(orgHandler.UpdateOrganization))),
 			),
 		),
 	).Methods("PUT", "PATCH")
 
 	r.Handle("/organizations/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("organization:delete", perms, metrics)(
-				http.HandlerFunc(orgHandler.DeleteOrganization),
+				limit(policy("organization:delete", orgHandler.PolicyResource)(http.HandlerFunc(orgHandler.DeleteOrganization))),
 			),
 		),
 	).Methods("DELETE")
 
 	r.Handle("/organization/patients",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:create", perms, metrics)(
-				http.HandlerFunc(patientHandler.CreatePatient),
+				limit(idempotent(http.HandlerFunc(patientHandler.CreatePatient))),
 			),
 		),
 	).Methods("POST")
 
 	r.Handle("/organization/patients",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
-				http.HandlerFunc(patientHandler.ListPatients),
+				limit(policy("patient:view", patientHandler.PolicyCollection)(http.HandlerFunc(patientHandler.ListPatients))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/patients/active",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
-				http.HandlerFunc(patientHandler.ListActivePatients),
+				limit(policy("patient:view", patientHandler.PolicyCollection)(http.HandlerFunc(patientHandler.ListActivePatients))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/organization/patients/search",
+		authenticate(
+			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
+				limit(policy("patient:view", patientHandler.PolicyCollection)(http.HandlerFunc(patientHandler.SearchPatients))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/organization/patients/imports",
+		authenticate(
+			auth.RequirePermissionWithMetrics("patient:create", perms, metrics)(
+				limit(idempotent(http.HandlerFunc(patientImportHandler.ImportPatients))),
+			),
+		),
+	).Methods("POST")
+
+	r.Handle("/organization/patients/imports/{id}",
+		authenticate(
+			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
+				limit(http.HandlerFunc(patientImportHandler.GetImportJob)),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/patients/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
-				http.HandlerFunc(patientHandler.GetPatient),
+				limit(policy("patient:view", patientHandler.PolicyResource)(http.HandlerFunc(patientHandler.GetPatient))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/patients/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:update", perms, metrics)(
-				http.HandlerFunc(patientHandler.UpdatePatient),
+				limit(policy("patient:update", patientHandler.PolicyResource)(http.HandlerFunc(patientHandler.UpdatePatient))),
 			),
 		),
 	).Methods("PUT", "PATCH")
 
 	r.Handle("/organization/patients/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:delete", perms, metrics)(
-				http.HandlerFunc(patientHandler.DeletePatient),
+				limit(policy("patient:delete", patientHandler.PolicyResource)(http.HandlerFunc(patientHandler.DeletePatient))),
 			),
 		),
 	).Methods("DELETE")
 
 	r.Handle("/organization/users",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:create", perms, metrics)(
-				http.HandlerFunc(userHandler.CreateUser),
+				limit(idempotent(http.HandlerFunc(userHandler.CreateUser))),
 			),
 		),
 	).Methods("POST")
 
 	r.Handle("/organization/users",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
-				http.HandlerFunc(userHandler.ListUsers),
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(userHandler.ListUsers))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/caregivers/active",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
-				http.HandlerFunc(userHandler.ListActiveCaregivers),
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(userHandler.ListActiveCaregivers))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/municipality/active",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
-				http.HandlerFunc(userHandler.ListActiveMunicipality),
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(userHandler.ListActiveMunicipality))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/insurers/active",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
-				http.HandlerFunc(userHandler.ListActiveInsurers),
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(userHandler.ListActiveInsurers))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/org-admins/active",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
-				http.HandlerFunc(userHandler.ListActiveOrgAdmins),
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(userHandler.ListActiveOrgAdmins))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/organization/users/export",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:export", perms, metrics)(
+				limit(http.HandlerFunc(userHandler.ExportUsers)),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/organization/users/imports",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:create", perms, metrics)(
+				limit(idempotent(http.HandlerFunc(userImportHandler.ImportUsers))),
+			),
+		),
+	).Methods("POST")
+
+	r.Handle("/organization/users/imports/{id}",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
+				limit(http.HandlerFunc(userImportHandler.GetImportJob)),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/me",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
-			http.HandlerFunc(userHandler.GetMyProfile),
+		authenticate(
+			limit(http.HandlerFunc(userHandler.GetMyProfile)),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/me",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
-			http.HandlerFunc(userHandler.UpdateMyProfile),
+		authenticate(
+			limit(http.HandlerFunc(userHandler.UpdateMyProfile)),
 		),
 	).Methods("PATCH")
 
 	r.Handle("/organization/patients/me",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
-				http.HandlerFunc(patientHandler.GetMyPatient),
+				limit(http.HandlerFunc(patientHandler.GetMyPatient)),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
-				http.HandlerFunc(userHandler.GetUser),
+				limit(policy("user:view", userHandler.PolicyResource)(http.HandlerFunc(userHandler.GetUser))),
 			),
 		),
 	).Methods("GET")
 
 	r.Handle("/organization/users/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:update", perms, metrics)(
-				http.HandlerFunc(userHandler.UpdateUser),
+				limit(policy("user:update", userHandler.PolicyResource)(http.HandlerFunc(userHandler.UpdateUser))),
 			),
 		),
 	).Methods("PATCH")
 
 	r.Handle("/organization/users/{id}/reset-password",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:update", perms, metrics)(
-				http.HandlerFunc(userHandler.ResetPassword),
+				limit(policy("user:update", userHandler.PolicyResource)(http.HandlerFunc(userHandler.ResetPassword))),
 			),
 		),
 	).Methods("POST")
 
 	r.Handle("/organization/users/{id}",
-		auth.MiddlewareWithMetrics(verifier, metrics)(
+		authenticate(
 			auth.RequirePermissionWithMetrics("user:delete", perms, metrics)(
-				http.HandlerFunc(userHandler.DeleteUser),
+				limit(policy("user:delete", userHandler.PolicyResource)(http.HandlerFunc(userHandler.DeleteUser))),
 			),
 		),
 	).Methods("DELETE")
 
+	// FHIR R4 read and search endpoints
+	r.Handle("/fhir/Patient",
+		authenticate(
+			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
+				limit(policy("patient:view", patientHandler.PolicyCollection)(http.HandlerFunc(fhirPatientHandler.SearchPatients))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/Patient/{id}",
+		authenticate(
+			auth.RequirePermissionWithMetrics("patient:view", perms, metrics)(
+				limit(policy("patient:view", patientHandler.PolicyResource)(http.HandlerFunc(fhirPatientHandler.ReadPatient))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/Practitioner",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(fhirPractitionerHandler.SearchPractitioners))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/Practitioner/{id}",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
+				limit(policy("user:view", userHandler.PolicyResource)(http.HandlerFunc(fhirPractitionerHandler.ReadPractitioner))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/PractitionerRole",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
+				limit(policy("user:view", userHandler.PolicyCollection)(http.HandlerFunc(fhirPractitionerHandler.SearchPractitionerRoles))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/PractitionerRole/{id}",
+		authenticate(
+			auth.RequirePermissionWithMetrics("user:view", perms, metrics)(
+				limit(policy("user:view", userHandler.PolicyResource)(http.HandlerFunc(fhirPractitionerHandler.ReadPractitionerRole))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/Organization",
+		authenticate(
+			auth.RequirePermissionWithMetrics("organization:view", perms, metrics)(
+				limit(policy("organization:view", orgHandler.PolicyCollection)(http.HandlerFunc(fhirOrganizationHandler.SearchOrganizations))),
+			),
+		),
+	).Methods("GET")
+
+	r.Handle("/fhir/Organization/{id}",
+		authenticate(
+			auth.RequirePermissionWithMetrics("organization:view", perms, metrics)(
+				limit(policy("organization:view", orgHandler.PolicyResource)(http.HandlerFunc(fhirOrganizationHandler.ReadOrganization))),
+			),
+		),
+	).Methods("GET")
+
 	return r
 }
+
+// healthReporter is implemented by dependencies that can check themselves
+type healthReporter interface {
+	Healthy(ctx context.Context) error
+}
+
+// healthChecks returns the readiness checks of the dependencies that are
+// configured. RabbitMQ is optional: without it events are skipped but the API
+// keeps working. Keycloak is optional too, since a cached admin token passes
+// the check and only user management depends on it.
+func healthChecks(db *sql.DB, verifier *auth.Verifier, publisher messaging.PublisherInterface, keycloakAdmin interface{}) []health.Check {
+	var checks []health.Check
+
+	if db != nil {
+		checks = append(checks, health.Check{
+			Name:    "database",
+			Timeout: 2 * time.Second,
+			Func:    db.PingContext,
+		})
+	}
+
+	rabbitmq := health.Check{
+		Name:     "rabbitmq",
+		Timeout:  time.Second,
+		Optional: true,
+		Func: func(ctx context.Context) error {
+			return errors.New("not connected")
+		},
+	}
+	if reporter, ok := publisher.(healthReporter); ok {
+		rabbitmq.Func = reporter.Healthy
+	}
+	checks = append(checks, rabbitmq)
+
+	if reporter, ok := keycloakAdmin.(healthReporter); ok {
+		checks = append(checks, health.Check{
+			Name:     "keycloak",
+			Timeout:  3 * time.Second,
+			Optional: true,
+			Func:     reporter.Healthy,
+		})
+	}
+
+	if verifier != nil {
+		checks = append(checks, health.Check{
+			Name:    "jwks",
+			Timeout: time.Second,
+			Func:    verifier.Healthy,
+		})
+	}
+
+	return checks
+}
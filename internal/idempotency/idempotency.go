@@ -0,0 +1,265 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+)
+
+const (
+	// Header is the request header carrying the client chosen key
+	Header = "Idempotency-Key"
+	// ReplayedHeader marks a response that repeats the stored original
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength bounds the length of a key
+	MaxKeyLength = 255
+	// MaxBodyBytes bounds the request body that is read to fingerprint it
+	MaxBodyBytes = 8 << 20 // 8 MB
+)
+
+// replayHeaders are the response headers stored and repeated on a replay
+var replayHeaders = []string{"Content-Type", "ETag", "Location"}
+
+var (
+	// ErrInvalidKey is returned for an empty, too long or non-printable key
+	ErrInvalidKey = apierror.BadRequest("invalid_idempotency_key", "Idempotency-Key must be 1 to 255 printable characters")
+	// ErrKeyReused is returned when a key is sent again with a different
+	// request
+	ErrKeyReused = apierror.New(http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	// ErrInProgress is returned while the original request of a key is still
+	// being processed
+	ErrInProgress = apierror.Conflict("idempotency_request_in_progress", "a request with this Idempotency-Key is still being processed, retry later")
+	// ErrBodyTooLarge is returned when the request body cannot be
+	// fingerprinted
+	ErrBodyTooLarge = apierror.New(http.StatusRequestEntityTooLarge, "request_too_large", "request body is too large")
+)
+
+// Config holds idempotency configuration
+type Config struct {
+	TTL time.Duration // How long a key and its response are kept
+}
+
+// LoadConfig loads idempotency configuration from environment variables
+func LoadConfig() Config {
+	// Get key lifetime with default
+	ttl := 24 * time.Hour
+	if ttlStr := os.Getenv("IDEMPOTENCY_KEY_TTL"); ttlStr != "" {
+		if duration, err := time.ParseDuration(ttlStr); err == nil && duration > 0 {
+			ttl = duration
+		}
+	}
+
+	return Config{
+		TTL: ttl,
+	}
+}
+
+// Response is a stored response
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"-"`
+}
+
+// Record is the state of a key held by an earlier request
+type Record struct {
+	Fingerprint string
+	Completed   bool // False while the original request is running
+	Response    Response
+}
+
+// InProgressLease is how long a key blocks retries while its request has not
+// completed. Requests finish well within it, so a key still in progress after
+// the lease belonged to a process that died before releasing it.
+const InProgressLease = 2 * time.Minute
+
+// Store persists idempotency keys per scope
+type Store interface {
+	// Reserve claims the key and returns nil, or returns the record of the
+	// earlier request holding it
+	Reserve(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*Record, error)
+	Complete(ctx context.Context, scope, key string, response Response) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// Middleware makes create requests with an Idempotency-Key header safe to
+// retry. The first request with a key runs and its response is stored for
+// cfg.TTL; a retry with the same key and body gets the stored response
+// instead of creating the resource again. Requests without the header are
+// passed through. Keys are scoped to the tenant and the caller, so it must run after the
+// auth middleware.
+func Middleware(store Store, cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, present := r.Header[http.CanonicalHeaderKey(Header)]
+			if !present {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) != 1 || !validKey(key[0]) {
+				apierror.Write(w, r, ErrInvalidKey, "")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes+1))
+			if err != nil {
+				apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Failed to read request body")
+				return
+			}
+			if len(body) > MaxBodyBytes {
+				apierror.Write(w, r, ErrBodyTooLarge, "")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			scope := Scope(r)
+			fingerprint := Fingerprint(r, body)
+
+			record, err := store.Reserve(ctx, scope, key[0], fingerprint, cfg.TTL)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to reserve idempotency key", "error", err)
+				apierror.Write(w, r, err, "Failed to process Idempotency-Key")
+				return
+			}
+			if record != nil {
+				replay(w, r, record, fingerprint)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// Server errors and panics leave nothing to replay; free the key
+				// so the client can retry
+				if !completed {
+					if err := store.Release(context.WithoutCancel(ctx), scope, key[0]); err != nil {
+						slog.ErrorContext(ctx, "failed to release idempotency key", "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			response := Response{
+				Status: recorder.status,
+				Header: map[string]string{},
+				Body:   recorder.body.Bytes(),
+			}
+			for _, name := range replayHeaders {
+				if value := w.Header().Get(name); value != "" {
+					response.Header[name] = value
+				}
+			}
+			if err := store.Complete(context.WithoutCancel(ctx), scope, key[0], response); err != nil {
+				slog.ErrorContext(ctx, "failed to store idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replay answers a retry from the record of the original request
+func replay(w http.ResponseWriter, r *http.Request, record *Record, fingerprint string) {
+	switch {
+	case record.Fingerprint != "" && record.Fingerprint != fingerprint:
+		apierror.Write(w, r, ErrKeyReused, "")
+	case !record.Completed:
+		w.Header().Set("Retry-After", "1")
+		apierror.Write(w, r, ErrInProgress, "")
+	default:
+		for name, value := range record.Response.Header {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(record.Response.Status)
+		w.Write(record.Response.Body)
+	}
+}
+
+// Scope returns the tenant a request creates a resource in and the caller
+// that sent it, so one caller's key never replays another caller's response.
+// The tenant is the organization of the token, or the X-Organization-ID
+// header of a SUPER_ADMIN, and empty for platform level requests such as
+// creating an organization. The caller is the user who authenticated the
+// request, or the client of a service account without a user ID.
+func Scope(r *http.Request) string {
+	tenant := r.Header.Get(auth.OrganizationHeader)
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return tenant + "/"
+	}
+	if principal.OrgID != "" {
+		tenant = principal.OrgID
+	}
+
+	caller := principal.Real()
+	callerID := caller.UserID
+	if callerID == "" {
+		callerID = caller.ClientID
+	}
+	return tenant + "/" + callerID
+}
+
+// Fingerprint identifies a request by method, path and body, so a key cannot
+// be reused for another endpoint or payload
+func Fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// validKey reports whether key is 1 to MaxKeyLength printable ASCII characters
+func validKey(key string) bool {
+	if key == "" || len(key) > MaxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder passes the response through and keeps a copy of status
+// and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
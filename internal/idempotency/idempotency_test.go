@@ -0,0 +1,299 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+)
+
+// memoryStore is an in-memory Store
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	err     error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]*Record{}}
+}
+
+func (m *memoryStore) Reserve(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	if record, ok := m.records[scope+"/"+key]; ok {
+		copied := *record
+		return &copied, nil
+	}
+	m.records[scope+"/"+key] = &Record{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (m *memoryStore) Complete(ctx context.Context, scope, key string, response Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.records[scope+"/"+key]
+	record.Completed = true
+	record.Response = response
+	return nil
+}
+
+func (m *memoryStore) Release(ctx context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, scope+"/"+key)
+	return nil
+}
+
+// createHandler counts calls and answers like a create endpoint
+func createHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":"patient-1"}`))
+	})
+}
+
+func newCreateRequest(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/organization/patients", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	principal := &auth.Principal{UserID: "admin-1", Roles: []string{"ORG_ADMIN"}, OrgID: "org-1"}
+	return req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+}
+
+func TestMiddlewareReplaysRetry(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, newCreateRequest("key-1", `{"firstName":"Jan"}`))
+
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, newCreateRequest("key-1", `{"firstName":"Jan"}`))
+
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls)
+	}
+	if retry.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected body %s, got %s", first.Body.String(), retry.Body.String())
+	}
+	if retry.Header().Get("ETag") != `"1"` || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected stored headers, got %v", retry.Header())
+	}
+	if retry.Header().Get(ReplayedHeader) != "true" {
+		t.Error("Expected replayed response to be marked")
+	}
+	if first.Header().Get(ReplayedHeader) != "" {
+		t.Error("Expected original response not to be marked")
+	}
+}
+
+func TestMiddlewareRejectsDifferentBody(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("key-1", `{"firstName":"Jan"}`))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newCreateRequest("key-1", `{"firstName":"Piet"}`))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", rec.Code)
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareScopesKeysPerTenant(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("key-1", `{}`))
+
+	req := httptest.NewRequest(http.MethodPost, "/organization/patients", strings.NewReader(`{}`))
+	req.Header.Set(Header, "key-1")
+	principal := &auth.Principal{UserID: "admin-2", Roles: []string{"ORG_ADMIN"}, OrgID: "org-2"}
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if calls != 2 {
+		t.Errorf("Expected handler to run for each tenant, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareScopesKeysPerCaller(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("key-1", `{}`))
+
+	// Another admin of the same organization reusing the key gets their own request
+	req := httptest.NewRequest(http.MethodPost, "/organization/patients", strings.NewReader(`{}`))
+	req.Header.Set(Header, "key-1")
+	principal := &auth.Principal{UserID: "admin-2", Roles: []string{"ORG_ADMIN"}, OrgID: "org-1"}
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if calls != 2 {
+		t.Errorf("Expected handler to run for each caller, ran %d times", calls)
+	}
+	if rec.Header().Get(ReplayedHeader) != "" {
+		t.Error("Expected another caller's response not to be replayed")
+	}
+}
+
+func TestScope(t *testing.T) {
+	testCases := []struct {
+		name      string
+		principal *auth.Principal
+		header    string
+		expected  string
+	}{
+		{"Organization user", &auth.Principal{UserID: "user-1", OrgID: "org-1"}, "", "org-1/user-1"},
+		{"SUPER_ADMIN selecting an organization", &auth.Principal{UserID: "admin-1", Roles: []string{"SUPER_ADMIN"}}, "org-2", "org-2/admin-1"},
+		{"Platform level request", &auth.Principal{UserID: "admin-1", Roles: []string{"SUPER_ADMIN"}}, "", "/admin-1"},
+		{"Service account without user ID", &auth.Principal{Type: auth.PrincipalTypeService, ClientID: "reporting", OrgID: "org-1"}, "org-1", "org-1/reporting"},
+		{"Impersonated user", &auth.Principal{UserID: "user-1", OrgID: "org-1", Impersonator: &auth.Principal{UserID: "admin-1"}}, "org-1", "org-1/admin-1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/organization/patients", nil)
+			if tc.header != "" {
+				req.Header.Set(auth.OrganizationHeader, tc.header)
+			}
+			req = req.WithContext(auth.ContextWithPrincipal(req.Context(), tc.principal))
+			if got := Scope(req); got != tc.expected {
+				t.Errorf("Expected scope %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	store := newMemoryStore()
+	store.records["org-1/admin-1/key-1"] = &Record{Fingerprint: Fingerprint(newCreateRequest("", ""), []byte(`{}`))}
+
+	calls := 0
+	handler := Middleware(store, Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newCreateRequest("key-1", `{}`))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header")
+	}
+	if calls != 0 {
+		t.Errorf("Expected handler not to run, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnServerError(t *testing.T) {
+	store := newMemoryStore()
+	calls := 0
+	handler := Middleware(store, Config{TTL: time.Hour})(createHandler(&calls, http.StatusInternalServerError))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("key-1", `{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("key-1", `{}`))
+
+	if calls != 2 {
+		t.Errorf("Expected failed request to be retried, ran %d times", calls)
+	}
+	if len(store.records) != 0 {
+		t.Errorf("Expected key to be released, got %v", store.records)
+	}
+}
+
+func TestMiddlewareStoresClientErrors(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusBadRequest))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("key-1", `{}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newCreateRequest("key-1", `{}`))
+
+	if calls != 1 || rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected stored 400 to be replayed, got %d after %d calls", rec.Code, calls)
+	}
+}
+
+func TestMiddlewareWithoutKey(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("", `{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("", `{}`))
+
+	if calls != 2 {
+		t.Errorf("Expected every request without key to run, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareInvalidKey(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemoryStore(), Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	for _, key := range []string{"", "has space", strings.Repeat("a", MaxKeyLength+1)} {
+		req := newCreateRequest("", `{}`)
+		req.Header[Header] = []string{key}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Key %q: expected status 400, got %d", key, rec.Code)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Expected handler not to run, ran %d times", calls)
+	}
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	store := newMemoryStore()
+	store.err = errors.New("connection refused")
+	calls := 0
+	handler := Middleware(store, Config{TTL: time.Hour})(createHandler(&calls, http.StatusCreated))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newCreateRequest("key-1", `{}`))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	if calls != 0 {
+		t.Errorf("Expected handler not to run, ran %d times", calls)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("IDEMPOTENCY_KEY_TTL", "2h")
+	if got := LoadConfig().TTL; got != 2*time.Hour {
+		t.Errorf("Expected TTL 2h, got %s", got)
+	}
+
+	t.Setenv("IDEMPOTENCY_KEY_TTL", "invalid")
+	if got := LoadConfig().TTL; got != 24*time.Hour {
+		t.Errorf("Expected default TTL 24h, got %s", got)
+	}
+}
@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ensure Repository implements Store
+var _ Store = (*Repository)(nil)
+
+// Repository stores idempotency keys in wailsalutem.idempotency_keys
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new idempotency key repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Reserve claims scope and key for a new request. An expired entry, or one
+// still in progress after InProgressLease, is taken over as if it did not
+// exist. When the key is held by an earlier request, the stored record is
+// returned instead.
+func (r *Repository) Reserve(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	query := `
+		INSERT INTO wailsalutem.idempotency_keys (scope, idempotency_key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			response_headers = NULL,
+			response_body = NULL,
+			created_at = now(),
+			expires_at = EXCLUDED.expires_at
+		WHERE wailsalutem.idempotency_keys.expires_at < now()
+			OR (wailsalutem.idempotency_keys.status_code IS NULL
+				AND wailsalutem.idempotency_keys.created_at < now() - make_interval(secs => $5))
+		RETURNING idempotency_key
+	`
+
+	var reserved string
+	err := r.db.QueryRowContext(ctx, query, scope, key, fingerprint, ttl.Seconds(), InProgressLease.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	return r.get(ctx, scope, key)
+}
+
+// get loads the record held by an unexpired key
+func (r *Repository) get(ctx context.Context, scope, key string) (*Record, error) {
+	query := `
+		SELECT fingerprint, status_code, response_headers, response_body
+		FROM wailsalutem.idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2
+	`
+
+	var record Record
+	var statusCode sql.NullInt64
+	var headers []byte
+	err := r.db.QueryRowContext(ctx, query, scope, key).Scan(
+		&record.Fingerprint,
+		&statusCode,
+		&headers,
+		&record.Response.Body,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released by the request holding it between our insert and select;
+		// report it as still running so the client retries
+		return &Record{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if statusCode.Valid {
+		record.Completed = true
+		record.Response.Status = int(statusCode.Int64)
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &record.Response.Header); err != nil {
+				return nil, fmt.Errorf("failed to decode idempotency response headers: %w", err)
+			}
+		}
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response of the request holding scope and key
+func (r *Repository) Complete(ctx context.Context, scope, key string, response Response) error {
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency response headers: %w", err)
+	}
+
+	query := `
+		UPDATE wailsalutem.idempotency_keys
+		SET status_code = $3, response_headers = $4, response_body = $5
+		WHERE scope = $1 AND idempotency_key = $2
+	`
+
+	_, err = r.db.ExecContext(ctx, query, scope, key, response.Status, headers, response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes the reservation of a request that did not complete, so the
+// client can retry with the same key
+func (r *Repository) Release(ctx context.Context, scope, key string) error {
+	query := `
+		DELETE FROM wailsalutem.idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2 AND status_code IS NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes all expired keys and returns how many were removed
+func (r *Repository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM wailsalutem.idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,52 @@
+//go:build integration
+
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
+)
+
+// TestRepositoryReserve_Integration tests that keys of requests that died
+// before releasing them are taken over after the in-progress lease
+func TestRepositoryReserve_Integration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := NewRepository(db)
+	scope, key := "test-scope", "key-"+time.Now().Format(time.RFC3339Nano)
+	defer db.Exec(`DELETE FROM wailsalutem.idempotency_keys WHERE scope = $1`, scope)
+
+	if record, err := repo.Reserve(ctx, scope, key, "fp", time.Hour); err != nil || record != nil {
+		t.Fatalf("Expected key to be reserved, got %+v, %v", record, err)
+	}
+	record, err := repo.Reserve(ctx, scope, key, "fp", time.Hour)
+	if err != nil || record == nil || record.Completed {
+		t.Fatalf("Expected key in progress, got %+v, %v", record, err)
+	}
+
+	// The request holding the key died without releasing it
+	_, err = db.Exec(`
+		UPDATE wailsalutem.idempotency_keys SET created_at = now() - make_interval(secs => $3)
+		WHERE scope = $1 AND idempotency_key = $2
+	`, scope, key, (InProgressLease + time.Second).Seconds())
+	if err != nil {
+		t.Fatalf("Failed to age key: %v", err)
+	}
+	if record, err := repo.Reserve(ctx, scope, key, "fp", time.Hour); err != nil || record != nil {
+		t.Errorf("Expected abandoned key to be taken over, got %+v, %v", record, err)
+	}
+
+	// Completed keys are kept until they expire
+	if err := repo.Complete(ctx, scope, key, Response{Status: 201}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	db.Exec(`UPDATE wailsalutem.idempotency_keys SET created_at = now() - interval '1 hour' WHERE scope = $1`, scope)
+	if record, err := repo.Reserve(ctx, scope, key, "fp", time.Hour); err != nil || record == nil || !record.Completed {
+		t.Errorf("Expected completed key to be kept, got %+v, %v", record, err)
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Job kinds
+const (
+	KindPatientImport = "patient_import"
+	KindUserImport    = "user_import"
+)
+
+// Row result statuses
+const (
+	RowCreated = "created"
+	RowSkipped = "skipped"
+	RowFailed  = "failed"
+)
+
+var ErrJobNotFound = apierror.NotFound(apierror.CodeNotFound, "import job not found")
+
+// RowResult records the outcome of a single imported row
+type RowResult struct {
+	Row        int    `json:"row"`
+	Status     string `json:"status"`
+	ResourceID string `json:"resource_id,omitempty"`
+	Code       string `json:"code,omitempty"` // API error code of a failed row
+	Error      string `json:"error,omitempty"`
+}
+
+// Job represents an asynchronous bulk import and its progress
+type Job struct {
+	ID             string      `json:"id"`
+	OrganizationID string      `json:"organization_id"`
+	Kind           string      `json:"kind"`
+	Status         string      `json:"status"`
+	TotalRows      int         `json:"total_rows"`
+	ProcessedRows  int         `json:"processed_rows"`
+	SucceededRows  int         `json:"succeeded_rows"`
+	FailedRows     int         `json:"failed_rows"`
+	SkippedRows    int         `json:"skipped_rows"`
+	Results        []RowResult `json:"results"`
+	Error          string      `json:"error,omitempty"`
+	CreatedBy      string      `json:"created_by,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	StartedAt      *time.Time  `json:"started_at,omitempty"`
+	FinishedAt     *time.Time  `json:"finished_at,omitempty"`
+}
+
+// RowError lists every validation problem found in one input row
+type RowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ValidationError reports rejected import rows as a problem with one entry
+// per row and message
+func ValidationError(detail string, rowErrors []RowError) *apierror.Error {
+	var fieldErrors []apierror.FieldError
+	for _, rowError := range rowErrors {
+		for _, message := range rowError.Errors {
+			fieldErrors = append(fieldErrors, apierror.FieldError{Row: rowError.Row, Message: message})
+		}
+	}
+	return apierror.Validation(detail, fieldErrors...)
+}
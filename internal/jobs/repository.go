@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepositoryInterface defines the contract for import job persistence
+type RepositoryInterface interface {
+	Create(ctx context.Context, job *Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	Update(ctx context.Context, job *Job) error
+}
+
+// Ensure Repository implements RepositoryInterface
+var _ RepositoryInterface = (*Repository)(nil)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, job *Job) error {
+	job.ID = uuid.New().String()
+	job.Status = StatusQueued
+	job.CreatedAt = time.Now()
+	if job.Results == nil {
+		job.Results = []RowResult{}
+	}
+
+	query := `
+		INSERT INTO wailsalutem.import_jobs
+		(id, organization_id, kind, status, total_rows, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		job.ID,
+		job.OrganizationID,
+		job.Kind,
+		job.Status,
+		job.TotalRows,
+		job.CreatedBy,
+		job.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) Get(ctx context.Context, id string) (*Job, error) {
+	query := `
+		SELECT id, organization_id, kind, status, total_rows, processed_rows, succeeded_rows, failed_rows,
+			   skipped_rows, results, error, created_by, created_at, started_at, finished_at
+		FROM wailsalutem.import_jobs
+		WHERE id = $1
+	`
+
+	var job Job
+	var results []byte
+	var jobError sql.NullString
+	var createdBy sql.NullString
+	var startedAt sql.NullTime
+	var finishedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&job.ID,
+		&job.OrganizationID,
+		&job.Kind,
+		&job.Status,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.SucceededRows,
+		&job.FailedRows,
+		&job.SkippedRows,
+		&results,
+		&jobError,
+		&createdBy,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	if err := json.Unmarshal(results, &job.Results); err != nil {
+		return nil, fmt.Errorf("failed to decode import job results: %w", err)
+	}
+	if jobError.Valid {
+		job.Error = jobError.String
+	}
+	if createdBy.Valid {
+		job.CreatedBy = createdBy.String
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
+
+func (r *Repository) Update(ctx context.Context, job *Job) error {
+	results, err := json.Marshal(job.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode import job results: %w", err)
+	}
+
+	query := `
+		UPDATE wailsalutem.import_jobs
+		SET status = $1, processed_rows = $2, succeeded_rows = $3, failed_rows = $4, skipped_rows = $5,
+			results = $6, error = NULLIF($7, ''), started_at = $8, finished_at = $9, updated_at = now()
+		WHERE id = $10
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		job.Status,
+		job.ProcessedRows,
+		job.SucceededRows,
+		job.FailedRows,
+		job.SkippedRows,
+		results,
+		job.Error,
+		job.StartedAt,
+		job.FinishedAt,
+		job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// FailStale marks queued and running jobs that have not saved progress for
+// staleAfter as failed, and returns how many it marked. Such jobs belonged to
+// a replica that stopped without finishing them.
+func (r *Repository) FailStale(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	query := `
+		UPDATE wailsalutem.import_jobs
+		SET status = $1, error = $2, finished_at = now(), updated_at = now()
+		WHERE status IN ($3, $4) AND updated_at < now() - make_interval(secs => $5)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, StatusFailed, ErrorInterrupted, StatusQueued, StatusRunning, staleAfter.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale import jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetForOrganization returns a job of the given kind that belongs to orgID.
+// Malformed IDs and jobs of other organizations are reported as ErrJobNotFound.
+func GetForOrganization(ctx context.Context, repo RepositoryInterface, orgID, kind, id string) (*Job, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	job, err := repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.OrganizationID != orgID || job.Kind != kind {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
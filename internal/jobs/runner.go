@@ -0,0 +1,188 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// progressInterval is the number of processed rows between progress writes
+const progressInterval = 10
+
+// StaleAfter is how long a queued or running job may go without saving
+// progress before it is considered abandoned
+const StaleAfter = 10 * time.Minute
+
+// ErrorInterrupted is the error of jobs that stopped before finishing
+const ErrorInterrupted = "import interrupted"
+
+// RowFunc processes the input row at the given index and reports its outcome
+type RowFunc func(ctx context.Context, index int) RowResult
+
+// FailedRow reports a row that could not be imported. Typed errors keep their
+// code and detail; any other error is logged and reported with fallback, so
+// internal error text never reaches the client.
+func FailedRow(ctx context.Context, row int, err error, fallback string) RowResult {
+	var typed *apierror.Error
+	if errors.As(err, &typed) {
+		return RowResult{Row: row, Status: RowFailed, Code: typed.Code, Error: typed.Detail}
+	}
+	slog.ErrorContext(ctx, "failed to import row", "row", row, "error", err)
+	return RowResult{Row: row, Status: RowFailed, Code: apierror.CodeInternal, Error: fallback}
+}
+
+// Run processes every row of a queued job, persisting progress as it goes.
+// It blocks until the job finishes and is meant to be started in its own goroutine.
+func Run(ctx context.Context, repo RepositoryInterface, job *Job, process RowFunc) {
+	startedAt := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &startedAt
+	save(ctx, repo, job)
+
+	for i := 0; i < job.TotalRows; i++ {
+		if err := ctx.Err(); err != nil {
+			job.Status = StatusFailed
+			job.Error = ErrorInterrupted + ": " + err.Error()
+			break
+		}
+
+		result := process(ctx, i)
+		job.Results = append(job.Results, result)
+		job.ProcessedRows++
+		switch result.Status {
+		case RowFailed:
+			job.FailedRows++
+		case RowSkipped:
+			job.SkippedRows++
+		default:
+			job.SucceededRows++
+		}
+
+		if job.ProcessedRows%progressInterval == 0 {
+			save(ctx, repo, job)
+		}
+	}
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	if job.Status == StatusRunning {
+		job.Status = StatusCompleted
+	}
+
+	// Use a fresh context so the final state is recorded even if ctx was cancelled
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	save(saveCtx, repo, job)
+
+	slog.InfoContext(ctx, "import job finished",
+		"job_id", job.ID,
+		"status", job.Status,
+		"processed", job.ProcessedRows,
+		"succeeded", job.SucceededRows,
+		"skipped", job.SkippedRows,
+		"failed", job.FailedRows,
+	)
+}
+
+func save(ctx context.Context, repo RepositoryInterface, job *Job) {
+	if err := repo.Update(ctx, job); err != nil {
+		slog.WarnContext(ctx, "failed to persist import job progress", "job_id", job.ID, "error", err)
+	}
+}
+
+// StaleJobFailer fails jobs abandoned by stopped replicas
+type StaleJobFailer interface {
+	FailStale(ctx context.Context, staleAfter time.Duration) (int64, error)
+}
+
+// Runner runs jobs in the background and drains them on shutdown
+type Runner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	quit     chan struct{}
+	quitOnce sync.Once
+	watcher  sync.WaitGroup
+}
+
+// NewRunner creates a new Runner
+func NewRunner() *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{ctx: ctx, cancel: cancel, quit: make(chan struct{})}
+}
+
+// Go runs the job with Run in its own goroutine. The request context ends
+// with the response, so jobs keep its values, such as the request ID logged
+// with every record, but are cancelled by the runner instead.
+func (r *Runner) Go(ctx context.Context, repo RepositoryInterface, job *Job, process RowFunc) {
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(r.ctx, cancel)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		defer stop()
+		Run(jobCtx, repo, job, process)
+	}()
+}
+
+// WatchStale fails jobs abandoned by stopped replicas now and then
+// periodically until Shutdown
+func (r *Runner) WatchStale(repo StaleJobFailer) {
+	failStale := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		n, err := repo.FailStale(ctx, StaleAfter)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to fail stale import jobs", "error", err)
+			return
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "marked abandoned import jobs as failed", "jobs", n)
+		}
+	}
+
+	failStale()
+	r.watcher.Add(1)
+	go func() {
+		defer r.watcher.Done()
+		ticker := time.NewTicker(StaleAfter / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				failStale()
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown waits for running jobs until ctx is done, then cancels them and
+// waits until they have recorded that they were interrupted
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.quitOnce.Do(func() { close(r.quit) })
+	r.watcher.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+	r.cancel()
+	<-done
+	return ctx.Err()
+}
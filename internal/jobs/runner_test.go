@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// memoryRepository is an in-memory RepositoryInterface for testing
+type memoryRepository struct {
+	mu      sync.Mutex
+	jobs    map[string]Job
+	updates int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{jobs: make(map[string]Job)}
+}
+
+func (m *memoryRepository) Create(ctx context.Context, job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.ID = fmt.Sprintf("job-%d", len(m.jobs)+1)
+	job.Status = StatusQueued
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+func (m *memoryRepository) Get(ctx context.Context, id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &job, nil
+}
+
+func (m *memoryRepository) Update(ctx context.Context, job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.jobs[job.ID]; !ok {
+		return ErrJobNotFound
+	}
+	stored := *job
+	stored.Results = append([]RowResult(nil), job.Results...)
+	m.jobs[job.ID] = stored
+	m.updates++
+	return nil
+}
+
+func TestRun_CountsOutcomes(t *testing.T) {
+	repo := newMemoryRepository()
+	job := &Job{Kind: KindPatientImport, TotalRows: 25}
+	if err := repo.Create(context.Background(), job); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	Run(context.Background(), repo, job, func(ctx context.Context, index int) RowResult {
+		if index%5 == 0 {
+			return RowResult{Row: index + 1, Status: RowFailed, Error: "boom"}
+		}
+		return RowResult{Row: index + 1, Status: RowCreated, ResourceID: fmt.Sprintf("id-%d", index)}
+	})
+
+	stored, err := repo.Get(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if stored.Status != StatusCompleted {
+		t.Errorf("Expected status %s, got %s", StatusCompleted, stored.Status)
+	}
+	if stored.ProcessedRows != 25 || stored.SucceededRows != 20 || stored.FailedRows != 5 {
+		t.Errorf("Unexpected counters: processed=%d succeeded=%d failed=%d",
+			stored.ProcessedRows, stored.SucceededRows, stored.FailedRows)
+	}
+	if len(stored.Results) != 25 {
+		t.Errorf("Expected 25 results, got %d", len(stored.Results))
+	}
+	if stored.StartedAt == nil || stored.FinishedAt == nil {
+		t.Error("Expected started_at and finished_at to be set")
+	}
+	// One write on start, two progress writes and one final write
+	if repo.updates != 4 {
+		t.Errorf("Expected 4 updates, got %d", repo.updates)
+	}
+}
+
+func TestRun_CancelledContext(t *testing.T) {
+	repo := newMemoryRepository()
+	job := &Job{Kind: KindPatientImport, TotalRows: 10}
+	repo.Create(context.Background(), job)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	Run(ctx, repo, job, func(ctx context.Context, index int) RowResult {
+		if index == 2 {
+			cancel()
+		}
+		return RowResult{Row: index + 1, Status: RowCreated}
+	})
+
+	stored, _ := repo.Get(context.Background(), job.ID)
+	if stored.Status != StatusFailed {
+		t.Errorf("Expected status %s, got %s", StatusFailed, stored.Status)
+	}
+	if stored.ProcessedRows != 3 {
+		t.Errorf("Expected 3 processed rows, got %d", stored.ProcessedRows)
+	}
+	if stored.Error == "" {
+		t.Error("Expected an error message on the interrupted job")
+	}
+}
+
+// staleFailer counts FailStale calls
+type staleFailer struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *staleFailer) FailStale(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return 0, nil
+}
+
+func TestRunner_ShutdownDrainsJobs(t *testing.T) {
+	repo := newMemoryRepository()
+	job := &Job{Kind: KindPatientImport, TotalRows: 3}
+	repo.Create(context.Background(), job)
+
+	runner := NewRunner()
+	failer := &staleFailer{}
+	runner.WatchStale(failer)
+	runner.Go(context.Background(), repo, job, func(ctx context.Context, index int) RowResult {
+		time.Sleep(10 * time.Millisecond)
+		return RowResult{Row: index + 1, Status: RowCreated}
+	})
+
+	if err := runner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected jobs to drain, got %v", err)
+	}
+	stored, _ := repo.Get(context.Background(), job.ID)
+	if stored.Status != StatusCompleted {
+		t.Errorf("Expected status %s, got %s", StatusCompleted, stored.Status)
+	}
+	if failer.calls != 1 {
+		t.Errorf("Expected stale jobs to be failed on start, got %d calls", failer.calls)
+	}
+}
+
+func TestRunner_ShutdownInterruptsJobs(t *testing.T) {
+	repo := newMemoryRepository()
+	job := &Job{Kind: KindPatientImport, TotalRows: 1000}
+	repo.Create(context.Background(), job)
+
+	runner := NewRunner()
+	runner.Go(context.Background(), repo, job, func(ctx context.Context, index int) RowResult {
+		time.Sleep(time.Millisecond)
+		return RowResult{Row: index + 1, Status: RowCreated}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := runner.Shutdown(ctx); err == nil {
+		t.Fatal("Expected shutdown to interrupt the job")
+	}
+	stored, _ := repo.Get(context.Background(), job.ID)
+	if stored.Status != StatusFailed || !strings.HasPrefix(stored.Error, ErrorInterrupted) {
+		t.Errorf("Expected interrupted job, got %s: %s", stored.Status, stored.Error)
+	}
+}
+
+// TestFailedRow tests that typed row errors keep their code and detail and
+// that other errors are hidden
+func TestFailedRow(t *testing.T) {
+	typed := fmt.Errorf("failed to create user: %w", apierror.Conflict("username_taken", "username is already taken"))
+	if got := FailedRow(context.Background(), 2, typed, "failed to create user"); got.Status != RowFailed || got.Code != "username_taken" || got.Error != "username is already taken" {
+		t.Errorf("Expected typed error to be kept, got %+v", got)
+	}
+
+	internal := errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`)
+	if got := FailedRow(context.Background(), 3, internal, "failed to create user"); got.Row != 3 || got.Code != apierror.CodeInternal || got.Error != "failed to create user" {
+		t.Errorf("Expected internal error to be hidden, got %+v", got)
+	}
+}
@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted replaces the value of every redacted attribute
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are the attribute keys treated as PII unless
+// LOG_REDACT_FIELDS overrides them
+var DefaultRedactFields = []string{
+	"email",
+	"username",
+	"first_name",
+	"last_name",
+	"phone_number",
+	"date_of_birth",
+	"address",
+	"keycloak_id",
+	"password",
+}
+
+// Config holds logging configuration
+type Config struct {
+	Level        slog.Level
+	Format       string   // json or text
+	RedactFields []string // Attribute keys whose values are never written
+}
+
+// LoadConfig loads logging configuration from environment variables
+func LoadConfig() Config {
+	// Get level with default
+	level := slog.LevelInfo
+	if levelStr := os.Getenv("LOG_LEVEL"); levelStr != "" {
+		if err := level.UnmarshalText([]byte(levelStr)); err != nil {
+			level = slog.LevelInfo
+		}
+	}
+
+	// Get format with default
+	format := strings.ToLower(os.Getenv("LOG_FORMAT"))
+	if format != "text" {
+		format = "json"
+	}
+
+	// Get redacted fields with default
+	redactFields := DefaultRedactFields
+	if fieldsStr, ok := os.LookupEnv("LOG_REDACT_FIELDS"); ok {
+		redactFields = nil
+		for _, field := range strings.Split(fieldsStr, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				redactFields = append(redactFields, field)
+			}
+		}
+	}
+
+	return Config{
+		Level:        level,
+		Format:       format,
+		RedactFields: redactFields,
+	}
+}
+
+// New returns a logger writing to w that adds the request correlation
+// fields of the context to every record and redacts the configured fields
+func New(w io.Writer, cfg Config) *slog.Logger {
+	redact := make(map[string]struct{}, len(cfg.RedactFields))
+	for _, field := range cfg.RedactFields {
+		redact[strings.ToLower(field)] = struct{}{}
+	}
+
+	opts := &slog.HandlerOptions{
+		Level: cfg.Level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if _, ok := redact[strings.ToLower(a.Key)]; ok && a.Value.Kind() != slog.KindGroup {
+				return slog.String(a.Key, Redacted)
+			}
+			return a
+		},
+	}
+
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup installs a logger on stdout as the slog default. Calls to the
+// standard log package are routed through it as well.
+func Setup(cfg Config) *slog.Logger {
+	logger := New(os.Stdout, cfg)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// contextHandler adds request ID, trace, tenant, user ID, client ID and
+// impersonator ID from the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if requestID := RequestID(ctx); requestID != "" {
+			r.AddAttrs(slog.String("request_id", requestID))
+		}
+		if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+			r.AddAttrs(
+				slog.String("trace_id", spanCtx.TraceID().String()),
+				slog.String("span_id", spanCtx.SpanID().String()),
+			)
+		}
+		if tenant, ok := ctx.Value(tenantKey).(string); ok && tenant != "" {
+			r.AddAttrs(slog.String("tenant", tenant))
+		}
+		if userID, ok := ctx.Value(userIDKey).(string); ok && userID != "" {
+			r.AddAttrs(slog.String("user_id", userID))
+		}
+		if clientID, ok := ctx.Value(clientIDKey).(string); ok && clientID != "" {
+			r.AddAttrs(slog.String("client_id", clientID))
+		}
+		if impersonatorID, ok := ctx.Value(impersonatorIDKey).(string); ok && impersonatorID != "" {
+			r.AddAttrs(slog.String("impersonator_id", impersonatorID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type ctxKey string
+
+const (
+	requestIDKey      ctxKey = "log_request_id"
+	tenantKey         ctxKey = "log_tenant"
+	userIDKey         ctxKey = "log_user_id"
+	clientIDKey       ctxKey = "log_client_id"
+	impersonatorIDKey ctxKey = "log_impersonator_id"
+)
+
+// WithRequestID returns a context whose log records carry the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID of the context, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithTenant returns a context whose log records carry the tenant schema
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// WithUserID returns a context whose log records carry the authenticated user ID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// WithClientID returns a context whose log records carry the client of an
+// authenticated service account
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey, clientID)
+}
+
+// WithImpersonatorID returns a context whose log records carry the user ID of
+// the SUPER_ADMIN impersonating the authenticated user
+func WithImpersonatorID(ctx context.Context, impersonatorID string) context.Context {
+	return context.WithValue(ctx, impersonatorIDKey, impersonatorID)
+}
@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to decode log record %q: %v", buf.String(), err)
+	}
+	return record
+}
+
+func TestLoggerRedactsConfiguredFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelInfo, RedactFields: []string{"email", "Keycloak_ID"}})
+
+	logger.Info("created user", "email", "jane@example.com", "keycloak_id", "kc-1", "role", "CAREGIVER")
+
+	record := decode(t, &buf)
+	if record["email"] != Redacted {
+		t.Errorf("Expected email to be redacted, got %v", record["email"])
+	}
+	if record["keycloak_id"] != Redacted {
+		t.Errorf("Expected keycloak_id to be redacted, got %v", record["keycloak_id"])
+	}
+	if record["role"] != "CAREGIVER" {
+		t.Errorf("Expected role to be kept, got %v", record["role"])
+	}
+}
+
+func TestLoggerRedactsFieldsInGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelInfo, RedactFields: []string{"username"}})
+
+	logger.Info("import row", slog.Group("row", "username", "jdoe", "line", 3))
+
+	row, ok := decode(t, &buf)["row"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected row group in record")
+	}
+	if row["username"] != Redacted {
+		t.Errorf("Expected username to be redacted, got %v", row["username"])
+	}
+	if row["line"] != float64(3) {
+		t.Errorf("Expected line 3, got %v", row["line"])
+	}
+}
+
+func TestLoggerAddsContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelInfo})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithTenant(ctx, "org_acme")
+	ctx = WithUserID(ctx, "user-1")
+
+	logger.InfoContext(ctx, "listing patients")
+
+	record := decode(t, &buf)
+	want := map[string]string{
+		"request_id": "req-1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"tenant":     "org_acme",
+		"user_id":    "user-1",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s %q, got %v", key, value, record[key])
+		}
+	}
+}
+
+func TestLoggerOmitsMissingContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelInfo})
+
+	logger.InfoContext(context.Background(), "starting")
+
+	record := decode(t, &buf)
+	for _, key := range []string{"request_id", "trace_id", "tenant", "user_id"} {
+		if _, ok := record[key]; ok {
+			t.Errorf("Expected no %s without context values", key)
+		}
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, Config{Level: slog.LevelWarn})
+
+	logger.Info("ignored")
+	if buf.Len() != 0 {
+		t.Errorf("Expected info record to be dropped at warn level, got %q", buf.String())
+	}
+
+	logger.Warn("kept")
+	if buf.Len() == 0 {
+		t.Error("Expected warn record to be written")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_FORMAT", "TEXT")
+	t.Setenv("LOG_REDACT_FIELDS", "email, ssn ,")
+
+	cfg := LoadConfig()
+
+	if cfg.Level != slog.LevelDebug {
+		t.Errorf("Expected debug level, got %s", cfg.Level)
+	}
+	if cfg.Format != "text" {
+		t.Errorf("Expected text format, got %s", cfg.Format)
+	}
+	if !reflect.DeepEqual(cfg.RedactFields, []string{"email", "ssn"}) {
+		t.Errorf("Expected redact fields [email ssn], got %v", cfg.RedactFields)
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("LOG_FORMAT", "")
+
+	cfg := LoadConfig()
+
+	if cfg.Level != slog.LevelInfo {
+		t.Errorf("Expected info level for unknown value, got %s", cfg.Level)
+	}
+	if cfg.Format != "json" {
+		t.Errorf("Expected json format, got %s", cfg.Format)
+	}
+	if !reflect.DeepEqual(cfg.RedactFields, DefaultRedactFields) {
+		t.Errorf("Expected default redact fields, got %v", cfg.RedactFields)
+	}
+}
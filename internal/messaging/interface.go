@@ -11,3 +11,15 @@ type PublisherInterface interface {
 
 // Ensure Publisher implements PublisherInterface
 var _ PublisherInterface = (*Publisher)(nil)
+
+// EventHandler handles an event received from the exchange
+type EventHandler func(ctx context.Context, routingKey string, body []byte)
+
+// SubscriberInterface defines the contract for receiving events published
+// by any replica
+type SubscriberInterface interface {
+	Subscribe(routingKeys []string, handle EventHandler) error
+}
+
+// Ensure Publisher implements SubscriberInterface
+var _ SubscriberInterface = (*Publisher)(nil)
@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/lib/pq"
@@ -27,7 +27,7 @@ func NewCleanupService(db *sql.DB) *CleanupService {
 // for more than 3 years, including dropping their schemas
 func (s *CleanupService) CleanupExpiredOrganizations(ctx context.Context) (int, error) {
 	cutoffDate := time.Now().Add(-RetentionPeriod)
-	log.Printf("Starting cleanup of organizations deleted before %s", cutoffDate.Format(time.RFC3339))
+	slog.InfoContext(ctx, "starting cleanup of expired organizations", "deleted_before", cutoffDate.Format(time.RFC3339))
 
 	// Find organizations that have been soft-deleted for more than 3 years
 	query := `
@@ -65,23 +65,23 @@ func (s *CleanupService) CleanupExpiredOrganizations(ctx context.Context) (int,
 	}
 
 	if len(expiredOrgs) == 0 {
-		log.Println("No expired organizations found for cleanup")
+		slog.InfoContext(ctx, "no expired organizations found for cleanup")
 		return 0, nil
 	}
 
-	log.Printf("Found %d organizations to permanently delete", len(expiredOrgs))
+	slog.InfoContext(ctx, "found organizations to permanently delete", "count", len(expiredOrgs))
 
 	// Process each expired organization
 	deletedCount := 0
 	for _, org := range expiredOrgs {
 		if err := s.permanentlyDeleteOrganization(ctx, org.ID, org.SchemaName); err != nil {
-			log.Printf("Failed to delete organization %s: %v", org.ID, err)
+			slog.ErrorContext(ctx, "failed to delete organization", "organization_id", org.ID, "error", err)
 			continue
 		}
 		deletedCount++
 	}
 
-	log.Printf("Successfully cleaned up %d/%d expired organizations", deletedCount, len(expiredOrgs))
+	slog.InfoContext(ctx, "cleaned up expired organizations", "deleted", deletedCount, "expired", len(expiredOrgs))
 	return deletedCount, nil
 }
 
@@ -128,7 +128,7 @@ func (s *CleanupService) permanentlyDeleteOrganization(ctx context.Context, orgI
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("Permanently deleted organization %s and dropped schema %s", orgID, schemaName)
+	slog.InfoContext(ctx, "permanently deleted organization and dropped schema", "organization_id", orgID, "schema", schemaName)
 	return nil
 }
 
@@ -0,0 +1,9 @@
+package organization
+
+import "github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+
+var (
+	ErrOrganizationNotFound = apierror.NotFound(apierror.CodeNotFound, "organization not found")
+	ErrNoOrganization       = apierror.Forbidden(apierror.CodeForbidden, "no organization associated with this user")
+	ErrForbidden            = apierror.Forbidden(apierror.CodeForbidden, "forbidden")
+)
@@ -4,9 +4,12 @@ package organization
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/gorilla/mux"
 )
@@ -21,11 +24,6 @@ func NewHandler(service ServiceInterface) *Handler {
 	return &Handler{service: service}
 }
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
-
 type SuccessResponse struct {
 	Success      bool                  `json:"success"`
 	Message      string                `json:"message"`
@@ -42,28 +40,29 @@ func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 
 	_, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
 	var req CreateOrganizationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
+		apierror.Respond(w, r, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
 		return
 	}
 
 	if req.Name == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Organization name is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Organization name is required")
 		return
 	}
 
 	org, err := h.service.CreateOrganization(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "creation_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to create organization")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, org.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(SuccessResponse{
 		Success:      true,
@@ -75,7 +74,7 @@ func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -85,7 +84,7 @@ func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
 	// Get paginated organizations with authorization
 	response, err := h.service.ListOrganizationsWithPagination(r.Context(), principal, params)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "fetch_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to list organizations")
 		return
 	}
 
@@ -96,7 +95,7 @@ func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -104,21 +103,22 @@ func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	if id == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Organization ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Organization ID is required")
 		return
 	}
 
 	org, err := h.service.GetOrganization(r.Context(), id, principal)
 	if err != nil {
-		if err.Error() == "forbidden" {
-			respondError(w, http.StatusForbidden, "forbidden", "You don't have permission to view this organization")
+		if errors.Is(err, ErrForbidden) {
+			apierror.Respond(w, r, http.StatusForbidden, apierror.CodeForbidden, "You don't have permission to view this organization")
 			return
 		}
-		respondError(w, http.StatusNotFound, "not_found", err.Error())
+		apierror.Write(w, r, err, "Failed to fetch organization")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, org.Version)
 	json.NewEncoder(w).Encode(SuccessResponse{
 		Success:      true,
 		Message:      "Organization retrieved successfully",
@@ -129,7 +129,7 @@ func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -137,27 +137,34 @@ func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	if id == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Organization ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Organization ID is required")
 		return
 	}
 
 	var req UpdateOrganizationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
+		apierror.Respond(w, r, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
+		return
+	}
+
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		apierror.Write(w, r, err, "")
 		return
 	}
 
-	org, err := h.service.UpdateOrganization(r.Context(), id, req, principal)
+	org, err := h.service.UpdateOrganization(r.Context(), id, req, version, principal)
 	if err != nil {
-		if err.Error() == "forbidden" {
-			respondError(w, http.StatusForbidden, "forbidden", "You don't have permission to update this organization")
+		if errors.Is(err, ErrForbidden) {
+			apierror.Respond(w, r, http.StatusForbidden, apierror.CodeForbidden, "You don't have permission to update this organization")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "update_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to update organization")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, org.Version)
 	json.NewEncoder(w).Encode(SuccessResponse{
 		Success:      true,
 		Message:      "Organization updated successfully",
@@ -168,7 +175,7 @@ func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	_, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -176,13 +183,19 @@ func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 
 	if id == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Organization ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Organization ID is required")
 		return
 	}
 
-	err := h.service.DeleteOrganization(r.Context(), id)
+	version, err := etag.IfMatch(r)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "deletion_failed", err.Error())
+		apierror.Write(w, r, err, "")
+		return
+	}
+
+	err = h.service.DeleteOrganization(r.Context(), id, version)
+	if err != nil {
+		apierror.Write(w, r, err, "Failed to delete organization")
 		return
 	}
 
@@ -190,11 +203,34 @@ func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func respondError(w http.ResponseWriter, statusCode int, errorType, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{
-		Error:   errorType,
-		Message: message,
-	})
+// PolicyResource resolves the organization of /organizations/{id} for policy
+// evaluation from its stored row. An organization belongs to itself.
+func (h *Handler) PolicyResource(r *http.Request) (auth.Resource, error) {
+	org, err := h.service.FindOrganization(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	return auth.Resource{Type: "organization", ID: org.ID, OrgID: org.ID}, nil
+}
+
+// PolicyCollection resolves the organizations a list request covers for
+// policy evaluation: the caller's own organization, or none when the token
+// names no live organization, so that only unconditional rules allow listing
+func (h *Handler) PolicyCollection(r *http.Request) (auth.Resource, error) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return auth.Resource{}, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+	}
+	if principal.OrgID == "" {
+		return auth.Resource{Type: "organization"}, nil
+	}
+
+	org, err := h.service.FindOrganization(r.Context(), principal.OrgID)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return auth.Resource{Type: "organization"}, nil
+	}
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	return auth.Resource{Type: "organization", ID: org.ID, OrgID: org.ID}, nil
 }
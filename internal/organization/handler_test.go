@@ -10,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/gorilla/mux"
 )
@@ -83,11 +85,11 @@ func TestHandlerCreateOrganization_Unauthenticated(t *testing.T) {
 		t.Errorf("Expected status 401, got %d", rec.Code)
 	}
 
-	var response ErrorResponse
+	var response apierror.Problem
 	json.NewDecoder(rec.Body).Decode(&response)
 
-	if response.Error != "unauthenticated" {
-		t.Errorf("Expected error 'unauthenticated', got '%s'", response.Error)
+	if response.Code != "unauthenticated" {
+		t.Errorf("Expected error 'unauthenticated', got '%s'", response.Code)
 	}
 }
 
@@ -109,11 +111,11 @@ func TestHandlerCreateOrganization_InvalidJSON(t *testing.T) {
 		t.Errorf("Expected status 400, got %d", rec.Code)
 	}
 
-	var response ErrorResponse
+	var response apierror.Problem
 	json.NewDecoder(rec.Body).Decode(&response)
 
-	if response.Error != "invalid_request" {
-		t.Errorf("Expected error 'invalid_request', got '%s'", response.Error)
+	if response.Code != "invalid_request" {
+		t.Errorf("Expected error 'invalid_request', got '%s'", response.Code)
 	}
 }
 
@@ -138,11 +140,11 @@ func TestHandlerCreateOrganization_EmptyName(t *testing.T) {
 		t.Errorf("Expected status 400, got %d", rec.Code)
 	}
 
-	var response ErrorResponse
+	var response apierror.Problem
 	json.NewDecoder(rec.Body).Decode(&response)
 
-	if response.Error != "validation_error" {
-		t.Errorf("Expected error 'validation_error', got '%s'", response.Error)
+	if response.Code != "validation_error" {
+		t.Errorf("Expected error 'validation_error', got '%s'", response.Code)
 	}
 }
 
@@ -234,9 +236,10 @@ func TestHandlerGetOrganization_Success(t *testing.T) {
 	mockService := &mockService{
 		getOrgFunc: func(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error) {
 			return &OrganizationResponse{
-				ID:     id,
-				Name:   "Test Org",
-				Status: "active",
+				ID:      id,
+				Name:    "Test Org",
+				Status:  "active",
+				Version: 2,
 			}, nil
 		},
 	}
@@ -263,13 +266,16 @@ func TestHandlerGetOrganization_Success(t *testing.T) {
 	if response.Organization.ID != "org-123" {
 		t.Errorf("Expected org ID 'org-123', got '%s'", response.Organization.ID)
 	}
+	if etagHeader := rec.Header().Get("ETag"); etagHeader != `"2"` {
+		t.Errorf(`Expected ETag "2", got %s`, etagHeader)
+	}
 }
 
 // TestHandlerGetOrganization_Forbidden tests forbidden access
 func TestHandlerGetOrganization_Forbidden(t *testing.T) {
 	mockService := &mockService{
 		getOrgFunc: func(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error) {
-			return nil, errors.New("forbidden")
+			return nil, ErrForbidden
 		},
 	}
 
@@ -289,11 +295,11 @@ func TestHandlerGetOrganization_Forbidden(t *testing.T) {
 		t.Errorf("Expected status 403, got %d", rec.Code)
 	}
 
-	var response ErrorResponse
+	var response apierror.Problem
 	json.NewDecoder(rec.Body).Decode(&response)
 
-	if response.Error != "forbidden" {
-		t.Errorf("Expected error 'forbidden', got '%s'", response.Error)
+	if response.Code != "forbidden" {
+		t.Errorf("Expected error 'forbidden', got '%s'", response.Code)
 	}
 }
 
@@ -301,7 +307,7 @@ func TestHandlerGetOrganization_Forbidden(t *testing.T) {
 func TestHandlerGetOrganization_NotFound(t *testing.T) {
 	mockService := &mockService{
 		getOrgFunc: func(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error) {
-			return nil, errors.New("organization not found")
+			return nil, ErrOrganizationNotFound
 		},
 	}
 
@@ -326,7 +332,7 @@ func TestHandlerGetOrganization_NotFound(t *testing.T) {
 func TestHandlerUpdateOrganization_Success(t *testing.T) {
 	newName := "Updated Org"
 	mockService := &mockService{
-		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, principal *auth.Principal) (*OrganizationResponse, error) {
+		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error) {
 			return &OrganizationResponse{
 				ID:   id,
 				Name: *req.Name,
@@ -364,8 +370,8 @@ func TestHandlerUpdateOrganization_Success(t *testing.T) {
 // TestHandlerUpdateOrganization_Forbidden tests forbidden update
 func TestHandlerUpdateOrganization_Forbidden(t *testing.T) {
 	mockService := &mockService{
-		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, principal *auth.Principal) (*OrganizationResponse, error) {
-			return nil, errors.New("forbidden")
+		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error) {
+			return nil, ErrForbidden
 		},
 	}
 
@@ -393,7 +399,7 @@ func TestHandlerUpdateOrganization_Forbidden(t *testing.T) {
 // TestHandlerDeleteOrganization_Success tests successful deletion
 func TestHandlerDeleteOrganization_Success(t *testing.T) {
 	mockService := &mockService{
-		deleteOrgFunc: func(ctx context.Context, id string) error {
+		deleteOrgFunc: func(ctx context.Context, id string, version int) error {
 			return nil
 		},
 	}
@@ -422,7 +428,7 @@ func TestHandlerDeleteOrganization_Success(t *testing.T) {
 // TestHandlerDeleteOrganization_ServiceError tests deletion error
 func TestHandlerDeleteOrganization_ServiceError(t *testing.T) {
 	mockService := &mockService{
-		deleteOrgFunc: func(ctx context.Context, id string) error {
+		deleteOrgFunc: func(ctx context.Context, id string, version int) error {
 			return errors.New("deletion failed")
 		},
 	}
@@ -444,6 +450,132 @@ func TestHandlerDeleteOrganization_ServiceError(t *testing.T) {
 	}
 }
 
+// TestHandlerUpdateOrganization_IfMatch tests that If-Match is passed on and
+// the new version is returned as ETag
+func TestHandlerUpdateOrganization_IfMatch(t *testing.T) {
+	var gotVersion int
+	mockService := &mockService{
+		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error) {
+			gotVersion = version
+			return &OrganizationResponse{ID: id, Name: *req.Name, Version: version + 1}, nil
+		},
+	}
+
+	handler := NewHandler(mockService)
+
+	newName := "Updated Org"
+	body, _ := json.Marshal(UpdateOrganizationRequest{Name: &newName})
+
+	req := httptest.NewRequest(http.MethodPatch, "/organizations/org-123", bytes.NewReader(body))
+	req.Header.Set("If-Match", `"3"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "org-123"})
+	principal := &auth.Principal{UserID: "user-1", Roles: []string{"SUPER_ADMIN"}}
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+
+	rec := httptest.NewRecorder()
+
+	handler.UpdateOrganization(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if gotVersion != 3 {
+		t.Errorf("Expected version 3 to be passed on, got %d", gotVersion)
+	}
+	if etagHeader := rec.Header().Get("ETag"); etagHeader != `"4"` {
+		t.Errorf(`Expected ETag "4", got %s`, etagHeader)
+	}
+}
+
+// TestHandlerUpdateOrganization_StaleVersion tests that a stale If-Match is
+// answered with 412
+func TestHandlerUpdateOrganization_StaleVersion(t *testing.T) {
+	mockService := &mockService{
+		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error) {
+			return nil, etag.ErrPreconditionFailed
+		},
+	}
+
+	handler := NewHandler(mockService)
+
+	newName := "Updated Org"
+	body, _ := json.Marshal(UpdateOrganizationRequest{Name: &newName})
+
+	req := httptest.NewRequest(http.MethodPatch, "/organizations/org-123", bytes.NewReader(body))
+	req.Header.Set("If-Match", `"2"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "org-123"})
+	principal := &auth.Principal{UserID: "user-1", Roles: []string{"SUPER_ADMIN"}}
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+
+	rec := httptest.NewRecorder()
+
+	handler.UpdateOrganization(rec, req)
+
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412, got %d", rec.Code)
+	}
+}
+
+// TestHandlerDeleteOrganization_MalformedIfMatch tests that an If-Match that
+// is not a row version fails before the service is called
+func TestHandlerDeleteOrganization_MalformedIfMatch(t *testing.T) {
+	mockService := &mockService{}
+
+	handler := NewHandler(mockService)
+
+	req := httptest.NewRequest(http.MethodDelete, "/organizations/org-123", nil)
+	req.Header.Set("If-Match", `W/"2"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "org-123"})
+	principal := &auth.Principal{UserID: "user-1", Roles: []string{"SUPER_ADMIN"}}
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+
+	rec := httptest.NewRecorder()
+
+	handler.DeleteOrganization(rec, req)
+
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412, got %d", rec.Code)
+	}
+}
+
+// TestHandlerPolicyResolvers tests that policies see the stored organization
+func TestHandlerPolicyResolvers(t *testing.T) {
+	mockService := &mockService{
+		findOrgFunc: func(ctx context.Context, id string) (*OrganizationResponse, error) {
+			if id == "org-123" {
+				return &OrganizationResponse{ID: id}, nil
+			}
+			return nil, ErrOrganizationNotFound
+		},
+	}
+	handler := NewHandler(mockService)
+
+	request := func(id string, principal *auth.Principal) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		return req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+	}
+	orgAdmin := &auth.Principal{UserID: "user-1", Roles: []string{"ORG_ADMIN"}, OrgID: "org-123"}
+
+	res, err := handler.PolicyResource(request("org-123", orgAdmin))
+	if err != nil || res.OrgID != "org-123" {
+		t.Errorf("Expected stored organization, got %+v, %v", res, err)
+	}
+	if _, err := handler.PolicyResource(request("org-999", orgAdmin)); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("Expected not found, got %v", err)
+	}
+
+	res, err = handler.PolicyCollection(request("", orgAdmin))
+	if err != nil || res.OrgID != "org-123" {
+		t.Errorf("Expected own organization, got %+v, %v", res, err)
+	}
+	stale := &auth.Principal{UserID: "user-2", Roles: []string{"ORG_ADMIN"}, OrgID: "org-999"}
+	res, err = handler.PolicyCollection(request("", stale))
+	if err != nil || res.OrgID != "" {
+		t.Errorf("Expected no organization for a deleted one, got %+v, %v", res, err)
+	}
+}
+
 // Mock service implementation
 
 type mockService struct {
@@ -451,8 +583,9 @@ type mockService struct {
 	listOrgsFunc          func(ctx context.Context, principal *auth.Principal) ([]OrganizationResponse, error)
 	listOrgsPaginatedFunc func(ctx context.Context, principal *auth.Principal, params pagination.Params) (*PaginatedListResponse, error)
 	getOrgFunc            func(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error)
-	updateOrgFunc         func(ctx context.Context, id string, req UpdateOrganizationRequest, principal *auth.Principal) (*OrganizationResponse, error)
-	deleteOrgFunc         func(ctx context.Context, id string) error
+	updateOrgFunc         func(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error)
+	deleteOrgFunc         func(ctx context.Context, id string, version int) error
+	findOrgFunc           func(ctx context.Context, id string) (*OrganizationResponse, error)
 }
 
 func (m *mockService) CreateOrganization(ctx context.Context, req CreateOrganizationRequest) (*OrganizationResponse, error) {
@@ -476,6 +609,13 @@ func (m *mockService) ListOrganizationsWithPagination(ctx context.Context, princ
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockService) FindOrganization(ctx context.Context, id string) (*OrganizationResponse, error) {
+	if m.findOrgFunc != nil {
+		return m.findOrgFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockService) GetOrganization(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error) {
 	if m.getOrgFunc != nil {
 		return m.getOrgFunc(ctx, id, principal)
@@ -483,16 +623,16 @@ func (m *mockService) GetOrganization(ctx context.Context, id string, principal
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, principal *auth.Principal) (*OrganizationResponse, error) {
+func (m *mockService) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error) {
 	if m.updateOrgFunc != nil {
-		return m.updateOrgFunc(ctx, id, req, principal)
+		return m.updateOrgFunc(ctx, id, req, version, principal)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) DeleteOrganization(ctx context.Context, id string) error {
+func (m *mockService) DeleteOrganization(ctx context.Context, id string, version int) error {
 	if m.deleteOrgFunc != nil {
-		return m.deleteOrgFunc(ctx, id)
+		return m.deleteOrgFunc(ctx, id, version)
 	}
 	return errors.New("not implemented")
 }
@@ -47,6 +47,21 @@ func GetSchemaNameByOrgID(ctx context.Context, db *sql.DB, orgID string) (string
 	return schemaName, nil
 }
 
+// GetOrgIDBySchemaName returns the organization that owns a tenant schema, or
+// "" when no live organization owns it
+func GetOrgIDBySchemaName(ctx context.Context, db *sql.DB, schemaName string) (string, error) {
+	query := `SELECT id FROM wailsalutem.organizations WHERE schema_name = $1 AND deleted_at IS NULL`
+	var orgID string
+	err := db.QueryRowContext(ctx, query, schemaName).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return orgID, nil
+}
+
 
 func ClearSchemaCache() {
 	schemaCacheMutex.Lock()
@@ -4,24 +4,37 @@ import (
 	"time"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/validation"
 )
 
-// CreateOrganizationRequest represents the request to create a new organization
+// CreateOrganizationRequest represents the request to create a new organization.
+// Maximum lengths match the columns of the organizations table.
 type CreateOrganizationRequest struct {
-	Name         string `json:"name"`
-	ContactEmail string `json:"contact_email"`
-	ContactPhone string `json:"contact_phone"`
+	Name         string `json:"name" validate:"required,max=255" label:"organization name"`
+	ContactEmail string `json:"contact_email" validate:"omitempty,email,max=255"`
+	ContactPhone string `json:"contact_phone" validate:"omitempty,phone,max=50"`
 	Address      string `json:"address"`
 }
 
+// Validate checks every field of the create organization request and reports
+// all problems at once
+func (r *CreateOrganizationRequest) Validate() error {
+	return validation.Struct(r)
+}
+
 // UpdateOrganizationRequest represents the request to update an organization
 type UpdateOrganizationRequest struct {
-	Name         *string `json:"name,omitempty"`
-	ContactEmail *string `json:"contact_email,omitempty"`
-	ContactPhone *string `json:"contact_phone,omitempty"`
+	Name         *string `json:"name,omitempty" validate:"omitnil,min=1,max=255" label:"organization name"`
+	ContactEmail *string `json:"contact_email,omitempty" validate:"omitempty,email,max=255"`
+	ContactPhone *string `json:"contact_phone,omitempty" validate:"omitempty,phone,max=50"`
 	Address      *string `json:"address,omitempty"`
 }
 
+// Validate checks the fields present in the update organization request
+func (r *UpdateOrganizationRequest) Validate() error {
+	return validation.Struct(r)
+}
+
 // OrganizationResponse represents the organization data returned to clients
 type OrganizationResponse struct {
 	ID           string    `json:"id"`
@@ -32,6 +45,7 @@ type OrganizationResponse struct {
 	Address      string    `json:"address"`
 	Status       string    `json:"status"`
 	CreatedAt    time.Time `json:"created_at"`
+	Version      int       `json:"version"` // Row version, also sent as the ETag
 }
 
 // PaginatedListResponse represents a paginated list of organizations
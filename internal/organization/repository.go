@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -41,7 +43,7 @@ func (r *Repository) CreateOrganization(ctx context.Context, req CreateOrganizat
         INSERT INTO wailsalutem.organizations 
         (id, name, schema_name, contact_email, contact_phone, address, status, created_at)
         VALUES ($1, $2, $3, $4, $5, $6, 'active', $7)
-        RETURNING id, name, schema_name, contact_email, contact_phone, address, status, created_at
+        RETURNING id, name, schema_name, contact_email, contact_phone, address, status, created_at, version
     `
 
 	createdAt := time.Now()
@@ -64,6 +66,7 @@ func (r *Repository) CreateOrganization(ctx context.Context, req CreateOrganizat
 		&org.Address,
 		&org.Status,
 		&org.CreatedAt,
+		&org.Version,
 	)
 
 	if err != nil {
@@ -98,7 +101,25 @@ func (r *Repository) createOrganizationSchema(ctx context.Context, tx *sql.Tx, s
 		return fmt.Errorf("failed to create tenant schema via database function: %w", err)
 	}
 
-	log.Printf("Created tenant schema '%s' via database function", schemaName)
+	_, err = tx.ExecContext(
+		ctx,
+		"SELECT wailsalutem.add_row_versions($1)",
+		schemaName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add row versions: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"SELECT wailsalutem.create_patient_search_indexes($1)",
+		schemaName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create patient search indexes: %w", err)
+	}
+
+	slog.InfoContext(ctx, "created tenant schema via database function", "schema", schemaName)
 	return nil
 }
 
@@ -122,7 +143,7 @@ func sanitizeName(name string) string {
 
 func (r *Repository) ListOrganizations(ctx context.Context) ([]OrganizationResponse, error) {
 	query := `
-		SELECT id, name, schema_name, contact_email, contact_phone, address, status, created_at
+		SELECT id, name, schema_name, contact_email, contact_phone, address, status, created_at, version
 		FROM wailsalutem.organizations
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -150,6 +171,7 @@ func (r *Repository) ListOrganizations(ctx context.Context) ([]OrganizationRespo
 			&address,
 			&org.Status,
 			&org.CreatedAt,
+			&org.Version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan organization: %w", err)
@@ -175,48 +197,70 @@ func (r *Repository) ListOrganizations(ctx context.Context) ([]OrganizationRespo
 	return orgs, nil
 }
 
+// organizationListFields whitelists the sort keys and filters of the organization list
+var organizationListFields = pagination.Fields{
+	Sort: map[string]string{
+		"name":       "name",
+		"status":     "status",
+		"created_at": "created_at",
+	},
+	Filters: map[string]pagination.Filter{
+		"created_after":  {Column: "created_at", Op: ">=", Type: pagination.FilterDate},
+		"created_before": {Column: "created_at", Op: "<", Type: pagination.FilterDate},
+	},
+}
+
 // ListOrganizationsWithPagination retrieves organizations with pagination support
-func (r *Repository) ListOrganizationsWithPagination(ctx context.Context, limit, offset int, search string, status string) ([]OrganizationResponse, int, error) {
+func (r *Repository) ListOrganizationsWithPagination(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error) {
 	// Build WHERE clause
 	whereClause := "WHERE deleted_at IS NULL"
-	args := []interface{}{limit, offset}
-	countArgs := []interface{}{}
-	argIndex := 3
+	args := []interface{}{}
+	argIndex := 1
 
-	if search != "" {
+	if listQuery.Search != "" {
 		whereClause += fmt.Sprintf(` AND (name ILIKE $%d OR contact_email ILIKE $%d)`, argIndex, argIndex)
-		args = append(args, "%"+search+"%")
-		countArgs = append(countArgs, "%"+search+"%")
+		args = append(args, "%"+listQuery.Search+"%")
 		argIndex++
 	}
 
-	if status != "" && status != "all" {
+	if listQuery.Status != "" && listQuery.Status != "all" {
 		whereClause += fmt.Sprintf(` AND status = $%d`, argIndex)
-		args = append(args, status)
-		countArgs = append(countArgs, status)
+		args = append(args, listQuery.Status)
+		argIndex++
 	}
 
-	// First, get total count
-	var totalCount int
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM wailsalutem.organizations
-		%s
-	`, whereClause)
+	conditions, filterArgs := organizationListFields.Where(listQuery.Filters, argIndex)
+	whereClause += conditions
+	args = append(args, filterArgs...)
+	argIndex += len(filterArgs)
 
-	err := r.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count organizations: %w", err)
+	// Count the total unless a cursor request skips it
+	var totalCount int
+	if window.CountTotal {
+		countQuery := fmt.Sprintf(`
+			SELECT COUNT(*) 
+			FROM wailsalutem.organizations
+			%s
+		`, whereClause)
+
+		err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count organizations: %w", err)
+		}
 	}
 
-	// Then get paginated results
+	// Then get the page, after the cursor if there is one
+	keyset, keysetArgs := window.KeysetClause(argIndex)
+	args = append(args, keysetArgs...)
+
 	query := fmt.Sprintf(`
-		SELECT id, name, schema_name, contact_email, contact_phone, address, status, created_at
+		SELECT id, name, schema_name, contact_email, contact_phone, address, status, created_at, version
 		FROM wailsalutem.organizations
-		%s
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`, whereClause)
+		%s%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, whereClause, keyset, organizationListFields.OrderBy(listQuery.Sort), len(args)+1, len(args)+2)
+	args = append(args, window.Limit, window.Offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -240,6 +284,7 @@ func (r *Repository) ListOrganizationsWithPagination(ctx context.Context, limit,
 			&address,
 			&org.Status,
 			&org.CreatedAt,
+			&org.Version,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan organization: %w", err)
@@ -267,7 +312,7 @@ func (r *Repository) ListOrganizationsWithPagination(ctx context.Context, limit,
 
 func (r *Repository) GetOrganization(ctx context.Context, id string) (*OrganizationResponse, error) {
 	query := `
-		SELECT id, name, schema_name, contact_email, contact_phone, address, status, created_at
+		SELECT id, name, schema_name, contact_email, contact_phone, address, status, created_at, version
 		FROM wailsalutem.organizations
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -286,10 +331,11 @@ func (r *Repository) GetOrganization(ctx context.Context, id string) (*Organizat
 		&address,
 		&org.Status,
 		&org.CreatedAt,
+		&org.Version,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("organization not found")
+		return nil, ErrOrganizationNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query organization: %w", err)
@@ -308,7 +354,9 @@ func (r *Repository) GetOrganization(ctx context.Context, id string) (*Organizat
 	return &org, nil
 }
 
-func (r *Repository) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest) (*OrganizationResponse, error) {
+// UpdateOrganization applies the set fields of req. Unless version is
+// etag.AnyVersion, the update only applies to that row version.
+func (r *Repository) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, version int) (*OrganizationResponse, error) {
 	// Build dynamic update query
 	var updates []string
 	var args []interface{}
@@ -339,20 +387,27 @@ func (r *Repository) UpdateOrganization(ctx context.Context, id string, req Upda
 		return nil, fmt.Errorf("no fields to update")
 	}
 
-	// Add updated_at timestamp
-	updates = append(updates, fmt.Sprintf("updated_at = $%d", argIndex))
+	// Add updated_at timestamp and bump the row version
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argIndex), "version = version + 1")
 	args = append(args, time.Now())
 	argIndex++
 
 	// Add ID parameter
 	args = append(args, id)
+	whereClause := fmt.Sprintf("id = $%d AND deleted_at IS NULL", argIndex)
+	argIndex++
+
+	if version != etag.AnyVersion {
+		whereClause += fmt.Sprintf(" AND version = $%d", argIndex)
+		args = append(args, version)
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE wailsalutem.organizations
 		SET %s
-		WHERE id = $%d AND deleted_at IS NULL
-		RETURNING id, name, schema_name, contact_email, contact_phone, address, status, created_at
-	`, strings.Join(updates, ", "), argIndex)
+		WHERE %s
+		RETURNING id, name, schema_name, contact_email, contact_phone, address, status, created_at, version
+	`, strings.Join(updates, ", "), whereClause)
 
 	var org OrganizationResponse
 	var contactEmail sql.NullString
@@ -368,10 +423,11 @@ func (r *Repository) UpdateOrganization(ctx context.Context, id string, req Upda
 		&address,
 		&org.Status,
 		&org.CreatedAt,
+		&org.Version,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("organization not found")
+		return nil, r.missingOrModified(ctx, id, version)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update organization: %w", err)
@@ -390,24 +446,30 @@ func (r *Repository) UpdateOrganization(ctx context.Context, id string, req Upda
 	return &org, nil
 }
 
-func (r *Repository) DeleteOrganization(ctx context.Context, id string) error {
+// DeleteOrganization soft deletes the organization. Unless version is
+// etag.AnyVersion, only that row version is deleted.
+func (r *Repository) DeleteOrganization(ctx context.Context, id string, version int) error {
 	// Get organization details before deleting (for event)
 	org, err := r.GetOrganization(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to get organization for deletion: %w", err)
 	}
+	if version != etag.AnyVersion && org.Version != version {
+		return etag.ErrPreconditionFailed
+	}
 
 	// Soft delete: Set deleted_at timestamp and update status to inactive
 	query := `
 		UPDATE wailsalutem.organizations
 		SET deleted_at = $1,
 		    status = 'inactive',
-		    updated_at = $1
-		WHERE id = $2 AND deleted_at IS NULL
+		    updated_at = $1,
+		    version = version + 1
+		WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
 	`
 
 	deletedAt := time.Now()
-	result, err := r.db.ExecContext(ctx, query, deletedAt, id)
+	result, err := r.db.ExecContext(ctx, query, deletedAt, id, version)
 	if err != nil {
 		return fmt.Errorf("failed to soft delete organization: %w", err)
 	}
@@ -417,7 +479,10 @@ func (r *Repository) DeleteOrganization(ctx context.Context, id string) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("organization not found or already deleted")
+		if version != etag.AnyVersion {
+			return etag.ErrPreconditionFailed
+		}
+		return fmt.Errorf("%w or already deleted", ErrOrganizationNotFound)
 	}
 
 	// Clear the schema from cache to prevent access to deleted organization
@@ -438,7 +503,7 @@ func (r *Repository) DeleteOrganization(ctx context.Context, id string) error {
 		}
 
 		if err := r.publisher.Publish(ctx, messaging.EventOrganizationDeleted, event); err != nil {
-			log.Printf("Warning: failed to publish organization.deleted event: %v", err)
+			slog.WarnContext(ctx, "failed to publish organization.deleted event", "error", err)
 			// Don't fail the delete if event publishing fails
 		}
 	}
@@ -448,3 +513,24 @@ func (r *Repository) DeleteOrganization(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// missingOrModified explains why an update matched no row: the organization
+// is gone, or it exists but no longer has the expected version
+func (r *Repository) missingOrModified(ctx context.Context, id string, version int) error {
+	if version == etag.AnyVersion {
+		return ErrOrganizationNotFound
+	}
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM wailsalutem.organizations WHERE id = $1 AND deleted_at IS NULL)",
+		id,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check organization: %w", err)
+	}
+	if !exists {
+		return ErrOrganizationNotFound
+	}
+	return etag.ErrPreconditionFailed
+}
@@ -5,8 +5,11 @@ package organization
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
 )
 
@@ -170,7 +173,7 @@ func TestRepositoryListOrganizationsWithPagination_Integration(t *testing.T) {
 	}
 
 	// Get first page (limit 2)
-	orgs, total, err := repo.ListOrganizationsWithPagination(context.Background(), 2, 0, "", "")
+	orgs, total, err := repo.ListOrganizationsWithPagination(context.Background(), pagination.Window{Limit: 2, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListOrganizationsWithPagination failed: %v", err)
 	}
@@ -184,7 +187,7 @@ func TestRepositoryListOrganizationsWithPagination_Integration(t *testing.T) {
 	}
 
 	// Get second page (limit 2, offset 2)
-	orgs, _, err = repo.ListOrganizationsWithPagination(context.Background(), 2, 2, "", "")
+	orgs, _, err = repo.ListOrganizationsWithPagination(context.Background(), pagination.Window{Limit: 2, Offset: 2, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListOrganizationsWithPagination page 2 failed: %v", err)
 	}
@@ -221,7 +224,7 @@ func TestRepositoryUpdateOrganization_Integration(t *testing.T) {
 		ContactPhone: &phone,
 	}
 
-	updated, err := repo.UpdateOrganization(context.Background(), created.ID, updateReq)
+	updated, err := repo.UpdateOrganization(context.Background(), created.ID, updateReq, created.Version)
 	if err != nil {
 		t.Fatalf("UpdateOrganization failed: %v", err)
 	}
@@ -233,6 +236,16 @@ func TestRepositoryUpdateOrganization_Integration(t *testing.T) {
 	if updated.ContactEmail != *updateReq.ContactEmail {
 		t.Errorf("Expected email %s, got %s", *updateReq.ContactEmail, updated.ContactEmail)
 	}
+
+	if updated.Version != created.Version+1 {
+		t.Errorf("Expected version %d, got %d", created.Version+1, updated.Version)
+	}
+
+	// Updating with the version that was just replaced fails the precondition
+	_, err = repo.UpdateOrganization(context.Background(), created.ID, updateReq, created.Version)
+	if !errors.Is(err, etag.ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+	}
 }
 
 // TestRepositoryDeleteOrganization_Integration tests soft deleting an organization
@@ -253,7 +266,7 @@ func TestRepositoryDeleteOrganization_Integration(t *testing.T) {
 	}
 
 	// Delete organization
-	err = repo.DeleteOrganization(context.Background(), created.ID)
+	err = repo.DeleteOrganization(context.Background(), created.ID, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("DeleteOrganization failed: %v", err)
 	}
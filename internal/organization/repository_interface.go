@@ -1,15 +1,19 @@
 package organization
 
-import "context"
+import (
+	"context"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+)
 
 // RepositoryInterface defines the contract for organization data access
 type RepositoryInterface interface {
 	CreateOrganization(ctx context.Context, req CreateOrganizationRequest) (*OrganizationResponse, error)
 	ListOrganizations(ctx context.Context) ([]OrganizationResponse, error)
-	ListOrganizationsWithPagination(ctx context.Context, limit, offset int, search, status string) ([]OrganizationResponse, int, error)
+	ListOrganizationsWithPagination(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error)
 	GetOrganization(ctx context.Context, id string) (*OrganizationResponse, error)
-	UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest) (*OrganizationResponse, error)
-	DeleteOrganization(ctx context.Context, id string) error
+	UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, version int) (*OrganizationResponse, error)
+	DeleteOrganization(ctx context.Context, id string, version int) error
 }
 
 // Ensure Repository implements RepositoryInterface
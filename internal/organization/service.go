@@ -5,28 +5,42 @@ package organization
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/telemetry"
 )
 
+// MetricsRecorder interface for recording organization operation metrics
+type MetricsRecorder interface {
+	RecordOrganizationOperation(ctx context.Context, operation, tenant, outcome string)
+}
+
 type Service struct {
-	repo RepositoryInterface
+	repo    RepositoryInterface
+	metrics MetricsRecorder
 }
 
 // NewService creates a new organization service
 // Development Team: Muhammad Faizan, Roozbeh Kouchaki, Fatemehalsadat Sabaghjafari, Dipika Bhandari
 func NewService(repo RepositoryInterface) *Service {
-	return &Service{repo: repo}
+	return NewServiceWithMetrics(repo, nil)
 }
 
-func (s *Service) CreateOrganization(ctx context.Context, req CreateOrganizationRequest) (*OrganizationResponse, error) {
-	if req.Name == "" {
-		return nil, fmt.Errorf("organization name is required")
+// NewServiceWithMetrics creates an organization service that counts its operations
+func NewServiceWithMetrics(repo RepositoryInterface, metrics MetricsRecorder) *Service {
+	return &Service{repo: repo, metrics: metrics}
+}
+
+func (s *Service) CreateOrganization(ctx context.Context, req CreateOrganizationRequest) (org *OrganizationResponse, err error) {
+	defer func() { s.recordOperation(ctx, "create", tenantOf(org), err) }()
+
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
-	org, err := s.repo.CreateOrganization(ctx, req)
+	org, err = s.repo.CreateOrganization(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create organization: %w", err)
 	}
@@ -34,9 +48,10 @@ func (s *Service) CreateOrganization(ctx context.Context, req CreateOrganization
 	return org, nil
 }
 
-func (s *Service) ListOrganizations(ctx context.Context, principal *auth.Principal) ([]OrganizationResponse, error) {
-	// Debug logging
-	log.Printf("[DEBUG] ListOrganizations - UserID: %s, OrgID: %s, Roles: %v", principal.UserID, principal.OrgID, principal.Roles)
+func (s *Service) ListOrganizations(ctx context.Context, principal *auth.Principal) (_ []OrganizationResponse, err error) {
+	defer func() { s.recordOperation(ctx, "list", principal.OrgSchemaName, err) }()
+
+	slog.DebugContext(ctx, "listing organizations", "organization_id", principal.OrgID, "roles", principal.Roles)
 
 	// Check if user is SUPER_ADMIN
 	isSuperAdmin := false
@@ -47,22 +62,20 @@ func (s *Service) ListOrganizations(ctx context.Context, principal *auth.Princip
 		}
 	}
 
-	log.Printf("[DEBUG] isSuperAdmin: %v", isSuperAdmin)
-
 	// SUPER_ADMIN can see all organizations
 	if isSuperAdmin {
 		orgs, err := s.repo.ListOrganizations(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list organizations: %w", err)
 		}
-		log.Printf("[DEBUG] Returning %d organizations for SUPER_ADMIN", len(orgs))
+		slog.DebugContext(ctx, "returning organizations for SUPER_ADMIN", "count", len(orgs))
 		return orgs, nil
 	}
 
 	// ORG_ADMIN can only see their own organization
 	if principal.OrgID == "" {
-		log.Printf("[DEBUG] No org_id for non-SUPER_ADMIN user")
-		return nil, fmt.Errorf("no organization associated with this user")
+		slog.DebugContext(ctx, "no organization ID for non-SUPER_ADMIN user")
+		return nil, ErrNoOrganization
 	}
 
 	org, err := s.repo.GetOrganization(ctx, principal.OrgID)
@@ -75,9 +88,17 @@ func (s *Service) ListOrganizations(ctx context.Context, principal *auth.Princip
 }
 
 // ListOrganizationsWithPagination retrieves organizations with pagination and authorization
-func (s *Service) ListOrganizationsWithPagination(ctx context.Context, principal *auth.Principal, params pagination.Params) (*PaginatedListResponse, error) {
-	// Validate pagination parameters
-	params.Validate()
+func (s *Service) ListOrganizationsWithPagination(ctx context.Context, principal *auth.Principal, params pagination.Params) (_ *PaginatedListResponse, err error) {
+	defer func() { s.recordOperation(ctx, "list", principal.OrgSchemaName, err) }()
+
+	// Validate pagination, sort and filter parameters and resolve the cursor
+	window, err := params.Window()
+	if err != nil {
+		return nil, err
+	}
+	if err := organizationListFields.Check(params); err != nil {
+		return nil, err
+	}
 
 	// Check if user is SUPER_ADMIN
 	isSuperAdmin := false
@@ -90,14 +111,14 @@ func (s *Service) ListOrganizationsWithPagination(ctx context.Context, principal
 
 	// SUPER_ADMIN can see all organizations with pagination
 	if isSuperAdmin {
-		// Get paginated data from repository with search and status filters
-		orgs, totalCount, err := s.repo.ListOrganizationsWithPagination(ctx, params.Limit, params.CalculateOffset(), params.Search, params.Status)
+		// Get paginated data from repository with search, status and filters
+		orgs, totalCount, err := s.repo.ListOrganizationsWithPagination(ctx, window, params.Query())
 		if err != nil {
 			return nil, fmt.Errorf("failed to list organizations: %w", err)
 		}
 
-		// Calculate pagination metadata
-		meta := params.CalculateMeta(totalCount)
+		// Trim to the page and calculate pagination metadata
+		orgs, meta := pagination.NewPage(&params, window, orgs, totalCount, organizationCursor)
 
 		response := &PaginatedListResponse{
 			Success:       true,
@@ -110,7 +131,7 @@ func (s *Service) ListOrganizationsWithPagination(ctx context.Context, principal
 
 	// ORG_ADMIN can only see their own organization (pagination not really needed, but keep consistent)
 	if principal.OrgID == "" {
-		return nil, fmt.Errorf("no organization associated with this user")
+		return nil, ErrNoOrganization
 	}
 
 	org, err := s.repo.GetOrganization(ctx, principal.OrgID)
@@ -130,20 +151,17 @@ func (s *Service) ListOrganizationsWithPagination(ctx context.Context, principal
 }
 
 func (s *Service) GetOrganization(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error) {
-	// Check if user is SUPER_ADMIN
-	isSuperAdmin := false
-	for _, role := range principal.Roles {
-		if role == "SUPER_ADMIN" {
-			isSuperAdmin = true
-			break
-		}
-	}
-
 	// ORG_ADMIN can only view their own organization
-	if !isSuperAdmin && principal.OrgID != id {
-		return nil, fmt.Errorf("forbidden")
+	if _, err := principal.TargetOrganization(id); err != nil {
+		return nil, ErrForbidden
 	}
 
+	return s.FindOrganization(ctx, id)
+}
+
+// FindOrganization returns an organization without checking the caller's
+// access, for policy resolvers that load the organization first
+func (s *Service) FindOrganization(ctx context.Context, id string) (*OrganizationResponse, error) {
 	org, err := s.repo.GetOrganization(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get organization: %w", err)
@@ -151,7 +169,9 @@ func (s *Service) GetOrganization(ctx context.Context, id string, principal *aut
 	return org, nil
 }
 
-func (s *Service) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, principal *auth.Principal) (*OrganizationResponse, error) {
+func (s *Service) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (org *OrganizationResponse, err error) {
+	defer func() { s.recordOperation(ctx, "update", tenantOf(org), err) }()
+
 	// Check if user is SUPER_ADMIN
 	isSuperAdmin := false
 	for _, role := range principal.Roles {
@@ -163,20 +183,46 @@ func (s *Service) UpdateOrganization(ctx context.Context, id string, req UpdateO
 
 	// Only SUPER_ADMIN can update organizations
 	if !isSuperAdmin {
-		return nil, fmt.Errorf("forbidden")
+		return nil, ErrForbidden
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
-	org, err := s.repo.UpdateOrganization(ctx, id, req)
+	org, err = s.repo.UpdateOrganization(ctx, id, req, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update organization: %w", err)
 	}
 	return org, nil
 }
 
-func (s *Service) DeleteOrganization(ctx context.Context, id string) error {
-	err := s.repo.DeleteOrganization(ctx, id)
+func (s *Service) DeleteOrganization(ctx context.Context, id string, version int) error {
+	err := s.repo.DeleteOrganization(ctx, id, version)
+	s.recordOperation(ctx, "delete", "", err)
 	if err != nil {
 		return fmt.Errorf("failed to delete organization: %w", err)
 	}
 	return nil
 }
+
+// recordOperation counts an organization operation and its outcome. tenant is
+// the schema of the organization, empty when unknown or for cross-tenant lists.
+func (s *Service) recordOperation(ctx context.Context, operation, tenant string, err error) {
+	if s.metrics != nil {
+		s.metrics.RecordOrganizationOperation(ctx, operation, tenant, telemetry.OutcomeOf(err))
+	}
+}
+
+// tenantOf returns the schema of an organization, empty for nil
+func tenantOf(org *OrganizationResponse) string {
+	if org == nil {
+		return ""
+	}
+	return org.SchemaName
+}
+
+// organizationCursor returns the list position of an organization
+func organizationCursor(o OrganizationResponse) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: o.CreatedAt, ID: o.ID}
+}
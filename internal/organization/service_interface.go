@@ -13,8 +13,9 @@ type ServiceInterface interface {
 	ListOrganizations(ctx context.Context, principal *auth.Principal) ([]OrganizationResponse, error)
 	ListOrganizationsWithPagination(ctx context.Context, principal *auth.Principal, params pagination.Params) (*PaginatedListResponse, error)
 	GetOrganization(ctx context.Context, id string, principal *auth.Principal) (*OrganizationResponse, error)
-	UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, principal *auth.Principal) (*OrganizationResponse, error)
-	DeleteOrganization(ctx context.Context, id string) error
+	FindOrganization(ctx context.Context, id string) (*OrganizationResponse, error)
+	UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, version int, principal *auth.Principal) (*OrganizationResponse, error)
+	DeleteOrganization(ctx context.Context, id string, version int) error
 }
 
 // Ensure Service implements ServiceInterface
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 )
 
@@ -188,7 +189,7 @@ func TestListOrganizations_OrgAdminNoOrgID(t *testing.T) {
 // TestListOrganizationsWithPagination_SuperAdmin tests pagination for SUPER_ADMIN
 func TestListOrganizationsWithPagination_SuperAdmin(t *testing.T) {
 	mockRepo := &mockRepository{
-		listOrgsPaginatedFunc: func(ctx context.Context, limit, offset int, search, status string) ([]OrganizationResponse, int, error) {
+		listOrgsPaginatedFunc: func(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error) {
 			// Simulate 25 total orgs, returning page of 10
 			return []OrganizationResponse{
 				{ID: "org-1", Name: "Org 1"},
@@ -238,8 +239,8 @@ func TestListOrganizationsWithPagination_SuperAdmin(t *testing.T) {
 // TestListOrganizationsWithPagination_WithSearch tests search functionality
 func TestListOrganizationsWithPagination_WithSearch(t *testing.T) {
 	mockRepo := &mockRepository{
-		listOrgsPaginatedFunc: func(ctx context.Context, limit, offset int, search, status string) ([]OrganizationResponse, int, error) {
-			if search == "hospital" {
+		listOrgsPaginatedFunc: func(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error) {
+			if listQuery.Search == "hospital" {
 				return []OrganizationResponse{
 					{ID: "org-1", Name: "City Hospital"},
 					{ID: "org-5", Name: "General Hospital"},
@@ -274,8 +275,8 @@ func TestListOrganizationsWithPagination_WithSearch(t *testing.T) {
 // TestListOrganizationsWithPagination_WithStatusFilter tests status filtering
 func TestListOrganizationsWithPagination_WithStatusFilter(t *testing.T) {
 	mockRepo := &mockRepository{
-		listOrgsPaginatedFunc: func(ctx context.Context, limit, offset int, search, status string) ([]OrganizationResponse, int, error) {
-			if status == "active" {
+		listOrgsPaginatedFunc: func(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error) {
+			if listQuery.Status == "active" {
 				return []OrganizationResponse{
 					{ID: "org-1", Name: "Active Org 1", Status: "active"},
 					{ID: "org-2", Name: "Active Org 2", Status: "active"},
@@ -307,6 +308,43 @@ func TestListOrganizationsWithPagination_WithStatusFilter(t *testing.T) {
 	}
 }
 
+// TestListOrganizationsWithPagination_SortAndFilters tests sort and created date filters
+func TestListOrganizationsWithPagination_SortAndFilters(t *testing.T) {
+	var got pagination.Query
+	mockRepo := &mockRepository{
+		listOrgsPaginatedFunc: func(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error) {
+			got = listQuery
+			return []OrganizationResponse{}, 0, nil
+		},
+	}
+
+	service := NewService(mockRepo)
+	principal := &auth.Principal{
+		UserID: "user-1",
+		Roles:  []string{"SUPER_ADMIN"},
+	}
+
+	params := pagination.Params{
+		Page:    1,
+		Limit:   10,
+		Sort:    "name:asc",
+		Filters: map[string]string{"created_after": "2026-01-01"},
+	}
+
+	if _, err := service.ListOrganizationsWithPagination(context.Background(), principal, params); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got.Sort != "name:asc" || got.Filters["created_after"] != "2026-01-01" {
+		t.Errorf("Expected sort and filters to be passed on, got %+v", got)
+	}
+
+	params.Sort = "schema_name"
+	_, err := service.ListOrganizationsWithPagination(context.Background(), principal, params)
+	if !errors.Is(err, pagination.ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort, got %v", err)
+	}
+}
+
 // TestListOrganizationsWithPagination_OrgAdmin tests ORG_ADMIN only sees their org
 func TestListOrganizationsWithPagination_OrgAdmin(t *testing.T) {
 	mockRepo := &mockRepository{
@@ -434,7 +472,7 @@ func TestGetOrganization_OrgAdminForbidden(t *testing.T) {
 func TestUpdateOrganization_SuperAdmin(t *testing.T) {
 	newName := "Updated Org"
 	mockRepo := &mockRepository{
-		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest) (*OrganizationResponse, error) {
+		updateOrgFunc: func(ctx context.Context, id string, req UpdateOrganizationRequest, version int) (*OrganizationResponse, error) {
 			return &OrganizationResponse{
 				ID:     id,
 				Name:   *req.Name,
@@ -454,7 +492,7 @@ func TestUpdateOrganization_SuperAdmin(t *testing.T) {
 		Name: &newName,
 	}
 
-	org, err := service.UpdateOrganization(context.Background(), "org-5", req, principal)
+	org, err := service.UpdateOrganization(context.Background(), "org-5", req, etag.AnyVersion, principal)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -480,7 +518,7 @@ func TestUpdateOrganization_OrgAdminForbidden(t *testing.T) {
 		Name: &newName,
 	}
 
-	org, err := service.UpdateOrganization(context.Background(), "org-2", req, principal)
+	org, err := service.UpdateOrganization(context.Background(), "org-2", req, etag.AnyVersion, principal)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -496,13 +534,13 @@ func TestUpdateOrganization_OrgAdminForbidden(t *testing.T) {
 // TestDeleteOrganization_Success tests successful organization deletion
 func TestDeleteOrganization_Success(t *testing.T) {
 	mockRepo := &mockRepository{
-		deleteOrgFunc: func(ctx context.Context, id string) error {
+		deleteOrgFunc: func(ctx context.Context, id string, version int) error {
 			return nil
 		},
 	}
 
 	service := NewService(mockRepo)
-	err := service.DeleteOrganization(context.Background(), "org-1")
+	err := service.DeleteOrganization(context.Background(), "org-1", etag.AnyVersion)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -512,13 +550,13 @@ func TestDeleteOrganization_Success(t *testing.T) {
 // TestDeleteOrganization_NotFound tests deleting non-existent organization
 func TestDeleteOrganization_NotFound(t *testing.T) {
 	mockRepo := &mockRepository{
-		deleteOrgFunc: func(ctx context.Context, id string) error {
+		deleteOrgFunc: func(ctx context.Context, id string, version int) error {
 			return errors.New("organization not found or already deleted")
 		},
 	}
 
 	service := NewService(mockRepo)
-	err := service.DeleteOrganization(context.Background(), "nonexistent")
+	err := service.DeleteOrganization(context.Background(), "nonexistent", etag.AnyVersion)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -526,13 +564,50 @@ func TestDeleteOrganization_NotFound(t *testing.T) {
 }
 
 // Mock repository for testing
+// TestOrganizationOperationMetrics tests that operations are counted with tenant and outcome
+func TestOrganizationOperationMetrics(t *testing.T) {
+	mockRepo := &mockRepository{
+		createOrgFunc: func(ctx context.Context, req CreateOrganizationRequest) (*OrganizationResponse, error) {
+			return &OrganizationResponse{ID: "org-123", Name: req.Name, SchemaName: "org_testorg_12345678"}, nil
+		},
+	}
+
+	metrics := &mockMetrics{}
+	service := NewServiceWithMetrics(mockRepo, metrics)
+
+	_, _ = service.CreateOrganization(context.Background(), CreateOrganizationRequest{Name: "Test Org"})
+	_, _ = service.CreateOrganization(context.Background(), CreateOrganizationRequest{})
+
+	want := []string{
+		"create org_testorg_12345678 success",
+		"create  error",
+	}
+	if len(metrics.operations) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, metrics.operations)
+	}
+	for i, op := range metrics.operations {
+		if op != want[i] {
+			t.Errorf("Operation %d: expected %q, got %q", i, want[i], op)
+		}
+	}
+}
+
+// mockMetrics records operations as "operation tenant outcome"
+type mockMetrics struct {
+	operations []string
+}
+
+func (m *mockMetrics) RecordOrganizationOperation(ctx context.Context, operation, tenant, outcome string) {
+	m.operations = append(m.operations, operation+" "+tenant+" "+outcome)
+}
+
 type mockRepository struct {
 	createOrgFunc         func(ctx context.Context, req CreateOrganizationRequest) (*OrganizationResponse, error)
 	listOrgsFunc          func(ctx context.Context) ([]OrganizationResponse, error)
-	listOrgsPaginatedFunc func(ctx context.Context, limit, offset int, search, status string) ([]OrganizationResponse, int, error)
+	listOrgsPaginatedFunc func(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error)
 	getOrgFunc            func(ctx context.Context, id string) (*OrganizationResponse, error)
-	updateOrgFunc         func(ctx context.Context, id string, req UpdateOrganizationRequest) (*OrganizationResponse, error)
-	deleteOrgFunc         func(ctx context.Context, id string) error
+	updateOrgFunc         func(ctx context.Context, id string, req UpdateOrganizationRequest, version int) (*OrganizationResponse, error)
+	deleteOrgFunc         func(ctx context.Context, id string, version int) error
 }
 
 func (m *mockRepository) CreateOrganization(ctx context.Context, req CreateOrganizationRequest) (*OrganizationResponse, error) {
@@ -549,9 +624,9 @@ func (m *mockRepository) ListOrganizations(ctx context.Context) ([]OrganizationR
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) ListOrganizationsWithPagination(ctx context.Context, limit, offset int, search, status string) ([]OrganizationResponse, int, error) {
+func (m *mockRepository) ListOrganizationsWithPagination(ctx context.Context, window pagination.Window, listQuery pagination.Query) ([]OrganizationResponse, int, error) {
 	if m.listOrgsPaginatedFunc != nil {
-		return m.listOrgsPaginatedFunc(ctx, limit, offset, search, status)
+		return m.listOrgsPaginatedFunc(ctx, window, listQuery)
 	}
 	return nil, 0, errors.New("not implemented")
 }
@@ -563,16 +638,16 @@ func (m *mockRepository) GetOrganization(ctx context.Context, id string) (*Organ
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest) (*OrganizationResponse, error) {
+func (m *mockRepository) UpdateOrganization(ctx context.Context, id string, req UpdateOrganizationRequest, version int) (*OrganizationResponse, error) {
 	if m.updateOrgFunc != nil {
-		return m.updateOrgFunc(ctx, id, req)
+		return m.updateOrgFunc(ctx, id, req, version)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) DeleteOrganization(ctx context.Context, id string) error {
+func (m *mockRepository) DeleteOrganization(ctx context.Context, id string, version int) error {
 	if m.deleteOrgFunc != nil {
-		return m.deleteOrgFunc(ctx, id)
+		return m.deleteOrgFunc(ctx, id, version)
 	}
 	return errors.New("not implemented")
 }
@@ -0,0 +1,116 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by this service
+var ErrInvalidCursor = apierror.BadRequest("invalid_cursor", "invalid pagination cursor")
+
+// Cursor identifies the last row of a page in the stable list order
+// (created_at DESC, id DESC) shared by all list endpoints. Clients receive it
+// as an opaque string and must not build it themselves.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// Encode returns the opaque string form of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor previously returned in Meta.NextCursor
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Window selects the rows of one list page, either by OFFSET or after a
+// keyset cursor
+type Window struct {
+	Limit      int     // Rows to select
+	Offset     int     // Rows to skip, only used without a cursor
+	After      *Cursor // Last row of the previous page
+	CountTotal bool    // Whether the repository should run COUNT(*)
+}
+
+// KeysetClause returns the condition selecting the rows after the cursor,
+// numbering its placeholders from argIndex. It is empty without a cursor.
+func (w Window) KeysetClause(argIndex int) (string, []interface{}) {
+	if w.After == nil {
+		return "", nil
+	}
+	clause := fmt.Sprintf(` AND (created_at, id) < ($%d::timestamp, $%d::uuid)`, argIndex, argIndex+1)
+	return clause, []interface{}{w.After.CreatedAt, w.After.ID}
+}
+
+// Window validates the parameters and returns the rows to fetch. It selects
+// one row more than the page size so that a next page can be detected without
+// counting. A cursor takes precedence over page; page-based requests always
+// count the total. Cursors follow the default order and cannot be combined
+// with a sort parameter.
+func (p *Params) Window() (Window, error) {
+	p.Validate()
+
+	w := Window{Limit: p.Limit + 1, CountTotal: true}
+	if p.Cursor == "" {
+		w.Offset = p.CalculateOffset()
+		return w, nil
+	}
+
+	if p.Sort != "" {
+		return Window{}, fmt.Errorf("%w: cannot be combined with sort", ErrInvalidCursor)
+	}
+
+	after, err := DecodeCursor(p.Cursor)
+	if err != nil {
+		return Window{}, err
+	}
+	w.After = after
+	w.CountTotal = p.IncludeTotal
+	return w, nil
+}
+
+// NewPage trims the rows fetched for w to the page size and builds the page
+// metadata. key returns the cursor of a row; sorted pages get no next cursor.
+func NewPage[T any](p *Params, w Window, rows []T, totalRecords int, key func(T) Cursor) ([]T, Meta) {
+	hasNext := len(rows) > p.Limit
+	if hasNext {
+		rows = rows[:p.Limit]
+	}
+
+	var meta Meta
+	if w.After == nil {
+		meta = p.CalculateMeta(totalRecords)
+		hasNext = hasNext || meta.HasNext
+	} else {
+		meta = Meta{
+			PerPage:      p.Limit,
+			TotalRecords: totalRecords,
+			HasNext:      hasNext,
+			HasPrevious:  true,
+			Cursor:       p.Cursor,
+			totalOmitted: !w.CountTotal,
+		}
+	}
+
+	if hasNext && len(rows) > 0 && p.Sort == "" {
+		meta.NextCursor = key(rows[len(rows)-1]).Encode()
+	}
+	return rows, meta
+}
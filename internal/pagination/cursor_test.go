@@ -0,0 +1,122 @@
+package pagination
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type row struct {
+	id        string
+	createdAt time.Time
+}
+
+func rowCursor(r row) Cursor {
+	return Cursor{CreatedAt: r.createdAt, ID: r.id}
+}
+
+func rows(n int) []row {
+	start := time.Date(2026, 1, 11, 10, 0, 0, 0, time.UTC)
+	result := make([]row, n)
+	for i := range result {
+		result[i] = row{id: string(rune('a' + i)), createdAt: start.Add(-time.Duration(i) * time.Minute)}
+	}
+	return result
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2026, 1, 11, 10, 0, 0, 123456000, time.UTC), ID: "4f7d8c1e-0000-4000-8000-000000000001"}
+
+	decoded, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(c.CreatedAt) || decoded.ID != c.ID {
+		t.Errorf("Expected %+v, got %+v", c, decoded)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"not base64!", "e30", Cursor{ID: "x"}.Encode()} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q): expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	p := Params{Page: 3, Limit: 10}
+	w, err := p.Window()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if w.Limit != 11 || w.Offset != 20 || w.After != nil || !w.CountTotal {
+		t.Errorf("Unexpected offset window: %+v", w)
+	}
+
+	p = Params{Page: 3, Limit: 10, Cursor: rowCursor(rows(1)[0]).Encode()}
+	w, err = p.Window()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if w.Offset != 0 || w.After == nil || w.CountTotal {
+		t.Errorf("Unexpected cursor window: %+v", w)
+	}
+
+	clause, args := w.KeysetClause(3)
+	if clause != ` AND (created_at, id) < ($3::timestamp, $4::uuid)` || len(args) != 2 {
+		t.Errorf("Unexpected keyset clause %q with %v", clause, args)
+	}
+
+	p.Cursor = "garbage"
+	if _, err := p.Window(); err != ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestNewPage_Offset(t *testing.T) {
+	p := Params{Page: 1, Limit: 2}
+	w, _ := p.Window()
+
+	page, meta := NewPage(&p, w, rows(3), 5, rowCursor)
+
+	if len(page) != 2 {
+		t.Fatalf("Expected the extra row to be trimmed, got %d rows", len(page))
+	}
+	if meta.TotalRecords != 5 || meta.TotalPages != 3 || !meta.HasNext {
+		t.Errorf("Unexpected meta: %+v", meta)
+	}
+	if meta.NextCursor != rowCursor(page[1]).Encode() {
+		t.Errorf("Expected next cursor after the last row on the page, got %q", meta.NextCursor)
+	}
+}
+
+func TestNewPage_Cursor(t *testing.T) {
+	all := rows(3)
+	p := Params{Limit: 2, Cursor: rowCursor(all[0]).Encode()}
+	w, _ := p.Window()
+
+	page, meta := NewPage(&p, w, all[1:], 0, rowCursor)
+	if len(page) != 2 || meta.HasNext || meta.NextCursor != "" {
+		t.Errorf("Expected a final page without next cursor, got %d rows and %+v", len(page), meta)
+	}
+
+	data, _ := json.Marshal(meta)
+	for _, field := range []string{"total_records", "current_page", "total_pages", "next_cursor"} {
+		if strings.Contains(string(data), field) {
+			t.Errorf("Expected %s to be omitted from %s", field, data)
+		}
+	}
+	if !strings.Contains(string(data), `"cursor":"`) {
+		t.Errorf("Expected the request cursor in %s", data)
+	}
+
+	p.IncludeTotal = true
+	w, _ = p.Window()
+	_, meta = NewPage(&p, w, all[1:], 3, rowCursor)
+	data, _ = json.Marshal(meta)
+	if !strings.Contains(string(data), `"total_records":3`) {
+		t.Errorf("Expected total_records when requested, got %s", data)
+	}
+}
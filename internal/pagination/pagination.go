@@ -1,6 +1,7 @@
 package pagination
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 )
@@ -18,16 +19,57 @@ type Params struct {
 	Limit  int    `json:"limit"`  // Number of items per page
 	Search string `json:"search"` // Search query string
 	Status string `json:"status"` // Status filter (e.g., "active", "inactive", "all")
+
+	// Sort orders the list, e.g. "last_name:asc,created_at:desc". Each list
+	// endpoint whitelists its sort keys.
+	Sort string `json:"sort,omitempty"`
+
+	// Filters holds the remaining query parameters keyed by name. Each list
+	// endpoint decides which keys it supports; unknown keys are ignored.
+	Filters map[string]string `json:"filters,omitempty"`
+
+	// Cursor continues a list after the page that returned it as next_cursor.
+	// Cursor requests skip the total count unless IncludeTotal is set.
+	Cursor       string `json:"cursor,omitempty"`
+	IncludeTotal bool   `json:"include_total,omitempty"`
 }
 
-// Meta contains pagination metadata for responses
+// Meta contains pagination metadata for responses. Cursor pages have no page
+// number or page count, and only carry total_records when it was requested.
 type Meta struct {
-	CurrentPage  int  `json:"current_page"`
-	PerPage      int  `json:"per_page"`
-	TotalPages   int  `json:"total_pages"`
-	TotalRecords int  `json:"total_records"`
-	HasNext      bool `json:"has_next"`
-	HasPrevious  bool `json:"has_previous"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PerPage      int    `json:"per_page"`
+	TotalPages   int    `json:"total_pages,omitempty"`
+	TotalRecords int    `json:"total_records"`
+	HasNext      bool   `json:"has_next"`
+	HasPrevious  bool   `json:"has_previous"`
+	Cursor       string `json:"cursor,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+
+	totalOmitted bool
+}
+
+// MarshalJSON leaves out total_records when the total was not counted
+func (m Meta) MarshalJSON() ([]byte, error) {
+	type plain Meta
+	if !m.totalOmitted {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		TotalRecords *int `json:"total_records,omitempty"`
+	}{plain: plain(m)})
+}
+
+// reservedParams are the query parameters that are not filters
+var reservedParams = map[string]bool{
+	"page":          true,
+	"limit":         true,
+	"search":        true,
+	"status":        true,
+	"sort":          true,
+	"cursor":        true,
+	"include_total": true,
 }
 
 // ParseParams extracts and validates pagination parameters from HTTP request
@@ -59,11 +101,31 @@ func ParseParams(r *http.Request) Params {
 	// Parse status parameter
 	status := r.URL.Query().Get("status")
 
+	// Parse cursor parameters
+	cursor := r.URL.Query().Get("cursor")
+	includeTotal, _ := strconv.ParseBool(r.URL.Query().Get("include_total"))
+
+	// Collect the remaining parameters as filters
+	var filters map[string]string
+	for name, values := range r.URL.Query() {
+		if reservedParams[name] || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[name] = values[0]
+	}
+
 	return Params{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
-		Status: status,
+		Page:         page,
+		Limit:        limit,
+		Search:       search,
+		Status:       status,
+		Sort:         r.URL.Query().Get("sort"),
+		Filters:      filters,
+		Cursor:       cursor,
+		IncludeTotal: includeTotal,
 	}
 }
 
@@ -101,4 +163,3 @@ func (p *Params) CalculateMeta(totalRecords int) Meta {
 		HasPrevious:  p.Page > 1,
 	}
 }
-
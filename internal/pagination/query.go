@@ -0,0 +1,227 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// MaxSortFields caps the number of keys in a sort parameter
+const MaxSortFields = 3
+
+var (
+	// ErrInvalidSort is returned for sort parameters naming unknown fields or directions
+	ErrInvalidSort = apierror.BadRequest("invalid_sort", "invalid sort parameter")
+	// ErrInvalidFilter is returned for filter values that do not match the filter type
+	ErrInvalidFilter = apierror.BadRequest("invalid_filter", "invalid filter parameter")
+)
+
+// IsInvalidParams reports whether err was caused by invalid list parameters
+// (cursor, sort or filter) rather than by a failing query
+func IsInvalidParams(err error) bool {
+	_, ok := ErrorType(err)
+	return ok
+}
+
+// ErrorType returns the API error type for invalid list parameters
+func ErrorType(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrInvalidCursor):
+		return "invalid_cursor", true
+	case errors.Is(err, ErrInvalidSort):
+		return "invalid_sort", true
+	case errors.Is(err, ErrInvalidFilter):
+		return "invalid_filter", true
+	}
+	return "", false
+}
+
+// SortField is one key of a sort parameter
+type SortField struct {
+	Name string
+	Desc bool
+}
+
+// ParseSort parses a sort parameter of the form "last_name:asc,created_at:desc".
+// The direction defaults to ascending. Field names are checked by Fields.
+func ParseSort(s string) ([]SortField, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) > MaxSortFields {
+		return nil, fmt.Errorf("%w: at most %d fields", ErrInvalidSort, MaxSortFields)
+	}
+
+	fields := make([]SortField, 0, len(parts))
+	for _, part := range parts {
+		name, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		field := SortField{Name: name}
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			return nil, fmt.Errorf("%w: unknown direction %q", ErrInvalidSort, dir)
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%w: empty field", ErrInvalidSort)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// FilterType determines how a filter value is parsed and compared
+type FilterType int
+
+const (
+	// FilterText compares case-insensitively for equality
+	FilterText FilterType = iota
+	// FilterDate takes a YYYY-MM-DD value
+	FilterDate
+	// FilterBool takes true or false
+	FilterBool
+)
+
+// Filter maps a filter parameter onto a column comparison
+type Filter struct {
+	Column string
+	Op     string // =, <, <=, > or >=
+	Type   FilterType
+}
+
+// Fields whitelists the sort keys and filters of one list endpoint. Keys are
+// the API names; values hold the SQL they translate to, so client input never
+// reaches the query text.
+type Fields struct {
+	Sort    map[string]string // Sort key -> column
+	Filters map[string]Filter // Filter parameter -> comparison
+}
+
+// Check validates the sort and filter values of p. Filters the endpoint does
+// not know are ignored, like any other unknown query parameter.
+func (f Fields) Check(p Params) error {
+	sortFields, err := ParseSort(p.Sort)
+	if err != nil {
+		return err
+	}
+	for _, field := range sortFields {
+		if _, ok := f.Sort[field.Name]; !ok {
+			return fmt.Errorf("%w: cannot sort by %q", ErrInvalidSort, field.Name)
+		}
+	}
+
+	for name, value := range p.Filters {
+		filter, ok := f.Filters[name]
+		if !ok {
+			continue
+		}
+		if _, err := filter.parse(value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidFilter, name, err)
+		}
+	}
+	return nil
+}
+
+// Where returns the conditions for the known filters in filters, each
+// prefixed with AND and numbering its placeholders from argIndex. Values that
+// fail to parse are skipped; call Check first to reject them.
+func (f Fields) Where(filters map[string]string, argIndex int) (string, []interface{}) {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		if _, ok := f.Filters[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var clause strings.Builder
+	var args []interface{}
+	for _, name := range names {
+		filter := f.Filters[name]
+		value, err := filter.parse(filters[name])
+		if err != nil {
+			continue
+		}
+
+		switch filter.Type {
+		case FilterText:
+			fmt.Fprintf(&clause, ` AND lower(%s) %s lower($%d)`, filter.Column, filter.Op, argIndex)
+		case FilterDate:
+			fmt.Fprintf(&clause, ` AND %s %s $%d::date`, filter.Column, filter.Op, argIndex)
+		default:
+			fmt.Fprintf(&clause, ` AND %s %s $%d`, filter.Column, filter.Op, argIndex)
+		}
+		args = append(args, value)
+		argIndex++
+	}
+	return clause.String(), args
+}
+
+// OrderBy returns the ORDER BY list for a sort parameter. Without one it is the
+// default list order (created_at DESC, id DESC); otherwise id breaks ties so
+// pages stay stable. Unknown keys are dropped; call Check first to reject them.
+func (f Fields) OrderBy(sortParam string) string {
+	sortFields, _ := ParseSort(sortParam)
+
+	var terms []string
+	for _, field := range sortFields {
+		column, ok := f.Sort[field.Name]
+		if !ok {
+			continue
+		}
+		if field.Desc {
+			terms = append(terms, column+" DESC NULLS LAST")
+		} else {
+			terms = append(terms, column+" ASC NULLS LAST")
+		}
+	}
+	if len(terms) == 0 {
+		return "created_at DESC, id DESC"
+	}
+	return strings.Join(append(terms, "id ASC"), ", ")
+}
+
+// parse converts a filter value to its query argument
+func (f Filter) parse(value string) (interface{}, error) {
+	switch f.Type {
+	case FilterDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return nil, fmt.Errorf("expected a date as YYYY-MM-DD")
+		}
+		return value, nil
+	case FilterBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+// Query carries the search, filters and sort of a list request to a repository
+type Query struct {
+	Search  string
+	Status  string
+	Sort    string
+	Filters map[string]string
+}
+
+// Query returns the list query of p
+func (p Params) Query() Query {
+	return Query{
+		Search:  p.Search,
+		Status:  p.Status,
+		Sort:    p.Sort,
+		Filters: p.Filters,
+	}
+}
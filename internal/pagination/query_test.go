@@ -0,0 +1,93 @@
+package pagination
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+var testFields = Fields{
+	Sort: map[string]string{"last_name": "last_name", "created_at": "created_at"},
+	Filters: map[string]Filter{
+		"careplan_type": {Column: "careplan_type", Op: "=", Type: FilterText},
+		"created_after": {Column: "created_at", Op: ">=", Type: FilterDate},
+		"is_active":     {Column: "is_active", Op: "=", Type: FilterBool},
+	},
+}
+
+func TestParseSort(t *testing.T) {
+	fields, err := ParseSort("last_name, created_at:DESC")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fields) != 2 || fields[0] != (SortField{Name: "last_name"}) || fields[1] != (SortField{Name: "created_at", Desc: true}) {
+		t.Errorf("Unexpected sort fields: %+v", fields)
+	}
+
+	for _, s := range []string{"last_name:up", ":asc", "a,b,c,d"} {
+		if _, err := ParseSort(s); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("ParseSort(%q): expected ErrInvalidSort, got %v", s, err)
+		}
+	}
+}
+
+func TestFieldsCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   error
+	}{
+		{"valid", Params{Sort: "last_name", Filters: map[string]string{"created_after": "2026-01-01", "is_active": "false"}}, nil},
+		{"unknown filter ignored", Params{Filters: map[string]string{"size": "20"}}, nil},
+		{"unknown sort key", Params{Sort: "medical_notes"}, ErrInvalidSort},
+		{"bad date", Params{Filters: map[string]string{"created_after": "01-01-2026"}}, ErrInvalidFilter},
+		{"bad bool", Params{Filters: map[string]string{"is_active": "yes please"}}, ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := testFields.Check(tt.params); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestFieldsWhere(t *testing.T) {
+	where, args := testFields.Where(map[string]string{
+		"is_active":     "true",
+		"careplan_type": "basic",
+		"created_after": "2026-01-01",
+		"unknown":       "x",
+	}, 2)
+
+	want := ` AND lower(careplan_type) = lower($2) AND created_at >= $3::date AND is_active = $4`
+	if where != want {
+		t.Errorf("Expected %q, got %q", want, where)
+	}
+	if len(args) != 3 || args[0] != "basic" || args[1] != "2026-01-01" || args[2] != true {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestFieldsOrderBy(t *testing.T) {
+	if got := testFields.OrderBy(""); got != "created_at DESC, id DESC" {
+		t.Errorf("Unexpected default order %q", got)
+	}
+	if got := testFields.OrderBy("last_name:desc,created_at"); got != "last_name DESC NULLS LAST, created_at ASC NULLS LAST, id ASC" {
+		t.Errorf("Unexpected order %q", got)
+	}
+}
+
+func TestParseParams_SortAndFilters(t *testing.T) {
+	r := httptest.NewRequest("GET", "/organization/patients?page=2&sort=last_name&careplan_type=basic&created_after=&search=an", nil)
+
+	p := ParseParams(r)
+
+	if p.Sort != "last_name" || p.Search != "an" {
+		t.Errorf("Unexpected params: %+v", p)
+	}
+	if len(p.Filters) != 1 || p.Filters["careplan_type"] != "basic" {
+		t.Errorf("Expected only the non-empty filter, got %v", p.Filters)
+	}
+}
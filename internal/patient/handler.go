@@ -3,10 +3,16 @@ package patient
 // Development Team: Muhammad Faizan, Roozbeh Kouchaki, Fatemehalsadat Sabaghjafari, Dipika Bhandari
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/gorilla/mux"
 )
@@ -40,7 +46,7 @@ type PatientListResponse struct {
 func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -61,17 +67,18 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -80,34 +87,25 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
 
 	var req CreatePatientRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
-		return
-	}
-
-	if req.FirstName == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "First name is required")
-		return
-	}
-
-	if req.LastName == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Last name is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
 		return
 	}
 
 	patient, err := h.service.CreatePatient(r.Context(), schemaName, orgID, req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "creation_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to create patient")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, patient.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(PatientSuccessResponse{
 		Success: true,
@@ -119,7 +117,7 @@ func (h *Handler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListPatients(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -140,17 +138,18 @@ func (h *Handler) ListPatients(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -159,7 +158,7 @@ func (h *Handler) ListPatients(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
@@ -170,7 +169,33 @@ func (h *Handler) ListPatients(w http.ResponseWriter, r *http.Request) {
 	// Get paginated patients
 	response, err := h.service.ListPatientsWithPagination(r.Context(), schemaName, params)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "fetch_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to list patients")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SearchPatients handles GET /organization/patients/search?q=...
+func (h *Handler) SearchPatients(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		return
+	}
+
+	_, schemaName, ok := resolveTenant(w, r, principal, h.schemaLookup)
+	if !ok {
+		return
+	}
+
+	params := pagination.ParseParams(r)
+	params.Search = r.URL.Query().Get("q")
+
+	response, err := h.service.SearchPatients(r.Context(), schemaName, params)
+	if err != nil {
+		apierror.Write(w, r, err, "Failed to search patients")
 		return
 	}
 
@@ -181,7 +206,7 @@ func (h *Handler) ListPatients(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListActivePatients(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -202,17 +227,18 @@ func (h *Handler) ListActivePatients(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -221,7 +247,7 @@ func (h *Handler) ListActivePatients(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
@@ -232,7 +258,7 @@ func (h *Handler) ListActivePatients(w http.ResponseWriter, r *http.Request) {
 	// Get paginated active patients
 	response, err := h.service.ListActivePatientsWithPagination(r.Context(), schemaName, params)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "fetch_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to list patients")
 		return
 	}
 
@@ -243,7 +269,7 @@ func (h *Handler) ListActivePatients(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -264,17 +290,18 @@ func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -283,7 +310,7 @@ func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
@@ -291,17 +318,18 @@ func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 	if id == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Patient ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Patient ID is required")
 		return
 	}
 
 	patient, err := h.service.GetPatient(r.Context(), schemaName, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "not_found", err.Error())
+		apierror.Write(w, r, err, "Failed to fetch patient")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, patient.Version)
 	json.NewEncoder(w).Encode(PatientSuccessResponse{
 		Success: true,
 		Message: "Patient retrieved successfully",
@@ -312,7 +340,7 @@ func (h *Handler) GetPatient(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetMyPatient(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -333,17 +361,18 @@ func (h *Handler) GetMyPatient(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -352,18 +381,19 @@ func (h *Handler) GetMyPatient(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
 
 	patient, err := h.service.GetMyPatient(r.Context(), schemaName, principal.UserID)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "not_found", err.Error())
+		apierror.Write(w, r, err, "Failed to fetch patient")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, patient.Version)
 	json.NewEncoder(w).Encode(PatientSuccessResponse{
 		Success: true,
 		Message: "Patient retrieved successfully",
@@ -374,7 +404,7 @@ func (h *Handler) GetMyPatient(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -395,17 +425,18 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -414,7 +445,7 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
@@ -422,23 +453,30 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 	if id == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Patient ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Patient ID is required")
 		return
 	}
 
 	var req UpdatePatientRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
+		apierror.Respond(w, r, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: "+err.Error())
+		return
+	}
+
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		apierror.Write(w, r, err, "")
 		return
 	}
 
-	patient, err := h.service.UpdatePatient(r.Context(), schemaName, id, req)
+	patient, err := h.service.UpdatePatient(r.Context(), schemaName, id, req, version)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "update_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to update patient")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, patient.Version)
 	json.NewEncoder(w).Encode(PatientSuccessResponse{
 		Success: true,
 		Message: "Patient updated successfully",
@@ -449,7 +487,7 @@ func (h *Handler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeletePatient(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
 		return
 	}
 
@@ -470,17 +508,18 @@ func (h *Handler) DeletePatient(w http.ResponseWriter, r *http.Request) {
 		// SUPER_ADMIN must provide X-Organization-ID header
 		orgID = r.Header.Get("X-Organization-ID")
 		if orgID == "" {
-			respondError(w, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org", "X-Organization-ID header is required for SUPER_ADMIN")
 			return
 		}
 
 		schemaName, err = h.schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema: "+err.Error())
+			slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+			apierror.Respond(w, r, http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
 			return
 		}
 		if schemaName == "" {
-			respondError(w, http.StatusNotFound, "org_not_found", "Organization schema not found")
+			apierror.Respond(w, r, http.StatusNotFound, "org_not_found", "Organization schema not found")
 			return
 		}
 	} else {
@@ -489,7 +528,7 @@ func (h *Handler) DeletePatient(w http.ResponseWriter, r *http.Request) {
 		schemaName = principal.OrgSchemaName
 
 		if orgID == "" || schemaName == "" {
-			respondError(w, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
+			apierror.Respond(w, r, http.StatusBadRequest, "missing_org_info", "Organization information not found in token")
 			return
 		}
 	}
@@ -497,13 +536,19 @@ func (h *Handler) DeletePatient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 	if id == "" {
-		respondError(w, http.StatusBadRequest, "validation_error", "Patient ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "validation_error", "Patient ID is required")
+		return
+	}
+
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		apierror.Write(w, r, err, "")
 		return
 	}
 
-	err = h.service.DeletePatient(r.Context(), schemaName, orgID, id)
+	err = h.service.DeletePatient(r.Context(), schemaName, orgID, id, version)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "deletion_failed", err.Error())
+		apierror.Write(w, r, err, "Failed to delete patient")
 		return
 	}
 
@@ -514,11 +559,103 @@ func (h *Handler) DeletePatient(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func respondError(w http.ResponseWriter, statusCode int, errorType, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error":   errorType,
-		"message": message,
-	})
+// resolveTenant determines the organization and schema a request operates on.
+// SUPER_ADMIN must name the organization via X-Organization-ID; other roles use
+// the organization from their token. On failure the error response is written.
+func resolveTenant(w http.ResponseWriter, r *http.Request, principal *auth.Principal, schemaLookup SchemaLookup) (string, string, bool) {
+	orgID, schemaName, err := tenantOf(r, principal, schemaLookup)
+	if err != nil {
+		apierror.Write(w, r, err, "Failed to lookup organization schema")
+		return "", "", false
+	}
+	return orgID, schemaName, true
+}
+
+// tenantOf is resolveTenant without writing the error response
+func tenantOf(r *http.Request, principal *auth.Principal, schemaLookup SchemaLookup) (string, string, error) {
+	orgID, err := principal.TargetOrganization(r.Header.Get(auth.OrganizationHeader))
+	if errors.Is(err, auth.ErrOtherOrganization) {
+		return "", "", apierror.Forbidden(apierror.CodeForbidden, "Cannot access a different organization")
+	}
+	if err != nil {
+		return "", "", apierror.BadRequest("missing_org", "X-Organization-ID header or an organization in the token is required")
+	}
+	if orgID == principal.OrgID && principal.OrgSchemaName != "" {
+		return orgID, principal.OrgSchemaName, nil
+	}
+
+	schemaName, err := schemaLookup.GetSchemaNameByOrgID(r.Context(), orgID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to look up organization schema", "organization_id", orgID, "error", err)
+		return "", "", apierror.New(http.StatusInternalServerError, "schema_lookup_failed", "Failed to lookup organization schema")
+	}
+	if schemaName == "" {
+		return "", "", apierror.NotFound("org_not_found", "Organization schema not found")
+	}
+	return orgID, schemaName, nil
+}
+
+// schemaOwner returns the organization that owns schemaName, the organization
+// policies compare with the principal's for records stored in that schema
+func schemaOwner(ctx context.Context, schemaName string, schemaLookup SchemaLookup) (string, error) {
+	orgID, err := schemaLookup.GetOrgIDBySchemaName(ctx, schemaName)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up schema owner: %w", err)
+	}
+	if orgID == "" {
+		return "", apierror.NotFound("org_not_found", "Organization not found")
+	}
+	return orgID, nil
+}
+
+// PolicyResource resolves the patient of /organization/patients/{id} for
+// policy evaluation. The organization is the owner of the schema the patient
+// is stored in, the owner the patient's own Keycloak account and the assigned
+// caregivers those with care sessions for the patient.
+func (h *Handler) PolicyResource(r *http.Request) (auth.Resource, error) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return auth.Resource{}, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+	}
+	_, schemaName, err := tenantOf(r, principal, h.schemaLookup)
+	if err != nil {
+		return auth.Resource{}, err
+	}
+
+	patient, err := h.service.GetPatient(r.Context(), schemaName, mux.Vars(r)["id"])
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	orgID, err := schemaOwner(r.Context(), schemaName, h.schemaLookup)
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	return auth.Resource{
+		Type:    "patient",
+		ID:      patient.ID,
+		OrgID:   orgID,
+		OwnerID: patient.KeycloakUserID,
+		Assigned: func(ctx context.Context) ([]string, error) {
+			return h.service.ListAssignedCaregivers(ctx, schemaName, patient.ID)
+		},
+	}, nil
+}
+
+// PolicyCollection resolves the patients of the requested organization for
+// policy evaluation of list and search endpoints. It has no owner and no
+// assigned caregivers, so rules that require either deny listing.
+func (h *Handler) PolicyCollection(r *http.Request) (auth.Resource, error) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return auth.Resource{}, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+	}
+	_, schemaName, err := tenantOf(r, principal, h.schemaLookup)
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	orgID, err := schemaOwner(r.Context(), schemaName, h.schemaLookup)
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	return auth.Resource{Type: "patient", OrgID: orgID}, nil
 }
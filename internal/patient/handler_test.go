@@ -20,11 +20,13 @@ type mockService struct {
 	createPatientFunc                    func(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error)
 	getPatientFunc                       func(ctx context.Context, schemaName, id string) (*PatientResponse, error)
 	getMyPatientFunc                     func(ctx context.Context, schemaName string, keycloakUserID string) (*PatientResponse, error)
+	listAssignedCaregiversFunc           func(ctx context.Context, schemaName, patientID string) ([]string, error)
 	listPatientsFunc                     func(ctx context.Context, schemaName string) ([]PatientResponse, error)
 	listPatientsWithPaginationFunc       func(ctx context.Context, schemaName string, params pagination.Params) (*PaginatedPatientListResponse, error)
 	listActivePatientsWithPaginationFunc func(ctx context.Context, schemaName string, params pagination.Params) (*PaginatedPatientListResponse, error)
-	updatePatientFunc                    func(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error)
-	deletePatientFunc                    func(ctx context.Context, schemaName, orgID, id string) error
+	searchPatientsFunc                   func(ctx context.Context, schemaName string, params pagination.Params) (*PatientSearchResponse, error)
+	updatePatientFunc                    func(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error)
+	deletePatientFunc                    func(ctx context.Context, schemaName, orgID, id string, version int) error
 }
 
 func (m *mockService) CreatePatient(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error) {
@@ -48,6 +50,13 @@ func (m *mockService) GetMyPatient(ctx context.Context, schemaName string, keycl
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockService) ListAssignedCaregivers(ctx context.Context, schemaName, patientID string) ([]string, error) {
+	if m.listAssignedCaregiversFunc != nil {
+		return m.listAssignedCaregiversFunc(ctx, schemaName, patientID)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockService) ListPatients(ctx context.Context, schemaName string) ([]PatientResponse, error) {
 	if m.listPatientsFunc != nil {
 		return m.listPatientsFunc(ctx, schemaName)
@@ -69,16 +78,23 @@ func (m *mockService) ListActivePatientsWithPagination(ctx context.Context, sche
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) UpdatePatient(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error) {
+func (m *mockService) SearchPatients(ctx context.Context, schemaName string, params pagination.Params) (*PatientSearchResponse, error) {
+	if m.searchPatientsFunc != nil {
+		return m.searchPatientsFunc(ctx, schemaName, params)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) UpdatePatient(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
 	if m.updatePatientFunc != nil {
-		return m.updatePatientFunc(ctx, schemaName, id, req)
+		return m.updatePatientFunc(ctx, schemaName, id, req, version)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) DeletePatient(ctx context.Context, schemaName, orgID, id string) error {
+func (m *mockService) DeletePatient(ctx context.Context, schemaName, orgID, id string, version int) error {
 	if m.deletePatientFunc != nil {
-		return m.deletePatientFunc(ctx, schemaName, orgID, id)
+		return m.deletePatientFunc(ctx, schemaName, orgID, id, version)
 	}
 	return errors.New("not implemented")
 }
@@ -86,6 +102,7 @@ func (m *mockService) DeletePatient(ctx context.Context, schemaName, orgID, id s
 // mockSchemaLookup implements SchemaLookup for testing
 type mockSchemaLookup struct {
 	getSchemaNameByOrgIDFunc func(ctx context.Context, orgID string) (string, error)
+	getOrgIDBySchemaNameFunc func(ctx context.Context, schemaName string) (string, error)
 }
 
 func (m *mockSchemaLookup) GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error) {
@@ -95,6 +112,13 @@ func (m *mockSchemaLookup) GetSchemaNameByOrgID(ctx context.Context, orgID strin
 	return "", errors.New("not implemented")
 }
 
+func (m *mockSchemaLookup) GetOrgIDBySchemaName(ctx context.Context, schemaName string) (string, error) {
+	if m.getOrgIDBySchemaNameFunc != nil {
+		return m.getOrgIDBySchemaNameFunc(ctx, schemaName)
+	}
+	return "", errors.New("not implemented")
+}
+
 // Test CreatePatient Handler
 
 func TestHandlerCreatePatient_Success(t *testing.T) {
@@ -238,7 +262,12 @@ func TestHandlerCreatePatient_InvalidJSON(t *testing.T) {
 }
 
 func TestHandlerCreatePatient_MissingFirstName(t *testing.T) {
-	handler := NewHandler(&mockService{}, &mockSchemaLookup{})
+	mockSvc := &mockService{
+		createPatientFunc: func(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error) {
+			return nil, req.Validate()
+		},
+	}
+	handler := NewHandler(mockSvc, &mockSchemaLookup{})
 
 	reqBody := CreatePatientRequest{
 		Username:    "patient1",
@@ -431,6 +460,48 @@ func TestHandlerListActivePatients_Success(t *testing.T) {
 	}
 }
 
+func TestHandlerSearchPatients(t *testing.T) {
+	var gotSearch string
+	mockSvc := &mockService{
+		searchPatientsFunc: func(ctx context.Context, schemaName string, params pagination.Params) (*PatientSearchResponse, error) {
+			if params.Search == "" {
+				return nil, ErrEmptySearchQuery
+			}
+			gotSearch = params.Search
+			return &PatientSearchResponse{Success: true, Query: params.Search, Patients: []PatientSearchResult{}}, nil
+		},
+	}
+
+	handler := NewHandler(mockSvc, &mockSchemaLookup{})
+	principal := &auth.Principal{
+		UserID:        "admin-123",
+		Roles:         []string{"ORG_ADMIN"},
+		OrgID:         "org-123",
+		OrgSchemaName: "org_123",
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/organization/patients/search?q=Jansen", nil)
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+	rr := httptest.NewRecorder()
+	handler.SearchPatients(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+	if gotSearch != "Jansen" {
+		t.Errorf("Expected query Jansen, got %q", gotSearch)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/organization/patients/search", nil)
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+	rr = httptest.NewRecorder()
+	handler.SearchPatients(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a query, got %d", rr.Code)
+	}
+}
+
 // Test GetPatient Handler
 
 func TestHandlerGetPatient_Success(t *testing.T) {
@@ -482,7 +553,7 @@ func TestHandlerGetPatient_Success(t *testing.T) {
 func TestHandlerGetPatient_NotFound(t *testing.T) {
 	mockSvc := &mockService{
 		getPatientFunc: func(ctx context.Context, schemaName, id string) (*PatientResponse, error) {
-			return nil, errors.New("patient not found")
+			return nil, ErrPatientNotFound
 		},
 	}
 
@@ -512,7 +583,7 @@ func TestHandlerGetPatient_NotFound(t *testing.T) {
 func TestHandlerUpdatePatient_Success(t *testing.T) {
 	firstName := "Updated"
 	mockSvc := &mockService{
-		updatePatientFunc: func(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error) {
+		updatePatientFunc: func(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
 			return &PatientResponse{
 				ID:        id,
 				FirstName: *req.FirstName,
@@ -584,7 +655,7 @@ func TestHandlerUpdatePatient_InvalidJSON(t *testing.T) {
 
 func TestHandlerDeletePatient_Success(t *testing.T) {
 	mockSvc := &mockService{
-		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string) error {
+		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string, version int) error {
 			return nil
 		},
 	}
@@ -621,7 +692,7 @@ func TestHandlerDeletePatient_Success(t *testing.T) {
 
 func TestHandlerDeletePatient_ServiceError(t *testing.T) {
 	mockSvc := &mockService{
-		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string) error {
+		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string, version int) error {
 			return errors.New("deletion failed")
 		},
 	}
@@ -646,3 +717,56 @@ func TestHandlerDeletePatient_ServiceError(t *testing.T) {
 		t.Errorf("Expected status 500, got %d", rr.Code)
 	}
 }
+
+// Test PolicyResource
+
+func TestHandlerPolicyResource(t *testing.T) {
+	mockSvc := &mockService{
+		getPatientFunc: func(ctx context.Context, schemaName, id string) (*PatientResponse, error) {
+			if schemaName != "org_123" {
+				t.Errorf("Expected schema org_123, got %s", schemaName)
+			}
+			if id == "missing" {
+				return nil, ErrPatientNotFound
+			}
+			return &PatientResponse{ID: id, KeycloakUserID: "kc-123"}, nil
+		},
+		listAssignedCaregiversFunc: func(ctx context.Context, schemaName, patientID string) ([]string, error) {
+			return []string{"kc-caregiver-1"}, nil
+		},
+	}
+	// The token names org-123 but its schema belongs to org-456
+	handler := NewHandler(mockSvc, &mockSchemaLookup{
+		getOrgIDBySchemaNameFunc: func(ctx context.Context, schemaName string) (string, error) {
+			return "org-456", nil
+		},
+	})
+
+	resolve := func(id string) (auth.Resource, error) {
+		req := httptest.NewRequest(http.MethodGet, "/organization/patients/"+id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		req = req.WithContext(auth.ContextWithPrincipal(req.Context(), &auth.Principal{
+			UserID:        "kc-123",
+			Roles:         []string{"PATIENT"},
+			OrgID:         "org-123",
+			OrgSchemaName: "org_123",
+		}))
+		return handler.PolicyResource(req)
+	}
+
+	res, err := resolve("patient-123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.ID != "patient-123" || res.OrgID != "org-456" || res.OwnerID != "kc-123" {
+		t.Errorf("Expected the organization of the stored patient, got %+v", res)
+	}
+	assigned, err := res.Assigned(context.Background())
+	if err != nil || len(assigned) != 1 || assigned[0] != "kc-caregiver-1" {
+		t.Errorf("Expected assigned caregiver, got %v, %v", assigned, err)
+	}
+
+	if _, err := resolve("missing"); !errors.Is(err, ErrPatientNotFound) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
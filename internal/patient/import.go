@@ -0,0 +1,277 @@
+package patient
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/validation"
+)
+
+// Supported bulk import formats
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+// Bulk import limits
+const (
+	MaxImportRows  = 1000
+	MaxImportBytes = 5 << 20 // 5 MB
+)
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported import format, use csv or jsonl")
+	ErrEmptyImport             = errors.New("import file contains no rows")
+	ErrTooManyImportRows       = fmt.Errorf("import file exceeds the maximum of %d rows", MaxImportRows)
+)
+
+// ImportRow is one parsed input row. Row is the 1-based position of the record
+// in the file, not counting the CSV header.
+type ImportRow struct {
+	Row     int
+	Request CreatePatientRequest
+	Errors  []string
+}
+
+// importColumns maps normalized CSV header names onto CreatePatientRequest fields.
+// Header names match the JSON field names of CreatePatientRequest; case, spaces,
+// dashes and underscores are ignored, so "firstName" and "first_name" are equivalent.
+var importColumns = map[string]func(req *CreatePatientRequest, value string) error{
+	"username":              func(req *CreatePatientRequest, v string) error { req.Username = v; return nil },
+	"temporarypassword":     func(req *CreatePatientRequest, v string) error { req.TemporaryPassword = v; return nil },
+	"firstname":             func(req *CreatePatientRequest, v string) error { req.FirstName = v; return nil },
+	"lastname":              func(req *CreatePatientRequest, v string) error { req.LastName = v; return nil },
+	"email":                 func(req *CreatePatientRequest, v string) error { req.Email = v; return nil },
+	"phonenumber":           func(req *CreatePatientRequest, v string) error { req.PhoneNumber = v; return nil },
+	"dateofbirth":           func(req *CreatePatientRequest, v string) error { req.DateOfBirth = v; return nil },
+	"address":               func(req *CreatePatientRequest, v string) error { req.Address = v; return nil },
+	"emergencycontactname":  func(req *CreatePatientRequest, v string) error { req.EmergencyContactName = v; return nil },
+	"emergencycontactphone": func(req *CreatePatientRequest, v string) error { req.EmergencyContactPhone = v; return nil },
+	"medicalnotes":          func(req *CreatePatientRequest, v string) error { req.MedicalNotes = v; return nil },
+	"careplantype":          func(req *CreatePatientRequest, v string) error { req.CareplanType = v; return nil },
+	"careplanfrequency":     func(req *CreatePatientRequest, v string) error { req.CareplanFrequency = v; return nil },
+	"sendresetemail": func(req *CreatePatientRequest, v string) error {
+		if v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("sendResetEmail must be true or false")
+		}
+		req.SendResetEmail = b
+		return nil
+	},
+}
+
+// ParseImport reads every row of a CSV or JSON Lines import file.
+// Rows that cannot be decoded are returned with their parse errors set.
+func ParseImport(body io.Reader, format string) ([]ImportRow, error) {
+	var rows []ImportRow
+	var err error
+
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseCSV(body)
+	case ImportFormatJSONL:
+		rows, err = parseJSONL(body)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyImport
+	}
+
+	return rows, nil
+}
+
+func parseCSV(body io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyImport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	setters := make([]func(*CreatePatientRequest, string) error, len(header))
+	for i, name := range header {
+		setter, ok := importColumns[normalizeColumn(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		setters[i] = setter
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", len(rows)+1, err)
+		}
+		if len(rows) >= MaxImportRows {
+			return nil, ErrTooManyImportRows
+		}
+
+		row := ImportRow{Row: len(rows) + 1}
+		if len(record) != len(header) {
+			row.Errors = append(row.Errors, fmt.Sprintf("expected %d columns, got %d", len(header), len(record)))
+		} else {
+			for i, value := range record {
+				if err := setters[i](&row.Request, strings.TrimSpace(value)); err != nil {
+					row.Errors = append(row.Errors, err.Error())
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseJSONL(body io.Reader) ([]ImportRow, error) {
+	decoder := json.NewDecoder(body)
+
+	var rows []ImportRow
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			// A syntax error leaves the decoder unable to find the next record
+			return nil, fmt.Errorf("failed to read JSON Lines record %d: %w", len(rows)+1, err)
+		}
+		if len(rows) >= MaxImportRows {
+			return nil, ErrTooManyImportRows
+		}
+
+		row := ImportRow{Row: len(rows) + 1}
+		if err := json.Unmarshal(raw, &row.Request); err != nil {
+			row.Errors = append(row.Errors, "invalid record: "+err.Error())
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(name)
+}
+
+// ValidateImportRows checks every row before anything is created and returns
+// all problems found, including usernames and emails repeated within the file.
+func ValidateImportRows(rows []ImportRow) []jobs.RowError {
+	var rowErrors []jobs.RowError
+	usernames := make(map[string]int)
+	emails := make(map[string]int)
+
+	for _, row := range rows {
+		problems := append([]string{}, row.Errors...)
+		if len(row.Errors) == 0 {
+			if err := row.Request.Validate(); err != nil {
+				problems = append(problems, validation.Messages(err)...)
+			}
+		}
+
+		if username := strings.ToLower(row.Request.Username); username != "" {
+			if first, ok := usernames[username]; ok {
+				problems = append(problems, fmt.Sprintf("username duplicates row %d", first))
+			} else {
+				usernames[username] = row.Row
+			}
+		}
+		if email := strings.ToLower(row.Request.Email); email != "" {
+			if first, ok := emails[email]; ok {
+				problems = append(problems, fmt.Sprintf("email duplicates row %d", first))
+			} else {
+				emails[email] = row.Row
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, jobs.RowError{Row: row.Row, Errors: problems})
+		}
+	}
+
+	return rowErrors
+}
+
+// Importer runs bulk patient imports as asynchronous jobs
+type Importer struct {
+	service ServiceInterface
+	jobs    jobs.RepositoryInterface
+	runner  *jobs.Runner
+}
+
+// NewImporter creates a new patient importer
+func NewImporter(service ServiceInterface, jobRepo jobs.RepositoryInterface) *Importer {
+	return NewImporterWithRunner(service, jobRepo, nil)
+}
+
+// NewImporterWithRunner creates a new patient importer whose jobs run on runner,
+// so they are drained when it shuts down
+func NewImporterWithRunner(service ServiceInterface, jobRepo jobs.RepositoryInterface, runner *jobs.Runner) *Importer {
+	if runner == nil {
+		runner = jobs.NewRunner()
+	}
+	return &Importer{
+		service: service,
+		jobs:    jobRepo,
+		runner:  runner,
+	}
+}
+
+// StartImport records a job for the already validated rows and creates the
+// patients in the background. The returned job reflects the queued state.
+func (i *Importer) StartImport(ctx context.Context, schemaName, orgID, createdBy string, rows []ImportRow) (*jobs.Job, error) {
+	job := &jobs.Job{
+		OrganizationID: orgID,
+		Kind:           jobs.KindPatientImport,
+		TotalRows:      len(rows),
+		CreatedBy:      createdBy,
+	}
+	if err := i.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	queued := *job
+	queued.Results = []jobs.RowResult{}
+
+	slog.InfoContext(ctx, "starting patient import job", "job_id", job.ID, "rows", len(rows), "organization_id", orgID)
+
+	i.runner.Go(ctx, i.jobs, job, func(ctx context.Context, index int) jobs.RowResult {
+		row := rows[index]
+		patient, err := i.service.CreatePatient(ctx, schemaName, orgID, row.Request)
+		if err != nil {
+			return jobs.FailedRow(ctx, row.Row, err, "failed to create patient")
+		}
+		return jobs.RowResult{Row: row.Row, Status: jobs.RowCreated, ResourceID: patient.ID}
+	})
+
+	return &queued, nil
+}
+
+// GetImport returns a patient import job belonging to the given organization
+func (i *Importer) GetImport(ctx context.Context, orgID, jobID string) (*jobs.Job, error) {
+	return jobs.GetForOrganization(ctx, i.jobs, orgID, jobs.KindPatientImport, jobID)
+}
@@ -0,0 +1,134 @@
+package patient
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/gorilla/mux"
+)
+
+// ImportHandler serves the bulk patient import endpoints
+type ImportHandler struct {
+	importer     *Importer
+	schemaLookup SchemaLookup
+}
+
+// NewImportHandler creates a new bulk patient import handler
+func NewImportHandler(importer *Importer, schemaLookup SchemaLookup) *ImportHandler {
+	return &ImportHandler{
+		importer:     importer,
+		schemaLookup: schemaLookup,
+	}
+}
+
+type ImportJobResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Job     *jobs.Job `json:"job"`
+}
+
+// ImportPatients validates an uploaded CSV or JSON Lines file and starts an
+// asynchronous job creating one patient per row. Nothing is created when any
+// row fails validation.
+func (h *ImportHandler) ImportPatients(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		return
+	}
+
+	orgID, schemaName, ok := resolveTenant(w, r, principal, h.schemaLookup)
+	if !ok {
+		return
+	}
+
+	format := importFormat(r)
+	if format == "" {
+		apierror.Respond(w, r, http.StatusUnsupportedMediaType, "unsupported_format", ErrUnsupportedImportFormat.Error())
+		return
+	}
+
+	rows, err := ParseImport(http.MaxBytesReader(w, r.Body, MaxImportBytes), format)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierror.Respond(w, r, http.StatusRequestEntityTooLarge, "file_too_large", "Import file exceeds the maximum size of 5 MB")
+			return
+		}
+		apierror.Respond(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if rowErrors := ValidateImportRows(rows); len(rowErrors) > 0 {
+		apierror.Write(w, r, jobs.ValidationError("Import file contains invalid rows, no patients were created", rowErrors), "")
+		return
+	}
+
+	job, err := h.importer.StartImport(r.Context(), schemaName, orgID, principal.UserID, rows)
+	if err != nil {
+		apierror.Write(w, r, err, "Failed to start patient import")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/organization/patients/imports/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ImportJobResponse{
+		Success: true,
+		Message: "Patient import started",
+		Job:     job,
+	})
+}
+
+// GetImportJob returns the progress and per-row results of an import job
+func (h *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "unauthenticated", "User not authenticated")
+		return
+	}
+
+	orgID, _, ok := resolveTenant(w, r, principal, h.schemaLookup)
+	if !ok {
+		return
+	}
+
+	job, err := h.importer.GetImport(r.Context(), orgID, mux.Vars(r)["id"])
+	if err != nil {
+		apierror.Write(w, r, err, "Failed to fetch import job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ImportJobResponse{
+		Success: true,
+		Message: "Import job retrieved successfully",
+		Job:     job,
+	})
+}
+
+// importFormat picks the import format from the format query parameter,
+// falling back to the request Content-Type
+func importFormat(r *http.Request) string {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if format == ImportFormatCSV || format == ImportFormatJSONL {
+			return format
+		}
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return ImportFormatJSONL
+	}
+	return ""
+}
@@ -0,0 +1,293 @@
+package patient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const validImportCSV = `username,first_name,Last Name,email,dateOfBirth,address,sendResetEmail
+jdoe,John,Doe,john@example.com,1950-02-01,1 Main St,true
+asmith,Ann,Smith,ann@example.com,1948-07-12,2 Main St,true
+`
+
+// Test import parsing
+
+func TestParseImport_CSV(t *testing.T) {
+	rows, err := ParseImport(strings.NewReader(validImportCSV), ImportFormatCSV)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Request.LastName != "Doe" || rows[1].Request.DateOfBirth != "1948-07-12" {
+		t.Errorf("Unexpected parsed rows: %+v", rows)
+	}
+	if !rows[0].Request.SendResetEmail {
+		t.Error("Expected sendResetEmail to be true")
+	}
+	if rows[1].Row != 2 {
+		t.Errorf("Expected row number 2, got %d", rows[1].Row)
+	}
+}
+
+func TestParseImport_CSVUnknownColumn(t *testing.T) {
+	_, err := ParseImport(strings.NewReader("username,shoe_size\njdoe,42\n"), ImportFormatCSV)
+	if err == nil || !strings.Contains(err.Error(), "shoe_size") {
+		t.Errorf("Expected unknown column error, got %v", err)
+	}
+}
+
+func TestParseImport_CSVHeaderOnly(t *testing.T) {
+	_, err := ParseImport(strings.NewReader("username,email\n"), ImportFormatCSV)
+	if !errors.Is(err, ErrEmptyImport) {
+		t.Errorf("Expected ErrEmptyImport, got %v", err)
+	}
+}
+
+func TestParseImport_CSVTooManyRows(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("username\n")
+	for i := 0; i <= MaxImportRows; i++ {
+		fmt.Fprintf(&b, "user%d\n", i)
+	}
+
+	_, err := ParseImport(strings.NewReader(b.String()), ImportFormatCSV)
+	if !errors.Is(err, ErrTooManyImportRows) {
+		t.Errorf("Expected ErrTooManyImportRows, got %v", err)
+	}
+}
+
+func TestParseImport_JSONL(t *testing.T) {
+	body := `{"username":"jdoe","firstName":"John","lastName":"Doe","email":"john@example.com","dateOfBirth":"1950-02-01","address":"1 Main St","sendResetEmail":true}
+{"username":42}
+`
+	rows, err := ParseImport(strings.NewReader(body), ImportFormatJSONL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Request.FirstName != "John" {
+		t.Errorf("Expected first name John, got %s", rows[0].Request.FirstName)
+	}
+	if len(rows[1].Errors) == 0 {
+		t.Error("Expected a decode error on the second row")
+	}
+}
+
+func TestParseImport_UnsupportedFormat(t *testing.T) {
+	_, err := ParseImport(strings.NewReader("{}"), "xml")
+	if !errors.Is(err, ErrUnsupportedImportFormat) {
+		t.Errorf("Expected ErrUnsupportedImportFormat, got %v", err)
+	}
+}
+
+// Test import validation
+
+func TestValidateImportRows_ReportsAllErrors(t *testing.T) {
+	rows := []ImportRow{
+		{Row: 1, Request: CreatePatientRequest{Username: "jdoe", FirstName: "John", LastName: "Doe", Email: "john@example.com", DateOfBirth: "1950-02-01", Address: "1 Main St", SendResetEmail: true}},
+		{Row: 2, Request: CreatePatientRequest{Username: "JDOE", Email: "not-an-email", DateOfBirth: "01/02/1950"}},
+	}
+
+	rowErrors := ValidateImportRows(rows)
+
+	if len(rowErrors) != 1 {
+		t.Fatalf("Expected errors for 1 row, got %d", len(rowErrors))
+	}
+	if rowErrors[0].Row != 2 {
+		t.Errorf("Expected errors on row 2, got row %d", rowErrors[0].Row)
+	}
+
+	joined := strings.Join(rowErrors[0].Errors, "; ")
+	for _, want := range []string{"email is invalid", "first name is required", "YYYY-MM-DD", "address is required", "username duplicates row 1"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected %q in errors, got %s", want, joined)
+		}
+	}
+}
+
+// Test Importer
+
+func TestImporterStartImport(t *testing.T) {
+	mockSvc := &mockService{
+		createPatientFunc: func(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error) {
+			if req.Username == "asmith" {
+				return nil, errors.New(`pq: duplicate key value violates unique constraint "patients_email_key"`)
+			}
+			return &PatientResponse{ID: "patient-" + req.Username}, nil
+		},
+	}
+	jobRepo := testutil.NewMockJobRepository()
+	importer := NewImporter(mockSvc, jobRepo)
+
+	rows, _ := ParseImport(strings.NewReader(validImportCSV), ImportFormatCSV)
+	job, err := importer.StartImport(context.Background(), "org_123", "org-123", "admin-123", rows)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if job.Status != jobs.StatusQueued {
+		t.Errorf("Expected status queued, got %s", job.Status)
+	}
+
+	importer.runner.Shutdown(context.Background())
+
+	stored, err := importer.GetImport(context.Background(), "org-123", job.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored.Status != jobs.StatusCompleted {
+		t.Errorf("Expected status completed, got %s", stored.Status)
+	}
+	if stored.SucceededRows != 1 || stored.FailedRows != 1 {
+		t.Errorf("Expected 1 succeeded and 1 failed row, got %d and %d", stored.SucceededRows, stored.FailedRows)
+	}
+	if stored.Results[0].ResourceID != "patient-jdoe" {
+		t.Errorf("Expected resource id patient-jdoe, got %s", stored.Results[0].ResourceID)
+	}
+	if got := stored.Results[1]; got.Code != apierror.CodeInternal || got.Error != "failed to create patient" {
+		t.Errorf("Expected internal row error to be hidden, got %+v", got)
+	}
+}
+
+func TestImporterGetImport_OtherOrganization(t *testing.T) {
+	jobRepo := testutil.NewMockJobRepository()
+	job := &jobs.Job{OrganizationID: "org-123", Kind: jobs.KindPatientImport}
+	jobRepo.Create(context.Background(), job)
+
+	importer := NewImporter(&mockService{}, jobRepo)
+
+	if _, err := importer.GetImport(context.Background(), "org-456", job.ID); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+	if _, err := importer.GetImport(context.Background(), "org-123", "not-a-uuid"); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound for malformed id, got %v", err)
+	}
+}
+
+// Test import handlers
+
+func orgAdminRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	principal := &auth.Principal{
+		UserID:        "admin-123",
+		Roles:         []string{"ORG_ADMIN"},
+		OrgID:         "org-123",
+		OrgSchemaName: "org_123",
+	}
+	return req.WithContext(auth.ContextWithPrincipal(req.Context(), principal))
+}
+
+func TestHandlerImportPatients_Accepted(t *testing.T) {
+	mockSvc := &mockService{
+		createPatientFunc: func(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error) {
+			return &PatientResponse{ID: "patient-" + req.Username}, nil
+		},
+	}
+	importer := NewImporter(mockSvc, testutil.NewMockJobRepository())
+	handler := NewImportHandler(importer, &mockSchemaLookup{})
+
+	req := orgAdminRequest(http.MethodPost, "/organization/patients/imports", validImportCSV)
+	req.Header.Set("Content-Type", "text/csv")
+
+	rr := httptest.NewRecorder()
+	handler.ImportPatients(rr, req)
+	importer.runner.Shutdown(context.Background())
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response ImportJobResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Job.TotalRows != 2 {
+		t.Errorf("Expected 2 total rows, got %d", response.Job.TotalRows)
+	}
+	if rr.Header().Get("Location") != "/organization/patients/imports/"+response.Job.ID {
+		t.Errorf("Unexpected Location header %q", rr.Header().Get("Location"))
+	}
+}
+
+func TestHandlerImportPatients_ValidationErrors(t *testing.T) {
+	called := false
+	mockSvc := &mockService{
+		createPatientFunc: func(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error) {
+			called = true
+			return &PatientResponse{}, nil
+		},
+	}
+	handler := NewImportHandler(NewImporter(mockSvc, testutil.NewMockJobRepository()), &mockSchemaLookup{})
+
+	body := `{"username":"jdoe","firstName":"John"}` + "\n"
+	req := orgAdminRequest(http.MethodPost, "/organization/patients/imports?format=jsonl", body)
+
+	rr := httptest.NewRecorder()
+	handler.ImportPatients(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", rr.Code)
+	}
+
+	var response apierror.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Errors) < 2 {
+		t.Errorf("Expected all problems of row 1 to be reported, got %+v", response.Errors)
+	}
+	for _, fieldError := range response.Errors {
+		if fieldError.Row != 1 {
+			t.Errorf("Expected errors on row 1 only, got %+v", response.Errors)
+		}
+	}
+	if called {
+		t.Error("Expected no patients to be created")
+	}
+}
+
+func TestHandlerImportPatients_UnsupportedMediaType(t *testing.T) {
+	handler := NewImportHandler(NewImporter(&mockService{}, testutil.NewMockJobRepository()), &mockSchemaLookup{})
+
+	req := orgAdminRequest(http.MethodPost, "/organization/patients/imports", "<patients/>")
+	req.Header.Set("Content-Type", "application/xml")
+
+	rr := httptest.NewRecorder()
+	handler.ImportPatients(rr, req)
+
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415, got %d", rr.Code)
+	}
+}
+
+func TestHandlerGetImportJob_NotFound(t *testing.T) {
+	handler := NewImportHandler(NewImporter(&mockService{}, testutil.NewMockJobRepository()), &mockSchemaLookup{})
+
+	req := orgAdminRequest(http.MethodGet, "/organization/patients/imports/"+uuid.New().String(), "")
+	req = mux.SetURLVars(req, map[string]string{"id": uuid.New().String()})
+
+	rr := httptest.NewRecorder()
+	handler.GetImportJob(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rr.Code)
+	}
+}
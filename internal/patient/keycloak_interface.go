@@ -1,15 +1,19 @@
 package patient
 
-import "github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+import (
+	"context"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+)
 
 // KeycloakAdminInterface defines the contract for Keycloak operations
 type KeycloakAdminInterface interface {
-	CreateUser(user auth.KeycloakUser) (string, error)
-	SetPassword(userID, password string, temporary bool) error
-	SendEmailAction(userID string, actions []string) error
-	GetRole(roleName string) (*auth.KeycloakRole, error)
-	AssignRole(userID string, role auth.KeycloakRole) error
-	DeleteUser(userID string) error
+	CreateUser(ctx context.Context, user auth.KeycloakUser) (string, error)
+	SetPassword(ctx context.Context, userID, password string, temporary bool) error
+	SendEmailAction(ctx context.Context, userID string, actions []string) error
+	GetRole(ctx context.Context, roleName string) (*auth.KeycloakRole, error)
+	AssignRole(ctx context.Context, userID string, role auth.KeycloakRole) error
+	DeleteUser(ctx context.Context, userID string) error
 }
 
 // Ensure KeycloakAdminClient implements KeycloakAdminInterface
@@ -3,48 +3,70 @@ package patient
 import (
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/validation"
 )
 
-// CreatePatientRequest represents the request to create a new patient user
+// CreatePatientRequest represents the request to create a new patient user.
+// Maximum lengths match the columns of the tenant patients table.
 type CreatePatientRequest struct {
 	// Authentication fields
-	Username          string `json:"username" validate:"required"`
+	Username          string `json:"username" validate:"required,max=255"`
 	TemporaryPassword string `json:"temporaryPassword"`
 	SendResetEmail    bool   `json:"sendResetEmail"`
 
 	// Personal information
-	FirstName   string `json:"firstName" validate:"required"`
-	LastName    string `json:"lastName" validate:"required"`
-	Email       string `json:"email" validate:"required"`
-	PhoneNumber string `json:"phoneNumber"`
+	FirstName   string `json:"firstName" validate:"required,max=100"`
+	LastName    string `json:"lastName" validate:"required,max=100"`
+	Email       string `json:"email" validate:"required,email,max=255"`
+	PhoneNumber string `json:"phoneNumber" validate:"omitempty,phone,max=50"`
 
 	// Patient-specific fields
-	DateOfBirth           string `json:"dateOfBirth" validate:"required"` // Format: YYYY-MM-DD
+	DateOfBirth           string `json:"dateOfBirth" validate:"required,datetime=2006-01-02,birthdate"` // Format: YYYY-MM-DD
 	Address               string `json:"address" validate:"required"`
-	EmergencyContactName  string `json:"emergencyContactName"`
-	EmergencyContactPhone string `json:"emergencyContactPhone"`
+	EmergencyContactName  string `json:"emergencyContactName" validate:"omitempty,max=255"`
+	EmergencyContactPhone string `json:"emergencyContactPhone" validate:"omitempty,phone,max=50"`
 	MedicalNotes          string `json:"medicalNotes"`
 
 	// Care plan fields
-	CareplanType      string `json:"careplanType"`      // e.g., "basic", "intensive", "palliative"
-	CareplanFrequency string `json:"careplanFrequency"` // e.g., "daily", "weekly", "monthly"
+	CareplanType      string `json:"careplanType" validate:"omitempty,max=100"`
+	CareplanFrequency string `json:"careplanFrequency" validate:"omitempty,max=100"`
 }
 
-// UpdatePatientRequest represents the request to update a patient
+// Validate checks every field of the create patient request and reports all
+// problems at once
+func (r *CreatePatientRequest) Validate() error {
+	fieldErrors := validation.Fields(r)
+	if r.TemporaryPassword == "" && !r.SendResetEmail {
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   "temporaryPassword",
+			Message: "either temporaryPassword or sendResetEmail must be provided",
+		})
+	}
+	return validation.Error(fieldErrors)
+}
+
+// UpdatePatientRequest represents the request to update a patient. Omitted
+// fields are left unchanged; required fields cannot be cleared.
 type UpdatePatientRequest struct {
-	FirstName             *string `json:"first_name,omitempty"`
-	LastName              *string `json:"last_name,omitempty"`
-	Email                 *string `json:"email,omitempty"`
-	PhoneNumber           *string `json:"phone_number,omitempty"`
-	DateOfBirth           *string `json:"date_of_birth,omitempty"`
-	Address               *string `json:"address,omitempty"`
-	EmergencyContactName  *string `json:"emergency_contact_name,omitempty"`
-	EmergencyContactPhone *string `json:"emergency_contact_phone,omitempty"`
+	FirstName             *string `json:"first_name,omitempty" validate:"omitnil,min=1,max=100"`
+	LastName              *string `json:"last_name,omitempty" validate:"omitnil,min=1,max=100"`
+	Email                 *string `json:"email,omitempty" validate:"omitnil,min=1,email,max=255"`
+	PhoneNumber           *string `json:"phone_number,omitempty" validate:"omitempty,phone,max=50"`
+	DateOfBirth           *string `json:"date_of_birth,omitempty" validate:"omitnil,min=1,datetime=2006-01-02,birthdate"`
+	Address               *string `json:"address,omitempty" validate:"omitnil,min=1"`
+	EmergencyContactName  *string `json:"emergency_contact_name,omitempty" validate:"omitempty,max=255"`
+	EmergencyContactPhone *string `json:"emergency_contact_phone,omitempty" validate:"omitempty,phone,max=50"`
 	MedicalNotes          *string `json:"medical_notes,omitempty"`
 	IsActive              *bool   `json:"is_active,omitempty"`
-	CareplanType          *string `json:"careplan_type,omitempty"`
-	CareplanFrequency     *string `json:"careplan_frequency,omitempty"`
+	CareplanType          *string `json:"careplan_type,omitempty" validate:"omitempty,max=100"`
+	CareplanFrequency     *string `json:"careplan_frequency,omitempty" validate:"omitempty,max=100"`
+}
+
+// Validate checks the fields present in the update patient request
+func (r *UpdatePatientRequest) Validate() error {
+	return validation.Struct(r)
 }
 
 // PatientResponse represents the patient data returned to clients
@@ -66,11 +88,32 @@ type PatientResponse struct {
 	IsActive              bool       `json:"is_active"`
 	CreatedAt             time.Time  `json:"created_at"`
 	UpdatedAt             *time.Time `json:"updated_at,omitempty"`
+	Version               int        `json:"version"` // Row version, also sent as the ETag
 }
 
+// FilterBirthDate is the pagination.Params filter key for an exact date of birth (YYYY-MM-DD)
+const FilterBirthDate = "birth_date"
+
+// FilterName is the pagination.Params filter key for a part of the first or last name
+const FilterName = "name"
+
 // PaginatedPatientListResponse represents a paginated list of patients
 type PaginatedPatientListResponse struct {
 	Success    bool             `json:"success"`
 	Patients   []PatientResponse `json:"patients"`
 	Pagination pagination.Meta   `json:"pagination"`
 }
+
+// PatientSearchResult is a patient search match with its relevance score
+type PatientSearchResult struct {
+	PatientResponse
+	Score float64 `json:"score"`
+}
+
+// PatientSearchResponse represents a ranked page of patient search results
+type PatientSearchResponse struct {
+	Success    bool                  `json:"success"`
+	Query      string                `json:"query"`
+	Patients   []PatientSearchResult `json:"patients"`
+	Pagination pagination.Meta       `json:"pagination"`
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -77,7 +79,7 @@ func (r *Repository) CreatePatient(ctx context.Context, schemaName string, orgID
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, true, $15)
 		RETURNING id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 				  emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-				  careplan_frequency, is_active, created_at
+				  careplan_frequency, is_active, created_at, version
 	`, pq.QuoteIdentifier(schemaName))
 
 	var patient PatientResponse
@@ -125,6 +127,7 @@ func (r *Repository) CreatePatient(ctx context.Context, schemaName string, orgID
 		&careplanFrequency,
 		&patient.IsActive,
 		&patient.CreatedAt,
+		&patient.Version,
 	)
 
 	if err != nil {
@@ -181,7 +184,7 @@ func (r *Repository) CreatePatient(ctx context.Context, schemaName string, orgID
 		}
 
 		if err := r.publisher.Publish(ctx, messaging.EventPatientCreated, event); err != nil {
-			log.Printf("Warning: failed to publish patient.created event: %v", err)
+			slog.WarnContext(ctx, "failed to publish patient.created event", "error", err)
 		}
 	}
 
@@ -200,7 +203,7 @@ func (r *Repository) ListPatients(ctx context.Context, schemaName string) ([]Pat
 	query := fmt.Sprintf(`
 		SELECT id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 			   emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-			   careplan_frequency, is_active, created_at, updated_at
+			   careplan_frequency, is_active, created_at, updated_at, version
 		FROM %s.patients
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -245,6 +248,7 @@ func (r *Repository) ListPatients(ctx context.Context, schemaName string) ([]Pat
 			&patient.IsActive,
 			&patient.CreatedAt,
 			&updatedAt,
+			&patient.Version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan patient: %w", err)
@@ -294,51 +298,82 @@ func (r *Repository) ListPatients(ctx context.Context, schemaName string) ([]Pat
 	return patients, nil
 }
 
-// ListPatientsWithPagination retrieves patients with pagination support
-func (r *Repository) ListPatientsWithPagination(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error) {
-	// Build WHERE clause for search
-	whereClause := "WHERE deleted_at IS NULL"
-	countWhereClause := "WHERE deleted_at IS NULL"
-	args := []interface{}{limit, offset}
+// patientListFields whitelists the sort keys and filters of the patient lists
+var patientListFields = pagination.Fields{
+	Sort: map[string]string{
+		"patient_id":    "patient_id",
+		"first_name":    "first_name",
+		"last_name":     "last_name",
+		"email":         "email",
+		"date_of_birth": "date_of_birth",
+		"careplan_type": "careplan_type",
+		"created_at":    "created_at",
+	},
+	Filters: map[string]pagination.Filter{
+		"careplan_type":      {Column: "careplan_type", Op: "=", Type: pagination.FilterText},
+		"careplan_frequency": {Column: "careplan_frequency", Op: "=", Type: pagination.FilterText},
+		FilterBirthDate:      {Column: "date_of_birth", Op: "=", Type: pagination.FilterDate},
+		"born_after":         {Column: "date_of_birth", Op: ">=", Type: pagination.FilterDate},
+		"born_before":        {Column: "date_of_birth", Op: "<=", Type: pagination.FilterDate},
+		"created_after":      {Column: "created_at", Op: ">=", Type: pagination.FilterDate},
+		"created_before":     {Column: "created_at", Op: "<", Type: pagination.FilterDate},
+	},
+}
+
+// buildPatientListFilter appends the search and filter conditions to a base
+// WHERE clause and returns the clause together with its positional arguments
+func buildPatientListFilter(where string, query pagination.Query) (string, []interface{}) {
+	var args []interface{}
 
-	if search != "" {
-		whereClause += ` AND (first_name ILIKE $3 OR last_name ILIKE $3 OR email ILIKE $3)`
-		countWhereClause += ` AND (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1)`
-		args = append(args, "%"+search+"%")
+	if query.Search != "" {
+		args = append(args, "%"+query.Search+"%")
+		n := len(args)
+		where += fmt.Sprintf(` AND (first_name ILIKE $%d OR last_name ILIKE $%d OR email ILIKE $%d)`, n, n, n)
+	}
+	if name := query.Filters[FilterName]; name != "" {
+		args = append(args, "%"+name+"%")
+		n := len(args)
+		where += fmt.Sprintf(` AND (first_name ILIKE $%d OR last_name ILIKE $%d)`, n, n)
 	}
 
-	// First, get total count
-	var totalCount int
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s.patients
-		%s
-	`, pq.QuoteIdentifier(schemaName), countWhereClause)
+	conditions, filterArgs := patientListFields.Where(query.Filters, len(args)+1)
+	return where + conditions, append(args, filterArgs...)
+}
 
-	if search != "" {
-		err := r.db.QueryRowContext(ctx, countQuery, "%"+search+"%").Scan(&totalCount)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count patients: %w", err)
-		}
-	} else {
-		err := r.db.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+// ListPatientsWithPagination retrieves patients with pagination support
+func (r *Repository) ListPatientsWithPagination(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
+	whereClause, args := buildPatientListFilter("WHERE deleted_at IS NULL", listQuery)
+
+	// Count the total unless a cursor request skips it
+	var totalCount int
+	if window.CountTotal {
+		countQuery := fmt.Sprintf(`
+			SELECT COUNT(*) 
+			FROM %s.patients
+			%s
+		`, pq.QuoteIdentifier(schemaName), whereClause)
+
+		err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to count patients: %w", err)
 		}
 	}
 
-	// Then get paginated results
+	// Then get the page, after the cursor if there is one
+	keyset, keysetArgs := window.KeysetClause(len(args) + 1)
+	args = append(args, keysetArgs...)
+
 	query := fmt.Sprintf(`
 		SELECT id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 			   emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-			   careplan_frequency, is_active, created_at, updated_at
+			   careplan_frequency, is_active, created_at, updated_at, version
 		FROM %s.patients
-		%s
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`, pq.QuoteIdentifier(schemaName), whereClause)
+		%s%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, pq.QuoteIdentifier(schemaName), whereClause, keyset, patientListFields.OrderBy(listQuery.Sort), len(args)+1, len(args)+2)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.db.QueryContext(ctx, query, append(args, window.Limit, window.Offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query patients: %w", err)
 	}
@@ -377,6 +412,7 @@ func (r *Repository) ListPatientsWithPagination(ctx context.Context, schemaName
 			&patient.IsActive,
 			&patient.CreatedAt,
 			&updatedAt,
+			&patient.Version,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan patient: %w", err)
@@ -427,50 +463,39 @@ func (r *Repository) ListPatientsWithPagination(ctx context.Context, schemaName
 }
 
 // ListActivePatientsWithPagination retrieves active patients (not soft deleted and is_active = true) with pagination support
-func (r *Repository) ListActivePatientsWithPagination(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error) {
-	// Build WHERE clause for search
-	whereClause := "WHERE deleted_at IS NULL AND is_active = true"
-	countWhereClause := "WHERE deleted_at IS NULL AND is_active = true"
-	args := []interface{}{limit, offset}
-
-	if search != "" {
-		whereClause += ` AND (first_name ILIKE $3 OR last_name ILIKE $3 OR email ILIKE $3)`
-		countWhereClause += ` AND (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1)`
-		args = append(args, "%"+search+"%")
-	}
+func (r *Repository) ListActivePatientsWithPagination(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
+	whereClause, args := buildPatientListFilter("WHERE deleted_at IS NULL AND is_active = true", listQuery)
 
-	// First, get total count of active patients
+	// Count active patients unless a cursor request skips it
 	var totalCount int
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s.patients
-		%s
-	`, pq.QuoteIdentifier(schemaName), countWhereClause)
-
-	if search != "" {
-		err := r.db.QueryRowContext(ctx, countQuery, "%"+search+"%").Scan(&totalCount)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count active patients: %w", err)
-		}
-	} else {
-		err := r.db.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+	if window.CountTotal {
+		countQuery := fmt.Sprintf(`
+			SELECT COUNT(*) 
+			FROM %s.patients
+			%s
+		`, pq.QuoteIdentifier(schemaName), whereClause)
+
+		err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to count active patients: %w", err)
 		}
 	}
 
-	// Then get paginated results
+	// Then get the page, after the cursor if there is one
+	keyset, keysetArgs := window.KeysetClause(len(args) + 1)
+	args = append(args, keysetArgs...)
+
 	query := fmt.Sprintf(`
 		SELECT id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 			   emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-			   careplan_frequency, is_active, created_at, updated_at
+			   careplan_frequency, is_active, created_at, updated_at, version
 		FROM %s.patients
-		%s
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`, pq.QuoteIdentifier(schemaName), whereClause)
+		%s%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, pq.QuoteIdentifier(schemaName), whereClause, keyset, patientListFields.OrderBy(listQuery.Sort), len(args)+1, len(args)+2)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.db.QueryContext(ctx, query, append(args, window.Limit, window.Offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query active patients: %w", err)
 	}
@@ -509,6 +534,7 @@ func (r *Repository) ListActivePatientsWithPagination(ctx context.Context, schem
 			&patient.IsActive,
 			&patient.CreatedAt,
 			&updatedAt,
+			&patient.Version,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan patient: %w", err)
@@ -558,11 +584,173 @@ func (r *Repository) ListActivePatientsWithPagination(ctx context.Context, schem
 	return patients, totalCount, nil
 }
 
+// Expressions used by patient search. They must match the expressions of the
+// tenant indexes created by wailsalutem.create_patient_search_indexes.
+const (
+	patientFullNameExpr    = `(coalesce(first_name, '') || ' ' || coalesce(last_name, ''))`
+	patientSearchDocExpr   = `to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, ''))`
+	patientPhoneDigitsExpr = `(regexp_replace(coalesce(phone_number, ''), '\D', '', 'g'))`
+)
+
+// buildPatientSearch returns the match condition, the relevance score
+// expression and their positional arguments for a patient search
+func buildPatientSearch(terms PatientSearchTerms) (string, string, []interface{}) {
+	args := []interface{}{terms.Text}
+	conditions := []string{`lower(patient_id) = lower($1)`}
+	scores := []string{`CASE WHEN lower(patient_id) = lower($1) THEN 1 ELSE 0 END`}
+
+	if terms.TSQuery != "" {
+		args = append(args, terms.TSQuery)
+		n := len(args)
+		conditions = append(conditions,
+			fmt.Sprintf(`%s @@ to_tsquery('simple', $%d)`, patientSearchDocExpr, n),
+			fmt.Sprintf(`$1 <%% %s`, patientFullNameExpr),
+		)
+		scores = append(scores,
+			fmt.Sprintf(`ts_rank(%s, to_tsquery('simple', $%d))`, patientSearchDocExpr, n),
+			fmt.Sprintf(`word_similarity($1, %s)`, patientFullNameExpr),
+		)
+	}
+	if terms.PhoneDigits != "" {
+		args = append(args, terms.PhoneDigits)
+		match := fmt.Sprintf(`%s LIKE '%%' || $%d`, patientPhoneDigitsExpr, len(args))
+		conditions = append(conditions, match)
+		scores = append(scores, fmt.Sprintf(`CASE WHEN %s THEN 1 ELSE 0 END`, match))
+	}
+	if terms.BirthDate != "" {
+		args = append(args, terms.BirthDate)
+		match := fmt.Sprintf(`date_of_birth = $%d::date`, len(args))
+		conditions = append(conditions, match)
+		scores = append(scores, fmt.Sprintf(`CASE WHEN %s THEN 1 ELSE 0 END`, match))
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", strings.Join(scores, " + "), args
+}
+
+// SearchPatients finds patients that have not been deleted by patient ID,
+// fuzzy name, phone number or date of birth, best matches first
+func (r *Repository) SearchPatients(ctx context.Context, schemaName string, terms PatientSearchTerms, limit, offset int) ([]PatientSearchResult, int, error) {
+	match, score, args := buildPatientSearch(terms)
+	whereClause := "WHERE deleted_at IS NULL AND " + match
+
+	var totalCount int
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s.patients
+		%s
+	`, pq.QuoteIdentifier(schemaName), whereClause)
+
+	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count patient search results: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address,
+			   emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type,
+			   careplan_frequency, is_active, created_at, updated_at, version, %s AS score
+		FROM %s.patients
+		%s
+		ORDER BY score DESC, last_name, first_name, id
+		LIMIT $%d OFFSET $%d
+	`, score, pq.QuoteIdentifier(schemaName), whereClause, len(args)+1, len(args)+2)
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search patients: %w", err)
+	}
+	defer rows.Close()
+
+	var results []PatientSearchResult
+	for rows.Next() {
+		var result PatientSearchResult
+		patient := &result.PatientResponse
+		var dob sql.NullString
+		var email sql.NullString
+		var phoneNumber sql.NullString
+		var address sql.NullString
+		var emergencyContactName sql.NullString
+		var emergencyContactPhone sql.NullString
+		var medicalNotes sql.NullString
+		var careplanType sql.NullString
+		var careplanFrequency sql.NullString
+		var updatedAt sql.NullTime
+		var patientIDStr sql.NullString
+
+		err := rows.Scan(
+			&patient.ID,
+			&patientIDStr,
+			&patient.KeycloakUserID,
+			&patient.FirstName,
+			&patient.LastName,
+			&email,
+			&phoneNumber,
+			&dob,
+			&address,
+			&emergencyContactName,
+			&emergencyContactPhone,
+			&medicalNotes,
+			&careplanType,
+			&careplanFrequency,
+			&patient.IsActive,
+			&patient.CreatedAt,
+			&updatedAt,
+			&patient.Version,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan patient: %w", err)
+		}
+
+		if patientIDStr.Valid {
+			patient.PatientID = patientIDStr.String
+		}
+		if dob.Valid {
+			patient.DateOfBirth = &dob.String
+		}
+		if email.Valid {
+			patient.Email = email.String
+		}
+		if phoneNumber.Valid {
+			patient.PhoneNumber = phoneNumber.String
+		}
+		if address.Valid {
+			patient.Address = address.String
+		}
+		if emergencyContactName.Valid {
+			patient.EmergencyContactName = emergencyContactName.String
+		}
+		if emergencyContactPhone.Valid {
+			patient.EmergencyContactPhone = emergencyContactPhone.String
+		}
+		if medicalNotes.Valid {
+			patient.MedicalNotes = medicalNotes.String
+		}
+		if careplanType.Valid {
+			patient.CareplanType = careplanType.String
+		}
+		if careplanFrequency.Valid {
+			patient.CareplanFrequency = careplanFrequency.String
+		}
+		if updatedAt.Valid {
+			patient.UpdatedAt = &updatedAt.Time
+		}
+
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating patient search results: %w", err)
+	}
+
+	return results, totalCount, nil
+}
+
 func (r *Repository) GetPatient(ctx context.Context, schemaName string, id string) (*PatientResponse, error) {
 	query := fmt.Sprintf(`
 		SELECT id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 			   emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-			   careplan_frequency, is_active, created_at, updated_at
+			   careplan_frequency, is_active, created_at, updated_at, version
 		FROM %s.patients
 		WHERE id = $1 AND deleted_at IS NULL
 	`, pq.QuoteIdentifier(schemaName))
@@ -598,10 +786,11 @@ func (r *Repository) GetPatient(ctx context.Context, schemaName string, id strin
 		&patient.IsActive,
 		&patient.CreatedAt,
 		&updatedAt,
+		&patient.Version,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("patient not found")
+		return nil, ErrPatientNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query patient: %w", err)
@@ -648,7 +837,7 @@ func (r *Repository) GetByKeycloakID(ctx context.Context, schemaName string, key
 	query := fmt.Sprintf(`
 		SELECT id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 			   emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-			   careplan_frequency, is_active, created_at, updated_at
+			   careplan_frequency, is_active, created_at, updated_at, version
 		FROM %s.patients
 		WHERE keycloak_user_id = $1 AND deleted_at IS NULL
 	`, pq.QuoteIdentifier(schemaName))
@@ -684,10 +873,11 @@ func (r *Repository) GetByKeycloakID(ctx context.Context, schemaName string, key
 		&patient.IsActive,
 		&patient.CreatedAt,
 		&updatedAt,
+		&patient.Version,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("patient not found")
+		return nil, ErrPatientNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query patient: %w", err)
@@ -730,7 +920,46 @@ func (r *Repository) GetByKeycloakID(ctx context.Context, schemaName string, key
 	return &patient, nil
 }
 
-func (r *Repository) UpdatePatient(ctx context.Context, schemaName string, id string, req UpdatePatientRequest) (*PatientResponse, error) {
+// ListAssignedCaregivers returns the Keycloak user IDs of the caregivers that
+// have care sessions with the patient. A session may record the caregiver's
+// user ID or Keycloak user ID; deleted caregivers are not assigned.
+func (r *Repository) ListAssignedCaregivers(ctx context.Context, schemaName string, patientID string) ([]string, error) {
+	schema := pq.QuoteIdentifier(schemaName)
+	query := fmt.Sprintf(`
+		SELECT DISTINCT u.keycloak_user_id::text
+		FROM %s.care_sessions cs
+		JOIN %s.users u ON cs.caregiver_id IN (u.id, u.keycloak_user_id)
+		WHERE cs.patient_id = $1 AND cs.deleted_at IS NULL AND u.deleted_at IS NULL
+	`, schema, schema)
+
+	rows, err := r.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assigned caregivers: %w", err)
+	}
+	defer rows.Close()
+
+	var caregivers []string
+	for rows.Next() {
+		var caregiverID string
+		if err := rows.Scan(&caregiverID); err != nil {
+			return nil, fmt.Errorf("failed to scan caregiver: %w", err)
+		}
+		caregivers = append(caregivers, caregiverID)
+	}
+	return caregivers, rows.Err()
+}
+
+// UpdatePatient applies the set fields of req. Unless version is
+// etag.AnyVersion, the update only applies to that row version.
+func (r *Repository) UpdatePatient(ctx context.Context, schemaName string, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
+	// Status changes are published, so the old status is needed
+	wasActive := true
+	if req.IsActive != nil && r.publisher != nil {
+		query := fmt.Sprintf("SELECT COALESCE(is_active, true) FROM %s.patients WHERE id = $1 AND deleted_at IS NULL", pq.QuoteIdentifier(schemaName))
+		if err := r.db.QueryRowContext(ctx, query, id).Scan(&wasActive); err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get patient status: %w", err)
+		}
+	}
 
 	var updates []string
 	var args []interface{}
@@ -801,20 +1030,27 @@ func (r *Repository) UpdatePatient(ctx context.Context, schemaName string, id st
 		return nil, fmt.Errorf("no fields to update")
 	}
 
-	updates = append(updates, fmt.Sprintf("updated_at = $%d", argIndex))
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argIndex), "version = version + 1")
 	args = append(args, time.Now())
 	argIndex++
 
 	args = append(args, id)
+	whereClause := fmt.Sprintf("id = $%d AND deleted_at IS NULL", argIndex)
+	argIndex++
+
+	if version != etag.AnyVersion {
+		whereClause += fmt.Sprintf(" AND version = $%d", argIndex)
+		args = append(args, version)
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE %s.patients
 		SET %s
-		WHERE id = $%d AND deleted_at IS NULL
+		WHERE %s
 		RETURNING id, patient_id, keycloak_user_id, first_name, last_name, email, phone_number, date_of_birth, address, 
 				  emergency_contact_name, emergency_contact_phone, medical_notes, careplan_type, 
-				  careplan_frequency, is_active, created_at, updated_at
-	`, pq.QuoteIdentifier(schemaName), strings.Join(updates, ", "), argIndex)
+				  careplan_frequency, is_active, created_at, updated_at, version
+	`, pq.QuoteIdentifier(schemaName), strings.Join(updates, ", "), whereClause)
 
 	var patient PatientResponse
 	var dob sql.NullString
@@ -847,10 +1083,11 @@ func (r *Repository) UpdatePatient(ctx context.Context, schemaName string, id st
 		&patient.IsActive,
 		&patient.CreatedAt,
 		&updatedAt,
+		&patient.Version,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("patient not found")
+		return nil, r.missingOrModified(ctx, schemaName, id, version)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update patient: %w", err)
@@ -891,18 +1128,57 @@ func (r *Repository) UpdatePatient(ctx context.Context, schemaName string, id st
 		patient.UpdatedAt = &updatedAt.Time
 	}
 
+	if req.IsActive != nil && patient.IsActive != wasActive {
+		r.publishStatusChanged(ctx, schemaName, id, wasActive, patient.IsActive)
+	}
+
 	return &patient, nil
 }
 
-func (r *Repository) DeletePatient(ctx context.Context, schemaName string, orgID string, id string) error {
+// publishStatusChanged publishes a patient.status_changed event
+func (r *Repository) publishStatusChanged(ctx context.Context, schemaName, id string, wasActive, isActive bool) {
+	if r.publisher == nil {
+		return
+	}
+
+	var orgID string
+	if err := r.db.QueryRowContext(ctx, `SELECT id FROM wailsalutem.organizations WHERE schema_name = $1`, schemaName).Scan(&orgID); err != nil {
+		slog.WarnContext(ctx, "failed to get organization of patient status change", "schema", schemaName, "error", err)
+	}
+
+	status := func(active bool) string {
+		if active {
+			return "active"
+		}
+		return "inactive"
+	}
+	event := messaging.PatientStatusChangedEvent{
+		BaseEvent: messaging.NewBaseEvent(messaging.EventPatientStatusChanged),
+		Data: messaging.PatientStatusChangedData{
+			PatientID:      id,
+			OrganizationID: orgID,
+			OldStatus:      status(wasActive),
+			NewStatus:      status(isActive),
+			ChangedAt:      time.Now(),
+		},
+	}
+
+	if err := r.publisher.Publish(ctx, messaging.EventPatientStatusChanged, event); err != nil {
+		slog.WarnContext(ctx, "failed to publish patient.status_changed event", "error", err)
+	}
+}
+
+// DeletePatient soft deletes the patient. Unless version is
+// etag.AnyVersion, only that row version is deleted.
+func (r *Repository) DeletePatient(ctx context.Context, schemaName string, orgID string, id string, version int) error {
 	query := fmt.Sprintf(`
 		UPDATE %s.patients
-		SET deleted_at = $1
-		WHERE id = $2 AND deleted_at IS NULL
+		SET deleted_at = $1, version = version + 1
+		WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
 	`, pq.QuoteIdentifier(schemaName))
 
 	deletedAt := time.Now()
-	result, err := r.db.ExecContext(ctx, query, deletedAt, id)
+	result, err := r.db.ExecContext(ctx, query, deletedAt, id, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete patient: %w", err)
 	}
@@ -913,7 +1189,7 @@ func (r *Repository) DeletePatient(ctx context.Context, schemaName string, orgID
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("patient not found")
+		return r.missingOrModified(ctx, schemaName, id, version)
 	}
 
 	// Publish patient.deleted event
@@ -928,9 +1204,30 @@ func (r *Repository) DeletePatient(ctx context.Context, schemaName string, orgID
 		}
 
 		if err := r.publisher.Publish(ctx, messaging.EventPatientDeleted, event); err != nil {
-			log.Printf("Warning: failed to publish patient.deleted event: %v", err)
+			slog.WarnContext(ctx, "failed to publish patient.deleted event", "error", err)
 		}
 	}
 
 	return nil
 }
+
+// missingOrModified explains why a write matched no row: the patient is
+// gone, or it exists but no longer has the expected version
+func (r *Repository) missingOrModified(ctx context.Context, schemaName string, id string, version int) error {
+	if version == etag.AnyVersion {
+		return ErrPatientNotFound
+	}
+
+	query := fmt.Sprintf(
+		"SELECT EXISTS (SELECT 1 FROM %s.patients WHERE id = $1 AND deleted_at IS NULL)",
+		pq.QuoteIdentifier(schemaName),
+	)
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check patient: %w", err)
+	}
+	if !exists {
+		return ErrPatientNotFound
+	}
+	return etag.ErrPreconditionFailed
+}
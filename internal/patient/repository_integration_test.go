@@ -7,6 +7,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
 	"github.com/google/uuid"
 )
@@ -221,7 +223,7 @@ func TestRepositoryListPatientsWithPagination_Integration(t *testing.T) {
 	}
 
 	// Get first page (limit 2)
-	patients, total, err := repo.ListPatientsWithPagination(context.Background(), schemaName, 2, 0, "")
+	patients, total, err := repo.ListPatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 2, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListPatientsWithPagination failed: %v", err)
 	}
@@ -235,7 +237,7 @@ func TestRepositoryListPatientsWithPagination_Integration(t *testing.T) {
 	}
 
 	// Get second page
-	patients, _, err = repo.ListPatientsWithPagination(context.Background(), schemaName, 2, 2, "")
+	patients, _, err = repo.ListPatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 2, Offset: 2, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListPatientsWithPagination page 2 failed: %v", err)
 	}
@@ -282,7 +284,7 @@ func TestRepositoryListPatientsWithPagination_Search_Integration(t *testing.T) {
 	}
 
 	// Search for "Alice"
-	patients, total, err := repo.ListPatientsWithPagination(context.Background(), schemaName, 10, 0, "Alice")
+	patients, total, err := repo.ListPatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "Alice"})
 	if err != nil {
 		t.Fatalf("Search for Alice failed: %v", err)
 	}
@@ -292,7 +294,7 @@ func TestRepositoryListPatientsWithPagination_Search_Integration(t *testing.T) {
 	}
 
 	// Search for "Johnson"
-	patients, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, 10, 0, "Johnson")
+	patients, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "Johnson"})
 	if err != nil {
 		t.Fatalf("Search for Johnson failed: %v", err)
 	}
@@ -302,7 +304,7 @@ func TestRepositoryListPatientsWithPagination_Search_Integration(t *testing.T) {
 	}
 
 	// Search by email
-	patients, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, 10, 0, "bob.smith")
+	patients, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "bob.smith"})
 	if err != nil {
 		t.Fatalf("Search by email failed: %v", err)
 	}
@@ -316,6 +318,129 @@ func TestRepositoryListPatientsWithPagination_Search_Integration(t *testing.T) {
 	}
 }
 
+// TestRepositoryListPatientsSortAndFilters_Integration tests sorting and typed filters
+func TestRepositoryListPatientsSortAndFilters_Integration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	orgID, schemaName := testutil.CreateTestOrg(t, db, "hospital_sort")
+	repo := NewRepository(db, nil)
+
+	testPatients := []CreatePatientRequest{
+		{FirstName: "Ans", LastName: "Visser", Email: "ans@test.com", DateOfBirth: "1940-03-01", Address: "Test Address", CareplanType: "intensive"},
+		{FirstName: "Bert", LastName: "Bakker", Email: "bert@test.com", DateOfBirth: "1955-07-12", Address: "Test Address", CareplanType: "Intensive"},
+		{FirstName: "Cor", LastName: "Mulder", Email: "cor@test.com", DateOfBirth: "1962-11-30", Address: "Test Address", CareplanType: "basic"},
+	}
+	for _, req := range testPatients {
+		if _, err := repo.CreatePatient(context.Background(), schemaName, orgID, uuid.New().String(), req); err != nil {
+			t.Fatalf("CreatePatient failed: %v", err)
+		}
+	}
+
+	window := pagination.Window{Limit: 10, CountTotal: true}
+
+	// Sort by last name
+	patients, _, err := repo.ListPatientsWithPagination(context.Background(), schemaName, window, pagination.Query{Sort: "last_name:asc"})
+	if err != nil {
+		t.Fatalf("Sorted list failed: %v", err)
+	}
+	if len(patients) != 3 || patients[0].LastName != "Bakker" || patients[2].LastName != "Visser" {
+		t.Errorf("Expected patients ordered by last name, got %v", patients)
+	}
+
+	// Careplan type matches case-insensitively
+	_, total, err := repo.ListPatientsWithPagination(context.Background(), schemaName, window, pagination.Query{
+		Filters: map[string]string{"careplan_type": "INTENSIVE"},
+	})
+	if err != nil {
+		t.Fatalf("Careplan filter failed: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("Expected 2 intensive patients, got %d", total)
+	}
+
+	// Date of birth range combined with sort descending
+	patients, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, window, pagination.Query{
+		Sort:    "date_of_birth:desc",
+		Filters: map[string]string{"born_after": "1950-01-01", "born_before": "1970-01-01"},
+	})
+	if err != nil {
+		t.Fatalf("Date of birth range failed: %v", err)
+	}
+	if total != 2 || len(patients) != 2 || patients[0].FirstName != "Cor" {
+		t.Errorf("Expected Cor and Bert born 1950-1970, got %v", patients)
+	}
+
+	// The name filter matches name parts but not email
+	patients, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, window, pagination.Query{
+		Filters: map[string]string{FilterName: "bak"},
+	})
+	if err != nil {
+		t.Fatalf("Name filter failed: %v", err)
+	}
+	if total != 1 || len(patients) != 1 || patients[0].FirstName != "Bert" {
+		t.Errorf("Expected Bert matching 'bak', got %v", patients)
+	}
+	if _, total, err = repo.ListPatientsWithPagination(context.Background(), schemaName, window, pagination.Query{
+		Filters: map[string]string{FilterName: "test.com"},
+	}); err != nil || total != 0 {
+		t.Errorf("Expected the name filter to skip email, got %d, %v", total, err)
+	}
+}
+
+// TestRepositorySearchPatients_Integration tests fuzzy, phone and date of birth search
+func TestRepositorySearchPatients_Integration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	orgID, schemaName := testutil.CreateTestOrg(t, db, "hospital_search")
+	repo := NewRepository(db, nil)
+
+	testPatients := []CreatePatientRequest{
+		{FirstName: "Jan", LastName: "Janssen", Email: "jan.janssen@test.com", PhoneNumber: "+31 6 1234 5678", DateOfBirth: "1950-02-01", Address: "Test Address"},
+		{FirstName: "Marieke", LastName: "de Vries", Email: "marieke@test.com", PhoneNumber: "0687654321", DateOfBirth: "1948-11-30", Address: "Test Address"},
+		{FirstName: "Pieter", LastName: "Bakker", Email: "pieter@test.com", DateOfBirth: "1950-02-01", Address: "Test Address"},
+	}
+	for _, req := range testPatients {
+		if _, err := repo.CreatePatient(context.Background(), schemaName, orgID, uuid.New().String(), req); err != nil {
+			t.Fatalf("CreatePatient failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query         string
+		expectedTotal int
+		expectedFirst string
+	}{
+		{query: "Jansen", expectedTotal: 1, expectedFirst: "Janssen"},
+		{query: "marieke vries", expectedTotal: 1, expectedFirst: "de Vries"},
+		{query: "PT-0003", expectedTotal: 1, expectedFirst: "Bakker"},
+		{query: "06-12345678", expectedTotal: 1, expectedFirst: "Janssen"},
+		{query: "01-02-1950", expectedTotal: 2},
+	}
+
+	for _, tt := range tests {
+		terms, err := ParseSearchQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseSearchQuery(%q) failed: %v", tt.query, err)
+		}
+
+		results, total, err := repo.SearchPatients(context.Background(), schemaName, terms, 10, 0)
+		if err != nil {
+			t.Fatalf("SearchPatients(%q) failed: %v", tt.query, err)
+		}
+
+		if total != tt.expectedTotal {
+			t.Errorf("%q: expected %d results, got %d", tt.query, tt.expectedTotal, total)
+		}
+		if tt.expectedFirst != "" && (len(results) == 0 || results[0].LastName != tt.expectedFirst) {
+			t.Errorf("%q: expected %s to rank first, got %+v", tt.query, tt.expectedFirst, results)
+		}
+	}
+}
+
 // TestRepositoryListActivePatientsWithPagination_Integration tests active patient filtering
 func TestRepositoryListActivePatientsWithPagination_Integration(t *testing.T) {
 	db := testutil.SetupTestDB(t)
@@ -342,7 +467,7 @@ func TestRepositoryListActivePatientsWithPagination_Integration(t *testing.T) {
 	}
 
 	// List active patients
-	patients, total, err := repo.ListActivePatientsWithPagination(context.Background(), schemaName, 10, 0, "")
+	patients, total, err := repo.ListActivePatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListActivePatientsWithPagination failed: %v", err)
 	}
@@ -393,7 +518,7 @@ func TestRepositoryUpdate_Integration(t *testing.T) {
 		CareplanType: &newCareplan,
 	}
 
-	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq)
+	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("UpdatePatient failed: %v", err)
 	}
@@ -440,7 +565,7 @@ func TestRepositoryDelete_Integration(t *testing.T) {
 	}
 
 	// Delete patient
-	err = repo.DeletePatient(context.Background(), schemaName, orgID, patient.ID)
+	err = repo.DeletePatient(context.Background(), schemaName, orgID, patient.ID, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("DeletePatient failed: %v", err)
 	}
@@ -534,7 +659,7 @@ func TestRepositorySoftDelete_ExcludesFromList_Integration(t *testing.T) {
 	}
 
 	// Soft delete one patient
-	err = repo.DeletePatient(context.Background(), schemaName, orgID, patientIDs[0])
+	err = repo.DeletePatient(context.Background(), schemaName, orgID, patientIDs[0], etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("DeletePatient failed: %v", err)
 	}
@@ -583,7 +708,7 @@ func TestRepositoryUpdate_NotFound_Integration(t *testing.T) {
 		FirstName: &newName,
 	}
 
-	_, err := repo.UpdatePatient(context.Background(), schemaName, uuid.New().String(), updateReq)
+	_, err := repo.UpdatePatient(context.Background(), schemaName, uuid.New().String(), updateReq, etag.AnyVersion)
 	if err == nil {
 		t.Error("Expected error when updating non-existent patient, got nil")
 	}
@@ -598,7 +723,7 @@ func TestRepositoryDelete_NotFound_Integration(t *testing.T) {
 	orgID, schemaName := testutil.CreateTestOrg(t, db, "hospital_m")
 	repo := NewRepository(db, nil)
 
-	err := repo.DeletePatient(context.Background(), schemaName, orgID, uuid.New().String())
+	err := repo.DeletePatient(context.Background(), schemaName, orgID, uuid.New().String(), etag.AnyVersion)
 	if err == nil {
 		t.Error("Expected error when deleting non-existent patient, got nil")
 	}
@@ -628,13 +753,13 @@ func TestRepositoryDelete_AlreadyDeleted_Integration(t *testing.T) {
 	}
 
 	// Delete once
-	err = repo.DeletePatient(context.Background(), schemaName, orgID, patient.ID)
+	err = repo.DeletePatient(context.Background(), schemaName, orgID, patient.ID, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("First delete failed: %v", err)
 	}
 
 	// Try to delete again
-	err = repo.DeletePatient(context.Background(), schemaName, orgID, patient.ID)
+	err = repo.DeletePatient(context.Background(), schemaName, orgID, patient.ID, etag.AnyVersion)
 	if err == nil {
 		t.Error("Expected error on second delete, got nil")
 	}
@@ -681,7 +806,7 @@ func TestRepositoryPatient_CareplanFields_Integration(t *testing.T) {
 		CareplanFrequency: &newFreq,
 	}
 
-	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq)
+	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("UpdatePatient failed: %v", err)
 	}
@@ -736,7 +861,7 @@ func TestRepositoryPatient_EmergencyContact_Integration(t *testing.T) {
 		EmergencyContactPhone: &newPhone,
 	}
 
-	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq)
+	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("UpdatePatient failed: %v", err)
 	}
@@ -783,7 +908,7 @@ func TestRepositoryPatient_IsActiveFlag_Integration(t *testing.T) {
 		IsActive: &isActive,
 	}
 
-	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq)
+	updated, err := repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("UpdatePatient failed: %v", err)
 	}
@@ -793,7 +918,7 @@ func TestRepositoryPatient_IsActiveFlag_Integration(t *testing.T) {
 	}
 
 	// Verify inactive patient is excluded from active list
-	activePatients, _, err := repo.ListActivePatientsWithPagination(context.Background(), schemaName, 10, 0, "")
+	activePatients, _, err := repo.ListActivePatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListActivePatientsWithPagination failed: %v", err)
 	}
@@ -828,7 +953,7 @@ func TestRepositoryPatient_IsActiveFlag_Integration(t *testing.T) {
 		IsActive: &isActive,
 	}
 
-	updated, err = repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq)
+	updated, err = repo.UpdatePatient(context.Background(), schemaName, patient.ID, updateReq, etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("UpdatePatient reactivation failed: %v", err)
 	}
@@ -838,7 +963,7 @@ func TestRepositoryPatient_IsActiveFlag_Integration(t *testing.T) {
 	}
 
 	// Verify reactivated patient appears in active list
-	activePatients, _, err = repo.ListActivePatientsWithPagination(context.Background(), schemaName, 10, 0, "")
+	activePatients, _, err = repo.ListActivePatientsWithPagination(context.Background(), schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListActivePatientsWithPagination after reactivation failed: %v", err)
 	}
@@ -855,3 +980,51 @@ func TestRepositoryPatient_IsActiveFlag_Integration(t *testing.T) {
 		t.Error("Reactivated patient should appear in active patients list")
 	}
 }
+
+// TestRepositoryListAssignedCaregivers_Integration tests that caregivers with
+// care sessions are found by the user ID or Keycloak user ID the session records
+func TestRepositoryListAssignedCaregivers_Integration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	orgID, schemaName := testutil.CreateTestOrg(t, db, "hospital_assigned")
+	repo := NewRepository(db, nil)
+
+	patient, err := repo.CreatePatient(context.Background(), schemaName, orgID, uuid.New().String(), CreatePatientRequest{FirstName: "Cara", LastName: "Patient"})
+	if err != nil {
+		t.Fatalf("CreatePatient failed: %v", err)
+	}
+
+	// One session records the user ID, one the Keycloak user ID, and one
+	// belongs to a deleted caregiver
+	caregivers := make([]struct{ id, keycloakID string }, 3)
+	for i := range caregivers {
+		caregivers[i].keycloakID = uuid.New().String()
+		err := db.QueryRow(`INSERT INTO `+schemaName+`.users (keycloak_user_id, role) VALUES ($1, 'CAREGIVER') RETURNING id`, caregivers[i].keycloakID).Scan(&caregivers[i].id)
+		if err != nil {
+			t.Fatalf("Failed to create caregiver: %v", err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE `+schemaName+`.users SET deleted_at = now() WHERE id = $1`, caregivers[2].id); err != nil {
+		t.Fatalf("Failed to delete caregiver: %v", err)
+	}
+	for _, caregiverID := range []string{caregivers[0].id, caregivers[1].keycloakID, caregivers[2].id} {
+		if _, err := db.Exec(`INSERT INTO `+schemaName+`.care_sessions (patient_id, caregiver_id) VALUES ($1, $2)`, patient.ID, caregiverID); err != nil {
+			t.Fatalf("Failed to create care session: %v", err)
+		}
+	}
+
+	assigned, err := repo.ListAssignedCaregivers(context.Background(), schemaName, patient.ID)
+	if err != nil {
+		t.Fatalf("ListAssignedCaregivers failed: %v", err)
+	}
+	if len(assigned) != 2 {
+		t.Fatalf("Expected 2 assigned caregivers, got %v", assigned)
+	}
+	for _, keycloakID := range []string{caregivers[0].keycloakID, caregivers[1].keycloakID} {
+		if assigned[0] != keycloakID && assigned[1] != keycloakID {
+			t.Errorf("Expected %s to be assigned, got %v", keycloakID, assigned)
+		}
+	}
+}
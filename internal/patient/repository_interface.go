@@ -1,17 +1,27 @@
 package patient
 
-import "context"
+import (
+	"context"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+)
+
+// ErrPatientNotFound is returned when no patient matches in the tenant schema
+var ErrPatientNotFound = apierror.NotFound(apierror.CodeNotFound, "patient not found")
 
 // RepositoryInterface defines the contract for patient data access
 type RepositoryInterface interface {
 	CreatePatient(ctx context.Context, schemaName string, orgID string, keycloakUserID string, req CreatePatientRequest) (*PatientResponse, error)
 	ListPatients(ctx context.Context, schemaName string) ([]PatientResponse, error)
-	ListPatientsWithPagination(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error)
-	ListActivePatientsWithPagination(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error)
+	ListPatientsWithPagination(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error)
+	ListActivePatientsWithPagination(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error)
+	SearchPatients(ctx context.Context, schemaName string, terms PatientSearchTerms, limit, offset int) ([]PatientSearchResult, int, error)
 	GetPatient(ctx context.Context, schemaName string, id string) (*PatientResponse, error)
 	GetByKeycloakID(ctx context.Context, schemaName string, keycloakUserID string) (*PatientResponse, error)
-	UpdatePatient(ctx context.Context, schemaName string, id string, req UpdatePatientRequest) (*PatientResponse, error)
-	DeletePatient(ctx context.Context, schemaName string, orgID string, id string) error
+	ListAssignedCaregivers(ctx context.Context, schemaName string, patientID string) ([]string, error)
+	UpdatePatient(ctx context.Context, schemaName string, id string, req UpdatePatientRequest, version int) (*PatientResponse, error)
+	DeletePatient(ctx context.Context, schemaName string, orgID string, id string, version int) error
 }
 
 // Ensure Repository implements RepositoryInterface
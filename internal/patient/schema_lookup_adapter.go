@@ -21,3 +21,8 @@ func NewDBSchemaLookup(db *sql.DB) *DBSchemaLookup {
 func (d *DBSchemaLookup) GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error) {
 	return organization.GetSchemaNameByOrgID(ctx, d.db, orgID)
 }
+
+// GetOrgIDBySchemaName looks up the organization that owns a schema
+func (d *DBSchemaLookup) GetOrgIDBySchemaName(ctx context.Context, schemaName string) (string, error) {
+	return organization.GetOrgIDBySchemaName(ctx, d.db, schemaName)
+}
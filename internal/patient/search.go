@@ -0,0 +1,95 @@
+package patient
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+// ErrEmptySearchQuery is returned when a patient search has no usable terms
+var ErrEmptySearchQuery = apierror.BadRequest(apierror.CodeValidation, "search query is required")
+
+// minPhoneDigits is the shortest digit sequence treated as a phone number.
+// Phone numbers are matched on their trailing digits so that 06..., +316...
+// and 00316... find the same patient.
+const (
+	minPhoneDigits   = 6
+	phoneSuffixWidth = 9
+)
+
+// Date formats accepted for date of birth searches
+var searchDateLayouts = []string{"2006-01-02", "02-01-2006", "02/01/2006", "2-1-2006"}
+
+// PatientSearchTerms is a parsed patient search query
+type PatientSearchTerms struct {
+	Text        string // Raw query, matched against patient_id and fuzzily against names
+	TSQuery     string // Prefix full-text query over names and email, empty if no words
+	PhoneDigits string // Trailing phone digits, empty if the query is not a phone number
+	BirthDate   string // YYYY-MM-DD, empty if the query is not a date
+}
+
+// ParseSearchQuery works out which patient fields a free-text query can
+// match: a date of birth, a phone number, or names and patient IDs.
+func ParseSearchQuery(q string) (PatientSearchTerms, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return PatientSearchTerms{}, ErrEmptySearchQuery
+	}
+
+	terms := PatientSearchTerms{Text: q}
+
+	for _, layout := range searchDateLayouts {
+		if date, err := time.Parse(layout, q); err == nil {
+			terms.BirthDate = date.Format("2006-01-02")
+			return terms, nil
+		}
+	}
+
+	if digits, ok := phoneDigits(q); ok {
+		terms.PhoneDigits = digits
+		return terms, nil
+	}
+
+	terms.TSQuery = prefixTSQuery(q)
+	return terms, nil
+}
+
+// phoneDigits returns the trailing digits of q if it only consists of digits
+// and common phone number punctuation
+func phoneDigits(q string) (string, bool) {
+	var digits strings.Builder
+	for _, r := range q {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' || r == '-' || r == ' ' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", false
+		}
+	}
+
+	s := digits.String()
+	if len(s) < minPhoneDigits {
+		return "", false
+	}
+	if len(s) > phoneSuffixWidth {
+		s = s[len(s)-phoneSuffixWidth:]
+	}
+	return s, true
+}
+
+// prefixTSQuery turns the words of q into a tsquery that matches every word
+// as a prefix, e.g. "jan de" becomes "jan:* & de:*"
+func prefixTSQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	parts := make([]string, 0, len(words))
+	for _, word := range words {
+		parts = append(parts, word+":*")
+	}
+	return strings.Join(parts, " & ")
+}
@@ -0,0 +1,117 @@
+package patient
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected PatientSearchTerms
+	}{
+		{
+			name:     "surname",
+			query:    "  Janssen ",
+			expected: PatientSearchTerms{Text: "Janssen", TSQuery: "janssen:*"},
+		},
+		{
+			name:     "full name with particle",
+			query:    "Jan de Vries",
+			expected: PatientSearchTerms{Text: "Jan de Vries", TSQuery: "jan:* & de:* & vries:*"},
+		},
+		{
+			name:     "patient id",
+			query:    "PT-0001",
+			expected: PatientSearchTerms{Text: "PT-0001", TSQuery: "pt:* & 0001:*"},
+		},
+		{
+			name:     "ISO date",
+			query:    "1950-02-01",
+			expected: PatientSearchTerms{Text: "1950-02-01", BirthDate: "1950-02-01"},
+		},
+		{
+			name:     "Dutch date",
+			query:    "01-02-1950",
+			expected: PatientSearchTerms{Text: "01-02-1950", BirthDate: "1950-02-01"},
+		},
+		{
+			name:     "international phone",
+			query:    "+31 6 1234 5678",
+			expected: PatientSearchTerms{Text: "+31 6 1234 5678", PhoneDigits: "612345678"},
+		},
+		{
+			name:     "national phone",
+			query:    "06-12345678",
+			expected: PatientSearchTerms{Text: "06-12345678", PhoneDigits: "612345678"},
+		},
+		{
+			name:     "short number is not a phone",
+			query:    "1234",
+			expected: PatientSearchTerms{Text: "1234", TSQuery: "1234:*"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terms, err := ParseSearchQuery(tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if terms != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, terms)
+			}
+		})
+	}
+}
+
+func TestParseSearchQuery_Empty(t *testing.T) {
+	if _, err := ParseSearchQuery("   "); err != ErrEmptySearchQuery {
+		t.Errorf("Expected ErrEmptySearchQuery, got %v", err)
+	}
+}
+
+func TestBuildPatientSearch(t *testing.T) {
+	match, score, args := buildPatientSearch(PatientSearchTerms{Text: "06-12345678", PhoneDigits: "612345678"})
+
+	if len(args) != 2 || args[1] != "612345678" {
+		t.Errorf("Unexpected args: %v", args)
+	}
+	if strings.Contains(match, "to_tsquery") || strings.Contains(score, "word_similarity") {
+		t.Errorf("Phone search should not use name matching: %s", match)
+	}
+	if !strings.Contains(match, "LIKE '%' || $2") {
+		t.Errorf("Expected a phone suffix match, got %s", match)
+	}
+}
+
+func TestServiceSearchPatients(t *testing.T) {
+	var gotTerms PatientSearchTerms
+	var gotLimit int
+	mockRepo := &mockRepository{
+		searchPatientsFunc: func(ctx context.Context, schemaName string, terms PatientSearchTerms, limit, offset int) ([]PatientSearchResult, int, error) {
+			gotTerms, gotLimit = terms, limit
+			return nil, 0, nil
+		},
+	}
+	service := NewService(mockRepo, &mockKeycloakAdmin{})
+
+	response, err := service.SearchPatients(context.Background(), "org_test", pagination.Params{Search: "Jansen", Limit: 500})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotTerms.TSQuery != "jansen:*" || gotLimit != pagination.MaxLimit {
+		t.Errorf("Unexpected repository call: %+v limit %d", gotTerms, gotLimit)
+	}
+	if response.Patients == nil || response.Query != "Jansen" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	if _, err := service.SearchPatients(context.Background(), "org_test", pagination.Params{}); err != ErrEmptySearchQuery {
+		t.Errorf("Expected ErrEmptySearchQuery, got %v", err)
+	}
+}
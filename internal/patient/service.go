@@ -3,51 +3,47 @@ package patient
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/telemetry"
 )
 
+// MetricsRecorder interface for recording patient operation metrics
+type MetricsRecorder interface {
+	RecordPatientOperation(ctx context.Context, operation, tenant, outcome string)
+}
+
 type Service struct {
 	repo          RepositoryInterface
 	keycloakAdmin KeycloakAdminInterface
+	metrics       MetricsRecorder
 }
 
 func NewService(repo RepositoryInterface, keycloakAdmin KeycloakAdminInterface) *Service {
+	return NewServiceWithMetrics(repo, keycloakAdmin, nil)
+}
+
+// NewServiceWithMetrics creates a patient service that counts its operations
+func NewServiceWithMetrics(repo RepositoryInterface, keycloakAdmin KeycloakAdminInterface, metrics MetricsRecorder) *Service {
 	return &Service{
 		repo:          repo,
 		keycloakAdmin: keycloakAdmin,
+		metrics:       metrics,
 	}
 }
 
-func (s *Service) CreatePatient(ctx context.Context, schemaName string, orgID string, req CreatePatientRequest) (*PatientResponse, error) {
+func (s *Service) CreatePatient(ctx context.Context, schemaName string, orgID string, req CreatePatientRequest) (_ *PatientResponse, err error) {
+	defer func() { s.recordOperation(ctx, "create", schemaName, err) }()
+
 	if s.keycloakAdmin == nil {
-		log.Printf("Keycloak admin client is not initialized")
+		slog.ErrorContext(ctx, "Keycloak admin client is not initialized")
 		return nil, fmt.Errorf("keycloak admin client is not available")
 	}
 
-	// Validate required fields
-	if req.Username == "" {
-		return nil, fmt.Errorf("username is required")
-	}
-	if req.Email == "" {
-		return nil, fmt.Errorf("email is required")
-	}
-	if req.FirstName == "" {
-		return nil, fmt.Errorf("first name is required")
-	}
-	if req.LastName == "" {
-		return nil, fmt.Errorf("last name is required")
-	}
-	if req.DateOfBirth == "" {
-		return nil, fmt.Errorf("date of birth is required")
-	}
-	if req.Address == "" {
-		return nil, fmt.Errorf("address is required")
-	}
-	if req.TemporaryPassword == "" && !req.SendResetEmail {
-		return nil, fmt.Errorf("either temporaryPassword or sendResetEmail must be provided")
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
 	// Create user in Keycloak
@@ -63,60 +59,61 @@ func (s *Service) CreatePatient(ctx context.Context, schemaName string, orgID st
 		},
 	}
 
-	log.Printf("Creating patient in Keycloak: %s", req.Username)
-	keycloakUserID, err := s.keycloakAdmin.CreateUser(keycloakUser)
+	slog.InfoContext(ctx, "creating patient in Keycloak", "username", req.Username)
+	keycloakUserID, err := s.keycloakAdmin.CreateUser(ctx, keycloakUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user in Keycloak: %w", err)
 	}
-	log.Printf("Created patient in Keycloak with ID: %s", keycloakUserID)
+	slog.InfoContext(ctx, "created patient in Keycloak", "keycloak_id", keycloakUserID)
 
 	// Set password or send reset email
 	if req.TemporaryPassword != "" {
-		err = s.keycloakAdmin.SetPassword(keycloakUserID, req.TemporaryPassword, false)
+		err = s.keycloakAdmin.SetPassword(ctx, keycloakUserID, req.TemporaryPassword, false)
 		if err != nil {
-			log.Printf("Failed to set password, rolling back: %s", keycloakUserID)
-			_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+			slog.ErrorContext(ctx, "failed to set password, rolling back", "keycloak_id", keycloakUserID, "error", err)
+			_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 			return nil, fmt.Errorf("failed to set password: %w", err)
 		}
 	} else if req.SendResetEmail {
-		err = s.keycloakAdmin.SendEmailAction(keycloakUserID, []string{"UPDATE_PASSWORD"})
+		err = s.keycloakAdmin.SendEmailAction(ctx, keycloakUserID, []string{"UPDATE_PASSWORD"})
 		if err != nil {
-			log.Printf("Failed to send reset email, rolling back: %s", keycloakUserID)
-			_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+			slog.ErrorContext(ctx, "failed to send reset email, rolling back", "keycloak_id", keycloakUserID, "error", err)
+			_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 			return nil, fmt.Errorf("failed to send reset email: %w", err)
 		}
 	}
 
 	// Assign PATIENT role
-	role, err := s.keycloakAdmin.GetRole("PATIENT")
+	role, err := s.keycloakAdmin.GetRole(ctx, "PATIENT")
 	if err != nil {
-		log.Printf("Failed to get PATIENT role, rolling back: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.ErrorContext(ctx, "failed to get PATIENT role, rolling back", "keycloak_id", keycloakUserID, "error", err)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("failed to get PATIENT role: %w", err)
 	}
 
-	err = s.keycloakAdmin.AssignRole(keycloakUserID, *role)
+	err = s.keycloakAdmin.AssignRole(ctx, keycloakUserID, *role)
 	if err != nil {
-		log.Printf("Failed to assign PATIENT role, rolling back: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.ErrorContext(ctx, "failed to assign PATIENT role, rolling back", "keycloak_id", keycloakUserID, "error", err)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("failed to assign PATIENT role: %w", err)
 	}
 
 	// Create patient in database with keycloak_user_id
 	patient, err := s.repo.CreatePatient(ctx, schemaName, orgID, keycloakUserID, req)
 	if err != nil {
-		log.Printf("Failed to create patient in database, rolling back: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.ErrorContext(ctx, "failed to create patient in database, rolling back", "keycloak_id", keycloakUserID, "error", err)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("failed to create patient in database: %w", err)
 	}
 
-	log.Printf("Successfully created patient end-to-end: %s (Keycloak ID: %s, DB ID: %s)", req.Username, keycloakUserID, patient.ID)
+	slog.InfoContext(ctx, "created patient end-to-end", "username", req.Username, "keycloak_id", keycloakUserID, "patient_id", patient.ID)
 
 	return patient, nil
 }
 
 func (s *Service) ListPatients(ctx context.Context, schemaName string) ([]PatientResponse, error) {
 	patients, err := s.repo.ListPatients(ctx, schemaName)
+	s.recordOperation(ctx, "list", schemaName, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list patients: %w", err)
 	}
@@ -124,18 +121,26 @@ func (s *Service) ListPatients(ctx context.Context, schemaName string) ([]Patien
 }
 
 // ListPatientsWithPagination retrieves patients with pagination
-func (s *Service) ListPatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (*PaginatedPatientListResponse, error) {
-	// Validate pagination parameters
-	params.Validate()
+func (s *Service) ListPatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (_ *PaginatedPatientListResponse, err error) {
+	defer func() { s.recordOperation(ctx, "list", schemaName, err) }()
+
+	// Validate pagination, sort and filter parameters and resolve the cursor
+	window, err := params.Window()
+	if err != nil {
+		return nil, err
+	}
+	if err := patientListFields.Check(params); err != nil {
+		return nil, err
+	}
 
 	// Get paginated data from repository
-	patients, totalCount, err := s.repo.ListPatientsWithPagination(ctx, schemaName, params.Limit, params.CalculateOffset(), params.Search)
+	patients, totalCount, err := s.repo.ListPatientsWithPagination(ctx, schemaName, window, params.Query())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list patients: %w", err)
 	}
 
-	// Calculate pagination metadata
-	meta := params.CalculateMeta(totalCount)
+	// Trim to the page and calculate pagination metadata
+	patients, meta := pagination.NewPage(&params, window, patients, totalCount, patientCursor)
 
 	response := &PaginatedPatientListResponse{
 		Success:    true,
@@ -147,18 +152,26 @@ func (s *Service) ListPatientsWithPagination(ctx context.Context, schemaName str
 }
 
 // ListActivePatientsWithPagination retrieves active patients (not soft deleted and is_active = true) with pagination
-func (s *Service) ListActivePatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (*PaginatedPatientListResponse, error) {
-	// Validate pagination parameters
-	params.Validate()
+func (s *Service) ListActivePatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (_ *PaginatedPatientListResponse, err error) {
+	defer func() { s.recordOperation(ctx, "list", schemaName, err) }()
+
+	// Validate pagination, sort and filter parameters and resolve the cursor
+	window, err := params.Window()
+	if err != nil {
+		return nil, err
+	}
+	if err := patientListFields.Check(params); err != nil {
+		return nil, err
+	}
 
 	// Get paginated data from repository
-	patients, totalCount, err := s.repo.ListActivePatientsWithPagination(ctx, schemaName, params.Limit, params.CalculateOffset(), params.Search)
+	patients, totalCount, err := s.repo.ListActivePatientsWithPagination(ctx, schemaName, window, params.Query())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list active patients: %w", err)
 	}
 
-	// Calculate pagination metadata
-	meta := params.CalculateMeta(totalCount)
+	// Trim to the page and calculate pagination metadata
+	patients, meta := pagination.NewPage(&params, window, patients, totalCount, patientCursor)
 
 	response := &PaginatedPatientListResponse{
 		Success:    true,
@@ -169,6 +182,35 @@ func (s *Service) ListActivePatientsWithPagination(ctx context.Context, schemaNa
 	return response, nil
 }
 
+// SearchPatients runs a ranked fuzzy search over the patients of a tenant.
+// params.Search holds the query.
+func (s *Service) SearchPatients(ctx context.Context, schemaName string, params pagination.Params) (*PatientSearchResponse, error) {
+	terms, err := ParseSearchQuery(params.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate pagination parameters
+	params.Validate()
+
+	results, totalCount, err := s.repo.SearchPatients(ctx, schemaName, terms, params.Limit, params.CalculateOffset())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search patients: %w", err)
+	}
+	if results == nil {
+		results = []PatientSearchResult{}
+	}
+
+	response := &PatientSearchResponse{
+		Success:    true,
+		Query:      terms.Text,
+		Patients:   results,
+		Pagination: params.CalculateMeta(totalCount),
+	}
+
+	return response, nil
+}
+
 func (s *Service) GetPatient(ctx context.Context, schemaName string, id string) (*PatientResponse, error) {
 	patient, err := s.repo.GetPatient(ctx, schemaName, id)
 	if err != nil {
@@ -186,18 +228,47 @@ func (s *Service) GetMyPatient(ctx context.Context, schemaName string, keycloakU
 	return patient, nil
 }
 
-func (s *Service) UpdatePatient(ctx context.Context, schemaName string, id string, req UpdatePatientRequest) (*PatientResponse, error) {
-	patient, err := s.repo.UpdatePatient(ctx, schemaName, id, req)
+// ListAssignedCaregivers returns the Keycloak user IDs of the caregivers
+// assigned to a patient
+func (s *Service) ListAssignedCaregivers(ctx context.Context, schemaName string, patientID string) ([]string, error) {
+	caregivers, err := s.repo.ListAssignedCaregivers(ctx, schemaName, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assigned caregivers: %w", err)
+	}
+	return caregivers, nil
+}
+
+func (s *Service) UpdatePatient(ctx context.Context, schemaName string, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
+	if err := req.Validate(); err != nil {
+		s.recordOperation(ctx, "update", schemaName, err)
+		return nil, err
+	}
+
+	patient, err := s.repo.UpdatePatient(ctx, schemaName, id, req, version)
+	s.recordOperation(ctx, "update", schemaName, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update patient: %w", err)
 	}
 	return patient, nil
 }
 
-func (s *Service) DeletePatient(ctx context.Context, schemaName string, orgID string, id string) error {
-	err := s.repo.DeletePatient(ctx, schemaName, orgID, id)
+func (s *Service) DeletePatient(ctx context.Context, schemaName string, orgID string, id string, version int) error {
+	err := s.repo.DeletePatient(ctx, schemaName, orgID, id, version)
+	s.recordOperation(ctx, "delete", schemaName, err)
 	if err != nil {
 		return fmt.Errorf("failed to delete patient: %w", err)
 	}
 	return nil
 }
+
+// recordOperation counts a patient operation and its outcome for the tenant
+func (s *Service) recordOperation(ctx context.Context, operation, schemaName string, err error) {
+	if s.metrics != nil {
+		s.metrics.RecordPatientOperation(ctx, operation, schemaName, telemetry.OutcomeOf(err))
+	}
+}
+
+// patientCursor returns the list position of a patient
+func patientCursor(p PatientResponse) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
@@ -11,14 +11,18 @@ type ServiceInterface interface {
 	CreatePatient(ctx context.Context, schemaName, orgID string, req CreatePatientRequest) (*PatientResponse, error)
 	GetPatient(ctx context.Context, schemaName, id string) (*PatientResponse, error)
 	GetMyPatient(ctx context.Context, schemaName string, keycloakUserID string) (*PatientResponse, error)
+	ListAssignedCaregivers(ctx context.Context, schemaName, patientID string) ([]string, error)
 	ListPatients(ctx context.Context, schemaName string) ([]PatientResponse, error)
 	ListPatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (*PaginatedPatientListResponse, error)
 	ListActivePatientsWithPagination(ctx context.Context, schemaName string, params pagination.Params) (*PaginatedPatientListResponse, error)
-	UpdatePatient(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error)
-	DeletePatient(ctx context.Context, schemaName, orgID, id string) error
+	SearchPatients(ctx context.Context, schemaName string, params pagination.Params) (*PatientSearchResponse, error)
+	UpdatePatient(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error)
+	DeletePatient(ctx context.Context, schemaName, orgID, id string, version int) error
 }
 
 // SchemaLookup defines the contract for looking up organization schemas
 type SchemaLookup interface {
 	GetSchemaNameByOrgID(ctx context.Context, orgID string) (string, error)
+	// GetOrgIDBySchemaName returns "" when no organization owns the schema
+	GetOrgIDBySchemaName(ctx context.Context, schemaName string) (string, error)
 }
@@ -3,10 +3,13 @@ package patient
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 )
 
@@ -162,6 +165,56 @@ func TestCreatePatient_ValidationError(t *testing.T) {
 				Address:     "123 Main St",
 			},
 		},
+		{
+			name: "Invalid email",
+			req: CreatePatientRequest{
+				Username:          "patient1",
+				Email:             "patient.example.com",
+				FirstName:         "John",
+				LastName:          "Doe",
+				DateOfBirth:       "1980-01-01",
+				Address:           "123 Main St",
+				TemporaryPassword: "temp123",
+			},
+		},
+		{
+			name: "Date of birth in the future",
+			req: CreatePatientRequest{
+				Username:          "patient1",
+				Email:             "patient@example.com",
+				FirstName:         "John",
+				LastName:          "Doe",
+				DateOfBirth:       "2999-01-01",
+				Address:           "123 Main St",
+				TemporaryPassword: "temp123",
+			},
+		},
+		{
+			name: "Phone number not in E.164 format",
+			req: CreatePatientRequest{
+				Username:          "patient1",
+				Email:             "patient@example.com",
+				FirstName:         "John",
+				LastName:          "Doe",
+				PhoneNumber:       "0612345678",
+				DateOfBirth:       "1980-01-01",
+				Address:           "123 Main St",
+				TemporaryPassword: "temp123",
+			},
+		},
+		{
+			name: "Careplan type too long",
+			req: CreatePatientRequest{
+				Username:          "patient1",
+				Email:             "patient@example.com",
+				FirstName:         "John",
+				LastName:          "Doe",
+				DateOfBirth:       "1980-01-01",
+				Address:           "123 Main St",
+				TemporaryPassword: "temp123",
+				CareplanType:      strings.Repeat("x", 101),
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -315,9 +368,9 @@ func TestListPatients_Success(t *testing.T) {
 // TestListPatientsWithPagination_Success tests pagination
 func TestListPatientsWithPagination_Success(t *testing.T) {
 	mockRepo := &mockRepository{
-		listPatientsWithPaginationFunc: func(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error) {
-			patients := make([]PatientResponse, limit)
-			for i := 0; i < limit; i++ {
+		listPatientsWithPaginationFunc: func(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
+			patients := make([]PatientResponse, window.Limit)
+			for i := 0; i < window.Limit; i++ {
 				patients[i] = PatientResponse{
 					ID:        string(rune('0' + i)),
 					FirstName: "Patient",
@@ -351,7 +404,7 @@ func TestListPatientsWithPagination_Success(t *testing.T) {
 // TestListActivePatientsWithPagination_Success tests active patient filtering
 func TestListActivePatientsWithPagination_Success(t *testing.T) {
 	mockRepo := &mockRepository{
-		listActivePatientsFunc: func(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error) {
+		listActivePatientsFunc: func(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
 			return []PatientResponse{
 				{ID: "patient-1", FirstName: "Active", IsActive: true},
 				{ID: "patient-2", FirstName: "Patient", IsActive: true},
@@ -382,6 +435,69 @@ func TestListActivePatientsWithPagination_Success(t *testing.T) {
 	}
 }
 
+// TestListPatientsWithPagination_SortAndFilters tests that sort and filters reach the repository
+func TestListPatientsWithPagination_SortAndFilters(t *testing.T) {
+	var got pagination.Query
+	mockRepo := &mockRepository{
+		listPatientsWithPaginationFunc: func(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
+			got = listQuery
+			return []PatientResponse{}, 0, nil
+		},
+	}
+
+	service := NewService(mockRepo, &mockKeycloakAdmin{})
+
+	params := pagination.Params{
+		Page:    1,
+		Limit:   10,
+		Sort:    "last_name:asc,date_of_birth:desc",
+		Filters: map[string]string{"careplan_type": "intensive", "born_after": "1950-01-01"},
+	}
+
+	_, err := service.ListPatientsWithPagination(context.Background(), "org_test_12345678", params)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got.Sort != params.Sort || got.Filters["careplan_type"] != "intensive" {
+		t.Errorf("Expected sort and filters to be passed on, got %+v", got)
+	}
+	if order := patientListFields.OrderBy(got.Sort); order != "last_name ASC NULLS LAST, date_of_birth DESC NULLS LAST, id ASC" {
+		t.Errorf("Unexpected ORDER BY %q", order)
+	}
+}
+
+// TestListPatientsWithPagination_InvalidParams tests that unknown sort keys and malformed filters are rejected
+func TestListPatientsWithPagination_InvalidParams(t *testing.T) {
+	mockRepo := &mockRepository{
+		listPatientsWithPaginationFunc: func(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
+			t.Error("Repository should not be called")
+			return nil, 0, nil
+		},
+	}
+
+	service := NewService(mockRepo, &mockKeycloakAdmin{})
+
+	tests := []struct {
+		name   string
+		params pagination.Params
+		want   error
+	}{
+		{"unknown sort key", pagination.Params{Sort: "medical_notes"}, pagination.ErrInvalidSort},
+		{"sql in sort", pagination.Params{Sort: "last_name; DROP TABLE patients"}, pagination.ErrInvalidSort},
+		{"malformed date", pagination.Params{Filters: map[string]string{"created_after": "yesterday"}}, pagination.ErrInvalidFilter},
+		{"cursor with sort", pagination.Params{Sort: "last_name", Cursor: "abc"}, pagination.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ListPatientsWithPagination(context.Background(), "org_test_12345678", tt.params)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
 // TestGetPatient_Success tests retrieving a single patient
 func TestGetPatient_Success(t *testing.T) {
 	mockRepo := &mockRepository{
@@ -418,7 +534,7 @@ func TestUpdatePatient_Success(t *testing.T) {
 	newAddress := "789 New St"
 
 	mockRepo := &mockRepository{
-		updatePatientFunc: func(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error) {
+		updatePatientFunc: func(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
 			return &PatientResponse{
 				ID:      id,
 				Email:   *req.Email,
@@ -435,7 +551,7 @@ func TestUpdatePatient_Success(t *testing.T) {
 		Address: &newAddress,
 	}
 
-	patient, err := service.UpdatePatient(context.Background(), "org_test_12345678", "patient-123", req)
+	patient, err := service.UpdatePatient(context.Background(), "org_test_12345678", "patient-123", req, etag.AnyVersion)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -448,10 +564,46 @@ func TestUpdatePatient_Success(t *testing.T) {
 	}
 }
 
+// TestUpdatePatient_ValidationError tests that all invalid fields are reported
+// and the repository is not called
+func TestUpdatePatient_ValidationError(t *testing.T) {
+	mockRepo := &mockRepository{
+		updatePatientFunc: func(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
+			t.Error("Expected repository not to be called")
+			return nil, nil
+		},
+	}
+	service := NewService(mockRepo, &mockKeycloakAdmin{})
+
+	empty := ""
+	badDate := "01-01-1980"
+	badFrequency := strings.Repeat("x", 101)
+	req := UpdatePatientRequest{
+		FirstName:         &empty,
+		DateOfBirth:       &badDate,
+		CareplanFrequency: &badFrequency,
+	}
+
+	_, err := service.UpdatePatient(context.Background(), "org_test_12345678", "patient-123", req, etag.AnyVersion)
+
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if len(apiErr.Errors) != 3 {
+		t.Fatalf("Expected 3 field errors, got %+v", apiErr.Errors)
+	}
+	for i, field := range []string{"first_name", "date_of_birth", "careplan_frequency"} {
+		if apiErr.Errors[i].Field != field {
+			t.Errorf("Expected field error %d for %s, got %s", i, field, apiErr.Errors[i].Field)
+		}
+	}
+}
+
 // TestDeletePatient_Success tests successful patient deletion
 func TestDeletePatient_Success(t *testing.T) {
 	mockRepo := &mockRepository{
-		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string) error {
+		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string, version int) error {
 			return nil
 		},
 	}
@@ -459,7 +611,7 @@ func TestDeletePatient_Success(t *testing.T) {
 	mockKeycloak := &mockKeycloakAdmin{}
 	service := NewService(mockRepo, mockKeycloak)
 
-	err := service.DeletePatient(context.Background(), "org_test_12345678", "org-123", "patient-123")
+	err := service.DeletePatient(context.Background(), "org_test_12345678", "org-123", "patient-123", etag.AnyVersion)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -469,7 +621,7 @@ func TestDeletePatient_Success(t *testing.T) {
 // TestDeletePatient_NotFound tests deleting non-existent patient
 func TestDeletePatient_NotFound(t *testing.T) {
 	mockRepo := &mockRepository{
-		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string) error {
+		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string, version int) error {
 			return errors.New("patient not found")
 		},
 	}
@@ -477,7 +629,7 @@ func TestDeletePatient_NotFound(t *testing.T) {
 	mockKeycloak := &mockKeycloakAdmin{}
 	service := NewService(mockRepo, mockKeycloak)
 
-	err := service.DeletePatient(context.Background(), "org_test_12345678", "org-123", "nonexistent")
+	err := service.DeletePatient(context.Background(), "org_test_12345678", "org-123", "nonexistent", etag.AnyVersion)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -486,15 +638,59 @@ func TestDeletePatient_NotFound(t *testing.T) {
 
 // Mock implementations
 
+// TestPatientOperationMetrics tests that operations are counted with tenant and outcome
+func TestPatientOperationMetrics(t *testing.T) {
+	mockRepo := &mockRepository{
+		updatePatientFunc: func(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
+			return &PatientResponse{ID: id}, nil
+		},
+		deletePatientFunc: func(ctx context.Context, schemaName, orgID, id string, version int) error {
+			return errors.New("patient not found")
+		},
+	}
+
+	metrics := &mockMetrics{}
+	service := NewServiceWithMetrics(mockRepo, &mockKeycloakAdmin{}, metrics)
+
+	_, _ = service.UpdatePatient(context.Background(), "org_test_12345678", "patient-1", UpdatePatientRequest{}, etag.AnyVersion)
+	_ = service.DeletePatient(context.Background(), "org_test_12345678", "org-123", "patient-1", etag.AnyVersion)
+	_, _ = service.ListPatientsWithPagination(context.Background(), "org_test_12345678", pagination.Params{Sort: "unknown"})
+
+	want := []string{
+		"update org_test_12345678 success",
+		"delete org_test_12345678 error",
+		"list org_test_12345678 error",
+	}
+	if len(metrics.operations) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, metrics.operations)
+	}
+	for i, op := range metrics.operations {
+		if op != want[i] {
+			t.Errorf("Operation %d: expected %q, got %q", i, want[i], op)
+		}
+	}
+}
+
+// mockMetrics records operations as "operation tenant outcome"
+type mockMetrics struct {
+	operations []string
+}
+
+func (m *mockMetrics) RecordPatientOperation(ctx context.Context, operation, tenant, outcome string) {
+	m.operations = append(m.operations, operation+" "+tenant+" "+outcome)
+}
+
 type mockRepository struct {
 	createPatientFunc              func(ctx context.Context, schemaName, orgID, keycloakUserID string, req CreatePatientRequest) (*PatientResponse, error)
 	listPatientsFunc               func(ctx context.Context, schemaName string) ([]PatientResponse, error)
-	listPatientsWithPaginationFunc func(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error)
-	listActivePatientsFunc         func(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error)
+	listPatientsWithPaginationFunc func(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error)
+	listActivePatientsFunc         func(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error)
+	searchPatientsFunc             func(ctx context.Context, schemaName string, terms PatientSearchTerms, limit, offset int) ([]PatientSearchResult, int, error)
 	getPatientFunc                 func(ctx context.Context, schemaName, id string) (*PatientResponse, error)
 	getByKeycloakIDFunc            func(ctx context.Context, schemaName string, keycloakUserID string) (*PatientResponse, error)
-	updatePatientFunc              func(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error)
-	deletePatientFunc              func(ctx context.Context, schemaName, orgID, id string) error
+	listAssignedCaregiversFunc     func(ctx context.Context, schemaName, patientID string) ([]string, error)
+	updatePatientFunc              func(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error)
+	deletePatientFunc              func(ctx context.Context, schemaName, orgID, id string, version int) error
 }
 
 func (m *mockRepository) CreatePatient(ctx context.Context, schemaName, orgID, keycloakUserID string, req CreatePatientRequest) (*PatientResponse, error) {
@@ -511,16 +707,23 @@ func (m *mockRepository) ListPatients(ctx context.Context, schemaName string) ([
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) ListPatientsWithPagination(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error) {
+func (m *mockRepository) ListPatientsWithPagination(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
 	if m.listPatientsWithPaginationFunc != nil {
-		return m.listPatientsWithPaginationFunc(ctx, schemaName, limit, offset, search)
+		return m.listPatientsWithPaginationFunc(ctx, schemaName, window, listQuery)
 	}
 	return nil, 0, errors.New("not implemented")
 }
 
-func (m *mockRepository) ListActivePatientsWithPagination(ctx context.Context, schemaName string, limit, offset int, search string) ([]PatientResponse, int, error) {
+func (m *mockRepository) ListActivePatientsWithPagination(ctx context.Context, schemaName string, window pagination.Window, listQuery pagination.Query) ([]PatientResponse, int, error) {
 	if m.listActivePatientsFunc != nil {
-		return m.listActivePatientsFunc(ctx, schemaName, limit, offset, search)
+		return m.listActivePatientsFunc(ctx, schemaName, window, listQuery)
+	}
+	return nil, 0, errors.New("not implemented")
+}
+
+func (m *mockRepository) SearchPatients(ctx context.Context, schemaName string, terms PatientSearchTerms, limit, offset int) ([]PatientSearchResult, int, error) {
+	if m.searchPatientsFunc != nil {
+		return m.searchPatientsFunc(ctx, schemaName, terms, limit, offset)
 	}
 	return nil, 0, errors.New("not implemented")
 }
@@ -539,16 +742,23 @@ func (m *mockRepository) GetByKeycloakID(ctx context.Context, schemaName string,
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) UpdatePatient(ctx context.Context, schemaName, id string, req UpdatePatientRequest) (*PatientResponse, error) {
+func (m *mockRepository) ListAssignedCaregivers(ctx context.Context, schemaName, patientID string) ([]string, error) {
+	if m.listAssignedCaregiversFunc != nil {
+		return m.listAssignedCaregiversFunc(ctx, schemaName, patientID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) UpdatePatient(ctx context.Context, schemaName, id string, req UpdatePatientRequest, version int) (*PatientResponse, error) {
 	if m.updatePatientFunc != nil {
-		return m.updatePatientFunc(ctx, schemaName, id, req)
+		return m.updatePatientFunc(ctx, schemaName, id, req, version)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) DeletePatient(ctx context.Context, schemaName, orgID, id string) error {
+func (m *mockRepository) DeletePatient(ctx context.Context, schemaName, orgID, id string, version int) error {
 	if m.deletePatientFunc != nil {
-		return m.deletePatientFunc(ctx, schemaName, orgID, id)
+		return m.deletePatientFunc(ctx, schemaName, orgID, id, version)
 	}
 	return errors.New("not implemented")
 }
@@ -562,42 +772,42 @@ type mockKeycloakAdmin struct {
 	deleteUserFunc      func(userID string) error
 }
 
-func (m *mockKeycloakAdmin) CreateUser(user auth.KeycloakUser) (string, error) {
+func (m *mockKeycloakAdmin) CreateUser(ctx context.Context, user auth.KeycloakUser) (string, error) {
 	if m.createUserFunc != nil {
 		return m.createUserFunc(user)
 	}
 	return "", errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) SetPassword(userID, password string, temporary bool) error {
+func (m *mockKeycloakAdmin) SetPassword(ctx context.Context, userID, password string, temporary bool) error {
 	if m.setPasswordFunc != nil {
 		return m.setPasswordFunc(userID, password, temporary)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) SendEmailAction(userID string, actions []string) error {
+func (m *mockKeycloakAdmin) SendEmailAction(ctx context.Context, userID string, actions []string) error {
 	if m.sendEmailActionFunc != nil {
 		return m.sendEmailActionFunc(userID, actions)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) GetRole(roleName string) (*auth.KeycloakRole, error) {
+func (m *mockKeycloakAdmin) GetRole(ctx context.Context, roleName string) (*auth.KeycloakRole, error) {
 	if m.getRoleFunc != nil {
 		return m.getRoleFunc(roleName)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) AssignRole(userID string, role auth.KeycloakRole) error {
+func (m *mockKeycloakAdmin) AssignRole(ctx context.Context, userID string, role auth.KeycloakRole) error {
 	if m.assignRoleFunc != nil {
 		return m.assignRoleFunc(userID, role)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) DeleteUser(userID string) error {
+func (m *mockKeycloakAdmin) DeleteUser(ctx context.Context, userID string) error {
 	if m.deleteUserFunc != nil {
 		return m.deleteUserFunc(userID)
 	}
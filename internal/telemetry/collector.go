@@ -0,0 +1,333 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CollectorConfig configures the tenant population collector
+type CollectorConfig struct {
+	Interval       time.Duration // Time between two collections
+	Concurrency    int           // Tenant schemas queried in parallel
+	PurgeRetention time.Duration // Age after which a deleted organization may be purged
+}
+
+// LoadCollectorConfig loads the collector configuration from environment
+// variables. purgeRetention is the retention period of the cleanup job.
+func LoadCollectorConfig(purgeRetention time.Duration) CollectorConfig {
+	// Get collection interval with default
+	interval := 5 * time.Minute
+	if intervalStr := os.Getenv("TENANT_METRICS_INTERVAL"); intervalStr != "" {
+		if duration, err := time.ParseDuration(intervalStr); err == nil && duration > 0 {
+			interval = duration
+		}
+	}
+
+	// Get concurrency with default
+	concurrency := 4
+	if concurrencyStr := os.Getenv("TENANT_METRICS_CONCURRENCY"); concurrencyStr != "" {
+		if n, err := strconv.Atoi(concurrencyStr); err == nil && n > 0 {
+			concurrency = n
+		}
+	}
+
+	return CollectorConfig{
+		Interval:       interval,
+		Concurrency:    concurrency,
+		PurgeRetention: purgeRetention,
+	}
+}
+
+// TenantStats is the population of one tenant schema
+type TenantStats struct {
+	ActivePatients    int64
+	ActiveStaffByRole map[string]int64
+	DeletedPatients   int64 // Soft-deleted, pending purge
+	DeletedStaff      int64 // Soft-deleted, pending purge
+}
+
+// TenantSnapshot is the result of one collection
+type TenantSnapshot struct {
+	Tenants                map[string]TenantStats // By schema name
+	OrganizationsPurgeable int64
+	CollectedAt            time.Time
+}
+
+// TenantCollector periodically queries every tenant schema and exports the
+// results as observable gauges. Gauges report the last completed collection,
+// so metric exports never hit the database.
+type TenantCollector struct {
+	db           *sql.DB
+	cfg          CollectorConfig
+	mu           sync.RWMutex
+	snapshot     TenantSnapshot
+	registration metric.Registration
+	started      bool
+	quit         chan struct{}
+	done         chan struct{}
+}
+
+// NewTenantCollector registers the tenant gauges. Call Start to begin
+// collecting and Close to stop.
+func NewTenantCollector(db *sql.DB, cfg CollectorConfig) (*TenantCollector, error) {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+
+	c := &TenantCollector{
+		db:   db,
+		cfg:  cfg,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	meter := otel.Meter("github.com/WailSalutem-Health-Care/organization-service")
+
+	activePatients, err := meter.Int64ObservableGauge(
+		"tenant_active_patients",
+		metric.WithDescription("Active patients per organization"),
+		metric.WithUnit("{patient}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	activeStaff, err := meter.Int64ObservableGauge(
+		"tenant_active_staff",
+		metric.WithDescription("Active staff per organization and role"),
+		metric.WithUnit("{user}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	softDeleted, err := meter.Int64ObservableGauge(
+		"tenant_soft_deleted_records",
+		metric.WithDescription("Soft-deleted records pending purge per organization"),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	purgeable, err := meter.Int64ObservableGauge(
+		"organizations_pending_purge",
+		metric.WithDescription("Deleted organizations past the retention period, eligible for cleanup"),
+		metric.WithUnit("{organization}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.registration, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		snapshot := c.Snapshot()
+		if snapshot.CollectedAt.IsZero() {
+			return nil
+		}
+
+		for tenant, stats := range snapshot.Tenants {
+			tenantAttr := attribute.String("tenant", tenant)
+			o.ObserveInt64(activePatients, stats.ActivePatients, metric.WithAttributes(tenantAttr))
+			for role, count := range stats.ActiveStaffByRole {
+				o.ObserveInt64(activeStaff, count, metric.WithAttributes(tenantAttr, attribute.String("role", role)))
+			}
+			o.ObserveInt64(softDeleted, stats.DeletedPatients, metric.WithAttributes(tenantAttr, attribute.String("table", "patients")))
+			o.ObserveInt64(softDeleted, stats.DeletedStaff, metric.WithAttributes(tenantAttr, attribute.String("table", "users")))
+		}
+		o.ObserveInt64(purgeable, snapshot.OrganizationsPurgeable)
+		return nil
+	}, activePatients, activeStaff, softDeleted, purgeable)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Start collects immediately and then at every interval in the background
+func (c *TenantCollector) Start() {
+	c.started = true
+	go c.loop()
+	log.Printf("✓ Tenant metrics collector started (interval %s, concurrency %d)", c.cfg.Interval, c.cfg.Concurrency)
+}
+
+func (c *TenantCollector) loop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.collectOnce()
+
+		select {
+		case <-ticker.C:
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// collectOnce runs one collection bounded by the interval
+func (c *TenantCollector) collectOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Interval)
+	defer cancel()
+
+	if err := c.Collect(ctx); err != nil {
+		log.Printf("Warning: tenant metrics collection failed: %v", err)
+	}
+}
+
+// Close stops the background collection and unregisters the gauges
+func (c *TenantCollector) Close() {
+	close(c.quit)
+	if c.started {
+		<-c.done
+	}
+	if err := c.registration.Unregister(); err != nil {
+		log.Printf("Error unregistering tenant gauges: %v", err)
+	}
+}
+
+// Snapshot returns the last completed collection
+func (c *TenantCollector) Snapshot() TenantSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshot
+}
+
+// Collect queries every tenant schema, at most cfg.Concurrency at a time, and
+// replaces the snapshot. Tenants that fail are logged and left out.
+func (c *TenantCollector) Collect(ctx context.Context) error {
+	cutoff := time.Now().Add(-c.cfg.PurgeRetention)
+
+	var purgeable int64
+	err := c.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM wailsalutem.organizations
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	`, cutoff).Scan(&purgeable)
+	if err != nil {
+		return fmt.Errorf("failed to count purgeable organizations: %w", err)
+	}
+
+	schemas, err := c.tenantSchemas(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		tenants = make(map[string]TenantStats, len(schemas))
+		slots   = make(chan struct{}, c.cfg.Concurrency)
+	)
+	for _, schema := range schemas {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(schema string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			stats, err := c.tenantStats(ctx, schema)
+			if err != nil {
+				log.Printf("Warning: failed to collect metrics for tenant %s: %v", schema, err)
+				return
+			}
+
+			mu.Lock()
+			tenants[schema] = stats
+			mu.Unlock()
+		}(schema)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	c.snapshot = TenantSnapshot{
+		Tenants:                tenants,
+		OrganizationsPurgeable: purgeable,
+		CollectedAt:            time.Now(),
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// tenantSchemas returns the schemas of all organizations that are not deleted
+func (c *TenantCollector) tenantSchemas(ctx context.Context) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT schema_name
+		FROM wailsalutem.organizations
+		WHERE deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant schema: %w", err)
+		}
+		schemas = append(schemas, schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant schemas: %w", err)
+	}
+
+	return schemas, nil
+}
+
+// tenantStats queries the population of one tenant schema
+func (c *TenantCollector) tenantStats(ctx context.Context, schema string) (TenantStats, error) {
+	quoted := pq.QuoteIdentifier(schema)
+	stats := TenantStats{ActiveStaffByRole: map[string]int64{}}
+
+	err := c.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			(SELECT COUNT(*) FROM %[1]s.patients WHERE deleted_at IS NULL AND is_active = true),
+			(SELECT COUNT(*) FROM %[1]s.patients WHERE deleted_at IS NOT NULL),
+			(SELECT COUNT(*) FROM %[1]s.users WHERE deleted_at IS NOT NULL)
+	`, quoted)).Scan(&stats.ActivePatients, &stats.DeletedPatients, &stats.DeletedStaff)
+	if err != nil {
+		return TenantStats{}, fmt.Errorf("failed to count records: %w", err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(role, ''), COUNT(*)
+		FROM %s.users
+		WHERE deleted_at IS NULL AND is_active = true
+		GROUP BY COALESCE(role, '')
+	`, quoted))
+	if err != nil {
+		return TenantStats{}, fmt.Errorf("failed to count staff: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role string
+		var count int64
+		if err := rows.Scan(&role, &count); err != nil {
+			return TenantStats{}, fmt.Errorf("failed to scan staff count: %w", err)
+		}
+		stats.ActiveStaffByRole[role] = count
+	}
+	if err := rows.Err(); err != nil {
+		return TenantStats{}, fmt.Errorf("error iterating staff counts: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
+)
+
+// TestTenantCollectorCollect_Integration tests counting the population of tenant schemas
+func TestTenantCollectorCollect_Integration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	_, schemaName := testutil.CreateTestOrg(t, db, "collector")
+
+	statements := []string{
+		`INSERT INTO %s.patients (first_name, last_name, is_active) VALUES ('Ans', 'Visser', true), ('Bert', 'Bakker', true), ('Cor', 'Mulder', false)`,
+		`INSERT INTO %s.patients (first_name, last_name, deleted_at) VALUES ('Dirk', 'Smit', NOW())`,
+		`INSERT INTO %s.users (keycloak_user_id, role) VALUES (gen_random_uuid(), 'CAREGIVER'), (gen_random_uuid(), 'CAREGIVER'), (gen_random_uuid(), 'ORG_ADMIN')`,
+		`INSERT INTO %s.users (keycloak_user_id, role, deleted_at) VALUES (gen_random_uuid(), 'CAREGIVER', NOW())`,
+		`INSERT INTO %s.users (keycloak_user_id) VALUES (gen_random_uuid())`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(fmt.Sprintf(statement, schemaName)); err != nil {
+			t.Fatalf("Failed to seed tenant: %v", err)
+		}
+	}
+
+	collector := &TenantCollector{db: db, cfg: CollectorConfig{Interval: time.Minute, Concurrency: 2, PurgeRetention: time.Hour}}
+	if err := collector.Collect(context.Background()); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	stats, ok := collector.Snapshot().Tenants[schemaName]
+	if !ok {
+		t.Fatalf("Expected stats for %s", schemaName)
+	}
+	if stats.ActivePatients != 2 {
+		t.Errorf("Expected 2 active patients, got %d", stats.ActivePatients)
+	}
+	if stats.ActiveStaffByRole["CAREGIVER"] != 2 || stats.ActiveStaffByRole["ORG_ADMIN"] != 1 || stats.ActiveStaffByRole[""] != 1 {
+		t.Errorf("Unexpected staff counts: %v", stats.ActiveStaffByRole)
+	}
+	if stats.DeletedPatients != 1 || stats.DeletedStaff != 1 {
+		t.Errorf("Expected 1 deleted patient and user, got %d and %d", stats.DeletedPatients, stats.DeletedStaff)
+	}
+}
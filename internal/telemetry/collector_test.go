@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestLoadCollectorConfig(t *testing.T) {
+	t.Setenv("TENANT_METRICS_INTERVAL", "90s")
+	t.Setenv("TENANT_METRICS_CONCURRENCY", "not-a-number")
+
+	cfg := LoadCollectorConfig(time.Hour)
+
+	if cfg.Interval != 90*time.Second {
+		t.Errorf("Expected interval 90s, got %s", cfg.Interval)
+	}
+	if cfg.Concurrency != 4 {
+		t.Errorf("Expected default concurrency 4, got %d", cfg.Concurrency)
+	}
+	if cfg.PurgeRetention != time.Hour {
+		t.Errorf("Expected retention 1h, got %s", cfg.PurgeRetention)
+	}
+}
+
+func TestTenantCollectorGauges(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(provider)
+	defer otel.SetMeterProvider(previous)
+
+	collector, err := NewTenantCollector(nil, CollectorConfig{Interval: time.Minute})
+	if err != nil {
+		t.Fatalf("NewTenantCollector failed: %v", err)
+	}
+	defer collector.Close()
+
+	// Nothing is observed before the first collection
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(gaugePoints(rm, "tenant_active_patients")) != 0 {
+		t.Error("Expected no observations before the first collection")
+	}
+
+	collector.snapshot = TenantSnapshot{
+		Tenants: map[string]TenantStats{
+			"org_acme_12345678": {
+				ActivePatients:    12,
+				ActiveStaffByRole: map[string]int64{"CAREGIVER": 3, "ORG_ADMIN": 1},
+				DeletedPatients:   2,
+			},
+		},
+		OrganizationsPurgeable: 1,
+		CollectedAt:            time.Now(),
+	}
+
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	patients := gaugePoints(rm, "tenant_active_patients")
+	if len(patients) != 1 || patients[0].Value != 12 {
+		t.Fatalf("Expected 12 active patients, got %+v", patients)
+	}
+	if tenant, _ := patients[0].Attributes.Value(attribute.Key("tenant")); tenant.AsString() != "org_acme_12345678" {
+		t.Errorf("Expected tenant attribute, got %v", patients[0].Attributes)
+	}
+
+	if staff := gaugePoints(rm, "tenant_active_staff"); len(staff) != 2 {
+		t.Errorf("Expected staff per role, got %+v", staff)
+	}
+	if deleted := gaugePoints(rm, "tenant_soft_deleted_records"); len(deleted) != 2 {
+		t.Errorf("Expected soft-deleted records per table, got %+v", deleted)
+	}
+	if purge := gaugePoints(rm, "organizations_pending_purge"); len(purge) != 1 || purge[0].Value != 1 {
+		t.Errorf("Expected 1 purgeable organization, got %+v", purge)
+	}
+}
+
+// gaugePoints returns the data points of an int64 gauge
+func gaugePoints(rm metricdata.ResourceMetrics, name string) []metricdata.DataPoint[int64] {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && m.Name == name {
+				return gauge.DataPoints
+			}
+		}
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ type Metrics struct {
 	// HTTP metrics
 	HTTPRequestsTotal    metric.Int64Counter
 	HTTPDurationMs       metric.Float64Histogram
+	HTTPThrottledTotal   metric.Int64Counter
 
 	// Business metrics
 	OrganizationTotal    metric.Int64Counter
@@ -23,6 +24,8 @@ type Metrics struct {
 	// Auth metrics
 	AuthFailuresTotal    metric.Int64Counter
 	PermissionCheckDuration metric.Float64Histogram
+	JWKSRefreshTotal     metric.Int64Counter
+	ServiceAccountRequestsTotal metric.Int64Counter
 }
 
 // InitMetrics initializes all custom metrics
@@ -49,6 +52,16 @@ func InitMetrics() (*Metrics, error) {
 		return nil, err
 	}
 
+	// Throttled request counter
+	httpThrottledTotal, err := meter.Int64Counter(
+		"http_server_throttled_total",
+		metric.WithDescription("Total number of requests rejected by rate limiting"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Organization counter
 	organizationTotal, err := meter.Int64Counter(
 		"organization_total",
@@ -99,21 +112,62 @@ func InitMetrics() (*Metrics, error) {
 		return nil, err
 	}
 
+	// JWKS refresh counter
+	jwksRefreshTotal, err := meter.Int64Counter(
+		"jwks_refresh_total",
+		metric.WithDescription("Total number of JWKS refreshes by trigger and outcome"),
+		metric.WithUnit("{refresh}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service account request counter
+	serviceAccountRequestsTotal, err := meter.Int64Counter(
+		"service_account_requests_total",
+		metric.WithDescription("Total number of authenticated requests of service accounts"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Println("✓ Custom metrics initialized")
 
 	return &Metrics{
 		HTTPRequestsTotal:       httpRequestsTotal,
 		HTTPDurationMs:          httpDurationMs,
+		HTTPThrottledTotal:      httpThrottledTotal,
 		OrganizationTotal:       organizationTotal,
 		PatientTotal:            patientTotal,
 		UserTotal:               userTotal,
 		AuthFailuresTotal:       authFailuresTotal,
 		PermissionCheckDuration: permissionCheckDuration,
+		JWKSRefreshTotal:        jwksRefreshTotal,
+		ServiceAccountRequestsTotal: serviceAccountRequestsTotal,
 	}, nil
 }
 
+// Outcomes recorded on the business operation counters
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// OutcomeOf returns the outcome attribute for the error of an operation
+func OutcomeOf(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
 // RecordHTTPRequest records an HTTP request metric
 func (m *Metrics) RecordHTTPRequest(ctx context.Context, method, route string, statusCode int, durationMs float64) {
+	if m == nil {
+		return
+	}
+
 	attrs := []attribute.KeyValue{
 		attribute.String("http_method", method),
 		attribute.String("http_route", route),
@@ -124,29 +178,56 @@ func (m *Metrics) RecordHTTPRequest(ctx context.Context, method, route string, s
 	m.HTTPDurationMs.Record(ctx, durationMs, metric.WithAttributes(attrs...))
 }
 
-// RecordOrganizationOperation records an organization operation metric
-func (m *Metrics) RecordOrganizationOperation(ctx context.Context, operation string) {
-	m.OrganizationTotal.Add(ctx, 1, metric.WithAttributes(
-		attribute.String("operation", operation),
+// RecordThrottledRequest records a request rejected by rate limiting. limit
+// is the budget that was used up, user or organization; tenant is the schema
+// name of the caller's organization.
+func (m *Metrics) RecordThrottledRequest(ctx context.Context, method, route, limit, tenant string) {
+	if m == nil {
+		return
+	}
+
+	m.HTTPThrottledTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("http_method", method),
+		attribute.String("http_route", route),
+		attribute.String("limit", limit),
+		attribute.String("tenant", tenant),
 	))
 }
 
-// RecordPatientOperation records a patient operation metric
-func (m *Metrics) RecordPatientOperation(ctx context.Context, operation string) {
-	m.PatientTotal.Add(ctx, 1, metric.WithAttributes(
-		attribute.String("operation", operation),
-	))
+// RecordOrganizationOperation records an organization operation metric.
+// tenant is the schema name of the organization, empty when unknown.
+func (m *Metrics) RecordOrganizationOperation(ctx context.Context, operation, tenant, outcome string) {
+	if m == nil {
+		return
+	}
+
+	m.OrganizationTotal.Add(ctx, 1, metric.WithAttributes(operationAttributes(operation, tenant, outcome)...))
 }
 
-// RecordUserOperation records a user operation metric
-func (m *Metrics) RecordUserOperation(ctx context.Context, operation string) {
-	m.UserTotal.Add(ctx, 1, metric.WithAttributes(
-		attribute.String("operation", operation),
-	))
+// RecordPatientOperation records a patient operation metric for a tenant schema
+func (m *Metrics) RecordPatientOperation(ctx context.Context, operation, tenant, outcome string) {
+	if m == nil {
+		return
+	}
+
+	m.PatientTotal.Add(ctx, 1, metric.WithAttributes(operationAttributes(operation, tenant, outcome)...))
+}
+
+// RecordUserOperation records a user operation metric for a tenant schema
+func (m *Metrics) RecordUserOperation(ctx context.Context, operation, tenant, outcome string) {
+	if m == nil {
+		return
+	}
+
+	m.UserTotal.Add(ctx, 1, metric.WithAttributes(operationAttributes(operation, tenant, outcome)...))
 }
 
 // RecordAuthFailure records an authentication failure metric
 func (m *Metrics) RecordAuthFailure(ctx context.Context, reason string) {
+	if m == nil {
+		return
+	}
+
 	m.AuthFailuresTotal.Add(ctx, 1, metric.WithAttributes(
 		attribute.String("reason", reason),
 	))
@@ -154,8 +235,47 @@ func (m *Metrics) RecordAuthFailure(ctx context.Context, reason string) {
 
 // RecordPermissionCheck records a permission check duration metric
 func (m *Metrics) RecordPermissionCheck(ctx context.Context, permission string, durationMs float64, allowed bool) {
+	if m == nil {
+		return
+	}
+
 	m.PermissionCheckDuration.Record(ctx, durationMs, metric.WithAttributes(
 		attribute.String("permission", permission),
 		attribute.Bool("allowed", allowed),
 	))
 }
+
+// RecordJWKSRefresh records a JWKS refresh, or an unknown kid refresh skipped
+// by the cooldown
+func (m *Metrics) RecordJWKSRefresh(ctx context.Context, trigger, outcome string) {
+	if m == nil {
+		return
+	}
+
+	m.JWKSRefreshTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("trigger", trigger),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// RecordServiceAccountRequest records an authenticated request of a service
+// account, separately from the requests of people
+func (m *Metrics) RecordServiceAccountRequest(ctx context.Context, clientID, tenant string) {
+	if m == nil {
+		return
+	}
+
+	m.ServiceAccountRequestsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("client_id", clientID),
+		attribute.String("tenant", tenant),
+	))
+}
+
+// operationAttributes returns the attributes of a business operation counter
+func operationAttributes(operation, tenant, outcome string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("operation", operation),
+		attribute.String("tenant", tenant),
+		attribute.String("outcome", outcome),
+	}
+}
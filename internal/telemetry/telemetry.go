@@ -2,14 +2,21 @@ package telemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -19,6 +26,13 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+// Metrics exporters selectable through OTEL_METRICS_EXPORTER
+const (
+	MetricsExporterOTLP       = "otlp"       // Push to the OTLP collector
+	MetricsExporterPrometheus = "prometheus" // Serve /metrics for scraping
+	MetricsExporterNone       = "none"       // Disable metrics export
+)
+
 // Config holds OpenTelemetry configuration
 type Config struct {
 	ServiceName      string
@@ -28,6 +42,18 @@ type Config struct {
 	OTLPEndpoint     string
 	TracesSampler    string
 	MetricsInterval  time.Duration
+	MetricsExporters []string // Any of otlp and prometheus; empty disables export
+	PrometheusAddr   string   // Admin listen address of the /metrics endpoint
+}
+
+// MetricsExporterEnabled reports whether the named metrics exporter is configured
+func (c Config) MetricsExporterEnabled(name string) bool {
+	for _, exporter := range c.MetricsExporters {
+		if exporter == name {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadConfig loads OpenTelemetry configuration from environment variables
@@ -77,6 +103,20 @@ func LoadConfig() Config {
 		}
 	}
 
+	// Get metrics exporters with default; a comma-separated list such as
+	// "otlp,prometheus" enables both
+	metricsExporters := parseMetricsExporters(os.Getenv("OTEL_METRICS_EXPORTER"))
+
+	// Get Prometheus admin address with defaults
+	prometheusHost := os.Getenv("OTEL_EXPORTER_PROMETHEUS_HOST")
+	if prometheusHost == "" {
+		prometheusHost = "0.0.0.0"
+	}
+	prometheusPort := os.Getenv("OTEL_EXPORTER_PROMETHEUS_PORT")
+	if prometheusPort == "" {
+		prometheusPort = "9464"
+	}
+
 	return Config{
 		ServiceName:      serviceName,
 		ServiceNamespace: serviceNamespace,
@@ -85,14 +125,41 @@ func LoadConfig() Config {
 		OTLPEndpoint:     otlpEndpoint,
 		TracesSampler:    tracesSampler,
 		MetricsInterval:  metricsInterval,
+		MetricsExporters: metricsExporters,
+		PrometheusAddr:   net.JoinHostPort(prometheusHost, prometheusPort),
 	}
 }
 
+// parseMetricsExporters parses OTEL_METRICS_EXPORTER. Unknown names are
+// logged and ignored; "none" disables export.
+func parseMetricsExporters(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return []string{MetricsExporterOTLP}
+	}
+
+	exporters := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case MetricsExporterOTLP, MetricsExporterPrometheus:
+			exporters = append(exporters, name)
+		case MetricsExporterNone:
+			return []string{}
+		case "":
+		default:
+			log.Printf("Warning: unknown metrics exporter %q ignored", name)
+		}
+	}
+	return exporters
+}
+
 // Provider holds the OpenTelemetry providers
 type Provider struct {
 	TracerProvider *trace.TracerProvider
 	MeterProvider  *metric.MeterProvider
 	config         Config
+	metricsServer  *http.Server // Prometheus admin server, nil unless enabled
+	metricsAddr    net.Addr
 }
 
 // InitProvider initializes OpenTelemetry tracer and meter providers
@@ -126,7 +193,7 @@ func InitProvider(ctx context.Context, cfg Config) (*Provider, error) {
 	}
 
 	// Initialize meter provider
-	meterProvider, err := initMeterProvider(ctx, cfg, res)
+	meterProvider, metricsServer, metricsAddr, err := initMeterProvider(ctx, cfg, res)
 	if err != nil {
 		log.Printf("Warning: failed to initialize meter provider: %v", err)
 		log.Println("Service will continue without metrics export")
@@ -147,9 +214,17 @@ func InitProvider(ctx context.Context, cfg Config) (*Provider, error) {
 		TracerProvider: tracerProvider,
 		MeterProvider:  meterProvider,
 		config:         cfg,
+		metricsServer:  metricsServer,
+		metricsAddr:    metricsAddr,
 	}, nil
 }
 
+// MetricsAddr returns the address the Prometheus endpoint listens on, or nil
+// when the Prometheus exporter is not enabled
+func (p *Provider) MetricsAddr() net.Addr {
+	return p.metricsAddr
+}
+
 // initTracerProvider initializes the trace provider with OTLP exporter
 func initTracerProvider(ctx context.Context, cfg Config, res *resource.Resource) (*trace.TracerProvider, error) {
 	// Create OTLP trace exporter with timeout and retry
@@ -191,30 +266,67 @@ func initTracerProvider(ctx context.Context, cfg Config, res *resource.Resource)
 	return tracerProvider, nil
 }
 
-// initMeterProvider initializes the meter provider with OTLP exporter
-func initMeterProvider(ctx context.Context, cfg Config, res *resource.Resource) (*metric.MeterProvider, error) {
-	// Create OTLP metric exporter with timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+// initMeterProvider initializes the meter provider with the configured
+// exporters. When Prometheus is enabled it also starts the admin server
+// serving /metrics.
+func initMeterProvider(ctx context.Context, cfg Config, res *resource.Resource) (*metric.MeterProvider, *http.Server, net.Addr, error) {
+	options := []metric.Option{metric.WithResource(res)}
+
+	if cfg.MetricsExporterEnabled(MetricsExporterOTLP) {
+		// Create OTLP metric exporter with timeout
+		otlpCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		metricExporter, err := otlpmetricgrpc.New(otlpCtx,
+			otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint),
+			otlpmetricgrpc.WithDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+			otlpmetricgrpc.WithTimeout(5*time.Second),
+		)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
 
-	metricExporter, err := otlpmetricgrpc.New(ctx,
-		otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint),
-		otlpmetricgrpc.WithDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
-		otlpmetricgrpc.WithTimeout(5*time.Second),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		// Push on a periodic reader
+		options = append(options, metric.WithReader(metric.NewPeriodicReader(metricExporter,
+			metric.WithInterval(cfg.MetricsInterval),
+		)))
 	}
 
-	// Create meter provider with periodic reader
-	meterProvider := metric.NewMeterProvider(
-		metric.WithResource(res),
-		metric.WithReader(metric.NewPeriodicReader(metricExporter,
-			metric.WithInterval(cfg.MetricsInterval),
-		)),
-	)
+	var server *http.Server
+	var addr net.Addr
+	if cfg.MetricsExporterEnabled(MetricsExporterPrometheus) {
+		// Use a dedicated registry so only this provider's metrics are served
+		registry := prometheus.NewRegistry()
+		promExporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+		}
+
+		// Listen before returning so a busy port is reported at startup
+		listener, err := net.Listen("tcp", cfg.PrometheusAddr)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to listen on %s for Prometheus metrics: %w", cfg.PrometheusAddr, err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		server = &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		addr = listener.Addr()
 
-	return meterProvider, nil
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Prometheus metrics server failed: %v", err)
+			}
+		}()
+		log.Printf("✓ Prometheus metrics served on %s/metrics", addr)
+
+		options = append(options, metric.WithReader(promExporter))
+	}
+
+	return metric.NewMeterProvider(options...), server, addr, nil
 }
 
 // Shutdown gracefully shuts down the OpenTelemetry providers
@@ -233,6 +345,16 @@ func (p *Provider) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Stop serving /metrics before the meter provider goes away
+	if p.metricsServer != nil {
+		if shutdownErr := p.metricsServer.Shutdown(ctx); shutdownErr != nil {
+			log.Printf("Error shutting down Prometheus metrics server: %v", shutdownErr)
+			if err == nil {
+				err = shutdownErr
+			}
+		}
+	}
+
 	// Shutdown meter provider
 	if p.MeterProvider != nil {
 		if shutdownErr := p.MeterProvider.Shutdown(ctx); shutdownErr != nil {
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestLoadConfigMetricsExporters(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"default", "", []string{"otlp"}},
+		{"prometheus only", "prometheus", []string{"prometheus"}},
+		{"both", "otlp, Prometheus", []string{"otlp", "prometheus"}},
+		{"unknown ignored", "otlp,statsd", []string{"otlp"}},
+		{"none", "none", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_METRICS_EXPORTER", tt.value)
+
+			cfg := LoadConfig()
+
+			if !reflect.DeepEqual(cfg.MetricsExporters, tt.want) {
+				t.Errorf("Expected exporters %v, got %v", tt.want, cfg.MetricsExporters)
+			}
+		})
+	}
+}
+
+func TestLoadConfigPrometheusAddr(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_PROMETHEUS_HOST", "127.0.0.1")
+	t.Setenv("OTEL_EXPORTER_PROMETHEUS_PORT", "9100")
+
+	cfg := LoadConfig()
+
+	if cfg.PrometheusAddr != "127.0.0.1:9100" {
+		t.Errorf("Expected address 127.0.0.1:9100, got %s", cfg.PrometheusAddr)
+	}
+}
+
+func TestPrometheusExporterServesMetrics(t *testing.T) {
+	cfg := Config{
+		MetricsExporters: []string{MetricsExporterPrometheus},
+		PrometheusAddr:   "127.0.0.1:0",
+	}
+
+	meterProvider, server, addr, err := initMeterProvider(context.Background(), cfg, resource.Empty())
+	if err != nil {
+		t.Fatalf("initMeterProvider failed: %v", err)
+	}
+	defer func() {
+		server.Shutdown(context.Background())
+		meterProvider.Shutdown(context.Background())
+	}()
+
+	counter, err := meterProvider.Meter("test").Int64Counter("patients_created")
+	if err != nil {
+		t.Fatalf("Failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 3)
+
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "patients_created_total") {
+		t.Errorf("Expected patients_created_total in scrape output, got:\n%s", body)
+	}
+}
+
+func TestInitMeterProviderWithoutExporters(t *testing.T) {
+	meterProvider, server, addr, err := initMeterProvider(context.Background(), Config{}, resource.Empty())
+	if err != nil {
+		t.Fatalf("initMeterProvider failed: %v", err)
+	}
+	defer meterProvider.Shutdown(context.Background())
+
+	if server != nil || addr != nil {
+		t.Error("Expected no metrics server when Prometheus is disabled")
+	}
+}
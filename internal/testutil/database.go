@@ -110,5 +110,15 @@ func CreateTestOrg(t *testing.T, db *sql.DB, name string) (orgID, schemaName str
 		t.Fatalf("Failed to create tenant schema: %v", err)
 	}
 
+	_, err = db.Exec(fmt.Sprintf("SELECT wailsalutem.add_row_versions('%s')", schemaName))
+	if err != nil {
+		t.Fatalf("Failed to add row versions: %v", err)
+	}
+
+	_, err = db.Exec(fmt.Sprintf("SELECT wailsalutem.create_patient_search_indexes('%s')", schemaName))
+	if err != nil {
+		t.Fatalf("Failed to create patient search indexes: %v", err)
+	}
+
 	return orgID, schemaName
 }
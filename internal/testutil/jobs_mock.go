@@ -0,0 +1,59 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/google/uuid"
+)
+
+// MockJobRepository is an in-memory import job repository for testing
+type MockJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]jobs.Job
+}
+
+// NewMockJobRepository creates a new in-memory import job repository
+func NewMockJobRepository() *MockJobRepository {
+	return &MockJobRepository{jobs: make(map[string]jobs.Job)}
+}
+
+// Create stores a new queued job
+func (m *MockJobRepository) Create(ctx context.Context, job *jobs.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job.ID = uuid.New().String()
+	job.Status = jobs.StatusQueued
+	m.jobs[job.ID] = *job
+	return nil
+}
+
+// Get returns a copy of a stored job
+func (m *MockJobRepository) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, jobs.ErrJobNotFound
+	}
+	return &job, nil
+}
+
+// Update replaces a stored job
+func (m *MockJobRepository) Update(ctx context.Context, job *jobs.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.jobs[job.ID]; !ok {
+		return jobs.ErrJobNotFound
+	}
+	stored := *job
+	stored.Results = append([]jobs.RowResult(nil), job.Results...)
+	m.jobs[job.ID] = stored
+	return nil
+}
+
+var _ jobs.RepositoryInterface = (*MockJobRepository)(nil)
@@ -164,6 +164,20 @@ func (m *MockKeycloakAdmin) GetUser(userID string) (*auth.KeycloakUser, error) {
 	return &userCopy, nil
 }
 
+// FindUserByUsername retrieves a user by username
+func (m *MockKeycloakAdmin) FindUserByUsername(username string) (*auth.KeycloakUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Username == username {
+			userCopy := *user
+			return &userCopy, nil
+		}
+	}
+	return nil, auth.ErrUserNotFound
+}
+
 // Helper methods for testing
 
 // GetAllUsers returns all users (for test verification)
@@ -215,6 +229,7 @@ var _ interface {
 	DeleteUser(userID string) error
 	UpdateUser(userID string, user auth.KeycloakUser) error
 	GetUser(userID string) (*auth.KeycloakUser, error)
+	FindUserByUsername(username string) (*auth.KeycloakUser, error)
 } = (*MockKeycloakAdmin)(nil)
 
 // Error wrapper for better error messages in tests
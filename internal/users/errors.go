@@ -1,18 +1,18 @@
 package users
 
-import "errors"
+import (
+	"net/http"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
 
 var (
-	ErrMissingUsername  = errors.New("username is required")
-	ErrMissingEmail     = errors.New("email is required")
-	ErrMissingFirstName = errors.New("first name is required")
-	ErrMissingLastName  = errors.New("last name is required")
-	ErrMissingRole      = errors.New("role is required")
-	ErrMissingPassword  = errors.New("temporary password is required when not sending reset email")
-	ErrInvalidRole      = errors.New("invalid role")
-	ErrRoleNotAllowed   = errors.New("org_admin cannot create this role")
-	ErrUserNotFound     = errors.New("user not found")
-	ErrUnauthorized     = errors.New("unauthorized")
-	ErrForbidden        = errors.New("forbidden - insufficient permissions")
-	ErrInvalidOrgSchema = errors.New("invalid organization schema name")
+	ErrMissingPassword  = apierror.BadRequest(apierror.CodeValidation, "temporary password is required when not sending reset email")
+	ErrInvalidRole      = apierror.BadRequest(apierror.CodeValidation, "invalid role")
+	ErrRoleNotAllowed   = apierror.Forbidden("role_not_allowed", "org_admin cannot create this role")
+	ErrUserNotFound     = apierror.NotFound(apierror.CodeNotFound, "user not found")
+	ErrUnauthorized     = apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
+	ErrForbidden        = apierror.Forbidden(apierror.CodeForbidden, "forbidden - insufficient permissions")
+	ErrInvalidOrgSchema = apierror.BadRequest("invalid_org_schema", "invalid organization schema name")
+	ErrUsernameTaken    = apierror.Conflict("username_taken", "username is already taken")
 )
@@ -3,11 +3,16 @@ package users
 // Development Team: Muhammad Faizan, Roozbeh Kouchaki, Fatemehalsadat Sabaghjafari, Dipika Bhandari
 
 import (
+	"encoding/csv"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/gorilla/mux"
 )
@@ -25,36 +30,28 @@ func NewHandler(service ServiceInterface) *Handler {
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	var req CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 
-	user, err := h.service.CreateUser(req, principal, targetOrgID)
+	user, err := h.service.CreateUser(r.Context(), req, principal, targetOrgID)
 	if err != nil {
-		log.Printf("Failed to create user: %v", err)
+		slog.ErrorContext(r.Context(), "failed to create user", "error", err)
 
-		switch err {
-		case ErrMissingUsername, ErrMissingEmail, ErrMissingFirstName, ErrMissingLastName, ErrMissingRole, ErrMissingPassword:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		case ErrRoleNotAllowed:
-			http.Error(w, err.Error(), http.StatusForbidden)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to create user", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to create user")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, user.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(user)
 }
@@ -62,7 +59,7 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
@@ -72,17 +69,11 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	params := pagination.ParseParams(r)
 
 	// Get paginated users
-	response, err := h.service.ListUsersWithPagination(principal, targetOrgID, params)
+	response, err := h.service.ListUsersWithPagination(r.Context(), principal, targetOrgID, params)
 	if err != nil {
-		log.Printf("Failed to list users: %v", err)
+		slog.ErrorContext(r.Context(), "failed to list users", "error", err)
 
-		if err == ErrInvalidOrgSchema {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else if err == ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "failed to list users", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to list users")
 		return
 	}
 
@@ -93,24 +84,18 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListActiveCaregivers(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 	params := pagination.ParseParams(r)
 
-	response, err := h.service.ListActiveUsersByRoleWithPagination(principal, targetOrgID, "CAREGIVER", params)
+	response, err := h.service.ListActiveUsersByRoleWithPagination(r.Context(), principal, targetOrgID, "CAREGIVER", params)
 	if err != nil {
-		log.Printf("Failed to list active caregivers: %v", err)
+		slog.ErrorContext(r.Context(), "failed to list active caregivers", "error", err)
 
-		if err == ErrInvalidOrgSchema {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else if err == ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "failed to list active caregivers", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to list active caregivers")
 		return
 	}
 
@@ -121,24 +106,18 @@ func (h *Handler) ListActiveCaregivers(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListActiveMunicipality(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 	params := pagination.ParseParams(r)
 
-	response, err := h.service.ListActiveUsersByRoleWithPagination(principal, targetOrgID, "MUNICIPALITY", params)
+	response, err := h.service.ListActiveUsersByRoleWithPagination(r.Context(), principal, targetOrgID, "MUNICIPALITY", params)
 	if err != nil {
-		log.Printf("Failed to list active municipality users: %v", err)
+		slog.ErrorContext(r.Context(), "failed to list active municipality users", "error", err)
 
-		if err == ErrInvalidOrgSchema {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else if err == ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "failed to list active municipality users", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to list active municipality users")
 		return
 	}
 
@@ -149,24 +128,18 @@ func (h *Handler) ListActiveMunicipality(w http.ResponseWriter, r *http.Request)
 func (h *Handler) ListActiveInsurers(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 	params := pagination.ParseParams(r)
 
-	response, err := h.service.ListActiveUsersByRoleWithPagination(principal, targetOrgID, "INSURER", params)
+	response, err := h.service.ListActiveUsersByRoleWithPagination(r.Context(), principal, targetOrgID, "INSURER", params)
 	if err != nil {
-		log.Printf("Failed to list active insurers: %v", err)
+		slog.ErrorContext(r.Context(), "failed to list active insurers", "error", err)
 
-		if err == ErrInvalidOrgSchema {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else if err == ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "failed to list active insurers", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to list active insurers")
 		return
 	}
 
@@ -177,24 +150,18 @@ func (h *Handler) ListActiveInsurers(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListActiveOrgAdmins(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 	params := pagination.ParseParams(r)
 
-	response, err := h.service.ListActiveUsersByRoleWithPagination(principal, targetOrgID, "ORG_ADMIN", params)
+	response, err := h.service.ListActiveUsersByRoleWithPagination(r.Context(), principal, targetOrgID, "ORG_ADMIN", params)
 	if err != nil {
-		log.Printf("Failed to list active org admins: %v", err)
+		slog.ErrorContext(r.Context(), "failed to list active org admins", "error", err)
 
-		if err == ErrInvalidOrgSchema {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else if err == ErrForbidden {
-			http.Error(w, err.Error(), http.StatusForbidden)
-		} else {
-			http.Error(w, "failed to list active org admins", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to list active org admins")
 		return
 	}
 
@@ -205,7 +172,7 @@ func (h *Handler) ListActiveOrgAdmins(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
@@ -214,31 +181,23 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 
-	user, err := h.service.GetUser(userID, principal, targetOrgID)
+	user, err := h.service.GetUser(r.Context(), userID, principal, targetOrgID)
 	if err != nil {
-		log.Printf("Failed to get user: %v", err)
+		slog.ErrorContext(r.Context(), "failed to get user", "error", err)
 
-		switch err {
-		case ErrUserNotFound:
-			http.Error(w, err.Error(), http.StatusNotFound)
-		case ErrForbidden:
-			http.Error(w, err.Error(), http.StatusForbidden)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to get user", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to get user")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
 
 func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
@@ -247,37 +206,35 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	var req UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	targetOrgID := r.Header.Get("X-Organization-ID")
 
-	user, err := h.service.UpdateUser(userID, req, principal, targetOrgID)
+	version, err := etag.IfMatch(r)
 	if err != nil {
-		log.Printf("Failed to update user: %v", err)
+		apierror.Write(w, r, err, "")
+		return
+	}
+
+	user, err := h.service.UpdateUser(r.Context(), userID, req, version, principal, targetOrgID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to update user", "error", err)
 
-		switch err {
-		case ErrUserNotFound:
-			http.Error(w, err.Error(), http.StatusNotFound)
-		case ErrForbidden:
-			http.Error(w, err.Error(), http.StatusForbidden)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to update user", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to update user")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
 
 func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
@@ -288,24 +245,15 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 	var req ResetPasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body")
 		return
 	}
 
-	err := h.service.ResetPassword(userID, req, principal, targetOrgID)
+	err := h.service.ResetPassword(r.Context(), userID, req, principal, targetOrgID)
 	if err != nil {
-		log.Printf("Failed to reset password: %v", err)
+		slog.ErrorContext(r.Context(), "failed to reset password", "error", err)
 
-		switch err {
-		case ErrUserNotFound:
-			http.Error(w, err.Error(), http.StatusNotFound)
-		case ErrForbidden:
-			http.Error(w, err.Error(), http.StatusForbidden)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to reset password", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to reset password")
 		return
 	}
 
@@ -318,27 +266,24 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
-	err := h.service.DeleteUser(userID, principal)
+	version, err := etag.IfMatch(r)
 	if err != nil {
-		log.Printf("Failed to delete user: %v", err)
+		apierror.Write(w, r, err, "")
+		return
+	}
 
-		switch err {
-		case ErrUserNotFound:
-			http.Error(w, err.Error(), http.StatusNotFound)
-		case ErrForbidden:
-			http.Error(w, err.Error(), http.StatusForbidden)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to delete user", http.StatusInternalServerError)
-		}
+	err = h.service.DeleteUser(r.Context(), userID, version, principal)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete user", "error", err)
+
+		apierror.Write(w, r, err, "failed to delete user")
 		return
 	}
 
@@ -348,57 +293,140 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetMyProfile(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
-	user, err := h.service.GetMyProfile(principal)
+	user, err := h.service.GetMyProfile(r.Context(), principal)
 	if err != nil {
-		log.Printf("Failed to get profile: %v", err)
+		slog.ErrorContext(r.Context(), "failed to get profile", "error", err)
 
-		switch err {
-		case ErrUserNotFound:
-			http.Error(w, err.Error(), http.StatusNotFound)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to get profile", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to get profile")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
 
 func (h *Handler) UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
 	principal, ok := auth.FromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
 		return
 	}
 
 	var req UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		apierror.Write(w, r, err, "")
 		return
 	}
 
-	user, err := h.service.UpdateMyProfile(req, principal)
+	user, err := h.service.UpdateMyProfile(r.Context(), req, version, principal)
 	if err != nil {
-		log.Printf("Failed to update profile: %v", err)
+		slog.ErrorContext(r.Context(), "failed to update profile", "error", err)
 
-		switch err {
-		case ErrUserNotFound:
-			http.Error(w, err.Error(), http.StatusNotFound)
-		case ErrInvalidOrgSchema:
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		default:
-			http.Error(w, "failed to update profile", http.StatusInternalServerError)
-		}
+		apierror.Write(w, r, err, "failed to update profile")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	etag.Set(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
+
+// exportColumns is the header of the staff CSV export. The import accepts the
+// same header, ignoring the read-only columns; a temporaryPassword or
+// sendResetEmail column must be added before an export is imported again.
+var exportColumns = []string{"id", "employeeId", "username", "email", "firstName", "lastName", "phoneNumber", "role", "isActive", "createdAt"}
+
+// ExportUsers writes the current staff list of the organization as CSV
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
+		return
+	}
+
+	targetOrgID := r.Header.Get("X-Organization-ID")
+
+	staff, err := h.service.ListStaff(r.Context(), principal, targetOrgID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to export users", "error", err)
+
+		apierror.Write(w, r, err, "failed to export users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="staff.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write(exportColumns)
+	for _, user := range staff {
+		writer.Write([]string{
+			user.ID,
+			escapeCSVCell(user.EmployeeID),
+			escapeCSVCell(user.Username),
+			escapeCSVCell(user.Email),
+			escapeCSVCell(user.FirstName),
+			escapeCSVCell(user.LastName),
+			escapeCSVCell(user.PhoneNumber),
+			user.Role,
+			strconv.FormatBool(user.IsActive),
+			user.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write staff export", "error", err)
+	}
+}
+
+// PolicyResource resolves the user of /organization/users/{id} for policy
+// evaluation. The organization is the owner of the schema the user is stored
+// in and the owner the user's own Keycloak account.
+func (h *Handler) PolicyResource(r *http.Request) (auth.Resource, error) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return auth.Resource{}, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
+	}
+
+	user, err := h.service.GetUser(r.Context(), mux.Vars(r)["id"], principal, r.Header.Get("X-Organization-ID"))
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	orgID, err := h.service.OrganizationOfSchema(r.Context(), user.OrgSchemaName)
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	return auth.Resource{Type: "user", ID: user.ID, OrgID: orgID, OwnerID: user.KeycloakUserID}, nil
+}
+
+// PolicyCollection resolves the users of the requested organization for
+// policy evaluation of list and search endpoints. It has no owner, so rules
+// that require ownership deny listing.
+func (h *Handler) PolicyCollection(r *http.Request) (auth.Resource, error) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		return auth.Resource{}, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
+	}
+
+	_, orgSchemaName, err := h.service.ResolveOrganization(r.Context(), principal, r.Header.Get("X-Organization-ID"))
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	orgID, err := h.service.OrganizationOfSchema(r.Context(), orgSchemaName)
+	if err != nil {
+		return auth.Resource{}, err
+	}
+	return auth.Resource{Type: "user", OrgID: orgID}, nil
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/gorilla/mux"
@@ -21,83 +23,115 @@ type mockService struct {
 	listUsersFunc                            func(principal *auth.Principal, targetOrgID string) ([]User, error)
 	listUsersWithPaginationFunc              func(principal *auth.Principal, targetOrgID string, params pagination.Params) (*PaginatedUserListResponse, error)
 	listActiveUsersByRoleWithPaginationFunc  func(principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*PaginatedUserListResponse, error)
-	updateUserFunc                           func(userID string, req UpdateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error)
+	updateUserFunc                           func(userID string, req UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (*User, error)
 	getMyProfileFunc                         func(principal *auth.Principal) (*User, error)
-	updateMyProfileFunc                      func(req UpdateUserRequest, principal *auth.Principal) (*User, error)
+	updateMyProfileFunc                      func(req UpdateUserRequest, version int, principal *auth.Principal) (*User, error)
 	resetPasswordFunc                        func(userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error
-	deleteUserFunc                           func(userID string, principal *auth.Principal) error
+	deleteUserFunc                           func(userID string, version int, principal *auth.Principal) error
+	resolveOrganizationFunc                  func(principal *auth.Principal, targetOrgID string) (string, string, error)
+	findExistingUserFunc                     func(orgSchemaName, username, email string) (*User, error)
+	listStaffFunc                            func(principal *auth.Principal, targetOrgID string) ([]User, error)
+	organizationOfSchemaFunc                 func(orgSchemaName string) (string, error)
 }
 
-func (m *mockService) CreateUser(req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+func (m *mockService) CreateUser(ctx context.Context, req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
 	if m.createUserFunc != nil {
 		return m.createUserFunc(req, principal, targetOrgID)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) GetUser(userID string, principal *auth.Principal, targetOrgID string) (*User, error) {
+func (m *mockService) GetUser(ctx context.Context, userID string, principal *auth.Principal, targetOrgID string) (*User, error) {
 	if m.getUserFunc != nil {
 		return m.getUserFunc(userID, principal, targetOrgID)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) ListUsers(principal *auth.Principal, targetOrgID string) ([]User, error) {
+func (m *mockService) ListUsers(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]User, error) {
 	if m.listUsersFunc != nil {
 		return m.listUsersFunc(principal, targetOrgID)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) ListUsersWithPagination(principal *auth.Principal, targetOrgID string, params pagination.Params) (*PaginatedUserListResponse, error) {
+func (m *mockService) ListUsersWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, params pagination.Params) (*PaginatedUserListResponse, error) {
 	if m.listUsersWithPaginationFunc != nil {
 		return m.listUsersWithPaginationFunc(principal, targetOrgID, params)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) ListActiveUsersByRoleWithPagination(principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*PaginatedUserListResponse, error) {
+func (m *mockService) ListActiveUsersByRoleWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*PaginatedUserListResponse, error) {
 	if m.listActiveUsersByRoleWithPaginationFunc != nil {
 		return m.listActiveUsersByRoleWithPaginationFunc(principal, targetOrgID, role, params)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) UpdateUser(userID string, req UpdateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+func (m *mockService) UpdateUser(ctx context.Context, userID string, req UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (*User, error) {
 	if m.updateUserFunc != nil {
-		return m.updateUserFunc(userID, req, principal, targetOrgID)
+		return m.updateUserFunc(userID, req, version, principal, targetOrgID)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) GetMyProfile(principal *auth.Principal) (*User, error) {
+func (m *mockService) GetMyProfile(ctx context.Context, principal *auth.Principal) (*User, error) {
 	if m.getMyProfileFunc != nil {
 		return m.getMyProfileFunc(principal)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) UpdateMyProfile(req UpdateUserRequest, principal *auth.Principal) (*User, error) {
+func (m *mockService) UpdateMyProfile(ctx context.Context, req UpdateUserRequest, version int, principal *auth.Principal) (*User, error) {
 	if m.updateMyProfileFunc != nil {
-		return m.updateMyProfileFunc(req, principal)
+		return m.updateMyProfileFunc(req, version, principal)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockService) ResetPassword(userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error {
+func (m *mockService) ResetPassword(ctx context.Context, userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error {
 	if m.resetPasswordFunc != nil {
 		return m.resetPasswordFunc(userID, req, principal, targetOrgID)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockService) DeleteUser(userID string, principal *auth.Principal) error {
+func (m *mockService) DeleteUser(ctx context.Context, userID string, version int, principal *auth.Principal) error {
 	if m.deleteUserFunc != nil {
-		return m.deleteUserFunc(userID, principal)
+		return m.deleteUserFunc(userID, version, principal)
 	}
 	return errors.New("not implemented")
 }
 
+func (m *mockService) ResolveOrganization(ctx context.Context, principal *auth.Principal, targetOrgID string) (string, string, error) {
+	if m.resolveOrganizationFunc != nil {
+		return m.resolveOrganizationFunc(principal, targetOrgID)
+	}
+	return "", "", errors.New("not implemented")
+}
+
+func (m *mockService) OrganizationOfSchema(ctx context.Context, orgSchemaName string) (string, error) {
+	if m.organizationOfSchemaFunc != nil {
+		return m.organizationOfSchemaFunc(orgSchemaName)
+	}
+	return "", errors.New("not implemented")
+}
+
+func (m *mockService) FindExistingUser(ctx context.Context, orgSchemaName, username, email string) (*User, error) {
+	if m.findExistingUserFunc != nil {
+		return m.findExistingUserFunc(orgSchemaName, username, email)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) ListStaff(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]User, error) {
+	if m.listStaffFunc != nil {
+		return m.listStaffFunc(principal, targetOrgID)
+	}
+	return nil, errors.New("not implemented")
+}
+
 // Test CreateUser Handler
 
 func TestHandlerCreateUser_Success(t *testing.T) {
@@ -200,7 +234,7 @@ func TestHandlerCreateUser_InvalidJSON(t *testing.T) {
 func TestHandlerCreateUser_ValidationError(t *testing.T) {
 	mockSvc := &mockService{
 		createUserFunc: func(req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
-			return nil, ErrMissingEmail
+			return nil, req.Validate()
 		},
 	}
 
@@ -225,6 +259,18 @@ func TestHandlerCreateUser_ValidationError(t *testing.T) {
 	if rr.Code != http.StatusBadRequest {
 		t.Errorf("Expected status 400, got %d", rr.Code)
 	}
+
+	var problem apierror.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, fieldError := range problem.Errors {
+		fields[fieldError.Field] = true
+	}
+	if !fields["email"] || !fields["temporaryPassword"] {
+		t.Errorf("Expected email and temporaryPassword field errors, got %+v", problem.Errors)
+	}
 }
 
 func TestHandlerCreateUser_ForbiddenRole(t *testing.T) {
@@ -495,7 +541,7 @@ func TestHandlerGetUser_Forbidden(t *testing.T) {
 
 func TestHandlerUpdateUser_Success(t *testing.T) {
 	mockSvc := &mockService{
-		updateUserFunc: func(userID string, req UpdateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+		updateUserFunc: func(userID string, req UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (*User, error) {
 			return &User{
 				ID:        userID,
 				Email:     req.Email,
@@ -559,7 +605,7 @@ func TestHandlerUpdateUser_InvalidJSON(t *testing.T) {
 
 func TestHandlerUpdateUser_Forbidden(t *testing.T) {
 	mockSvc := &mockService{
-		updateUserFunc: func(userID string, req UpdateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+		updateUserFunc: func(userID string, req UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (*User, error) {
 			return nil, ErrForbidden
 		},
 	}
@@ -587,7 +633,7 @@ func TestHandlerUpdateUser_Forbidden(t *testing.T) {
 
 func TestHandlerUpdateMyProfile_Success(t *testing.T) {
 	mockSvc := &mockService{
-		updateMyProfileFunc: func(req UpdateUserRequest, principal *auth.Principal) (*User, error) {
+		updateMyProfileFunc: func(req UpdateUserRequest, version int, principal *auth.Principal) (*User, error) {
 			return &User{
 				ID:        principal.UserID,
 				Email:     req.Email,
@@ -716,7 +762,7 @@ func TestHandlerResetPassword_NotFound(t *testing.T) {
 
 func TestHandlerDeleteUser_Success(t *testing.T) {
 	mockSvc := &mockService{
-		deleteUserFunc: func(userID string, principal *auth.Principal) error {
+		deleteUserFunc: func(userID string, version int, principal *auth.Principal) error {
 			return nil
 		},
 	}
@@ -739,7 +785,7 @@ func TestHandlerDeleteUser_Success(t *testing.T) {
 
 func TestHandlerDeleteUser_NotFound(t *testing.T) {
 	mockSvc := &mockService{
-		deleteUserFunc: func(userID string, principal *auth.Principal) error {
+		deleteUserFunc: func(userID string, version int, principal *auth.Principal) error {
 			return ErrUserNotFound
 		},
 	}
@@ -762,7 +808,7 @@ func TestHandlerDeleteUser_NotFound(t *testing.T) {
 
 func TestHandlerDeleteUser_Forbidden(t *testing.T) {
 	mockSvc := &mockService{
-		deleteUserFunc: func(userID string, principal *auth.Principal) error {
+		deleteUserFunc: func(userID string, version int, principal *auth.Principal) error {
 			return ErrForbidden
 		},
 	}
@@ -782,3 +828,42 @@ func TestHandlerDeleteUser_Forbidden(t *testing.T) {
 		t.Errorf("Expected status 403, got %d", rr.Code)
 	}
 }
+
+func TestHandlerPolicyResource(t *testing.T) {
+	mockSvc := &mockService{
+		getUserFunc: func(userID string, principal *auth.Principal, targetOrgID string) (*User, error) {
+			return &User{ID: userID, KeycloakUserID: "kc-123", OrgID: "org-123", OrgSchemaName: "org_123"}, nil
+		},
+		resolveOrganizationFunc: func(principal *auth.Principal, targetOrgID string) (string, string, error) {
+			return "org-123", "org_123", nil
+		},
+		// The token names org-123 but its schema belongs to org-456
+		organizationOfSchemaFunc: func(orgSchemaName string) (string, error) {
+			if orgSchemaName != "org_123" {
+				t.Errorf("Expected schema org_123, got %s", orgSchemaName)
+			}
+			return "org-456", nil
+		},
+	}
+	handler := NewHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/organization/users/user-123", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "user-123"})
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), &auth.Principal{UserID: "kc-123", Roles: []string{"CAREGIVER"}, OrgID: "org-123"}))
+
+	res, err := handler.PolicyResource(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.ID != "user-123" || res.OrgID != "org-456" || res.OwnerID != "kc-123" {
+		t.Errorf("Expected the organization of the stored user, got %+v", res)
+	}
+
+	res, err = handler.PolicyCollection(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if res.Type != "user" || res.OrgID != "org-456" || res.OwnerID != "" {
+		t.Errorf("Expected the users of the schema owner, got %+v", res)
+	}
+}
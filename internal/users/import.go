@@ -0,0 +1,300 @@
+package users
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/validation"
+)
+
+// Bulk staff import limits
+const (
+	MaxImportRows  = 1000
+	MaxImportBytes = 5 << 20 // 5 MB
+)
+
+var (
+	ErrEmptyImport       = errors.New("import file contains no rows")
+	ErrTooManyImportRows = fmt.Errorf("import file exceeds the maximum of %d rows", MaxImportRows)
+)
+
+// ImportableRoles are the staff roles that can be created through bulk import
+var ImportableRoles = map[string]bool{
+	"CAREGIVER":    true,
+	"MUNICIPALITY": true,
+	"INSURER":      true,
+}
+
+// ImportRow is one parsed CSV row. Row is the 1-based position of the record
+// in the file, not counting the header.
+type ImportRow struct {
+	Row     int
+	Request CreateUserRequest
+	Errors  []string
+}
+
+// importColumns maps normalized CSV header names onto CreateUserRequest fields.
+// Case, spaces, dashes and underscores are ignored in header names.
+var importColumns = map[string]func(req *CreateUserRequest, value string) error{
+	"username":          func(req *CreateUserRequest, v string) error { req.Username = v; return nil },
+	"email":             func(req *CreateUserRequest, v string) error { req.Email = v; return nil },
+	"firstname":         func(req *CreateUserRequest, v string) error { req.FirstName = v; return nil },
+	"lastname":          func(req *CreateUserRequest, v string) error { req.LastName = v; return nil },
+	"phonenumber":       func(req *CreateUserRequest, v string) error { req.PhoneNumber = v; return nil },
+	"role":              func(req *CreateUserRequest, v string) error { req.Role = strings.ToUpper(v); return nil },
+	"temporarypassword": func(req *CreateUserRequest, v string) error { req.TemporaryPassword = v; return nil },
+	"sendresetemail": func(req *CreateUserRequest, v string) error {
+		if v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("sendResetEmail must be true or false")
+		}
+		req.SendResetEmail = b
+		return nil
+	},
+}
+
+// exportOnlyColumns are written by the staff export and ignored on import,
+// so an edited export can be uploaded again
+var exportOnlyColumns = map[string]bool{
+	"id":         true,
+	"employeeid": true,
+	"isactive":   true,
+	"createdat":  true,
+}
+
+// ParseImportCSV reads every row of a staff import CSV file.
+// Rows that cannot be decoded are returned with their parse errors set.
+func ParseImportCSV(body io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyImport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	setters := make([]func(*CreateUserRequest, string) error, len(header))
+	for i, name := range header {
+		column := normalizeColumn(name)
+		if exportOnlyColumns[column] {
+			continue
+		}
+		setter, ok := importColumns[column]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		setters[i] = setter
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", len(rows)+1, err)
+		}
+		if len(rows) >= MaxImportRows {
+			return nil, ErrTooManyImportRows
+		}
+
+		row := ImportRow{Row: len(rows) + 1}
+		if len(record) != len(header) {
+			row.Errors = append(row.Errors, fmt.Sprintf("expected %d columns, got %d", len(header), len(record)))
+		} else {
+			for i, value := range record {
+				if setters[i] == nil {
+					continue
+				}
+				if err := setters[i](&row.Request, unescapeCSVCell(strings.TrimSpace(value))); err != nil {
+					row.Errors = append(row.Errors, err.Error())
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyImport
+	}
+
+	return rows, nil
+}
+
+// escapeCSVCell prefixes values that spreadsheets would evaluate as formulas
+// with an apostrophe, so exported names cannot run as formulas when opened
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVCell undoes escapeCSVCell, so an export can be imported again
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(name)
+}
+
+// ValidateImportRows checks every row with CreateUserRequest.Validate and the
+// role rules of CreateUser before anything is created, and reports usernames
+// and emails repeated within the file.
+func ValidateImportRows(rows []ImportRow, principal *auth.Principal) []jobs.RowError {
+	var rowErrors []jobs.RowError
+	superAdmin := isSuperAdmin(principal)
+	usernames := make(map[string]int)
+	emails := make(map[string]int)
+
+	for _, row := range rows {
+		problems := append([]string{}, row.Errors...)
+		if len(row.Errors) == 0 {
+			if err := row.Request.Validate(); err != nil {
+				problems = append(problems, validation.Messages(err)...)
+			}
+			if role := row.Request.Role; role != "" {
+				if !ImportableRoles[role] {
+					problems = append(problems, fmt.Sprintf("role %s cannot be imported, use CAREGIVER, MUNICIPALITY or INSURER", role))
+				} else if !superAdmin && !IsRoleAllowedForOrgAdmin(role) {
+					problems = append(problems, ErrRoleNotAllowed.Error())
+				}
+			}
+		}
+
+		if username := strings.ToLower(row.Request.Username); username != "" {
+			if first, ok := usernames[username]; ok {
+				problems = append(problems, fmt.Sprintf("username duplicates row %d", first))
+			} else {
+				usernames[username] = row.Row
+			}
+		}
+		if email := strings.ToLower(row.Request.Email); email != "" {
+			if first, ok := emails[email]; ok {
+				problems = append(problems, fmt.Sprintf("email duplicates row %d", first))
+			} else {
+				emails[email] = row.Row
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, jobs.RowError{Row: row.Row, Errors: problems})
+		}
+	}
+
+	return rowErrors
+}
+
+// Importer runs bulk staff imports as asynchronous jobs
+type Importer struct {
+	service ServiceInterface
+	jobs    jobs.RepositoryInterface
+	runner  *jobs.Runner
+}
+
+// NewImporter creates a new staff importer
+func NewImporter(service ServiceInterface, jobRepo jobs.RepositoryInterface) *Importer {
+	return NewImporterWithRunner(service, jobRepo, nil)
+}
+
+// NewImporterWithRunner creates a new staff importer whose jobs run on runner,
+// so they are drained when it shuts down
+func NewImporterWithRunner(service ServiceInterface, jobRepo jobs.RepositoryInterface, runner *jobs.Runner) *Importer {
+	if runner == nil {
+		runner = jobs.NewRunner()
+	}
+	return &Importer{
+		service: service,
+		jobs:    jobRepo,
+		runner:  runner,
+	}
+}
+
+// StartImport records a job for the already validated rows and creates the
+// users in the background. With skipExisting set, rows whose username or email
+// already belongs to a staff member are skipped, so a partially failed import
+// can be uploaded again as is.
+func (i *Importer) StartImport(ctx context.Context, principal *auth.Principal, targetOrgID string, skipExisting bool, rows []ImportRow) (*jobs.Job, error) {
+	orgID, orgSchemaName, err := i.service.ResolveOrganization(ctx, principal, targetOrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// CreateUser only accepts an explicit target organization from SUPER_ADMIN
+	createTargetOrgID := ""
+	if isSuperAdmin(principal) {
+		createTargetOrgID = orgID
+	}
+
+	job := &jobs.Job{
+		OrganizationID: orgID,
+		Kind:           jobs.KindUserImport,
+		TotalRows:      len(rows),
+		CreatedBy:      principal.UserID,
+	}
+	if err := i.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	queued := *job
+	queued.Results = []jobs.RowResult{}
+
+	slog.InfoContext(ctx, "starting staff import job", "job_id", job.ID, "rows", len(rows), "organization_id", orgID, "skip_existing", skipExisting)
+
+	i.runner.Go(ctx, i.jobs, job, func(ctx context.Context, index int) jobs.RowResult {
+		row := rows[index]
+
+		if skipExisting {
+			existing, err := i.service.FindExistingUser(ctx, orgSchemaName, row.Request.Username, row.Request.Email)
+			if err == nil {
+				return jobs.RowResult{Row: row.Row, Status: jobs.RowSkipped, ResourceID: existing.ID}
+			}
+			if !errors.Is(err, ErrUserNotFound) {
+				return jobs.FailedRow(ctx, row.Row, err, "failed to check for an existing user")
+			}
+		}
+
+		user, err := i.service.CreateUser(ctx, row.Request, principal, createTargetOrgID)
+		if err != nil {
+			return jobs.FailedRow(ctx, row.Row, err, "failed to create user")
+		}
+		return jobs.RowResult{Row: row.Row, Status: jobs.RowCreated, ResourceID: user.ID}
+	})
+
+	return &queued, nil
+}
+
+// GetImport returns a staff import job belonging to the given organization
+func (i *Importer) GetImport(ctx context.Context, orgID, jobID string) (*jobs.Job, error) {
+	return jobs.GetForOrganization(ctx, i.jobs, orgID, jobs.KindUserImport, jobID)
+}
+
+func isSuperAdmin(principal *auth.Principal) bool {
+	for _, role := range principal.Roles {
+		if strings.EqualFold(role, "SUPER_ADMIN") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/gorilla/mux"
+)
+
+// ImportHandler serves the bulk staff import endpoints
+type ImportHandler struct {
+	importer *Importer
+	service  ServiceInterface
+}
+
+// NewImportHandler creates a new bulk staff import handler
+func NewImportHandler(importer *Importer, service ServiceInterface) *ImportHandler {
+	return &ImportHandler{
+		importer: importer,
+		service:  service,
+	}
+}
+
+type ImportJobResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Job     *jobs.Job `json:"job"`
+}
+
+// ImportUsers validates an uploaded CSV file and starts an asynchronous job
+// creating one staff member per row. Nothing is created when any row fails
+// validation. Pass skip_existing=true to re-run an import idempotently.
+func (h *ImportHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
+		return
+	}
+
+	targetOrgID := r.Header.Get("X-Organization-ID")
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "text/csv" && mediaType != "application/csv" {
+			apierror.Respond(w, r, http.StatusUnsupportedMediaType, "unsupported_format", "import file must be text/csv")
+			return
+		}
+	}
+
+	skipExisting := false
+	if value := r.URL.Query().Get("skip_existing"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "skip_existing must be true or false")
+			return
+		}
+		skipExisting = parsed
+	}
+
+	rows, err := ParseImportCSV(http.MaxBytesReader(w, r.Body, MaxImportBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierror.Respond(w, r, http.StatusRequestEntityTooLarge, "file_too_large", "import file exceeds the maximum size of 5 MB")
+			return
+		}
+		apierror.Respond(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if rowErrors := ValidateImportRows(rows, principal); len(rowErrors) > 0 {
+		apierror.Write(w, r, jobs.ValidationError("Import file contains invalid rows, no users were created", rowErrors), "")
+		return
+	}
+
+	job, err := h.importer.StartImport(r.Context(), principal, targetOrgID, skipExisting, rows)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to start staff import", "error", err)
+
+		apierror.Write(w, r, err, "failed to start import")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/organization/users/imports/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ImportJobResponse{
+		Success: true,
+		Message: "Staff import started",
+		Job:     job,
+	})
+}
+
+// GetImportJob returns the progress and per-row results of a staff import job
+func (h *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, apierror.CodeUnauthenticated, "unauthorized")
+		return
+	}
+
+	targetOrgID := r.Header.Get("X-Organization-ID")
+
+	orgID, _, err := h.service.ResolveOrganization(r.Context(), principal, targetOrgID)
+	if err != nil {
+		apierror.Write(w, r, err, "failed to get import job")
+		return
+	}
+
+	job, err := h.importer.GetImport(r.Context(), orgID, mux.Vars(r)["id"])
+	if err != nil {
+		if !errors.Is(err, jobs.ErrJobNotFound) {
+			slog.ErrorContext(r.Context(), "failed to get staff import job", "error", err)
+		}
+		apierror.Write(w, r, err, "failed to get import job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ImportJobResponse{
+		Success: true,
+		Message: "Import job retrieved successfully",
+		Job:     job,
+	})
+}
@@ -0,0 +1,410 @@
+package users
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/jobs"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
+)
+
+const validStaffCSV = `username,email,first_name,lastName,role,sendResetEmail
+cgiver,carla@example.com,Carla,Giver,caregiver,true
+minsurer,mike@example.com,Mike,Insurer,INSURER,true
+`
+
+var orgAdminPrincipal = &auth.Principal{
+	UserID:        "admin-123",
+	Roles:         []string{"ORG_ADMIN"},
+	OrgID:         "org-123",
+	OrgSchemaName: "org_123",
+}
+
+// Test staff import parsing and validation
+
+func TestParseImportCSV_Success(t *testing.T) {
+	rows, err := ParseImportCSV(strings.NewReader(validStaffCSV))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Request.Role != "CAREGIVER" {
+		t.Errorf("Expected role to be upper-cased, got %s", rows[0].Request.Role)
+	}
+	if rows[1].Request.FirstName != "Mike" {
+		t.Errorf("Expected first name Mike, got %s", rows[1].Request.FirstName)
+	}
+}
+
+func TestParseImportCSV_IgnoresExportColumns(t *testing.T) {
+	body := "id,employeeId,username,email,firstName,lastName,role,isActive,createdAt\n" +
+		"u-1,EMP-1,cgiver,carla@example.com,Carla,Giver,CAREGIVER,true,2026-01-01T00:00:00Z\n"
+
+	rows, err := ParseImportCSV(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rows[0].Errors) != 0 || rows[0].Request.Username != "cgiver" {
+		t.Errorf("Unexpected row: %+v", rows[0])
+	}
+}
+
+func TestValidateImportRows_Errors(t *testing.T) {
+	rows := []ImportRow{
+		{Row: 1, Request: CreateUserRequest{Username: "a", Email: "a@example.com", FirstName: "A", LastName: "A", Role: "ORG_ADMIN", SendResetEmail: true}},
+		{Row: 2, Request: CreateUserRequest{Username: "b", Email: "A@example.com", FirstName: "B", LastName: "B", Role: "CAREGIVER"}},
+		{Row: 3, Request: CreateUserRequest{Username: "c", Email: "c@example.com", FirstName: "C", LastName: "C", Role: "PATIENT", SendResetEmail: true}},
+	}
+
+	rowErrors := ValidateImportRows(rows, orgAdminPrincipal)
+
+	if len(rowErrors) != 3 {
+		t.Fatalf("Expected errors for 3 rows, got %d: %+v", len(rowErrors), rowErrors)
+	}
+	if !strings.Contains(rowErrors[0].Errors[0], "cannot be imported") {
+		t.Errorf("Expected ORG_ADMIN role to be rejected, got %v", rowErrors[0].Errors)
+	}
+	joined := strings.Join(rowErrors[1].Errors, "; ")
+	if !strings.Contains(joined, ErrMissingPassword.Error()) || !strings.Contains(joined, "email duplicates row 1") {
+		t.Errorf("Expected missing password and duplicate email errors, got %s", joined)
+	}
+}
+
+// Test Importer
+
+func TestImporterStartImport_SkipExisting(t *testing.T) {
+	var created []string
+	mockSvc := &mockService{
+		resolveOrganizationFunc: func(principal *auth.Principal, targetOrgID string) (string, string, error) {
+			return "org-123", "org_123", nil
+		},
+		findExistingUserFunc: func(orgSchemaName, username, email string) (*User, error) {
+			if username == "cgiver" {
+				return &User{ID: "user-existing"}, nil
+			}
+			return nil, ErrUserNotFound
+		},
+		createUserFunc: func(req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+			if targetOrgID != "" {
+				t.Errorf("Expected no target org for ORG_ADMIN, got %s", targetOrgID)
+			}
+			created = append(created, req.Username)
+			return &User{ID: "user-" + req.Username}, nil
+		},
+	}
+	importer := NewImporter(mockSvc, testutil.NewMockJobRepository())
+
+	rows, _ := ParseImportCSV(strings.NewReader(validStaffCSV))
+	job, err := importer.StartImport(context.Background(), orgAdminPrincipal, "", true, rows)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	importer.runner.Shutdown(context.Background())
+
+	stored, err := importer.GetImport(context.Background(), "org-123", job.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored.SkippedRows != 1 || stored.SucceededRows != 1 {
+		t.Errorf("Expected 1 skipped and 1 created row, got %d and %d", stored.SkippedRows, stored.SucceededRows)
+	}
+	if stored.Results[0].Status != jobs.RowSkipped || stored.Results[0].ResourceID != "user-existing" {
+		t.Errorf("Unexpected result for existing user: %+v", stored.Results[0])
+	}
+	if len(created) != 1 || created[0] != "minsurer" {
+		t.Errorf("Expected only minsurer to be created, got %v", created)
+	}
+}
+
+// TestImporterStartImport_RowErrors tests that failed rows report typed
+// errors and hide internal ones
+func TestImporterStartImport_RowErrors(t *testing.T) {
+	mockSvc := &mockService{
+		resolveOrganizationFunc: func(principal *auth.Principal, targetOrgID string) (string, string, error) {
+			return "org-123", "org_123", nil
+		},
+		findExistingUserFunc: func(orgSchemaName, username, email string) (*User, error) {
+			if username == "cgiver" {
+				return nil, ErrUsernameTaken
+			}
+			return nil, ErrUserNotFound
+		},
+		createUserFunc: func(req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+			return nil, errors.New("failed to create user in Keycloak: keycloak request failed: status 500")
+		},
+	}
+	importer := NewImporter(mockSvc, testutil.NewMockJobRepository())
+
+	rows, _ := ParseImportCSV(strings.NewReader(validStaffCSV))
+	job, err := importer.StartImport(context.Background(), orgAdminPrincipal, "", true, rows)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	importer.runner.Shutdown(context.Background())
+
+	stored, err := importer.GetImport(context.Background(), "org-123", job.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := stored.Results[0]; got.Code != "username_taken" || got.Error != ErrUsernameTaken.Detail {
+		t.Errorf("Expected typed row error, got %+v", got)
+	}
+	if got := stored.Results[1]; got.Code != apierror.CodeInternal || got.Error != "failed to create user" {
+		t.Errorf("Expected internal row error to be hidden, got %+v", got)
+	}
+}
+
+func TestImporterStartImport_Forbidden(t *testing.T) {
+	mockSvc := &mockService{
+		resolveOrganizationFunc: func(principal *auth.Principal, targetOrgID string) (string, string, error) {
+			return "", "", ErrForbidden
+		},
+	}
+	importer := NewImporter(mockSvc, testutil.NewMockJobRepository())
+
+	rows, _ := ParseImportCSV(strings.NewReader(validStaffCSV))
+	if _, err := importer.StartImport(context.Background(), orgAdminPrincipal, "org-456", false, rows); err != ErrForbidden {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+}
+
+// Test import and export handlers
+
+func TestHandlerImportUsers_ValidationErrors(t *testing.T) {
+	handler := NewImportHandler(NewImporter(&mockService{}, testutil.NewMockJobRepository()), &mockService{})
+
+	body := "username,email,firstName,lastName,role\ncgiver,carla@example.com,Carla,Giver,CAREGIVER\n"
+	req := httptest.NewRequest(http.MethodPost, "/organization/users/imports", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), orgAdminPrincipal))
+
+	rr := httptest.NewRecorder()
+	handler.ImportUsers(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", rr.Code)
+	}
+
+	var response apierror.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != apierror.CodeValidation {
+		t.Errorf("Expected code %s, got %s", apierror.CodeValidation, response.Code)
+	}
+	if len(response.Errors) == 0 {
+		t.Fatal("Expected validation errors")
+	}
+	for _, fieldError := range response.Errors {
+		if fieldError.Row != 1 {
+			t.Errorf("Expected errors on row 1 only, got %+v", response.Errors)
+		}
+	}
+}
+
+func TestHandlerImportUsers_InvalidSkipExisting(t *testing.T) {
+	handler := NewImportHandler(NewImporter(&mockService{}, testutil.NewMockJobRepository()), &mockService{})
+
+	req := httptest.NewRequest(http.MethodPost, "/organization/users/imports?skip_existing=maybe", strings.NewReader(validStaffCSV))
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), orgAdminPrincipal))
+
+	rr := httptest.NewRecorder()
+	handler.ImportUsers(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+}
+
+func TestHandlerExportUsers_Success(t *testing.T) {
+	mockSvc := &mockService{
+		listStaffFunc: func(principal *auth.Principal, targetOrgID string) ([]User, error) {
+			return []User{
+				{ID: "user-1", EmployeeID: "EMP-1", Username: "cgiver", Email: "carla@example.com", FirstName: "Carla", LastName: "Giver", Role: "CAREGIVER", IsActive: true, CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}, nil
+		},
+	}
+	handler := NewHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/organization/users/export", nil)
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), orgAdminPrincipal))
+
+	rr := httptest.NewRecorder()
+	handler.ExportUsers(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected CSV content type, got %s", rr.Header().Get("Content-Type"))
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected header and 1 row, got %d records", len(records))
+	}
+	if records[1][2] != "cgiver" || records[1][3] != "carla@example.com" || records[1][9] != "2026-01-01T00:00:00Z" {
+		t.Errorf("Unexpected export row: %v", records[1])
+	}
+}
+
+func TestHandlerExportUsers_RoundTrip(t *testing.T) {
+	exported := User{ID: "user-1", Username: "cgiver", Email: "carla@example.com", FirstName: "=HYPERLINK(\"http://evil\")", LastName: "-Giver", PhoneNumber: "+31612345678", Role: "CAREGIVER", IsActive: true}
+	mockSvc := &mockService{
+		listStaffFunc: func(principal *auth.Principal, targetOrgID string) ([]User, error) {
+			return []User{exported}, nil
+		},
+	}
+	handler := NewHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/organization/users/export", nil)
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), orgAdminPrincipal))
+	rr := httptest.NewRecorder()
+	handler.ExportUsers(rr, req)
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	for _, cell := range records[1] {
+		if strings.ContainsAny(cell[:min(1, len(cell))], "=+-@") {
+			t.Errorf("Expected formula cells to be escaped, got %q", cell)
+		}
+	}
+
+	// The export imports again once a password column is added
+	var edited strings.Builder
+	writer := csv.NewWriter(&edited)
+	writer.Write(append(records[0], "sendResetEmail"))
+	writer.Write(append(records[1], "true"))
+	writer.Flush()
+
+	rows, err := ParseImportCSV(strings.NewReader(edited.String()))
+	if err != nil {
+		t.Fatalf("Expected export to parse, got %v", err)
+	}
+	got := rows[0].Request
+	if len(rows[0].Errors) != 0 || got.Validate() != nil {
+		t.Fatalf("Expected valid row, got %v and %v", rows[0].Errors, got.Validate())
+	}
+	if got.Username != exported.Username || got.FirstName != exported.FirstName || got.LastName != exported.LastName || got.PhoneNumber != exported.PhoneNumber || got.Role != exported.Role {
+		t.Errorf("Expected the exported user, got %+v", got)
+	}
+}
+
+func TestServiceListStaff_Usernames(t *testing.T) {
+	repo := &mockRepository{
+		getSchemaNameFunc: func(orgID string) (string, error) {
+			return "org_123", nil
+		},
+		listStaffFunc: func(schemaName string) ([]User, error) {
+			return []User{
+				{ID: "user-1", KeycloakUserID: "kc-1"},
+				{ID: "user-2", KeycloakUserID: "kc-2"},
+				{ID: "user-3", KeycloakUserID: "kc-3"},
+			}, nil
+		},
+	}
+	var lookups []string
+	keycloak := &mockKeycloakAdmin{
+		listByAttributeFunc: func(name, value string) ([]auth.KeycloakUser, error) {
+			if name != "orgSchemaName" || value != "org_123" {
+				t.Errorf("Unexpected attribute search %s=%s", name, value)
+			}
+			return []auth.KeycloakUser{{ID: "kc-1", Username: "cgiver"}}, nil
+		},
+		getUserFunc: func(userID string) (*auth.KeycloakUser, error) {
+			lookups = append(lookups, userID)
+			if userID == "kc-2" {
+				return &auth.KeycloakUser{ID: userID, Username: "legacy"}, nil
+			}
+			return nil, auth.ErrUserNotFound
+		},
+	}
+	service := NewService(repo, keycloak)
+
+	staff, err := service.ListStaff(context.Background(), orgAdminPrincipal, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if staff[0].Username != "cgiver" || staff[1].Username != "legacy" || staff[2].Username != "" {
+		t.Errorf("Expected usernames from Keycloak, got %+v", staff)
+	}
+	// Only users missing from the batch are looked up one by one
+	if len(lookups) != 2 || lookups[0] != "kc-2" || lookups[1] != "kc-3" {
+		t.Errorf("Expected individual lookups of kc-2 and kc-3, got %v", lookups)
+	}
+}
+
+func TestHandlerExportUsers_Forbidden(t *testing.T) {
+	mockSvc := &mockService{
+		listStaffFunc: func(principal *auth.Principal, targetOrgID string) ([]User, error) {
+			return nil, ErrForbidden
+		},
+	}
+	handler := NewHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/organization/users/export", nil)
+	req.Header.Set("X-Organization-ID", "org-456")
+	req = req.WithContext(auth.ContextWithPrincipal(req.Context(), orgAdminPrincipal))
+
+	rr := httptest.NewRecorder()
+	handler.ExportUsers(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rr.Code)
+	}
+}
+
+// Test FindExistingUser
+
+func TestServiceFindExistingUser(t *testing.T) {
+	repo := &mockRepository{
+		getByEmailFunc: func(schemaName, email string) (*User, error) {
+			return nil, ErrUserNotFound
+		},
+		getByKeycloakIDFunc: func(schemaName, keycloakID string) (*User, error) {
+			if keycloakID == "kc-staff" {
+				return &User{ID: "user-1"}, nil
+			}
+			return nil, ErrUserNotFound
+		},
+	}
+	keycloak := &mockKeycloakAdmin{
+		findUserFunc: func(username string) (*auth.KeycloakUser, error) {
+			switch username {
+			case "staff":
+				return &auth.KeycloakUser{ID: "kc-staff"}, nil
+			case "patient":
+				return &auth.KeycloakUser{ID: "kc-patient"}, nil
+			}
+			return nil, auth.ErrUserNotFound
+		},
+	}
+	service := NewService(repo, keycloak)
+
+	if user, err := service.FindExistingUser(context.Background(), "org_123", "staff", "staff@example.com"); err != nil || user.ID != "user-1" {
+		t.Errorf("Expected user-1, got %v, %v", user, err)
+	}
+	if _, err := service.FindExistingUser(context.Background(), "org_123", "patient", "p@example.com"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("Expected ErrUsernameTaken, got %v", err)
+	}
+	if _, err := service.FindExistingUser(context.Background(), "org_123", "new", "new@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -1,17 +1,23 @@
 package users
 
-import "github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+import (
+	"context"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+)
 
 // KeycloakAdminInterface defines the contract for Keycloak operations
 type KeycloakAdminInterface interface {
-	CreateUser(user auth.KeycloakUser) (string, error)
-	SetPassword(userID, password string, temporary bool) error
-	SendEmailAction(userID string, actions []string) error
-	GetRole(roleName string) (*auth.KeycloakRole, error)
-	AssignRole(userID string, role auth.KeycloakRole) error
-	DeleteUser(userID string) error
-	UpdateUser(userID string, user auth.KeycloakUser) error
-	GetUser(userID string) (*auth.KeycloakUser, error)
+	CreateUser(ctx context.Context, user auth.KeycloakUser) (string, error)
+	SetPassword(ctx context.Context, userID, password string, temporary bool) error
+	SendEmailAction(ctx context.Context, userID string, actions []string) error
+	GetRole(ctx context.Context, roleName string) (*auth.KeycloakRole, error)
+	AssignRole(ctx context.Context, userID string, role auth.KeycloakRole) error
+	DeleteUser(ctx context.Context, userID string) error
+	UpdateUser(ctx context.Context, userID string, user auth.KeycloakUser) error
+	GetUser(ctx context.Context, userID string) (*auth.KeycloakUser, error)
+	FindUserByUsername(ctx context.Context, username string) (*auth.KeycloakUser, error)
+	ListUsersByAttribute(ctx context.Context, name, value string) ([]auth.KeycloakUser, error)
 }
 
 // Ensure KeycloakAdminClient implements KeycloakAdminInterface
@@ -3,13 +3,16 @@ package users
 import (
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/validation"
 )
 
 // User represents a user in the system
 type User struct {
 	ID             string    `json:"id"`
 	KeycloakUserID string    `json:"keycloakUserId"`
+	Username       string    `json:"username,omitempty"` // Keycloak username, only set by ListStaff
 	EmployeeID     string    `json:"employeeId,omitempty"`
 	Email          string    `json:"email"`
 	FirstName      string    `json:"firstName"`
@@ -21,26 +24,28 @@ type User struct {
 	OrgSchemaName  string    `json:"orgSchemaName"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt,omitempty"`
+	Version        int       `json:"version"` // Row version, also sent as the ETag
 }
 
-// CreateUserRequest represents the request to create a new user (non-PATIENT roles only)
+// CreateUserRequest represents the request to create a new user (non-PATIENT roles only).
+// Maximum lengths match the columns of the tenant users table.
 type CreateUserRequest struct {
-	Username          string `json:"username"`
-	Email             string `json:"email"`
-	FirstName         string `json:"firstName"`
-	LastName          string `json:"lastName"`
-	PhoneNumber       string `json:"phoneNumber,omitempty"`
-	Role              string `json:"role"`
+	Username          string `json:"username" validate:"required,max=255"`
+	Email             string `json:"email" validate:"required,email,max=255"`
+	FirstName         string `json:"firstName" validate:"required,max=100"`
+	LastName          string `json:"lastName" validate:"required,max=100"`
+	PhoneNumber       string `json:"phoneNumber,omitempty" validate:"omitempty,phone,max=50"`
+	Role              string `json:"role" validate:"required,max=50"`
 	TemporaryPassword string `json:"temporaryPassword"`
 	SendResetEmail    bool   `json:"sendResetEmail"`
 }
 
 // UpdateUserRequest represents the request to update a user
 type UpdateUserRequest struct {
-	Email       string `json:"email,omitempty"`
-	FirstName   string `json:"firstName,omitempty"`
-	LastName    string `json:"lastName,omitempty"`
-	PhoneNumber string `json:"phoneNumber,omitempty"`
+	Email       string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	FirstName   string `json:"firstName,omitempty" validate:"omitempty,max=100"`
+	LastName    string `json:"lastName,omitempty" validate:"omitempty,max=100"`
+	PhoneNumber string `json:"phoneNumber,omitempty" validate:"omitempty,phone,max=50"`
 }
 
 // ResetPasswordRequest represents the request to reset a user's password
@@ -62,27 +67,33 @@ func IsRoleAllowedForOrgAdmin(role string) bool {
 	return AllowedRolesForOrgAdmin[role]
 }
 
-// Validate validates the create user request
+// Validate checks every field of the create user request and reports all
+// problems at once
 func (r *CreateUserRequest) Validate() error {
-	if r.Username == "" {
-		return ErrMissingUsername
-	}
-	if r.Email == "" {
-		return ErrMissingEmail
-	}
-	if r.FirstName == "" {
-		return ErrMissingFirstName
-	}
-	if r.LastName == "" {
-		return ErrMissingLastName
-	}
-	if r.Role == "" {
-		return ErrMissingRole
-	}
+	fieldErrors := validation.Fields(r)
 	if r.TemporaryPassword == "" && !r.SendResetEmail {
-		return ErrMissingPassword
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   "temporaryPassword",
+			Message: ErrMissingPassword.Detail,
+		})
 	}
+	return validation.Error(fieldErrors)
+}
+
+// Validate checks the fields present in the update user request
+func (r *UpdateUserRequest) Validate() error {
+	return validation.Struct(r)
+}
 
+// Validate checks that the reset password request sets a password or asks
+// for the reset email
+func (r *ResetPasswordRequest) Validate() error {
+	if r.TemporaryPassword == "" && !r.SendEmail {
+		return validation.Error([]apierror.FieldError{{
+			Field:   "temporaryPassword",
+			Message: ErrMissingPassword.Detail,
+		}})
+	}
 	return nil
 }
 
@@ -3,10 +3,12 @@ package users
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/messaging"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/google/uuid"
 )
 
@@ -35,6 +37,20 @@ func (r *Repository) GetSchemaNameByOrgID(orgID string) (string, error) {
 	return schemaName, nil
 }
 
+// GetOrgIDBySchemaName returns the live organization that owns a tenant schema
+func (r *Repository) GetOrgIDBySchemaName(schemaName string) (string, error) {
+	query := `SELECT id FROM wailsalutem.organizations WHERE schema_name = $1 AND deleted_at IS NULL`
+	var orgID string
+	err := r.db.QueryRow(query, schemaName).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		return "", ErrInvalidOrgSchema
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get organization of schema: %w", err)
+	}
+	return orgID, nil
+}
+
 func (r *Repository) ValidateOrgSchema(schemaName string) error {
 	query := `SELECT 1 FROM wailsalutem.organizations WHERE schema_name = $1`
 	var exists int
@@ -119,8 +135,9 @@ func (r *Repository) Create(user *User) error {
 	if execErr != nil {
 		return fmt.Errorf("failed to create user in database: %w", execErr)
 	}
+	user.Version = 1 // Column default of a new row
 
-	log.Printf("Created user in database: %s %s (schema: %s)", user.FirstName, user.LastName, user.OrgSchemaName)
+	slog.Info("created user in database", "user_id", user.ID, "schema", user.OrgSchemaName)
 
 	// Publish user.created event
 	if r.publisher != nil {
@@ -140,7 +157,7 @@ func (r *Repository) Create(user *User) error {
 		}
 
 		if err := r.publisher.Publish(nil, messaging.EventUserCreated, event); err != nil {
-			log.Printf("Warning: failed to publish user.created event: %v", err)
+			slog.Warn("failed to publish user.created event", "error", err)
 		}
 	}
 
@@ -153,7 +170,7 @@ func (r *Repository) GetByID(schemaName, userID string) (*User, error) {
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
 		FROM %s.users
 		WHERE id = $1
 	`, schemaName)
@@ -178,6 +195,7 @@ func (r *Repository) GetByID(schemaName, userID string) (*User, error) {
 		&user.IsActive,
 		&user.CreatedAt,
 		&updatedAt,
+		&user.Version,
 	)
 
 	if err == sql.ErrNoRows {
@@ -217,7 +235,7 @@ func (r *Repository) GetByKeycloakID(schemaName, keycloakUserID string) (*User,
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
 		FROM %s.users
 		WHERE keycloak_user_id = $1
 	`, schemaName)
@@ -242,6 +260,7 @@ func (r *Repository) GetByKeycloakID(schemaName, keycloakUserID string) (*User,
 		&user.IsActive,
 		&user.CreatedAt,
 		&updatedAt,
+		&user.Version,
 	)
 
 	if err == sql.ErrNoRows {
@@ -281,7 +300,7 @@ func (r *Repository) List(schemaName string) ([]User, error) {
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
 		FROM %s.users
 		ORDER BY created_at DESC
 	`, schemaName)
@@ -314,6 +333,7 @@ func (r *Repository) List(schemaName string) ([]User, error) {
 			&user.IsActive,
 			&user.CreatedAt,
 			&updatedAt,
+			&user.Version,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -349,50 +369,68 @@ func (r *Repository) List(schemaName string) ([]User, error) {
 	return users, nil
 }
 
+// userListFields whitelists the sort keys and filters of the user lists
+var userListFields = pagination.Fields{
+	Sort: map[string]string{
+		"employee_id": "employee_id",
+		"first_name":  "first_name",
+		"last_name":   "last_name",
+		"email":       "email",
+		"role":        "role",
+		"created_at":  "created_at",
+	},
+	Filters: map[string]pagination.Filter{
+		"role":           {Column: "role", Op: "=", Type: pagination.FilterText},
+		"is_active":      {Column: "is_active", Op: "=", Type: pagination.FilterBool},
+		"created_after":  {Column: "created_at", Op: ">=", Type: pagination.FilterDate},
+		"created_before": {Column: "created_at", Op: "<", Type: pagination.FilterDate},
+	},
+}
+
 // ListWithPagination retrieves users with pagination support
-func (r *Repository) ListWithPagination(schemaName string, limit, offset int, search string) ([]User, int, error) {
+func (r *Repository) ListWithPagination(schemaName string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
 	if err := r.ValidateOrgSchema(schemaName); err != nil {
 		return nil, 0, err
 	}
 
-	// Build WHERE clause for search
-	whereClause := ""
-	countWhereClause := ""
-	args := []interface{}{limit, offset}
-	if search != "" {
-		whereClause = `WHERE (first_name ILIKE $3 OR last_name ILIKE $3 OR email ILIKE $3)`
-		countWhereClause = `WHERE (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1)`
-		args = append(args, "%"+search+"%")
+	// Build WHERE clause for search and filters
+	whereClause := "WHERE true"
+	args := []interface{}{}
+	if listQuery.Search != "" {
+		whereClause += ` AND (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1)`
+		args = append(args, "%"+listQuery.Search+"%")
 	}
+	conditions, filterArgs := userListFields.Where(listQuery.Filters, len(args)+1)
+	whereClause += conditions
+	args = append(args, filterArgs...)
 
-	// First, get total count
+	// Count the total unless a cursor request skips it
 	var totalCount int
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s.users
-		%s
-	`, schemaName, countWhereClause)
-
-	if search != "" {
-		err := r.db.QueryRow(countQuery, "%"+search+"%").Scan(&totalCount)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to count users: %w", err)
-		}
-	} else {
-		err := r.db.QueryRow(countQuery).Scan(&totalCount)
+	if window.CountTotal {
+		countQuery := fmt.Sprintf(`
+			SELECT COUNT(*) 
+			FROM %s.users
+			%s
+		`, schemaName, whereClause)
+
+		err := r.db.QueryRow(countQuery, args...).Scan(&totalCount)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to count users: %w", err)
 		}
 	}
 
-	// Then get paginated results
+	// Then get the page, after the cursor if there is one
+	keyset, keysetArgs := window.KeysetClause(len(args) + 1)
+	args = append(args, keysetArgs...)
+
 	query := fmt.Sprintf(`
-		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
 		FROM %s.users
-		%s
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`, schemaName, whereClause)
+		%s%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, schemaName, whereClause, keyset, userListFields.OrderBy(listQuery.Sort), len(args)+1, len(args)+2)
+	args = append(args, window.Limit, window.Offset)
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
@@ -422,6 +460,7 @@ func (r *Repository) ListWithPagination(schemaName string, limit, offset int, se
 			&user.IsActive,
 			&user.CreatedAt,
 			&updatedAt,
+			&user.Version,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
@@ -458,43 +497,50 @@ func (r *Repository) ListWithPagination(schemaName string, limit, offset int, se
 }
 
 // ListActiveUsersByRoleWithPagination retrieves active users (not soft deleted) by role with pagination support
-func (r *Repository) ListActiveUsersByRoleWithPagination(schemaName string, role string, limit, offset int, search string) ([]User, int, error) {
+func (r *Repository) ListActiveUsersByRoleWithPagination(schemaName string, role string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
 	if err := r.ValidateOrgSchema(schemaName); err != nil {
 		return nil, 0, err
 	}
 
-	// Build WHERE clause for search
+	// Build WHERE clause for search and filters
 	searchClause := ""
-	countArgs := []interface{}{role}
-	queryArgs := []interface{}{role, limit, offset}
+	queryArgs := []interface{}{role}
 
-	if search != "" {
+	if listQuery.Search != "" {
 		searchClause = ` AND (first_name ILIKE $2 OR last_name ILIKE $2 OR email ILIKE $2)`
-		countArgs = append(countArgs, "%"+search+"%")
-		queryArgs = []interface{}{role, "%" + search + "%", limit, offset}
+		queryArgs = append(queryArgs, "%"+listQuery.Search+"%")
 	}
+	conditions, filterArgs := userListFields.Where(listQuery.Filters, len(queryArgs)+1)
+	searchClause += conditions
+	queryArgs = append(queryArgs, filterArgs...)
 
-	// First, get total count of active users with specific role
+	// Count active users with the role unless a cursor request skips it
 	var totalCount int
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM %s.users
-		WHERE deleted_at IS NULL AND role = $1%s
-	`, schemaName, searchClause)
-
-	err := r.db.QueryRow(countQuery, countArgs...).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count active users by role: %w", err)
+	if window.CountTotal {
+		countQuery := fmt.Sprintf(`
+			SELECT COUNT(*) 
+			FROM %s.users
+			WHERE deleted_at IS NULL AND role = $1%s
+		`, schemaName, searchClause)
+
+		err := r.db.QueryRow(countQuery, queryArgs...).Scan(&totalCount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count active users by role: %w", err)
+		}
 	}
 
-	// Then get paginated results
+	// Then get the page, after the cursor if there is one
+	keyset, keysetArgs := window.KeysetClause(len(queryArgs) + 1)
+	queryArgs = append(queryArgs, keysetArgs...)
+
 	query := fmt.Sprintf(`
-		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
 		FROM %s.users
-		WHERE deleted_at IS NULL AND role = $1%s
-		ORDER BY created_at DESC
+		WHERE deleted_at IS NULL AND role = $1%s%s
+		ORDER BY %s
 		LIMIT $%d OFFSET $%d
-	`, schemaName, searchClause, len(queryArgs)-1, len(queryArgs))
+	`, schemaName, searchClause, keyset, userListFields.OrderBy(listQuery.Sort), len(queryArgs)+1, len(queryArgs)+2)
+	queryArgs = append(queryArgs, window.Limit, window.Offset)
 
 	rows, err := r.db.Query(query, queryArgs...)
 	if err != nil {
@@ -524,6 +570,7 @@ func (r *Repository) ListActiveUsersByRoleWithPagination(schemaName string, role
 			&user.IsActive,
 			&user.CreatedAt,
 			&updatedAt,
+			&user.Version,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
@@ -559,6 +606,153 @@ func (r *Repository) ListActiveUsersByRoleWithPagination(schemaName string, role
 	return users, totalCount, nil
 }
 
+// GetByEmail retrieves a user that has not been deleted by email, ignoring case
+func (r *Repository) GetByEmail(schemaName, email string) (*User, error) {
+	if err := r.ValidateOrgSchema(schemaName); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
+		FROM %s.users
+		WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL
+		LIMIT 1
+	`, schemaName)
+
+	user := &User{}
+	var updatedAt sql.NullTime
+	var phoneNumber sql.NullString
+	var emailValue sql.NullString
+	var firstName sql.NullString
+	var lastName sql.NullString
+	var employeeID sql.NullString
+
+	err := r.db.QueryRow(query, email).Scan(
+		&user.ID,
+		&user.KeycloakUserID,
+		&employeeID,
+		&emailValue,
+		&firstName,
+		&lastName,
+		&phoneNumber,
+		&user.Role,
+		&user.IsActive,
+		&user.CreatedAt,
+		&updatedAt,
+		&user.Version,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	if employeeID.Valid {
+		user.EmployeeID = employeeID.String
+	}
+	if emailValue.Valid {
+		user.Email = emailValue.String
+	}
+	if firstName.Valid {
+		user.FirstName = firstName.String
+	}
+	if lastName.Valid {
+		user.LastName = lastName.String
+	}
+	if phoneNumber.Valid {
+		user.PhoneNumber = phoneNumber.String
+	}
+	if updatedAt.Valid {
+		user.UpdatedAt = updatedAt.Time
+	}
+
+	user.OrgSchemaName = schemaName
+
+	return user, nil
+}
+
+// ListStaff retrieves every user that has not been deleted, ordered for export
+func (r *Repository) ListStaff(schemaName string) ([]User, error) {
+	if err := r.ValidateOrgSchema(schemaName); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, keycloak_user_id, employee_id, email, first_name, last_name, phone_number, role, is_active, created_at, updated_at, version
+		FROM %s.users
+		WHERE deleted_at IS NULL
+		ORDER BY role, last_name, first_name
+	`, schemaName)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staff: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var updatedAt sql.NullTime
+		var phoneNumber sql.NullString
+		var email sql.NullString
+		var firstName sql.NullString
+		var lastName sql.NullString
+		var employeeID sql.NullString
+
+		err := rows.Scan(
+			&user.ID,
+			&user.KeycloakUserID,
+			&employeeID,
+			&email,
+			&firstName,
+			&lastName,
+			&phoneNumber,
+			&user.Role,
+			&user.IsActive,
+			&user.CreatedAt,
+			&updatedAt,
+			&user.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+
+		if employeeID.Valid {
+			user.EmployeeID = employeeID.String
+		}
+		if email.Valid {
+			user.Email = email.String
+		}
+		if firstName.Valid {
+			user.FirstName = firstName.String
+		}
+		if lastName.Valid {
+			user.LastName = lastName.String
+		}
+		if phoneNumber.Valid {
+			user.PhoneNumber = phoneNumber.String
+		}
+		if updatedAt.Valid {
+			user.UpdatedAt = updatedAt.Time
+		}
+
+		user.OrgSchemaName = schemaName
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+// Update saves the profile fields of user. The update only applies while the
+// row still has user.Version, unless that is etag.AnyVersion; on success
+// user.Version holds the new version.
 func (r *Repository) Update(user *User) error {
 	if err := r.ValidateOrgSchema(user.OrgSchemaName); err != nil {
 		return err
@@ -568,8 +762,8 @@ func (r *Repository) Update(user *User) error {
 
 	query := fmt.Sprintf(`
 		UPDATE %s.users
-		SET email = $1, first_name = $2, last_name = $3, phone_number = $4, updated_at = $5
-		WHERE id = $6
+		SET email = $1, first_name = $2, last_name = $3, phone_number = $4, updated_at = $5, version = version + 1
+		WHERE id = $6 AND ($7 = 0 OR version = $7)
 	`, user.OrgSchemaName)
 
 	result, err := r.db.Exec(query,
@@ -579,6 +773,7 @@ func (r *Repository) Update(user *User) error {
 		user.PhoneNumber,
 		user.UpdatedAt,
 		user.ID,
+		user.Version,
 	)
 
 	if err != nil {
@@ -591,15 +786,18 @@ func (r *Repository) Update(user *User) error {
 	}
 
 	if rows == 0 {
-		return ErrUserNotFound
+		return r.missingOrModified(user.OrgSchemaName, user.ID, user.Version)
 	}
+	user.Version++
 
-	log.Printf("Updated user in database: %s %s (schema: %s)", user.FirstName, user.LastName, user.OrgSchemaName)
+	slog.Info("updated user in database", "user_id", user.ID, "schema", user.OrgSchemaName)
 
 	return nil
 }
 
-func (r *Repository) Delete(schemaName, orgID, userID string, role string) error {
+// Delete soft deletes the user. Unless version is etag.AnyVersion, only that
+// row version is deleted.
+func (r *Repository) Delete(schemaName, orgID, userID string, role string, version int) error {
 	if err := r.ValidateOrgSchema(schemaName); err != nil {
 		return err
 	}
@@ -608,12 +806,13 @@ func (r *Repository) Delete(schemaName, orgID, userID string, role string) error
 	query := fmt.Sprintf(`
 		UPDATE %s.users 
 	SET deleted_at = $1,
-	    updated_at = $1
-	WHERE id = $2 AND deleted_at IS NULL
+	    updated_at = $1,
+	    version = version + 1
+	WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
 `, schemaName)
 
 	deletedAt := time.Now()
-	result, err := r.db.Exec(query, deletedAt, userID)
+	result, err := r.db.Exec(query, deletedAt, userID, version)
 	if err != nil {
 		return fmt.Errorf("failed to soft delete user: %w", err)
 	}
@@ -624,7 +823,7 @@ func (r *Repository) Delete(schemaName, orgID, userID string, role string) error
 	}
 
 	if rows == 0 {
-		return ErrUserNotFound
+		return r.missingOrModified(schemaName, userID, version)
 	}
 
 	// Publish user.deleted event
@@ -640,11 +839,29 @@ func (r *Repository) Delete(schemaName, orgID, userID string, role string) error
 		}
 
 		if err := r.publisher.Publish(nil, messaging.EventUserDeleted, event); err != nil {
-			log.Printf("Warning: failed to publish user.deleted event: %v", err)
+			slog.Warn("failed to publish user.deleted event", "error", err)
 		}
 	}
 
-	log.Printf("Deleted user from database: %s (schema: %s)", userID, schemaName)
+	slog.Info("deleted user from database", "user_id", userID, "schema", schemaName)
 
 	return nil
 }
+
+// missingOrModified explains why a write matched no row: the user is gone,
+// or it exists but no longer has the expected version
+func (r *Repository) missingOrModified(schemaName, userID string, version int) error {
+	if version == etag.AnyVersion {
+		return ErrUserNotFound
+	}
+
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.users WHERE id = $1 AND deleted_at IS NULL)`, schemaName)
+	var exists bool
+	if err := r.db.QueryRow(query, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return etag.ErrPreconditionFailed
+}
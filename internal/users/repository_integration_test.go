@@ -6,6 +6,8 @@ package users
 import (
 	"testing"
 
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/testutil"
 	"github.com/google/uuid"
 )
@@ -233,7 +235,7 @@ func TestRepositoryListWithPagination_Integration(t *testing.T) {
 	}
 
 	// Get first page (limit 2)
-	users, total, err := repo.ListWithPagination(schemaName, 2, 0, "")
+	users, total, err := repo.ListWithPagination(schemaName, pagination.Window{Limit: 2, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListWithPagination failed: %v", err)
 	}
@@ -247,7 +249,7 @@ func TestRepositoryListWithPagination_Integration(t *testing.T) {
 	}
 
 	// Get second page
-	users, _, err = repo.ListWithPagination(schemaName, 2, 2, "")
+	users, _, err = repo.ListWithPagination(schemaName, pagination.Window{Limit: 2, Offset: 2, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListWithPagination page 2 failed: %v", err)
 	}
@@ -285,7 +287,7 @@ func TestRepositoryListActiveUsersByRole_Integration(t *testing.T) {
 	}
 
 	// List only caregivers
-	users, total, err := repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", 10, 0, "")
+	users, total, err := repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("ListActiveUsersByRoleWithPagination failed: %v", err)
 	}
@@ -377,7 +379,7 @@ func TestRepositoryDelete_Integration(t *testing.T) {
 	}
 
 	// Delete user
-	err = repo.Delete(schemaName, orgID, user.ID, "CAREGIVER")
+	err = repo.Delete(schemaName, orgID, user.ID, "CAREGIVER", etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
@@ -567,7 +569,7 @@ func TestRepositoryListWithPagination_Search_Integration(t *testing.T) {
 	}
 
 	// Search for "Alice"
-	users, total, err := repo.ListWithPagination(schemaName, 10, 0, "Alice")
+	users, total, err := repo.ListWithPagination(schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "Alice"})
 	if err != nil {
 		t.Fatalf("Search for Alice failed: %v", err)
 	}
@@ -577,7 +579,7 @@ func TestRepositoryListWithPagination_Search_Integration(t *testing.T) {
 	}
 
 	// Search for "Smith"
-	users, total, err = repo.ListWithPagination(schemaName, 10, 0, "Smith")
+	users, total, err = repo.ListWithPagination(schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "Smith"})
 	if err != nil {
 		t.Fatalf("Search for Smith failed: %v", err)
 	}
@@ -587,7 +589,7 @@ func TestRepositoryListWithPagination_Search_Integration(t *testing.T) {
 	}
 
 	// Search by email
-	users, total, err = repo.ListWithPagination(schemaName, 10, 0, "bob.johnson")
+	users, total, err = repo.ListWithPagination(schemaName, pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "bob.johnson"})
 	if err != nil {
 		t.Fatalf("Search by email failed: %v", err)
 	}
@@ -638,7 +640,7 @@ func TestRepositoryListActiveUsersByRole_Search_Integration(t *testing.T) {
 	repo.Create(user)
 
 	// Search for "Alice" among caregivers only
-	users, total, err := repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", 10, 0, "Alice")
+	users, total, err := repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "Alice"})
 	if err != nil {
 		t.Fatalf("Search caregivers failed: %v", err)
 	}
@@ -652,7 +654,7 @@ func TestRepositoryListActiveUsersByRole_Search_Integration(t *testing.T) {
 	}
 
 	// Search for "Alice" among municipality users
-	users, total, err = repo.ListActiveUsersByRoleWithPagination(schemaName, "MUNICIPALITY", 10, 0, "Alice")
+	users, total, err = repo.ListActiveUsersByRoleWithPagination(schemaName, "MUNICIPALITY", pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{Search: "Alice"})
 	if err != nil {
 		t.Fatalf("Search municipality failed: %v", err)
 	}
@@ -692,7 +694,7 @@ func TestRepositorySoftDelete_ExcludesFromActiveList_Integration(t *testing.T) {
 	}
 
 	// Verify all 3 are in active list
-	users, total, err := repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", 10, 0, "")
+	users, total, err := repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("List active users failed: %v", err)
 	}
@@ -702,13 +704,13 @@ func TestRepositorySoftDelete_ExcludesFromActiveList_Integration(t *testing.T) {
 	}
 
 	// Soft delete one user
-	err = repo.Delete(schemaName, orgID, userIDs[0], "CAREGIVER")
+	err = repo.Delete(schemaName, orgID, userIDs[0], "CAREGIVER", etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
 	// Verify only 2 are in active list now
-	users, total, err = repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", 10, 0, "")
+	users, total, err = repo.ListActiveUsersByRoleWithPagination(schemaName, "CAREGIVER", pagination.Window{Limit: 10, Offset: 0, CountTotal: true}, pagination.Query{})
 	if err != nil {
 		t.Fatalf("List active users after delete failed: %v", err)
 	}
@@ -774,7 +776,7 @@ func TestRepositoryDelete_NotFound_Integration(t *testing.T) {
 	orgID, schemaName := testutil.CreateTestOrg(t, db, "hospital_q")
 	repo := NewRepository(db, nil)
 
-	err := repo.Delete(schemaName, orgID, "00000000-0000-0000-0000-000000000000", "CAREGIVER")
+	err := repo.Delete(schemaName, orgID, "00000000-0000-0000-0000-000000000000", "CAREGIVER", etag.AnyVersion)
 	if err != ErrUserNotFound {
 		t.Errorf("Expected ErrUserNotFound, got %v", err)
 	}
@@ -806,13 +808,13 @@ func TestRepositoryDelete_AlreadyDeleted_Integration(t *testing.T) {
 	}
 
 	// Delete once
-	err = repo.Delete(schemaName, orgID, user.ID, "CAREGIVER")
+	err = repo.Delete(schemaName, orgID, user.ID, "CAREGIVER", etag.AnyVersion)
 	if err != nil {
 		t.Fatalf("First delete failed: %v", err)
 	}
 
 	// Try to delete again
-	err = repo.Delete(schemaName, orgID, user.ID, "CAREGIVER")
+	err = repo.Delete(schemaName, orgID, user.ID, "CAREGIVER", etag.AnyVersion)
 	if err != ErrUserNotFound {
 		t.Errorf("Expected ErrUserNotFound on second delete, got %v", err)
 	}
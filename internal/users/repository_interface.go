@@ -1,17 +1,22 @@
 package users
 
+import "github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+
 // RepositoryInterface defines the contract for user data access
 type RepositoryInterface interface {
 	GetSchemaNameByOrgID(orgID string) (string, error)
+	GetOrgIDBySchemaName(schemaName string) (string, error)
 	ValidateOrgSchema(schemaName string) error
 	Create(user *User) error
 	GetByID(schemaName, userID string) (*User, error)
 	GetByKeycloakID(schemaName, keycloakUserID string) (*User, error)
+	GetByEmail(schemaName, email string) (*User, error)
 	List(schemaName string) ([]User, error)
-	ListWithPagination(schemaName string, limit, offset int, search string) ([]User, int, error)
-	ListActiveUsersByRoleWithPagination(schemaName string, role string, limit, offset int, search string) ([]User, int, error)
+	ListWithPagination(schemaName string, window pagination.Window, listQuery pagination.Query) ([]User, int, error)
+	ListActiveUsersByRoleWithPagination(schemaName string, role string, window pagination.Window, listQuery pagination.Query) ([]User, int, error)
+	ListStaff(schemaName string) ([]User, error)
 	Update(user *User) error
-	Delete(schemaName, orgID, userID string, role string) error
+	Delete(schemaName, orgID, userID string, role string, version int) error
 }
 
 // Ensure Repository implements RepositoryInterface
@@ -1,29 +1,47 @@
 package users
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/telemetry"
 )
 
+// MetricsRecorder interface for recording user operation metrics
+type MetricsRecorder interface {
+	RecordUserOperation(ctx context.Context, operation, tenant, outcome string)
+}
+
 type Service struct {
 	repo          RepositoryInterface
 	keycloakAdmin KeycloakAdminInterface
+	metrics       MetricsRecorder
 }
 
 func NewService(repo RepositoryInterface, keycloakAdmin KeycloakAdminInterface) *Service {
+	return NewServiceWithMetrics(repo, keycloakAdmin, nil)
+}
+
+// NewServiceWithMetrics creates a user service that counts its operations
+func NewServiceWithMetrics(repo RepositoryInterface, keycloakAdmin KeycloakAdminInterface, metrics MetricsRecorder) *Service {
 	return &Service{
 		repo:          repo,
 		keycloakAdmin: keycloakAdmin,
+		metrics:       metrics,
 	}
 }
 
-func (s *Service) CreateUser(req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+func (s *Service) CreateUser(ctx context.Context, req CreateUserRequest, principal *auth.Principal, targetOrgID string) (_ *User, err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "create", orgSchemaName, err) }()
+
 	if s.keycloakAdmin == nil {
-		log.Printf("Keycloak admin client is not initialized")
+		slog.ErrorContext(ctx, "Keycloak admin client is not initialized")
 		return nil, fmt.Errorf("keycloak admin client is not available")
 	}
 
@@ -33,43 +51,43 @@ func (s *Service) CreateUser(req CreateUserRequest, principal *auth.Principal, t
 
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID == "" {
-			log.Printf("SUPER_ADMIN must provide X-Organization-ID header")
+			slog.WarnContext(ctx, "SUPER_ADMIN must provide X-Organization-ID header")
 			return nil, fmt.Errorf("SUPER_ADMIN must provide X-Organization-ID header to specify target organization")
 		}
 		effectiveOrgID = targetOrgID
-		log.Printf("SUPER_ADMIN creating user in org: %s", effectiveOrgID)
+		slog.DebugContext(ctx, "SUPER_ADMIN creating user in organization", "organization_id", effectiveOrgID)
 	} else {
 		if targetOrgID != "" {
-			log.Printf("ORG_ADMIN attempted to create user in different org")
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to create user in a different organization", "organization_id", targetOrgID)
 			return nil, ErrForbidden
 		}
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return nil, ErrInvalidOrgSchema
 		}
-		log.Printf("ORG_ADMIN creating user in own org: %s", effectiveOrgID)
+		slog.DebugContext(ctx, "ORG_ADMIN creating user in own organization", "organization_id", effectiveOrgID)
 	}
 
-	if !s.hasRole(principal, "SUPER_ADMIN") {
+	if !principal.HasRole(auth.RoleSuperAdmin) {
 		if !IsRoleAllowedForOrgAdmin(req.Role) {
-			log.Printf("ORG_ADMIN attempted to create forbidden role: %s", req.Role)
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to create forbidden role", "role", req.Role)
 			return nil, ErrRoleNotAllowed
 		}
 	}
 
-	orgSchemaName := principal.OrgSchemaName
+	orgSchemaName = principal.OrgSchemaName
 
 	if targetOrgID != "" || orgSchemaName == "" {
 		var err error
 		orgSchemaName, err = s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 		if err != nil {
-			log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+			slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 			return nil, ErrInvalidOrgSchema
 		}
-		log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+		slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 	}
 
 	if err := s.repo.ValidateOrgSchema(orgSchemaName); err != nil {
@@ -88,42 +106,42 @@ func (s *Service) CreateUser(req CreateUserRequest, principal *auth.Principal, t
 		},
 	}
 
-	log.Printf("Creating Keycloak user with attributes: organizationID=%s, orgSchemaName=%s", effectiveOrgID, orgSchemaName)
+	slog.DebugContext(ctx, "creating Keycloak user", "organization_id", effectiveOrgID, "schema", orgSchemaName)
 
-	keycloakUserID, err := s.keycloakAdmin.CreateUser(keycloakUser)
+	keycloakUserID, err := s.keycloakAdmin.CreateUser(ctx, keycloakUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user in Keycloak: %w", err)
 	}
 
-	log.Printf("Created user in Keycloak: %s (ID: %s)", req.Username, keycloakUserID)
+	slog.InfoContext(ctx, "created user in Keycloak", "username", req.Username, "keycloak_id", keycloakUserID)
 
 	if req.TemporaryPassword != "" {
-		err = s.keycloakAdmin.SetPassword(keycloakUserID, req.TemporaryPassword, false)
+		err = s.keycloakAdmin.SetPassword(ctx, keycloakUserID, req.TemporaryPassword, false)
 		if err != nil {
-			log.Printf("Failed to set password, rolling back user creation: %s", keycloakUserID)
-			_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+			slog.ErrorContext(ctx, "failed to set password, rolling back user creation", "keycloak_id", keycloakUserID, "error", err)
+			_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 			return nil, fmt.Errorf("failed to set password: %w", err)
 		}
 	} else if req.SendResetEmail {
-		err = s.keycloakAdmin.SendEmailAction(keycloakUserID, []string{"UPDATE_PASSWORD"})
+		err = s.keycloakAdmin.SendEmailAction(ctx, keycloakUserID, []string{"UPDATE_PASSWORD"})
 		if err != nil {
-			log.Printf("Failed to send reset email, rolling back user creation: %s", keycloakUserID)
-			_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+			slog.ErrorContext(ctx, "failed to send reset email, rolling back user creation", "keycloak_id", keycloakUserID, "error", err)
+			_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 			return nil, fmt.Errorf("failed to send reset email: %w", err)
 		}
 	}
 
-	role, err := s.keycloakAdmin.GetRole(req.Role)
+	role, err := s.keycloakAdmin.GetRole(ctx, req.Role)
 	if err != nil {
-		log.Printf("Failed to get role, rolling back user creation: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.ErrorContext(ctx, "failed to get role, rolling back user creation", "keycloak_id", keycloakUserID, "error", err)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("failed to get role: %w", err)
 	}
 
-	err = s.keycloakAdmin.AssignRole(keycloakUserID, *role)
+	err = s.keycloakAdmin.AssignRole(ctx, keycloakUserID, *role)
 	if err != nil {
-		log.Printf("Failed to assign role, rolling back user creation: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.ErrorContext(ctx, "failed to assign role, rolling back user creation", "keycloak_id", keycloakUserID, "error", err)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("failed to assign role: %w", err)
 	}
 
@@ -140,109 +158,114 @@ func (s *Service) CreateUser(req CreateUserRequest, principal *auth.Principal, t
 
 	// Reject PATIENT role - patients should be created via /organization/patients endpoint
 	if req.Role == "PATIENT" {
-		log.Printf("Attempted to create PATIENT via users endpoint: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.WarnContext(ctx, "attempted to create PATIENT via users endpoint", "keycloak_id", keycloakUserID)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("PATIENT users must be created via /organization/patients endpoint")
 	}
 
 	// Create user in users table (for CAREGIVER, MUNICIPALITY, INSURER, etc.)
 	err = s.repo.Create(user)
 	if err != nil {
-		log.Printf("Failed to create user in database, rolling back: %s", keycloakUserID)
-		_ = s.keycloakAdmin.DeleteUser(keycloakUserID)
+		slog.ErrorContext(ctx, "failed to create user in database, rolling back", "keycloak_id", keycloakUserID, "error", err)
+		_ = s.keycloakAdmin.DeleteUser(ctx, keycloakUserID)
 		return nil, fmt.Errorf("failed to create user in database: %w", err)
 	}
-	log.Printf("Successfully created user record: %s", user.ID)
+	slog.DebugContext(ctx, "created user record", "user_id", user.ID)
 
-	log.Printf("Successfully created user end-to-end: %s (Keycloak ID: %s, DB ID: %s)", req.Username, keycloakUserID, user.ID)
+	slog.InfoContext(ctx, "created user end-to-end", "username", req.Username, "keycloak_id", keycloakUserID, "user_id", user.ID)
 
 	return user, nil
 }
 
-func (s *Service) GetUser(userID string, principal *auth.Principal, targetOrgID string) (*User, error) {
+func (s *Service) GetUser(ctx context.Context, userID string, principal *auth.Principal, targetOrgID string) (*User, error) {
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID != "" {
 			effectiveOrgID = targetOrgID
-			log.Printf("SUPER_ADMIN getting user from org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN getting user from organization", "organization_id", effectiveOrgID)
 		} else {
 			effectiveOrgID = principal.OrgID
 			if effectiveOrgID == "" {
-				log.Printf("SUPER_ADMIN token has no orgId and no X-Organization-ID header provided")
+				slog.WarnContext(ctx, "SUPER_ADMIN token has no organization ID and no X-Organization-ID header provided")
 				return nil, ErrInvalidOrgSchema
 			}
-			log.Printf("SUPER_ADMIN getting user from own org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN getting user from own organization", "organization_id", effectiveOrgID)
 		}
 	} else {
 		// For non-SUPER_ADMIN (ORG_ADMIN, PATIENT, etc.), they can only access their own org
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return nil, ErrInvalidOrgSchema
 		}
 		
 		// If X-Organization-ID header is provided, verify it matches the user's org
 		if targetOrgID != "" {
 			if targetOrgID != effectiveOrgID {
-				log.Printf("Non-SUPER_ADMIN attempted to get user from different org (header: %s, token: %s)", targetOrgID, effectiveOrgID)
+				slog.WarnContext(ctx, "non-SUPER_ADMIN attempted to get user from a different organization", "organization_id", targetOrgID, "token_organization_id", effectiveOrgID)
 				return nil, ErrForbidden
 			}
-			log.Printf("Non-SUPER_ADMIN accessing own org via header: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "non-SUPER_ADMIN accessing own organization via header", "organization_id", effectiveOrgID)
 		} else {
-			log.Printf("Getting user from own org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "getting user from own organization", "organization_id", effectiveOrgID)
 		}
 	}
 
 	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
-	log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+	slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 
 	user, err := s.repo.GetByID(orgSchemaName, userID)
 	if err != nil {
 		return nil, err
 	}
+	user.OrgID = effectiveOrgID
+	user.OrgSchemaName = orgSchemaName
 
 	return user, nil
 }
 
-func (s *Service) ListUsers(principal *auth.Principal, targetOrgID string) ([]User, error) {
+func (s *Service) ListUsers(ctx context.Context, principal *auth.Principal, targetOrgID string) (_ []User, err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "list", orgSchemaName, err) }()
+
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID != "" {
 			effectiveOrgID = targetOrgID
-			log.Printf("SUPER_ADMIN listing users from org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN listing users from organization", "organization_id", effectiveOrgID)
 		} else {
 			effectiveOrgID = principal.OrgID
 			if effectiveOrgID == "" {
-				log.Printf("SUPER_ADMIN token has no orgId and no X-Organization-ID header provided")
+				slog.WarnContext(ctx, "SUPER_ADMIN token has no organization ID and no X-Organization-ID header provided")
 				return nil, ErrInvalidOrgSchema
 			}
-			log.Printf("SUPER_ADMIN listing users from own org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN listing users from own organization", "organization_id", effectiveOrgID)
 		}
 	} else {
 		if targetOrgID != "" {
-			log.Printf("ORG_ADMIN attempted to list users from different org")
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to list users from a different organization", "organization_id", targetOrgID)
 			return nil, ErrForbidden
 		}
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return nil, ErrInvalidOrgSchema
 		}
-		log.Printf("ORG_ADMIN listing users from own org: %s", effectiveOrgID)
+		slog.DebugContext(ctx, "ORG_ADMIN listing users from own organization", "organization_id", effectiveOrgID)
 	}
 
-	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
+	orgSchemaName, err = s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
-	log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+	slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 
 	users, err := s.repo.List(orgSchemaName)
 	if err != nil {
@@ -253,52 +276,61 @@ func (s *Service) ListUsers(principal *auth.Principal, targetOrgID string) ([]Us
 }
 
 // ListUsersWithPagination retrieves users with pagination
-func (s *Service) ListUsersWithPagination(principal *auth.Principal, targetOrgID string, params pagination.Params) (*PaginatedUserListResponse, error) {
+func (s *Service) ListUsersWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, params pagination.Params) (_ *PaginatedUserListResponse, err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "list", orgSchemaName, err) }()
+
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID != "" {
 			effectiveOrgID = targetOrgID
-			log.Printf("SUPER_ADMIN listing users from org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN listing users from organization", "organization_id", effectiveOrgID)
 		} else {
 			effectiveOrgID = principal.OrgID
 			if effectiveOrgID == "" {
-				log.Printf("SUPER_ADMIN token has no orgId and no X-Organization-ID header provided")
+				slog.WarnContext(ctx, "SUPER_ADMIN token has no organization ID and no X-Organization-ID header provided")
 				return nil, ErrInvalidOrgSchema
 			}
-			log.Printf("SUPER_ADMIN listing users from own org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN listing users from own organization", "organization_id", effectiveOrgID)
 		}
 	} else {
 		if targetOrgID != "" {
-			log.Printf("ORG_ADMIN attempted to list users from different org")
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to list users from a different organization", "organization_id", targetOrgID)
 			return nil, ErrForbidden
 		}
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return nil, ErrInvalidOrgSchema
 		}
-		log.Printf("ORG_ADMIN listing users from own org: %s", effectiveOrgID)
+		slog.DebugContext(ctx, "ORG_ADMIN listing users from own organization", "organization_id", effectiveOrgID)
 	}
 
-	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
+	orgSchemaName, err = s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
-	log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+	slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 
-	// Validate pagination parameters
-	params.Validate()
+	// Validate pagination, sort and filter parameters and resolve the cursor
+	window, err := params.Window()
+	if err != nil {
+		return nil, err
+	}
+	if err := userListFields.Check(params); err != nil {
+		return nil, err
+	}
 
 	// Get paginated data from repository
-	users, totalCount, err := s.repo.ListWithPagination(orgSchemaName, params.Limit, params.CalculateOffset(), params.Search)
+	users, totalCount, err := s.repo.ListWithPagination(orgSchemaName, window, params.Query())
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate pagination metadata
-	meta := params.CalculateMeta(totalCount)
+	// Trim to the page and calculate pagination metadata
+	users, meta := pagination.NewPage(&params, window, users, totalCount, userCursor)
 
 	response := &PaginatedUserListResponse{
 		Users:      users,
@@ -309,52 +341,61 @@ func (s *Service) ListUsersWithPagination(principal *auth.Principal, targetOrgID
 }
 
 // ListActiveUsersByRoleWithPagination retrieves active users (not soft deleted) by role with pagination
-func (s *Service) ListActiveUsersByRoleWithPagination(principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*PaginatedUserListResponse, error) {
+func (s *Service) ListActiveUsersByRoleWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (_ *PaginatedUserListResponse, err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "list", orgSchemaName, err) }()
+
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID != "" {
 			effectiveOrgID = targetOrgID
-			log.Printf("SUPER_ADMIN listing active %s users from org: %s", role, effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN listing active users from organization", "role", role, "organization_id", effectiveOrgID)
 		} else {
 			effectiveOrgID = principal.OrgID
 			if effectiveOrgID == "" {
-				log.Printf("SUPER_ADMIN token has no orgId and no X-Organization-ID header provided")
+				slog.WarnContext(ctx, "SUPER_ADMIN token has no organization ID and no X-Organization-ID header provided")
 				return nil, ErrInvalidOrgSchema
 			}
-			log.Printf("SUPER_ADMIN listing active %s users from own org: %s", role, effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN listing active users from own organization", "role", role, "organization_id", effectiveOrgID)
 		}
 	} else {
 		if targetOrgID != "" {
-			log.Printf("ORG_ADMIN attempted to list active %s users from different org", role)
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to list active users from a different organization", "role", role, "organization_id", targetOrgID)
 			return nil, ErrForbidden
 		}
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return nil, ErrInvalidOrgSchema
 		}
-		log.Printf("ORG_ADMIN listing active %s users from own org: %s", role, effectiveOrgID)
+		slog.DebugContext(ctx, "ORG_ADMIN listing active users from own organization", "role", role, "organization_id", effectiveOrgID)
 	}
 
-	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
+	orgSchemaName, err = s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
-	log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+	slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 
-	// Validate pagination parameters
-	params.Validate()
+	// Validate pagination, sort and filter parameters and resolve the cursor
+	window, err := params.Window()
+	if err != nil {
+		return nil, err
+	}
+	if err := userListFields.Check(params); err != nil {
+		return nil, err
+	}
 
 	// Get paginated data from repository
-	users, totalCount, err := s.repo.ListActiveUsersByRoleWithPagination(orgSchemaName, role, params.Limit, params.CalculateOffset(), params.Search)
+	users, totalCount, err := s.repo.ListActiveUsersByRoleWithPagination(orgSchemaName, role, window, params.Query())
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate pagination metadata
-	meta := params.CalculateMeta(totalCount)
+	// Trim to the page and calculate pagination metadata
+	users, meta := pagination.NewPage(&params, window, users, totalCount, userCursor)
 
 	response := &PaginatedUserListResponse{
 		Users:      users,
@@ -364,50 +405,60 @@ func (s *Service) ListActiveUsersByRoleWithPagination(principal *auth.Principal,
 	return response, nil
 }
 
-func (s *Service) UpdateUser(userID string, req UpdateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error) {
+func (s *Service) UpdateUser(ctx context.Context, userID string, req UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (_ *User, err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "update", orgSchemaName, err) }()
+
 	if s.keycloakAdmin == nil {
-		log.Printf("Keycloak admin client is not initialized")
+		slog.ErrorContext(ctx, "Keycloak admin client is not initialized")
 		return nil, fmt.Errorf("keycloak admin client is not available")
 	}
 
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID != "" {
 			effectiveOrgID = targetOrgID
-			log.Printf("SUPER_ADMIN updating user in org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN updating user in organization", "organization_id", effectiveOrgID)
 		} else {
 			effectiveOrgID = principal.OrgID
 			if effectiveOrgID == "" {
-				log.Printf("SUPER_ADMIN token has no orgId and no X-Organization-ID header provided")
+				slog.WarnContext(ctx, "SUPER_ADMIN token has no organization ID and no X-Organization-ID header provided")
 				return nil, ErrInvalidOrgSchema
 			}
-			log.Printf("SUPER_ADMIN updating user in own org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN updating user in own organization", "organization_id", effectiveOrgID)
 		}
 	} else {
 		if targetOrgID != "" {
-			log.Printf("ORG_ADMIN attempted to update user in different org")
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to update user in a different organization", "organization_id", targetOrgID)
 			return nil, ErrForbidden
 		}
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return nil, ErrInvalidOrgSchema
 		}
-		log.Printf("ORG_ADMIN updating user in own org: %s", effectiveOrgID)
+		slog.DebugContext(ctx, "ORG_ADMIN updating user in own organization", "organization_id", effectiveOrgID)
 	}
 
-	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
+	orgSchemaName, err = s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
-	log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+	slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 
 	user, err := s.repo.GetByID(orgSchemaName, userID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(user, version); err != nil {
+		return nil, err
+	}
 
 	keycloakUpdateNeeded := false
 
@@ -428,9 +479,9 @@ func (s *Service) UpdateUser(userID string, req UpdateUserRequest, principal *au
 	}
 
 	if keycloakUpdateNeeded {
-		keycloakUserData, err := s.keycloakAdmin.GetUser(user.KeycloakUserID)
+		keycloakUserData, err := s.keycloakAdmin.GetUser(ctx, user.KeycloakUserID)
 		if err != nil {
-			log.Printf("Failed to get user from Keycloak: %v", err)
+			slog.ErrorContext(ctx, "failed to get user from Keycloak", "error", err)
 			return nil, fmt.Errorf("failed to get user from Keycloak: %w", err)
 		}
 
@@ -442,12 +493,12 @@ func (s *Service) UpdateUser(userID string, req UpdateUserRequest, principal *au
 			Enabled:   true,
 		}
 
-		err = s.keycloakAdmin.UpdateUser(user.KeycloakUserID, keycloakUser)
+		err = s.keycloakAdmin.UpdateUser(ctx, user.KeycloakUserID, keycloakUser)
 		if err != nil {
-			log.Printf("Failed to update user in Keycloak: %v", err)
+			slog.ErrorContext(ctx, "failed to update user in Keycloak", "error", err)
 			return nil, fmt.Errorf("failed to update user in Keycloak: %w", err)
 		}
-		log.Printf("Updated user in Keycloak: %s", user.KeycloakUserID)
+		slog.InfoContext(ctx, "updated user in Keycloak", "keycloak_id", user.KeycloakUserID)
 	}
 
 	err = s.repo.Update(user)
@@ -459,49 +510,59 @@ func (s *Service) UpdateUser(userID string, req UpdateUserRequest, principal *au
 }
 
 // GetMyProfile retrieves the current user's profile
-func (s *Service) GetMyProfile(principal *auth.Principal) (*User, error) {
+func (s *Service) GetMyProfile(ctx context.Context, principal *auth.Principal) (*User, error) {
 	if principal.OrgID == "" {
-		log.Printf("User token missing organizationID claim - cannot get profile")
+		slog.WarnContext(ctx, "user token missing organizationID claim, cannot get profile")
 		return nil, fmt.Errorf("user token must contain organizationID claim")
 	}
 
 	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(principal.OrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", principal.OrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", principal.OrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
 
 	user, err := s.repo.GetByKeycloakID(orgSchemaName, principal.UserID)
 	if err != nil {
-		log.Printf("Failed to get user by Keycloak ID: %v", err)
+		slog.ErrorContext(ctx, "failed to get user by Keycloak ID", "error", err)
 		return nil, ErrUserNotFound
 	}
 
 	return user, nil
 }
 
-func (s *Service) UpdateMyProfile(req UpdateUserRequest, principal *auth.Principal) (*User, error) {
+func (s *Service) UpdateMyProfile(ctx context.Context, req UpdateUserRequest, version int, principal *auth.Principal) (_ *User, err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "update", orgSchemaName, err) }()
+
 	if s.keycloakAdmin == nil {
-		log.Printf("Keycloak admin client is not initialized")
+		slog.ErrorContext(ctx, "Keycloak admin client is not initialized")
 		return nil, fmt.Errorf("keycloak admin client is not available")
 	}
 
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
 	if principal.OrgID == "" {
-		log.Printf("User token missing organizationID claim - cannot update profile")
+		slog.WarnContext(ctx, "user token missing organizationID claim, cannot update profile")
 		return nil, fmt.Errorf("user token must contain organizationID claim")
 	}
 
-	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(principal.OrgID)
+	orgSchemaName, err = s.repo.GetSchemaNameByOrgID(principal.OrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", principal.OrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", principal.OrgID, "error", err)
 		return nil, ErrInvalidOrgSchema
 	}
 
 	user, err := s.repo.GetByKeycloakID(orgSchemaName, principal.UserID)
 	if err != nil {
-		log.Printf("Failed to get user by Keycloak ID: %v", err)
+		slog.ErrorContext(ctx, "failed to get user by Keycloak ID", "error", err)
 		return nil, ErrUserNotFound
 	}
+	if err := checkVersion(user, version); err != nil {
+		return nil, err
+	}
 
 	keycloakUpdateNeeded := false
 
@@ -522,9 +583,9 @@ func (s *Service) UpdateMyProfile(req UpdateUserRequest, principal *auth.Princip
 	}
 
 	if keycloakUpdateNeeded {
-		keycloakUserData, err := s.keycloakAdmin.GetUser(user.KeycloakUserID)
+		keycloakUserData, err := s.keycloakAdmin.GetUser(ctx, user.KeycloakUserID)
 		if err != nil {
-			log.Printf("Failed to get user from Keycloak: %v", err)
+			slog.ErrorContext(ctx, "failed to get user from Keycloak", "error", err)
 			return nil, fmt.Errorf("failed to get user from Keycloak: %w", err)
 		}
 
@@ -536,12 +597,12 @@ func (s *Service) UpdateMyProfile(req UpdateUserRequest, principal *auth.Princip
 			Enabled:   true,
 		}
 
-		err = s.keycloakAdmin.UpdateUser(user.KeycloakUserID, keycloakUser)
+		err = s.keycloakAdmin.UpdateUser(ctx, user.KeycloakUserID, keycloakUser)
 		if err != nil {
-			log.Printf("Failed to update user in Keycloak: %v", err)
+			slog.ErrorContext(ctx, "failed to update user in Keycloak", "error", err)
 			return nil, fmt.Errorf("failed to update user in Keycloak: %w", err)
 		}
-		log.Printf("User updated their own profile in Keycloak: %s", user.KeycloakUserID)
+		slog.InfoContext(ctx, "user updated their own profile in Keycloak", "keycloak_id", user.KeycloakUserID)
 	}
 
 	err = s.repo.Update(user)
@@ -549,50 +610,54 @@ func (s *Service) UpdateMyProfile(req UpdateUserRequest, principal *auth.Princip
 		return nil, err
 	}
 
-	log.Printf("User updated their own profile: %s (Keycloak ID: %s)", user.Email, user.KeycloakUserID)
+	slog.InfoContext(ctx, "user updated their own profile", "email", user.Email, "keycloak_id", user.KeycloakUserID)
 
 	return user, nil
 }
 
-func (s *Service) ResetPassword(userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error {
+func (s *Service) ResetPassword(ctx context.Context, userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error {
 	if s.keycloakAdmin == nil {
-		log.Printf("Keycloak admin client is not initialized")
+		slog.ErrorContext(ctx, "Keycloak admin client is not initialized")
 		return fmt.Errorf("keycloak admin client is not available")
 	}
 
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
 	var effectiveOrgID string
 
-	if s.hasRole(principal, "SUPER_ADMIN") {
+	if principal.HasRole(auth.RoleSuperAdmin) {
 		if targetOrgID != "" {
 			effectiveOrgID = targetOrgID
-			log.Printf("SUPER_ADMIN resetting password for user in org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN resetting password for user in organization", "organization_id", effectiveOrgID)
 		} else {
 			effectiveOrgID = principal.OrgID
 			if effectiveOrgID == "" {
-				log.Printf("SUPER_ADMIN token has no orgId and no X-Organization-ID header provided")
+				slog.WarnContext(ctx, "SUPER_ADMIN token has no organization ID and no X-Organization-ID header provided")
 				return ErrInvalidOrgSchema
 			}
-			log.Printf("SUPER_ADMIN resetting password for user in own org: %s", effectiveOrgID)
+			slog.DebugContext(ctx, "SUPER_ADMIN resetting password for user in own organization", "organization_id", effectiveOrgID)
 		}
 	} else {
 		if targetOrgID != "" {
-			log.Printf("ORG_ADMIN attempted to reset password in different org")
+			slog.WarnContext(ctx, "ORG_ADMIN attempted to reset password in a different organization", "organization_id", targetOrgID)
 			return ErrForbidden
 		}
 		effectiveOrgID = principal.OrgID
 		if effectiveOrgID == "" {
-			log.Printf("No organization ID in token")
+			slog.WarnContext(ctx, "no organization ID in token")
 			return ErrInvalidOrgSchema
 		}
-		log.Printf("ORG_ADMIN resetting password for user in own org: %s", effectiveOrgID)
+		slog.DebugContext(ctx, "ORG_ADMIN resetting password for user in own organization", "organization_id", effectiveOrgID)
 	}
 
 	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
 	if err != nil {
-		log.Printf("Failed to get schema name for orgId %s: %v", effectiveOrgID, err)
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
 		return ErrInvalidOrgSchema
 	}
-	log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, effectiveOrgID)
+	slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", effectiveOrgID)
 
 	user, err := s.repo.GetByID(orgSchemaName, userID)
 	if err != nil {
@@ -600,42 +665,45 @@ func (s *Service) ResetPassword(userID string, req ResetPasswordRequest, princip
 	}
 
 	if req.TemporaryPassword != "" {
-		err = s.keycloakAdmin.SetPassword(user.KeycloakUserID, req.TemporaryPassword, true)
+		err = s.keycloakAdmin.SetPassword(ctx, user.KeycloakUserID, req.TemporaryPassword, true)
 		if err != nil {
 			return fmt.Errorf("failed to reset password: %w", err)
 		}
 	} else if req.SendEmail {
-		err = s.keycloakAdmin.SendEmailAction(user.KeycloakUserID, []string{"UPDATE_PASSWORD"})
+		err = s.keycloakAdmin.SendEmailAction(ctx, user.KeycloakUserID, []string{"UPDATE_PASSWORD"})
 		if err != nil {
 			return fmt.Errorf("failed to send reset email: %w", err)
 		}
 	}
 
-	log.Printf("Reset password for user: %s (Keycloak ID: %s)", user.Email, user.KeycloakUserID)
+	slog.InfoContext(ctx, "reset password for user", "email", user.Email, "keycloak_id", user.KeycloakUserID)
 
 	return nil
 }
 
-func (s *Service) DeleteUser(userID string, principal *auth.Principal) error {
+func (s *Service) DeleteUser(ctx context.Context, userID string, version int, principal *auth.Principal) (err error) {
+	var orgSchemaName string
+	defer func() { s.recordOperation(ctx, "delete", orgSchemaName, err) }()
+
 	if s.keycloakAdmin == nil {
-		log.Printf("Keycloak admin client is not initialized")
+		slog.ErrorContext(ctx, "Keycloak admin client is not initialized")
 		return fmt.Errorf("keycloak admin client is not available")
 	}
 
-	orgSchemaName := principal.OrgSchemaName
+	orgSchemaName = principal.OrgSchemaName
 	if orgSchemaName == "" {
 		if principal.OrgID == "" {
-			log.Printf("Principal has no orgId or orgSchemaName")
+			slog.WarnContext(ctx, "principal has no organization ID or schema name")
 			return ErrInvalidOrgSchema
 		}
 
 		var err error
 		orgSchemaName, err = s.repo.GetSchemaNameByOrgID(principal.OrgID)
 		if err != nil {
-			log.Printf("Failed to get schema name for orgId %s: %v", principal.OrgID, err)
+			slog.ErrorContext(ctx, "failed to get schema name", "organization_id", principal.OrgID, "error", err)
 			return ErrInvalidOrgSchema
 		}
-		log.Printf("Looked up schema name '%s' for orgId '%s'", orgSchemaName, principal.OrgID)
+		slog.DebugContext(ctx, "looked up schema name", "schema", orgSchemaName, "organization_id", principal.OrgID)
 	}
 
 	user, err := s.repo.GetByID(orgSchemaName, userID)
@@ -646,29 +714,169 @@ func (s *Service) DeleteUser(userID string, principal *auth.Principal) error {
 	if principal.OrgID != "" && user.OrgID != principal.OrgID {
 		return ErrForbidden
 	}
+	if err := checkVersion(user, version); err != nil {
+		return err
+	}
 
-	err = s.keycloakAdmin.DeleteUser(user.KeycloakUserID)
+	err = s.keycloakAdmin.DeleteUser(ctx, user.KeycloakUserID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user from Keycloak: %w", err)
 	}
 
-	err = s.repo.Delete(orgSchemaName, user.OrgID, userID, user.Role)
+	err = s.repo.Delete(orgSchemaName, user.OrgID, userID, user.Role, user.Version)
 	if err != nil {
-		log.Printf("WARNING: User deleted from Keycloak but failed to delete from database: %s", userID)
+		slog.ErrorContext(ctx, "user deleted from Keycloak but failed to delete from database", "user_id", userID, "error", err)
 		return fmt.Errorf("failed to delete user from database: %w", err)
 	}
 
-	log.Printf("Successfully deleted user: %s (Keycloak ID: %s, Role: %s)", user.Email, user.KeycloakUserID, user.Role)
+	slog.InfoContext(ctx, "deleted user", "email", user.Email, "keycloak_id", user.KeycloakUserID, "role", user.Role)
 
 	return nil
 }
 
-func (s *Service) hasRole(principal *auth.Principal, role string) bool {
-	roleUpper := strings.ToUpper(role)
-	for _, r := range principal.Roles {
-		if r == role || strings.ToUpper(r) == roleUpper {
-			return true
+// checkVersion fails when the If-Match version of a request is not the
+// current version of user. It runs before Keycloak is changed, so a stale
+// request leaves both stores untouched; the repository checks the version
+// again when writing.
+func checkVersion(user *User, version int) error {
+	if version != etag.AnyVersion && user.Version != version {
+		return etag.ErrPreconditionFailed
+	}
+	return nil
+}
+
+// ResolveOrganization returns the organization and tenant schema a request acts on.
+// SUPER_ADMIN may target any organization through X-Organization-ID, other roles
+// are limited to the organization in their token.
+func (s *Service) ResolveOrganization(ctx context.Context, principal *auth.Principal, targetOrgID string) (string, string, error) {
+	effectiveOrgID, err := principal.TargetOrganization(targetOrgID)
+	if errors.Is(err, auth.ErrOtherOrganization) {
+		slog.WarnContext(ctx, "non-SUPER_ADMIN attempted to access a different organization", "organization_id", targetOrgID, "token_organization_id", principal.OrgID)
+		return "", "", ErrForbidden
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "no organization ID in token and no X-Organization-ID header provided")
+		return "", "", ErrInvalidOrgSchema
+	}
+
+	orgSchemaName, err := s.repo.GetSchemaNameByOrgID(effectiveOrgID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get schema name", "organization_id", effectiveOrgID, "error", err)
+		return "", "", ErrInvalidOrgSchema
+	}
+
+	return effectiveOrgID, orgSchemaName, nil
+}
+
+// OrganizationOfSchema returns the organization that owns a tenant schema,
+// which policies compare with the caller's organization
+func (s *Service) OrganizationOfSchema(ctx context.Context, orgSchemaName string) (string, error) {
+	orgID, err := s.repo.GetOrgIDBySchemaName(orgSchemaName)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidOrgSchema) {
+			slog.ErrorContext(ctx, "failed to get organization of schema", "schema", orgSchemaName, "error", err)
+		}
+		return "", err
+	}
+	return orgID, nil
+}
+
+// FindExistingUser looks for a staff member of the organization with the given
+// email or Keycloak username. Returns ErrUserNotFound when neither is in use and
+// ErrUsernameTaken when the username belongs to an account outside the users table.
+func (s *Service) FindExistingUser(ctx context.Context, orgSchemaName, username, email string) (*User, error) {
+	if email != "" {
+		user, err := s.repo.GetByEmail(orgSchemaName, email)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	if username == "" || s.keycloakAdmin == nil {
+		return nil, ErrUserNotFound
+	}
+
+	keycloakUser, err := s.keycloakAdmin.FindUserByUsername(ctx, username)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up username in Keycloak: %w", err)
+	}
+
+	user, err := s.repo.GetByKeycloakID(orgSchemaName, keycloakUser.ID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrUsernameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListStaff returns every user of the organization that has not been deleted,
+// with their Keycloak usernames
+func (s *Service) ListStaff(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]User, error) {
+	effectiveOrgID, orgSchemaName, err := s.ResolveOrganization(ctx, principal, targetOrgID)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "listing staff for export", "organization_id", effectiveOrgID)
+
+	staff, err := s.repo.ListStaff(orgSchemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Usernames live in Keycloak only; the import needs them to create users
+	if s.keycloakAdmin != nil {
+		s.fillUsernames(ctx, orgSchemaName, staff)
+	}
+	return staff, nil
+}
+
+// fillUsernames sets the Keycloak usernames of staff. Users are listed by
+// their orgSchemaName attribute a page at a time; only users without the
+// attribute are looked up one by one. Usernames that cannot be loaded stay
+// empty.
+func (s *Service) fillUsernames(ctx context.Context, orgSchemaName string, staff []User) {
+	keycloakUsers, err := s.keycloakAdmin.ListUsersByAttribute(ctx, "orgSchemaName", orgSchemaName)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to list usernames from Keycloak", "schema", orgSchemaName, "error", err)
+		return
+	}
+	usernames := make(map[string]string, len(keycloakUsers))
+	for _, u := range keycloakUsers {
+		usernames[u.ID] = u.Username
+	}
+
+	for i := range staff {
+		if username, ok := usernames[staff[i].KeycloakUserID]; ok {
+			staff[i].Username = username
+			continue
 		}
+		keycloakUser, err := s.keycloakAdmin.GetUser(ctx, staff[i].KeycloakUserID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to get username from Keycloak", "user_id", staff[i].ID, "error", err)
+			continue
+		}
+		staff[i].Username = keycloakUser.Username
+	}
+}
+
+// recordOperation counts a user operation and its outcome for the tenant schema,
+// which is empty when the request failed before the organization was resolved
+func (s *Service) recordOperation(ctx context.Context, operation, orgSchemaName string, err error) {
+	if s.metrics != nil {
+		s.metrics.RecordUserOperation(ctx, operation, orgSchemaName, telemetry.OutcomeOf(err))
 	}
-	return false
+}
+
+// userCursor returns the list position of a user
+func userCursor(u User) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
 }
@@ -1,20 +1,26 @@
 package users
 
 import (
+	"context"
+
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 )
 
 // ServiceInterface defines the contract for user business logic operations
 type ServiceInterface interface {
-	CreateUser(req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error)
-	GetUser(userID string, principal *auth.Principal, targetOrgID string) (*User, error)
-	ListUsers(principal *auth.Principal, targetOrgID string) ([]User, error)
-	ListUsersWithPagination(principal *auth.Principal, targetOrgID string, params pagination.Params) (*PaginatedUserListResponse, error)
-	ListActiveUsersByRoleWithPagination(principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*PaginatedUserListResponse, error)
-	UpdateUser(userID string, req UpdateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error)
-	GetMyProfile(principal *auth.Principal) (*User, error)
-	UpdateMyProfile(req UpdateUserRequest, principal *auth.Principal) (*User, error)
-	ResetPassword(userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error
-	DeleteUser(userID string, principal *auth.Principal) error
+	CreateUser(ctx context.Context, req CreateUserRequest, principal *auth.Principal, targetOrgID string) (*User, error)
+	GetUser(ctx context.Context, userID string, principal *auth.Principal, targetOrgID string) (*User, error)
+	ListUsers(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]User, error)
+	ListUsersWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, params pagination.Params) (*PaginatedUserListResponse, error)
+	ListActiveUsersByRoleWithPagination(ctx context.Context, principal *auth.Principal, targetOrgID string, role string, params pagination.Params) (*PaginatedUserListResponse, error)
+	UpdateUser(ctx context.Context, userID string, req UpdateUserRequest, version int, principal *auth.Principal, targetOrgID string) (*User, error)
+	GetMyProfile(ctx context.Context, principal *auth.Principal) (*User, error)
+	UpdateMyProfile(ctx context.Context, req UpdateUserRequest, version int, principal *auth.Principal) (*User, error)
+	ResetPassword(ctx context.Context, userID string, req ResetPasswordRequest, principal *auth.Principal, targetOrgID string) error
+	DeleteUser(ctx context.Context, userID string, version int, principal *auth.Principal) error
+	ResolveOrganization(ctx context.Context, principal *auth.Principal, targetOrgID string) (string, string, error)
+	OrganizationOfSchema(ctx context.Context, orgSchemaName string) (string, error)
+	FindExistingUser(ctx context.Context, orgSchemaName, username, email string) (*User, error)
+	ListStaff(ctx context.Context, principal *auth.Principal, targetOrgID string) ([]User, error)
 }
@@ -1,10 +1,12 @@
 package users
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/WailSalutem-Health-Care/organization-service/internal/auth"
+	"github.com/WailSalutem-Health-Care/organization-service/internal/etag"
 	"github.com/WailSalutem-Health-Care/organization-service/internal/pagination"
 )
 
@@ -55,7 +57,7 @@ func TestCreateUser_Success(t *testing.T) {
 		OrgID:  "",
 	}
 
-	user, err := service.CreateUser(req, principal, "org-target-123")
+	user, err := service.CreateUser(context.Background(), req, principal, "org-target-123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -116,7 +118,7 @@ func TestCreateUser_OrgAdminSuccess(t *testing.T) {
 		OrgSchemaName: "org_myorg_87654321",
 	}
 
-	user, err := service.CreateUser(req, principal, "")
+	user, err := service.CreateUser(context.Background(), req, principal, "")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -152,7 +154,7 @@ func TestCreateUser_OrgAdminForbiddenRole(t *testing.T) {
 		OrgSchemaName: "org_test_11111111",
 	}
 
-	user, err := service.CreateUser(req, principal, "")
+	user, err := service.CreateUser(context.Background(), req, principal, "")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -189,7 +191,7 @@ func TestCreateUser_OrgAdminCrossOrgForbidden(t *testing.T) {
 	}
 
 	// Trying to create in different org
-	user, err := service.CreateUser(req, principal, "org-5")
+	user, err := service.CreateUser(context.Background(), req, principal, "org-5")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -225,7 +227,7 @@ func TestCreateUser_SuperAdminMissingOrgID(t *testing.T) {
 	}
 
 	// SUPER_ADMIN without target org ID
-	user, err := service.CreateUser(req, principal, "")
+	user, err := service.CreateUser(context.Background(), req, principal, "")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -295,7 +297,7 @@ func TestCreateUser_ValidationError(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			user, err := service.CreateUser(tc.req, principal, "org-123")
+			user, err := service.CreateUser(context.Background(), tc.req, principal, "org-123")
 
 			if err == nil {
 				t.Error("Expected validation error, got nil")
@@ -352,7 +354,7 @@ func TestCreateUser_PatientRoleRejected(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	user, err := service.CreateUser(req, principal, "org-123")
+	user, err := service.CreateUser(context.Background(), req, principal, "org-123")
 
 	if err == nil {
 		t.Error("Expected error for PATIENT role, got nil")
@@ -413,7 +415,7 @@ func TestCreateUser_KeycloakRollback(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	user, err := service.CreateUser(req, principal, "org-123")
+	user, err := service.CreateUser(context.Background(), req, principal, "org-123")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -475,7 +477,7 @@ func TestCreateUser_SendResetEmail(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	user, err := service.CreateUser(req, principal, "org-123")
+	user, err := service.CreateUser(context.Background(), req, principal, "org-123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -516,7 +518,7 @@ func TestGetUser_Success(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	user, err := service.GetUser("user-123", principal, "org-123")
+	user, err := service.GetUser(context.Background(), "user-123", principal, "org-123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -543,7 +545,7 @@ func TestGetUser_OrgAdminCrossOrgForbidden(t *testing.T) {
 	}
 
 	// Trying to get user from different org
-	user, err := service.GetUser("user-123", principal, "org-2")
+	user, err := service.GetUser(context.Background(), "user-123", principal, "org-2")
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -578,7 +580,7 @@ func TestListUsers_Success(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	users, err := service.ListUsers(principal, "org-123")
+	users, err := service.ListUsers(context.Background(), principal, "org-123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -594,9 +596,9 @@ func TestListUsersWithPagination_Success(t *testing.T) {
 		getSchemaNameFunc: func(orgID string) (string, error) {
 			return "org_test_12345678", nil
 		},
-		listWithPaginationFunc: func(schemaName string, limit, offset int, search string) ([]User, int, error) {
-			users := make([]User, limit)
-			for i := 0; i < limit; i++ {
+		listWithPaginationFunc: func(schemaName string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
+			users := make([]User, window.Limit)
+			for i := 0; i < window.Limit; i++ {
 				users[i] = User{
 					ID:    string(rune('0' + i)),
 					Email: "user@example.com",
@@ -619,7 +621,7 @@ func TestListUsersWithPagination_Success(t *testing.T) {
 		Limit: 10,
 	}
 
-	response, err := service.ListUsersWithPagination(principal, "org-123", params)
+	response, err := service.ListUsersWithPagination(context.Background(), principal, "org-123", params)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -638,7 +640,7 @@ func TestListActiveUsersByRoleWithPagination_Success(t *testing.T) {
 		getSchemaNameFunc: func(orgID string) (string, error) {
 			return "org_test_12345678", nil
 		},
-		listActiveByRoleFunc: func(schemaName string, role string, limit, offset int, search string) ([]User, int, error) {
+		listActiveByRoleFunc: func(schemaName string, role string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
 			return []User{
 				{ID: "user-1", Role: role, IsActive: true},
 				{ID: "user-2", Role: role, IsActive: true},
@@ -659,7 +661,7 @@ func TestListActiveUsersByRoleWithPagination_Success(t *testing.T) {
 		Limit: 10,
 	}
 
-	response, err := service.ListActiveUsersByRoleWithPagination(principal, "org-123", "CAREGIVER", params)
+	response, err := service.ListActiveUsersByRoleWithPagination(context.Background(), principal, "org-123", "CAREGIVER", params)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -672,6 +674,56 @@ func TestListActiveUsersByRoleWithPagination_Success(t *testing.T) {
 	}
 }
 
+// TestListUsersWithPagination_SortAndFilters tests sorting and filtering the user list
+func TestListUsersWithPagination_SortAndFilters(t *testing.T) {
+	var got pagination.Query
+	mockRepo := &mockRepository{
+		getSchemaNameFunc: func(orgID string) (string, error) {
+			return "org_test_12345678", nil
+		},
+		listWithPaginationFunc: func(schemaName string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
+			got = listQuery
+			return []User{}, 0, nil
+		},
+	}
+
+	service := NewService(mockRepo, &mockKeycloakAdmin{})
+	principal := &auth.Principal{UserID: "admin-13", Roles: []string{"SUPER_ADMIN"}}
+
+	params := pagination.Params{
+		Limit:   10,
+		Sort:    "role,last_name:desc",
+		Filters: map[string]string{"role": "CAREGIVER", "is_active": "true"},
+	}
+	if _, err := service.ListUsersWithPagination(context.Background(), principal, "org-123", params); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got.Filters["role"] != "CAREGIVER" || got.Sort != "role,last_name:desc" {
+		t.Errorf("Expected sort and filters to be passed on, got %+v", got)
+	}
+
+	where, args := userListFields.Where(got.Filters, 1)
+	if where != ` AND is_active = $1 AND lower(role) = lower($2)` {
+		t.Errorf("Unexpected conditions %q", where)
+	}
+	if len(args) != 2 || args[0] != true {
+		t.Errorf("Expected typed arguments, got %v", args)
+	}
+
+	params.Filters = map[string]string{"is_active": "maybe"}
+	_, err := service.ListUsersWithPagination(context.Background(), principal, "org-123", params)
+	if !errors.Is(err, pagination.ErrInvalidFilter) {
+		t.Errorf("Expected ErrInvalidFilter, got %v", err)
+	}
+
+	params.Filters = nil
+	params.Sort = "keycloak_user_id"
+	_, err = service.ListUsersWithPagination(context.Background(), principal, "org-123", params)
+	if !errors.Is(err, pagination.ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort, got %v", err)
+	}
+}
+
 // TestUpdateUser_Success tests successful user update
 func TestUpdateUser_Success(t *testing.T) {
 	mockRepo := &mockRepository{
@@ -718,7 +770,7 @@ func TestUpdateUser_Success(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	user, err := service.UpdateUser("user-123", req, principal, "org-123")
+	user, err := service.UpdateUser(context.Background(), "user-123", req, etag.AnyVersion, principal, "org-123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -774,7 +826,7 @@ func TestUpdateMyProfile_Success(t *testing.T) {
 		OrgID:  "org-123",
 	}
 
-	user, err := service.UpdateMyProfile(req, principal)
+	user, err := service.UpdateMyProfile(context.Background(), req, etag.AnyVersion, principal)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -815,7 +867,7 @@ func TestResetPassword_Success(t *testing.T) {
 		Roles:  []string{"SUPER_ADMIN"},
 	}
 
-	err := service.ResetPassword("user-123", req, principal, "org-123")
+	err := service.ResetPassword(context.Background(), "user-123", req, principal, "org-123")
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -837,7 +889,7 @@ func TestDeleteUser_Success(t *testing.T) {
 				OrgSchemaName:  schemaName,
 			}, nil
 		},
-		deleteFunc: func(schemaName, orgID, userID, role string) error {
+		deleteFunc: func(schemaName, orgID, userID, role string, version int) error {
 			return nil
 		},
 	}
@@ -857,26 +909,130 @@ func TestDeleteUser_Success(t *testing.T) {
 		OrgSchemaName: "org_test_12345678",
 	}
 
-	err := service.DeleteUser("user-123", principal)
+	err := service.DeleteUser(context.Background(), "user-123", etag.AnyVersion, principal)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
 }
 
+// TestDeleteUser_StaleVersion tests that a stale version stops the delete
+// before the Keycloak account is removed
+func TestDeleteUser_StaleVersion(t *testing.T) {
+	mockRepo := &mockRepository{
+		getSchemaNameFunc: func(orgID string) (string, error) {
+			return "org_test_12345678", nil
+		},
+		getByIDFunc: func(schemaName, userID string) (*User, error) {
+			return &User{
+				ID:             userID,
+				KeycloakUserID: "keycloak-123",
+				OrgID:          "org-123",
+				Role:           "CAREGIVER",
+				OrgSchemaName:  schemaName,
+				Version:        3,
+			}, nil
+		},
+	}
+
+	keycloakCalled := false
+	mockKeycloak := &mockKeycloakAdmin{
+		deleteUserFunc: func(userID string) error {
+			keycloakCalled = true
+			return nil
+		},
+	}
+
+	service := NewService(mockRepo, mockKeycloak)
+
+	principal := &auth.Principal{
+		UserID:        "admin-17",
+		Roles:         []string{"ORG_ADMIN"},
+		OrgID:         "org-123",
+		OrgSchemaName: "org_test_12345678",
+	}
+
+	err := service.DeleteUser(context.Background(), "user-123", 2, principal)
+
+	if !errors.Is(err, etag.ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed, got: %v", err)
+	}
+	if keycloakCalled {
+		t.Error("Expected Keycloak user to be kept")
+	}
+}
+
 // Mock implementations
 
+// TestUserOperationMetrics tests that operations are counted with tenant and outcome
+func TestUserOperationMetrics(t *testing.T) {
+	mockRepo := &mockRepository{
+		getSchemaNameFunc: func(orgID string) (string, error) {
+			return "org_test_12345678", nil
+		},
+		getByIDFunc: func(schemaName, userID string) (*User, error) {
+			return &User{ID: userID, KeycloakUserID: "keycloak-123", OrgID: "org-123", Role: "CAREGIVER"}, nil
+		},
+		deleteFunc: func(schemaName, orgID, userID, role string, version int) error {
+			return nil
+		},
+	}
+	mockKeycloak := &mockKeycloakAdmin{
+		deleteUserFunc: func(userID string) error {
+			return nil
+		},
+	}
+
+	metrics := &mockMetrics{}
+	service := NewServiceWithMetrics(mockRepo, mockKeycloak, metrics)
+
+	principal := &auth.Principal{
+		UserID:        "admin-17",
+		Roles:         []string{"ORG_ADMIN"},
+		OrgID:         "org-123",
+		OrgSchemaName: "org_test_12345678",
+	}
+
+	_ = service.DeleteUser(context.Background(), "user-123", etag.AnyVersion, principal)
+	_, _ = service.ListUsers(context.Background(), principal, "org-other")
+
+	want := []string{
+		"delete org_test_12345678 success",
+		"list  error",
+	}
+	if len(metrics.operations) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, metrics.operations)
+	}
+	for i, op := range metrics.operations {
+		if op != want[i] {
+			t.Errorf("Operation %d: expected %q, got %q", i, want[i], op)
+		}
+	}
+}
+
+// mockMetrics records operations as "operation tenant outcome"
+type mockMetrics struct {
+	operations []string
+}
+
+func (m *mockMetrics) RecordUserOperation(ctx context.Context, operation, tenant, outcome string) {
+	m.operations = append(m.operations, operation+" "+tenant+" "+outcome)
+}
+
 type mockRepository struct {
 	getSchemaNameFunc      func(orgID string) (string, error)
+	getOrgIDFunc           func(schemaName string) (string, error)
 	validateSchemaFunc     func(schemaName string) error
 	createFunc             func(user *User) error
 	getByIDFunc            func(schemaName, userID string) (*User, error)
 	getByKeycloakIDFunc    func(schemaName, keycloakID string) (*User, error)
+	getByEmailFunc         func(schemaName, email string) (*User, error)
 	listFunc               func(schemaName string) ([]User, error)
-	listWithPaginationFunc func(schemaName string, limit, offset int, search string) ([]User, int, error)
-	listActiveByRoleFunc   func(schemaName string, role string, limit, offset int, search string) ([]User, int, error)
+	listWithPaginationFunc func(schemaName string, window pagination.Window, listQuery pagination.Query) ([]User, int, error)
+	listActiveByRoleFunc   func(schemaName string, role string, window pagination.Window, listQuery pagination.Query) ([]User, int, error)
+	listStaffFunc          func(schemaName string) ([]User, error)
 	updateFunc             func(user *User) error
-	deleteFunc             func(schemaName, orgID, userID, role string) error
+	deleteFunc             func(schemaName, orgID, userID, role string, version int) error
 }
 
 func (m *mockRepository) GetSchemaNameByOrgID(orgID string) (string, error) {
@@ -886,6 +1042,13 @@ func (m *mockRepository) GetSchemaNameByOrgID(orgID string) (string, error) {
 	return "", errors.New("not implemented")
 }
 
+func (m *mockRepository) GetOrgIDBySchemaName(schemaName string) (string, error) {
+	if m.getOrgIDFunc != nil {
+		return m.getOrgIDFunc(schemaName)
+	}
+	return "", errors.New("not implemented")
+}
+
 func (m *mockRepository) ValidateOrgSchema(schemaName string) error {
 	if m.validateSchemaFunc != nil {
 		return m.validateSchemaFunc(schemaName)
@@ -914,6 +1077,13 @@ func (m *mockRepository) GetByKeycloakID(schemaName, keycloakUserID string) (*Us
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockRepository) GetByEmail(schemaName, email string) (*User, error) {
+	if m.getByEmailFunc != nil {
+		return m.getByEmailFunc(schemaName, email)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockRepository) List(schemaName string) ([]User, error) {
 	if m.listFunc != nil {
 		return m.listFunc(schemaName)
@@ -921,20 +1091,27 @@ func (m *mockRepository) List(schemaName string) ([]User, error) {
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockRepository) ListWithPagination(schemaName string, limit, offset int, search string) ([]User, int, error) {
+func (m *mockRepository) ListWithPagination(schemaName string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
 	if m.listWithPaginationFunc != nil {
-		return m.listWithPaginationFunc(schemaName, limit, offset, search)
+		return m.listWithPaginationFunc(schemaName, window, listQuery)
 	}
 	return nil, 0, errors.New("not implemented")
 }
 
-func (m *mockRepository) ListActiveUsersByRoleWithPagination(schemaName string, role string, limit, offset int, search string) ([]User, int, error) {
+func (m *mockRepository) ListActiveUsersByRoleWithPagination(schemaName string, role string, window pagination.Window, listQuery pagination.Query) ([]User, int, error) {
 	if m.listActiveByRoleFunc != nil {
-		return m.listActiveByRoleFunc(schemaName, role, limit, offset, search)
+		return m.listActiveByRoleFunc(schemaName, role, window, listQuery)
 	}
 	return nil, 0, errors.New("not implemented")
 }
 
+func (m *mockRepository) ListStaff(schemaName string) ([]User, error) {
+	if m.listStaffFunc != nil {
+		return m.listStaffFunc(schemaName)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockRepository) Update(user *User) error {
 	if m.updateFunc != nil {
 		return m.updateFunc(user)
@@ -942,9 +1119,9 @@ func (m *mockRepository) Update(user *User) error {
 	return errors.New("not implemented")
 }
 
-func (m *mockRepository) Delete(schemaName, orgID, userID string, role string) error {
+func (m *mockRepository) Delete(schemaName, orgID, userID string, role string, version int) error {
 	if m.deleteFunc != nil {
-		return m.deleteFunc(schemaName, orgID, userID, role)
+		return m.deleteFunc(schemaName, orgID, userID, role, version)
 	}
 	return errors.New("not implemented")
 }
@@ -958,60 +1135,76 @@ type mockKeycloakAdmin struct {
 	deleteUserFunc      func(userID string) error
 	updateUserFunc      func(userID string, user auth.KeycloakUser) error
 	getUserFunc         func(userID string) (*auth.KeycloakUser, error)
+	findUserFunc        func(username string) (*auth.KeycloakUser, error)
+	listByAttributeFunc func(name, value string) ([]auth.KeycloakUser, error)
 }
 
-func (m *mockKeycloakAdmin) CreateUser(user auth.KeycloakUser) (string, error) {
+func (m *mockKeycloakAdmin) CreateUser(ctx context.Context, user auth.KeycloakUser) (string, error) {
 	if m.createUserFunc != nil {
 		return m.createUserFunc(user)
 	}
 	return "", errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) SetPassword(userID, password string, temporary bool) error {
+func (m *mockKeycloakAdmin) SetPassword(ctx context.Context, userID, password string, temporary bool) error {
 	if m.setPasswordFunc != nil {
 		return m.setPasswordFunc(userID, password, temporary)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) SendEmailAction(userID string, actions []string) error {
+func (m *mockKeycloakAdmin) SendEmailAction(ctx context.Context, userID string, actions []string) error {
 	if m.sendEmailActionFunc != nil {
 		return m.sendEmailActionFunc(userID, actions)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) GetRole(roleName string) (*auth.KeycloakRole, error) {
+func (m *mockKeycloakAdmin) GetRole(ctx context.Context, roleName string) (*auth.KeycloakRole, error) {
 	if m.getRoleFunc != nil {
 		return m.getRoleFunc(roleName)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) AssignRole(userID string, role auth.KeycloakRole) error {
+func (m *mockKeycloakAdmin) AssignRole(ctx context.Context, userID string, role auth.KeycloakRole) error {
 	if m.assignRoleFunc != nil {
 		return m.assignRoleFunc(userID, role)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) DeleteUser(userID string) error {
+func (m *mockKeycloakAdmin) DeleteUser(ctx context.Context, userID string) error {
 	if m.deleteUserFunc != nil {
 		return m.deleteUserFunc(userID)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) UpdateUser(userID string, user auth.KeycloakUser) error {
+func (m *mockKeycloakAdmin) UpdateUser(ctx context.Context, userID string, user auth.KeycloakUser) error {
 	if m.updateUserFunc != nil {
 		return m.updateUserFunc(userID, user)
 	}
 	return errors.New("not implemented")
 }
 
-func (m *mockKeycloakAdmin) GetUser(userID string) (*auth.KeycloakUser, error) {
+func (m *mockKeycloakAdmin) GetUser(ctx context.Context, userID string) (*auth.KeycloakUser, error) {
 	if m.getUserFunc != nil {
 		return m.getUserFunc(userID)
 	}
 	return nil, errors.New("not implemented")
 }
+
+func (m *mockKeycloakAdmin) FindUserByUsername(ctx context.Context, username string) (*auth.KeycloakUser, error) {
+	if m.findUserFunc != nil {
+		return m.findUserFunc(username)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockKeycloakAdmin) ListUsersByAttribute(ctx context.Context, name, value string) ([]auth.KeycloakUser, error) {
+	if m.listByAttributeFunc != nil {
+		return m.listByAttributeFunc(name, value)
+	}
+	return nil, errors.New("not implemented")
+}
@@ -0,0 +1,183 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+	"github.com/go-playground/validator/v10"
+)
+
+// DateLayout is the format of all date-only request fields
+const DateLayout = "2006-01-02"
+
+// MaxAge bounds how far in the past a date of birth may lie
+const MaxAge = 130
+
+// e164 matches an international phone number once the visual separators
+// are removed
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// now is replaced in tests
+var now = time.Now
+
+var validate = newValidator()
+
+// newValidator returns a validator that reports fields by their JSON name and
+// knows the phone and birthdate tags
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return ValidPhone(fl.Field().String())
+	})
+	v.RegisterValidation("birthdate", func(fl validator.FieldLevel) bool {
+		return ValidBirthDate(fl.Field().String())
+	})
+	return v
+}
+
+// ValidPhone reports whether phone is an E.164 number. Spaces, dashes, dots
+// and parentheses are allowed for readability, e.g. "+31 6 1234 5678".
+func ValidPhone(phone string) bool {
+	stripped := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, phone)
+	return e164.MatchString(stripped)
+}
+
+// ValidBirthDate reports whether date is a YYYY-MM-DD date that is not in the
+// future and at most MaxAge years ago
+func ValidBirthDate(date string) bool {
+	birthDate, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return false
+	}
+	today := now().UTC().Truncate(24 * time.Hour)
+	return !birthDate.After(today) && !birthDate.Before(today.AddDate(-MaxAge, 0, 0))
+}
+
+// Fields checks v against its validate struct tags and returns every failing
+// field. Fields are named by their JSON name; messages use the label tag or,
+// without one, the JSON name split into words ("dateOfBirth" reads as
+// "date of birth").
+func Fields(v any) []apierror.FieldError {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []apierror.FieldError{{Message: err.Error()}}
+	}
+
+	structType := reflect.Indirect(reflect.ValueOf(v)).Type()
+	fieldErrors := make([]apierror.FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		label := humanize(fe.Field())
+		if field, ok := structType.FieldByName(fe.StructField()); ok {
+			if tag := field.Tag.Get("label"); tag != "" {
+				label = tag
+			}
+		}
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   fe.Field(),
+			Message: message(label, fe),
+		})
+	}
+	return fieldErrors
+}
+
+// Error turns field errors into a single validation error, or nil when there
+// are none. The detail lists all messages.
+func Error(fieldErrors []apierror.FieldError) error {
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	messages := make([]string, len(fieldErrors))
+	for i, fe := range fieldErrors {
+		messages[i] = fe.Message
+	}
+	return apierror.Validation(strings.Join(messages, "; "), fieldErrors...)
+}
+
+// Struct validates v and returns all field errors as one validation error
+func Struct(v any) error {
+	return Error(Fields(v))
+}
+
+// Messages returns the field messages of a validation error, or the error
+// text for any other error. Import endpoints use it to list every problem of
+// a row.
+func Messages(err error) []string {
+	var typed *apierror.Error
+	if !errors.As(err, &typed) || len(typed.Errors) == 0 {
+		return []string{err.Error()}
+	}
+	messages := make([]string, len(typed.Errors))
+	for i, fe := range typed.Errors {
+		messages[i] = fe.Message
+	}
+	return messages
+}
+
+// message renders the failed rule of a field as a sentence
+func message(label string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return label + " is required"
+	case "email":
+		return label + " is invalid"
+	case "phone":
+		return label + " must be an international phone number such as +31612345678"
+	case "datetime":
+		return label + " must use the YYYY-MM-DD format"
+	case "birthdate":
+		return fmt.Sprintf("%s must not be in the future or more than %d years ago", label, MaxAge)
+	case "min":
+		if fe.Param() == "1" {
+			return label + " cannot be empty"
+		}
+		return fmt.Sprintf("%s must be at least %s characters", label, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", label, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", label, strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+	return label + " is invalid"
+}
+
+// humanize splits a camelCase or snake_case JSON name into lower case words
+func humanize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_':
+			b.WriteRune(' ')
+		case unicode.IsUpper(r):
+			if i > 0 {
+				b.WriteRune(' ')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
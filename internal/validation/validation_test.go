@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WailSalutem-Health-Care/organization-service/internal/apierror"
+)
+
+type testRequest struct {
+	Name        string  `json:"name" validate:"required,max=5" label:"organization name"`
+	Email       string  `json:"email" validate:"omitempty,email"`
+	PhoneNumber string  `json:"phoneNumber" validate:"omitempty,phone"`
+	DateOfBirth *string `json:"date_of_birth,omitempty" validate:"omitnil,min=1,datetime=2006-01-02,birthdate"`
+	Frequency   string  `json:"frequency" validate:"omitempty,oneof=daily weekly"`
+}
+
+func TestFieldsReportsEveryError(t *testing.T) {
+	dateOfBirth := "15-05-1960"
+	req := testRequest{
+		Email:       "not-an-email",
+		PhoneNumber: "0612345678",
+		DateOfBirth: &dateOfBirth,
+		Frequency:   "yearly",
+	}
+
+	got := Fields(&req)
+	want := []apierror.FieldError{
+		{Field: "name", Message: "organization name is required"},
+		{Field: "email", Message: "email is invalid"},
+		{Field: "phoneNumber", Message: "phone number must be an international phone number such as +31612345678"},
+		{Field: "date_of_birth", Message: "date of birth must use the YYYY-MM-DD format"},
+		{Field: "frequency", Message: "frequency must be one of daily, weekly"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d field errors, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Field error %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestFieldsValidRequest(t *testing.T) {
+	dateOfBirth := "1960-05-15"
+	req := testRequest{
+		Name:        "Care",
+		Email:       "info@example.com",
+		PhoneNumber: "+31 6 1234 5678",
+		DateOfBirth: &dateOfBirth,
+		Frequency:   "daily",
+	}
+	if got := Fields(&req); len(got) != 0 {
+		t.Errorf("Expected no field errors, got %+v", got)
+	}
+
+	// Omitted optional fields are not checked
+	if got := Fields(&testRequest{Name: "Care"}); len(got) != 0 {
+		t.Errorf("Expected no field errors, got %+v", got)
+	}
+}
+
+func TestFieldsMaxLength(t *testing.T) {
+	got := Fields(&testRequest{Name: "Too long"})
+	if len(got) != 1 || got[0].Message != "organization name must be at most 5 characters" {
+		t.Errorf("Expected max length error, got %+v", got)
+	}
+}
+
+func TestStruct(t *testing.T) {
+	if err := Struct(&testRequest{Name: "Care"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	err := Struct(&testRequest{Email: "bad"})
+	var typed *apierror.Error
+	if !errors.As(err, &typed) {
+		t.Fatalf("Expected *apierror.Error, got %T", err)
+	}
+	if typed.Code != apierror.CodeValidation || len(typed.Errors) != 2 {
+		t.Errorf("Expected validation error with 2 fields, got %+v", typed)
+	}
+	if err.Error() != "organization name is required; email is invalid" {
+		t.Errorf("Unexpected detail %q", err.Error())
+	}
+	if got := Messages(err); strings.Join(got, "|") != "organization name is required|email is invalid" {
+		t.Errorf("Unexpected messages %v", got)
+	}
+	if got := Messages(errors.New("boom")); len(got) != 1 || got[0] != "boom" {
+		t.Errorf("Expected plain error text, got %v", got)
+	}
+}
+
+func TestValidBirthDate(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		date string
+		want bool
+	}{
+		{"1960-05-15", true},
+		{"2026-01-15", true},
+		{"2026-01-16", false},
+		{"1896-01-15", true},
+		{"1896-01-14", false},
+		{"1960-02-30", false},
+	}
+	for _, tt := range tests {
+		if got := ValidBirthDate(tt.date); got != tt.want {
+			t.Errorf("ValidBirthDate(%q) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestValidPhone(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  bool
+	}{
+		{"+31612345678", true},
+		{"+31 20 123 4567", true},
+		{"+1 (555) 123-4567", true},
+		{"0612345678", false},
+		{"+0612345678", false},
+		{"+31 6 1234 5678 9012 34", false},
+		{"+31abc", false},
+	}
+	for _, tt := range tests {
+		if got := ValidPhone(tt.phone); got != tt.want {
+			t.Errorf("ValidPhone(%q) = %v, want %v", tt.phone, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Search indexes for a tenant's patients. The indexed expressions must match
+-- the ones used by the patient search query in internal/patient/repository.go.
+CREATE OR REPLACE FUNCTION wailsalutem.create_patient_search_indexes(schema_name TEXT)
+RETURNS void AS $$
+BEGIN
+    EXECUTE format(
+        'CREATE INDEX IF NOT EXISTS idx_patients_full_name_trgm ON %I.patients
+            USING gin ((coalesce(first_name, '''') || '' '' || coalesce(last_name, '''')) gin_trgm_ops)',
+        schema_name
+    );
+
+    EXECUTE format(
+        'CREATE INDEX IF NOT EXISTS idx_patients_search_fts ON %I.patients
+            USING gin (to_tsvector(''simple'', coalesce(first_name, '''') || '' '' || coalesce(last_name, '''') || '' '' || coalesce(email, '''')))',
+        schema_name
+    );
+
+    EXECUTE format(
+        'CREATE INDEX IF NOT EXISTS idx_patients_phone_digits_trgm ON %I.patients
+            USING gin ((regexp_replace(coalesce(phone_number, ''''), ''\D'', '''', ''g'')) gin_trgm_ops)',
+        schema_name
+    );
+
+    EXECUTE format(
+        'CREATE INDEX IF NOT EXISTS idx_patients_date_of_birth ON %I.patients(date_of_birth)',
+        schema_name
+    );
+
+    EXECUTE format(
+        'CREATE INDEX IF NOT EXISTS idx_patients_patient_id_lower ON %I.patients(lower(patient_id))',
+        schema_name
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    s RECORD;
+BEGIN
+    FOR s IN
+        SELECT schema_name
+        FROM wailsalutem.organizations
+    LOOP
+        PERFORM wailsalutem.create_patient_search_indexes(s.schema_name);
+    END LOOP;
+END $$;
@@ -0,0 +1,31 @@
+-- Row versions for optimistic concurrency control. Every update increments
+-- version; the API exposes it as the ETag and checks If-Match against it.
+ALTER TABLE wailsalutem.organizations
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION wailsalutem.add_row_versions(schema_name TEXT)
+RETURNS void AS $$
+BEGIN
+    EXECUTE format(
+        'ALTER TABLE %I.users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1',
+        schema_name
+    );
+
+    EXECUTE format(
+        'ALTER TABLE %I.patients ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1',
+        schema_name
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    s RECORD;
+BEGIN
+    FOR s IN
+        SELECT schema_name
+        FROM wailsalutem.organizations
+    LOOP
+        PERFORM wailsalutem.add_row_versions(s.schema_name);
+    END LOOP;
+END $$;
@@ -0,0 +1,16 @@
+-- Idempotency keys of create requests. scope is the organization the request
+-- creates a resource in, or empty for platform level requests such as
+-- creating an organization.
+CREATE TABLE IF NOT EXISTS wailsalutem.idempotency_keys (
+    scope VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status_code INTEGER,
+    response_headers JSONB,
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON wailsalutem.idempotency_keys(expires_at);
@@ -0,0 +1,9 @@
+-- Heartbeat of import jobs. Running jobs save their progress regularly, so a
+-- queued or running job that has not been updated for a while was abandoned
+-- by a replica that stopped without finishing it.
+ALTER TABLE wailsalutem.import_jobs
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_unfinished
+    ON wailsalutem.import_jobs(updated_at)
+    WHERE status IN ('queued', 'running');
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS wailsalutem.import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES wailsalutem.organizations(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    status VARCHAR(30)
+        CHECK (status IN ('queued', 'running', 'completed', 'failed'))
+        DEFAULT 'queued',
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    succeeded_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    results JSONB NOT NULL DEFAULT '[]'::jsonb,
+    error TEXT,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT now(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_org ON wailsalutem.import_jobs(organization_id, created_at DESC);
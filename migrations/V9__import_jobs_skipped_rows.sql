@@ -0,0 +1,2 @@
+ALTER TABLE wailsalutem.import_jobs
+    ADD COLUMN IF NOT EXISTS skipped_rows INTEGER NOT NULL DEFAULT 0;